@@ -23,6 +23,27 @@ If release name contains chart name it will be used as a full name.
 {{- end }}
 {{- end }}
 
+{{/*
+Standard Helm labels added to every resource.
+*/}}
+{{- define "project.labels" -}}
+helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}
+{{- with .Chart.AppVersion }}
+app.kubernetes.io/version: {{ . | quote }}
+{{- end }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+app.kubernetes.io/managed-by: {{ .Release.Service }}
+{{- end }}
+
+{{/*
+Selector labels matching the pods of this release: the name label and the release instance, so two
+releases of the chart select only their own pods.
+*/}}
+{{- define "project.selectorLabels" -}}
+app.kubernetes.io/name: {{ include "project.name" . }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end }}
+
 {{/*
 Namespace for generated references.
 Always uses the Helm release namespace.
@@ -61,3 +82,86 @@ When disabled, serviceAccount.name must be set; use "default" to pick the namesp
 {{- required "serviceAccount.name is required when serviceAccount.enabled=false (set name: default explicitly to use the namespace default ServiceAccount)" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Manager pod tolerations: manager.tolerations followed by NoExecute tolerations of the
+node.kubernetes.io/not-ready and node.kubernetes.io/unreachable taints when
+manager.nodeFailureTolerations.enabled is true, unless manager.tolerations already tolerates them.
+The pod is evicted from a failed node after manager.nodeFailureTolerations.tolerationSeconds.
+*/}}
+{{- define "project.managerTolerations" -}}
+{{- $manager := .Values.manager | default dict }}
+{{- $tolerations := $manager.tolerations | default list }}
+{{- $nodeFailure := $manager.nodeFailureTolerations | default dict }}
+{{- if $nodeFailure.enabled }}
+{{- $keys := list }}
+{{- range $tolerations }}
+{{- $keys = append $keys .key }}
+{{- end }}
+{{- range list "node.kubernetes.io/not-ready" "node.kubernetes.io/unreachable" }}
+{{- if not (has . $keys) }}
+{{- $toleration := dict "key" . "operator" "Exists" "effect" "NoExecute" }}
+{{- if not (kindIs "invalid" $nodeFailure.tolerationSeconds) }}
+{{- $_ := set $toleration "tolerationSeconds" (int64 $nodeFailure.tolerationSeconds) }}
+{{- end }}
+{{- $tolerations = append $tolerations $toleration }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- with $tolerations }}
+{{- toYaml . }}
+{{- end }}
+{{- end }}
+
+{{/*
+Manager pod affinity: manager.affinity when set. Otherwise, when manager.defaultAffinity is true,
+a preferred pod anti-affinity on the manager selector labels spreading the replicas across nodes.
+*/}}
+{{- define "project.managerAffinity" -}}
+{{- $manager := .Values.manager | default dict }}
+{{- if $manager.affinity }}
+{{- toYaml $manager.affinity }}
+{{- else if $manager.defaultAffinity }}
+podAntiAffinity:
+  preferredDuringSchedulingIgnoredDuringExecution:
+  - weight: 100
+    podAffinityTerm:
+      topologyKey: kubernetes.io/hostname
+      labelSelector:
+        matchLabels:
+          app.kubernetes.io/name: {{ include "project.name" . }}
+          control-plane: controller-manager
+{{- end }}
+{{- end }}
+
+{{/*
+Manager container resources for the manager.resourcesProfile named profile: small, medium or large.
+Empty when no profile is set; manager.resources is merged over it.
+*/}}
+{{- define "project.managerResourcesProfile" -}}
+{{- $profile := (.Values.manager | default dict).resourcesProfile | default "" }}
+{{- if eq $profile "small" }}
+limits:
+  cpu: 500m
+  memory: 128Mi
+requests:
+  cpu: 10m
+  memory: 64Mi
+{{- else if eq $profile "medium" }}
+limits:
+  cpu: "1"
+  memory: 512Mi
+requests:
+  cpu: 100m
+  memory: 256Mi
+{{- else if eq $profile "large" }}
+limits:
+  cpu: "2"
+  memory: 2Gi
+requests:
+  cpu: 500m
+  memory: 1Gi
+{{- else if $profile }}
+{{- fail (printf "manager.resourcesProfile %q is not one of small, medium or large" $profile) }}
+{{- end }}
+{{- end }}
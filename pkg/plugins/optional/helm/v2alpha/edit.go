@@ -125,9 +125,18 @@ func (p *editSubcommand) Scaffold(fs machinery.Filesystem) error {
 		}
 	}
 
-	scaffolder := scaffolds.NewChartScaffolder(p.config, p.force, p.manifestsFile, p.outputDir)
+	// The chart options are read from the PROJECT file before the chart is generated.
+	key := plugin.GetPluginKeyForConfig(p.config.GetPluginChain(), Plugin{})
+	cfg, isFirstRun, err := p.decodePluginConfig(key)
+	if err != nil && !errors.As(err, &config.UnsupportedFieldError{}) {
+		return err
+	}
+	// Config versions without plugin metadata generate the chart without saving any configuration.
+	saveConfig := err == nil
+
+	scaffolder := scaffolds.NewChartScaffolder(p.config, p.force, p.manifestsFile, p.outputDir, cfg.ChartOptions)
 	scaffolder.InjectFS(fs)
-	err := scaffolder.Scaffold()
+	err = scaffolder.Scaffold()
 	if err != nil {
 		return fmt.Errorf("error scaffolding Helm chart: %w", err)
 	}
@@ -136,35 +145,8 @@ func (p *editSubcommand) Scaffold(fs machinery.Filesystem) error {
 	// This must happen in Scaffold (before config is saved) to be persisted
 	p.removeV1AlphaPluginEntry()
 
-	// Save plugin config to PROJECT file
-	key := plugin.GetPluginKeyForConfig(p.config.GetPluginChain(), Plugin{})
-	canonicalKey := plugin.KeyFor(Plugin{})
-	cfg := pluginConfig{}
-	isFirstRun := false
-	if err = p.config.DecodePluginConfig(key, &cfg); err != nil {
-		switch {
-		case errors.As(err, &config.UnsupportedFieldError{}):
-			// Config version doesn't support plugin metadata
-			return nil
-		case errors.As(err, &config.PluginKeyNotFoundError{}):
-			// This is the first time the plugin is run
-			isFirstRun = true
-			if key != canonicalKey {
-				if err2 := p.config.DecodePluginConfig(canonicalKey, &cfg); err2 != nil {
-					if errors.As(err2, &config.UnsupportedFieldError{}) {
-						return nil
-					}
-					if !errors.As(err2, &config.PluginKeyNotFoundError{}) {
-						return fmt.Errorf("error decoding plugin configuration: %w", err2)
-					}
-				} else {
-					// Found config under canonical key, not first run
-					isFirstRun = false
-				}
-			}
-		default:
-			return fmt.Errorf("error decoding plugin configuration: %w", err)
-		}
+	if !saveConfig {
+		return nil
 	}
 
 	// Update configuration with current parameters
@@ -188,6 +170,38 @@ func (p *editSubcommand) Scaffold(fs machinery.Filesystem) error {
 	return nil
 }
 
+// decodePluginConfig returns the plugin configuration saved in the PROJECT file under key, falling
+// back to the canonical plugin key, and whether this is the first time the plugin is run. It returns
+// a config.UnsupportedFieldError when the config version doesn't support plugin metadata.
+func (p *editSubcommand) decodePluginConfig(key string) (pluginConfig, bool, error) {
+	canonicalKey := plugin.KeyFor(Plugin{})
+	cfg := pluginConfig{}
+	err := p.config.DecodePluginConfig(key, &cfg)
+	switch {
+	case err == nil:
+		return cfg, false, nil
+	case errors.As(err, &config.UnsupportedFieldError{}):
+		return cfg, false, err
+	case !errors.As(err, &config.PluginKeyNotFoundError{}):
+		return cfg, false, fmt.Errorf("error decoding plugin configuration: %w", err)
+	}
+
+	// This is the first time the plugin is run, unless the config is under the canonical key
+	if key == canonicalKey {
+		return cfg, true, nil
+	}
+	err = p.config.DecodePluginConfig(canonicalKey, &cfg)
+	switch {
+	case err == nil:
+		return cfg, false, nil
+	case errors.As(err, &config.UnsupportedFieldError{}):
+		return cfg, false, err
+	case !errors.As(err, &config.PluginKeyNotFoundError{}):
+		return cfg, false, fmt.Errorf("error decoding plugin configuration: %w", err)
+	}
+	return cfg, true, nil
+}
+
 func (p *editSubcommand) ensureManifestsExist() error {
 	slog.Info("Generating default manifests file", "file", p.manifestsFile)
 
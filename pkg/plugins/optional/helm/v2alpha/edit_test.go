@@ -113,6 +113,44 @@ version: "3"
 		})
 	})
 
+	Context("decodePluginConfig", func() {
+		key := plugin.KeyFor(Plugin{})
+
+		It("should report the first run when no configuration is saved", func() {
+			pluginCfg, isFirstRun, err := editCmd.decodePluginConfig(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(isFirstRun).To(BeTrue())
+			Expect(pluginCfg).To(Equal(pluginConfig{}))
+		})
+
+		It("should read the chart options saved in the PROJECT file", func() {
+			err := cfg.EncodePluginConfig(key, map[string]any{
+				"manifests":     DefaultManifestsFile,
+				"output":        common.DefaultOutputDir,
+				"managedBy":     "Helm",
+				"keepManagedBy": true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			pluginCfg, isFirstRun, err := editCmd.decodePluginConfig(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(isFirstRun).To(BeFalse())
+			Expect(pluginCfg.ManagedBy).To(Equal("Helm"))
+			Expect(pluginCfg.KeepManagedBy).To(BeTrue())
+		})
+
+		It("should keep the chart options when the configuration is saved again", func() {
+			pluginCfg := pluginConfig{ManifestsFile: DefaultManifestsFile, OutputDir: common.DefaultOutputDir}
+			pluginCfg.ManagedBy = "Helm"
+			Expect(cfg.EncodePluginConfig(key, pluginCfg)).To(Succeed())
+
+			var saved map[string]any
+			Expect(cfg.DecodePluginConfig(key, &saved)).To(Succeed())
+			Expect(saved).To(HaveKeyWithValue("managedBy", "Helm"))
+			Expect(saved).NotTo(HaveKey("keepManagedBy"))
+		})
+	})
+
 	Context("removeV1AlphaPluginEntry", func() {
 		It("should remove v1-alpha plugin entry if it exists", func() {
 			// Add v1-alpha plugin entry to config
@@ -35,8 +35,24 @@ const (
 	KindValidatingWebhook  = "ValidatingWebhookConfiguration"
 	KindMutatingWebhook    = "MutatingWebhookConfiguration"
 	KindDeployment         = "Deployment"
+	KindStatefulSet        = "StatefulSet"
+	KindDaemonSet          = "DaemonSet"
 	KindCRD                = "CustomResourceDefinition"
 	KindNetworkPolicy      = "NetworkPolicy"
+	KindSecret             = "Secret"
+	KindConfigMap          = "ConfigMap"
+	KindJob                = "Job"
+	KindCronJob            = "CronJob"
+
+	KindValidatingAdmissionPolicy        = "ValidatingAdmissionPolicy"
+	KindValidatingAdmissionPolicyBinding = "ValidatingAdmissionPolicyBinding"
+
+	KindGateway   = "Gateway"
+	KindHTTPRoute = "HTTPRoute"
+
+	KindLimitRange    = "LimitRange"
+	KindResourceQuota = "ResourceQuota"
+	KindPriorityClass = "PriorityClass"
 )
 
 // API versions
@@ -44,6 +60,9 @@ const (
 	APIVersionCertManager = "cert-manager.io/v1"
 	APIVersionMonitoring  = "monitoring.coreos.com/v1"
 	APIVersionNetworking  = "networking.k8s.io/v1"
+
+	// APIGroupGateway is the Gateway API group; Gateway and HTTPRoute are served at several versions.
+	APIGroupGateway = "gateway.networking.k8s.io"
 )
 
 // YAML keys
@@ -59,6 +78,9 @@ const (
 const (
 	DefaultContainerAnnotation  = "kubectl.kubernetes.io/default-container"
 	DefaultManagerContainerName = "manager"
+	// KubeRbacProxyContainerName names the metrics proxy sidecar of scaffolds predating the
+	// controller-runtime metrics authentication.
+	KubeRbacProxyContainerName = "kube-rbac-proxy"
 )
 
 // Standard Kubernetes/Helm label keys
@@ -66,5 +88,6 @@ const (
 	LabelKeyAppName      = "app.kubernetes.io/name:"
 	LabelKeyAppInstance  = "app.kubernetes.io/instance:"
 	LabelKeyAppManagedBy = "app.kubernetes.io/managed-by:"
+	LabelKeyAppVersion   = "app.kubernetes.io/version:"
 	LabelKeyHelmChart    = "helm.sh/chart:"
 )
@@ -22,6 +22,7 @@ import (
 	"sigs.k8s.io/kubebuilder/v4/pkg/model/stage"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugin"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins"
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds"
 )
 
 const pluginName = "helm." + plugins.DefaultNameQualifier
@@ -42,6 +43,8 @@ var _ plugin.Edit = Plugin{}
 type pluginConfig struct {
 	ManifestsFile string `json:"manifests,omitempty"`
 	OutputDir     string `json:"output,omitempty"`
+	// ChartOptions are set by editing the PROJECT file; the plugin keeps them as they are.
+	scaffolds.ChartOptions
 }
 
 // Name returns the name of the plugin
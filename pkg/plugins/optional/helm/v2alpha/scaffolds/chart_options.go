@@ -0,0 +1,96 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffolds
+
+import (
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/kustomize/templater"
+)
+
+// ChartOptions customize how the chart is generated from the kustomize output. They are read from
+// the plugin entry of the PROJECT file, next to the manifests and output paths. The zero value
+// generates the default chart.
+type ChartOptions struct {
+	// ManagedBy replaces {{ .Release.Service }} as the app.kubernetes.io/managed-by label value.
+	ManagedBy string `json:"managedBy,omitempty"`
+	// KeepManagedBy keeps the app.kubernetes.io/managed-by labels of the kustomize output and leaves
+	// the label out of the standard labels helper. It takes precedence over ManagedBy.
+	KeepManagedBy bool `json:"keepManagedBy,omitempty"`
+	// FlatValues moves the manager image settings from manager.image to the top-level image key of
+	// values.yaml, which is shorter to override with helm --set.
+	FlatValues bool `json:"flatValues,omitempty"`
+	// HelperRBAC names RBAC resources of the kustomize output that are rendered only when
+	// rbac.helpers.enabled, like the scaffolded admin, editor and viewer roles.
+	HelperRBAC []string `json:"helperRBAC,omitempty"`
+	// EssentialRBAC names RBAC resources of the kustomize output that are always rendered, even when
+	// their name matches a helper or metrics role. It takes precedence over HelperRBAC.
+	EssentialRBAC []string `json:"essentialRBAC,omitempty"`
+	// KeepNamespace keeps the Namespace of the kustomize output in the chart, named after the release
+	// namespace and created only when namespace.create is set.
+	KeepNamespace bool `json:"keepNamespace,omitempty"`
+	// CanonicalKeyOrder orders the top-level keys of the templates as apiVersion, kind, metadata,
+	// spec and then the other keys, instead of the alphabetical order of the kustomize output.
+	CanonicalKeyOrder bool `json:"canonicalKeyOrder,omitempty"`
+	// UnquotedImage renders the templated image references without the surrounding double quotes,
+	// for yamllint configurations that reject quoted strings.
+	UnquotedImage bool `json:"unquotedImage,omitempty"`
+	// ArgsAsYAML renders the manager.args values with toYaml instead of one item per arg, so args
+	// holding spaces or YAML special characters are quoted by Helm.
+	ArgsAsYAML bool `json:"argsAsYAML,omitempty"`
+	// OmitCertManager leaves the cert-manager Certificates and Issuers out of the chart, instead of
+	// rendering them behind certManager.enabled, and disables certManager by default.
+	OmitCertManager bool `json:"omitCertManager,omitempty"`
+	// OmitCRDs leaves the CustomResourceDefinitions out of the chart, instead of rendering them behind
+	// crd.enabled, for CRDs installed by a separate chart or operator.
+	OmitCRDs bool `json:"omitCRDs,omitempty"`
+	// RequireImageRepository renders the manager image repository with Helm's required function
+	// instead of defaulting it to "controller", so an install with an empty repository fails.
+	RequireImageRepository bool `json:"requireImageRepository,omitempty"`
+	// RevisionAnnotation adds an app.kubernetes.io/revision annotation set to revision to every
+	// templated resource, for GitOps tooling tracking deploys.
+	RevisionAnnotation bool `json:"revisionAnnotation,omitempty"`
+	// ProvenanceAnnotations adds app.kubernetes.io/part-of, set to partOf or the project name, and
+	// helm.sh/chart annotations to every templated resource, for catalogs tracking where resources come from.
+	ProvenanceAnnotations bool `json:"provenanceAnnotations,omitempty"`
+	// ReleaseSelectorLabels makes Service selectors match the release instance, so two releases of the
+	// chart in one namespace do not select each other's pods.
+	ReleaseSelectorLabels bool `json:"releaseSelectorLabels,omitempty"`
+	// ReportSubstitutions logs, for every templated resource, the substitution steps that changed it,
+	// to debug a generated chart.
+	ReportSubstitutions bool `json:"reportSubstitutions,omitempty"`
+}
+
+// templaterOptions returns the templater Options applying o.
+func (o ChartOptions) templaterOptions() templater.Options {
+	return templater.Options{
+		ManagedBy:              o.ManagedBy,
+		KeepManagedBy:          o.KeepManagedBy,
+		FlatValues:             o.FlatValues,
+		HelperRBAC:             o.HelperRBAC,
+		EssentialRBAC:          o.EssentialRBAC,
+		KeepNamespace:          o.KeepNamespace,
+		CanonicalKeyOrder:      o.CanonicalKeyOrder,
+		UnquotedImage:          o.UnquotedImage,
+		ArgsAsYAML:             o.ArgsAsYAML,
+		OmitCertManager:        o.OmitCertManager,
+		OmitCRDs:               o.OmitCRDs,
+		RequireImageRepository: o.RequireImageRepository,
+		RevisionAnnotation:     o.RevisionAnnotation,
+		ProvenanceAnnotations:  o.ProvenanceAnnotations,
+		ReleaseSelectorLabels:  o.ReleaseSelectorLabels,
+		RecordReport:           o.ReportSubstitutions,
+	}
+}
@@ -43,15 +43,19 @@ type chartScaffolder struct {
 	force         bool
 	manifestsFile string
 	outputDir     string
+	options       ChartOptions
 }
 
 // NewChartScaffolder returns a new Scaffolder for Helm chart generation from kustomize output.
-func NewChartScaffolder(cfg config.Config, force bool, manifestsFile, outputDir string) plugins.Scaffolder {
+func NewChartScaffolder(
+	cfg config.Config, force bool, manifestsFile, outputDir string, options ChartOptions,
+) plugins.Scaffolder {
 	return &chartScaffolder{
 		config:        cfg,
 		force:         force,
 		manifestsFile: manifestsFile,
 		outputDir:     outputDir,
+		options:       options,
 	}
 }
 
@@ -79,6 +83,7 @@ func (s *chartScaffolder) Scaffold() error {
 		ManifestsFile: s.manifestsFile,
 		OutputDir:     s.outputDir,
 		Force:         s.force,
+		Options:       s.options.templaterOptions(),
 	})
 
 	builders, err := chartScaffolder.PrepareTemplates(s.fs)
@@ -19,11 +19,15 @@ package internal
 import (
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
 
+	"github.com/spf13/afero"
+
 	"sigs.k8s.io/kubebuilder/v4/pkg/machinery"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/extractor"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/kustomize"
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/kustomize/templater"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/templates"
 	charttemplates "sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/templates/chart-templates"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/templates/github"
@@ -35,6 +39,8 @@ type ChartScaffolderConfig struct {
 	ManifestsFile string
 	OutputDir     string
 	Force         bool
+	// Options configure the templating of the kustomize resources and the helpers they include.
+	Options templater.Options
 }
 
 // ChartScaffolder converts kustomize output to a Helm chart.
@@ -50,7 +56,7 @@ func NewChartScaffolder(config ChartScaffolderConfig) *ChartScaffolder {
 
 // PrepareTemplates parses kustomize YAML, converts resources to Helm templates, and returns
 // the resulting machinery.Builders ready for file generation.
-func (s *ChartScaffolder) PrepareTemplates(_ machinery.Filesystem) ([]machinery.Builder, error) {
+func (s *ChartScaffolder) PrepareTemplates(fs machinery.Filesystem) ([]machinery.Builder, error) {
 	parser := kustomize.NewParser(s.config.ManifestsFile)
 
 	// Note: We always use os.Open() (via parser.Parse()) because the manifests file is on the OS filesystem.
@@ -91,6 +97,7 @@ func (s *ChartScaffolder) PrepareTemplates(_ machinery.Filesystem) ([]machinery.
 	extraction, err := resourceExtractor.Extract(&extractor.ResourceSet{
 		Namespace:                 resources.Namespace,
 		Deployment:                resources.Deployment,
+		ExtraDeployments:          resources.ExtraDeployments,
 		Services:                  resources.Services,
 		CustomResourceDefinitions: resources.CustomResourceDefinitions,
 		ServiceAccount:            resources.ServiceAccount,
@@ -116,11 +123,16 @@ func (s *ChartScaffolder) PrepareTemplates(_ machinery.Filesystem) ([]machinery.
 		extraction.Metadata.ManagerNamespace,
 		s.config.OutputDir,
 		extraction.Features.RoleNamespaces,
+		s.config.Options,
 	)
 
 	// Get builders for kustomize-derived chart templates
 	chartBuilders := chartConverter.GetChartBuilders()
 
+	if !s.config.Force {
+		s.warnOnOutdatedHelpers(fs, extraction.Metadata.ChartName)
+	}
+
 	builders := []machinery.Builder{
 		&github.HelmChartCI{Force: s.config.Force},
 		&templates.HelmChart{
@@ -128,12 +140,25 @@ func (s *ChartScaffolder) PrepareTemplates(_ machinery.Filesystem) ([]machinery.
 			ChartMetadata: extraction.Metadata,
 		},
 		&templates.HelmValues{
-			Extraction: extraction,
-			OutputDir:  s.config.OutputDir,
-			Force:      s.config.Force,
+			Extraction:            extraction,
+			OutputDir:             s.config.OutputDir,
+			Force:                 s.config.Force,
+			FlatValues:            s.config.Options.FlatValues,
+			KeepNamespace:         s.config.Options.KeepNamespace,
+			OmitCertManager:       s.config.Options.OmitCertManager,
+			OmitCRDs:              s.config.Options.OmitCRDs,
+			RevisionAnnotation:    s.config.Options.RevisionAnnotation,
+			ProvenanceAnnotations: s.config.Options.ProvenanceAnnotations,
 		},
 		&templates.HelmIgnore{OutputDir: s.config.OutputDir, Force: s.config.Force},
-		&charttemplates.HelmHelpers{OutputDir: s.config.OutputDir, Force: s.config.Force},
+		&charttemplates.HelmHelpers{
+			OutputDir:             s.config.OutputDir,
+			Force:                 s.config.Force,
+			ManagedBy:             s.config.Options.ManagedBy,
+			OmitManagedBy:         s.config.Options.KeepManagedBy,
+			RevisionAnnotation:    s.config.Options.RevisionAnnotation,
+			ProvenanceAnnotations: s.config.Options.ProvenanceAnnotations,
+		},
 		&charttemplates.Notes{
 			OutputDir: s.config.OutputDir,
 			Force:     s.config.Force,
@@ -179,3 +204,35 @@ func (s *ChartScaffolder) PrepareTemplates(_ machinery.Filesystem) ([]machinery.
 
 	return builders, nil
 }
+
+// warnOnOutdatedHelpers warns when the preserved _helpers.tpl does not define a helper that the chart
+// templates include, such as the standard labels helper, since the chart would fail to render until it
+// is regenerated.
+func (s *ChartScaffolder) warnOnOutdatedHelpers(fs machinery.Filesystem, chartName string) {
+	if fs.FS == nil {
+		return
+	}
+	helpers := &charttemplates.HelmHelpers{OutputDir: s.config.OutputDir}
+	if err := helpers.SetTemplateDefaults(); err != nil {
+		return
+	}
+	content, err := afero.ReadFile(fs.FS, filepath.Clean(helpers.Path))
+	if err != nil {
+		// Not scaffolded yet: it is written with the current helpers.
+		return
+	}
+	included := []string{chartName + ".labels"}
+	if s.config.Options.RevisionAnnotation {
+		included = append(included, chartName+".revisionAnnotations")
+	}
+	if s.config.Options.ProvenanceAnnotations {
+		included = append(included, chartName+".provenanceAnnotations")
+	}
+	for _, helper := range included {
+		if !strings.Contains(string(content), `define "`+helper+`"`) {
+			slog.Warn("The preserved _helpers.tpl does not define a helper the chart templates include; "+
+				"run with --force or copy the helper from a freshly generated chart",
+				"file", helpers.Path, "helper", helper)
+		}
+	}
+}
@@ -18,8 +18,10 @@ package extractor
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
@@ -34,6 +36,20 @@ type ValuesConfig struct {
 	Manager     ManagerConfig
 	WebhookPort int
 	MetricsPort int
+	// KubeRbacProxy is nil when the pod has no kube-rbac-proxy sidecar.
+	KubeRbacProxy *KubeRbacProxyConfig
+	// Deployments are the Deployments shipped next to the manager, in kustomize output order.
+	Deployments []DeploymentValues
+}
+
+// DeploymentValues contains the configuration of a Deployment shipped next to the manager, kept in
+// values.yaml under Key.
+type DeploymentValues struct {
+	Name      string
+	Key       string
+	Replicas  *int
+	Image     ImageConfig
+	Resources map[string]any
 }
 
 // ManagerConfig contains manager deployment configuration.
@@ -50,17 +66,30 @@ type ManagerConfig struct {
 	PodSecurityContext            map[string]any
 	ImagePullSecrets              []any
 	PriorityClassName             string
+	HostNetwork                   bool
+	DNSPolicy                     string
 	TopologySpreadConstraints     []any
 	TerminationGracePeriodSeconds *int
+	ProgressDeadlineSeconds       *int
+	MinReadySeconds               *int
+	AutomountServiceAccountToken  *bool // nil when the pod spec does not set it
 	Strategy                      map[string]any
 	ExtraVolumes                  []any
 	ExtraVolumeMounts             []any
+	LeaderElection                *bool // nil when the manager has no --leader-elect arg
+}
+
+// KubeRbacProxyConfig contains the kube-rbac-proxy sidecar configuration.
+type KubeRbacProxyConfig struct {
+	Image     ImageConfig
+	Resources map[string]any
 }
 
 // ImageConfig contains image configuration.
 type ImageConfig struct {
 	Repository string
 	Tag        string
+	Digest     string
 	PullPolicy string
 }
 
@@ -78,6 +107,8 @@ func (d *DeploymentExtractor) ExtractDeploymentConfig(deployment *unstructured.U
 
 	extractDeploymentReplicas(deployment, extracted)
 	extractDeploymentStrategy(deployment, extracted)
+	extractDeploymentProgressDeadlineSeconds(deployment, extracted)
+	extractDeploymentMinReadySeconds(deployment, extracted)
 
 	specMap := extractDeploymentSpec(deployment)
 	if specMap != nil {
@@ -87,8 +118,10 @@ func (d *DeploymentExtractor) ExtractDeploymentConfig(deployment *unstructured.U
 		extractPodTolerations(specMap, extracted)
 		extractPodAffinity(specMap, extracted)
 		extractPriorityClassName(specMap, extracted)
+		extractHostNetwork(specMap, extracted)
 		extractTopologySpreadConstraints(specMap, extracted)
 		extractTerminationGracePeriodSeconds(specMap, extracted)
+		extractAutomountServiceAccountToken(specMap, extracted)
 
 		container := findManagerContainer(deployment, specMap)
 		if container == nil {
@@ -104,6 +137,8 @@ func (d *DeploymentExtractor) ExtractDeploymentConfig(deployment *unstructured.U
 
 		extractExtraVolumes(specMap, extracted)
 		extractExtraVolumeMounts(container, extracted)
+
+		config.KubeRbacProxy = extractKubeRbacProxy(specMap)
 	}
 
 	config.Manager = convertToManagerConfig(extracted)
@@ -129,6 +164,7 @@ func convertToManagerConfig(configMap map[string]any) ManagerConfig {
 		cfg.Image = ImageConfig{
 			Repository: getStringValue(image, "repository"),
 			Tag:        getStringValue(image, "tag"),
+			Digest:     getStringValue(image, "digest"),
 			PullPolicy: getStringValue(image, "pullPolicy"),
 		}
 	}
@@ -162,6 +198,12 @@ func convertToManagerConfig(configMap map[string]any) ManagerConfig {
 	if priorityClassName, ok := configMap["priorityClassName"].(string); ok {
 		cfg.PriorityClassName = priorityClassName
 	}
+	if hostNetwork, ok := configMap["hostNetwork"].(bool); ok {
+		cfg.HostNetwork = hostNetwork
+	}
+	if dnsPolicy, ok := configMap["dnsPolicy"].(string); ok {
+		cfg.DNSPolicy = dnsPolicy
+	}
 	if topologySpreadConstraints, ok := configMap["topologySpreadConstraints"].([]any); ok {
 		cfg.TopologySpreadConstraints = topologySpreadConstraints
 	}
@@ -169,6 +211,18 @@ func convertToManagerConfig(configMap map[string]any) ManagerConfig {
 		gracePeriod := terminationGracePeriodSeconds
 		cfg.TerminationGracePeriodSeconds = &gracePeriod
 	}
+	if automount, ok := configMap["automountServiceAccountToken"].(bool); ok {
+		enabled := automount
+		cfg.AutomountServiceAccountToken = &enabled
+	}
+	if progressDeadlineSeconds, ok := configMap["progressDeadlineSeconds"].(int); ok {
+		deadline := progressDeadlineSeconds
+		cfg.ProgressDeadlineSeconds = &deadline
+	}
+	if minReadySeconds, ok := configMap["minReadySeconds"].(int); ok {
+		minReady := minReadySeconds
+		cfg.MinReadySeconds = &minReady
+	}
 	if strategy, ok := configMap["strategy"].(map[string]any); ok {
 		cfg.Strategy = strategy
 	}
@@ -178,6 +232,10 @@ func convertToManagerConfig(configMap map[string]any) ManagerConfig {
 	if extraVolumeMounts, ok := configMap["extraVolumeMounts"].([]any); ok {
 		cfg.ExtraVolumeMounts = extraVolumeMounts
 	}
+	if leaderElection, ok := configMap["leaderElection"].(bool); ok {
+		enabled := leaderElection
+		cfg.LeaderElection = &enabled
+	}
 
 	return cfg
 }
@@ -318,6 +376,96 @@ func findManagerContainer(deployment *unstructured.Unstructured, specMap map[str
 	return firstContainer
 }
 
+// ExtractDeploymentValues extracts the replicas and the image and resources of the main container of
+// each Deployment shipped next to the manager. The main container is found like the manager
+// container: by the default-container annotation or the "manager" name, else the first container.
+func (d *DeploymentExtractor) ExtractDeploymentValues(
+	deployments []*unstructured.Unstructured, detectedPrefix string,
+) []DeploymentValues {
+	result := make([]DeploymentValues, 0, len(deployments))
+	for _, deployment := range deployments {
+		extracted := make(map[string]any)
+		extractDeploymentReplicas(deployment, extracted)
+		if specMap := extractDeploymentSpec(deployment); specMap != nil {
+			if container := findManagerContainer(deployment, specMap); container != nil {
+				extractContainerImage(container, extracted)
+				extractContainerResources(container, extracted)
+			}
+		}
+
+		config := convertToManagerConfig(extracted)
+		result = append(result, DeploymentValues{
+			Name:      deployment.GetName(),
+			Key:       DeploymentValuesKey(detectedPrefix, deployment.GetName()),
+			Replicas:  config.Replicas,
+			Image:     config.Image,
+			Resources: config.Resources,
+		})
+	}
+	return result
+}
+
+// reservedValuesKeys are the top-level values.yaml keys the chart already uses.
+var reservedValuesKeys = []string{
+	"nameOverride", "fullnameOverride", "global", "commonLabels", "commonAnnotations", "manager", "image",
+	"rbac", "serviceAccount", "crd", "metrics", "kubeRbacProxy", "certManager", "config", "jobs",
+	"admissionPolicy", "gateway", "governance", "priorityClass", "webhook", "prometheus", "networkPolicy",
+	"namespace", "partOf",
+}
+
+// DeploymentValuesKey returns the values.yaml key holding the settings of the Deployment called name:
+// the name without the project prefix in lower camel case, e.g. "someOperator" for
+// "project-some-operator". A key the chart already uses gets a "Deployment" suffix.
+func DeploymentValuesKey(detectedPrefix, name string) string {
+	words := strings.FieldsFunc(strings.TrimPrefix(name, detectedPrefix+"-"), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var key strings.Builder
+	for i, word := range words {
+		if i == 0 {
+			key.WriteString(strings.ToLower(word[:1]) + word[1:])
+		} else {
+			key.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+
+	result := key.String()
+	if result == "" || unicode.IsDigit(rune(result[0])) {
+		// Helm cannot read a key starting with a digit as .Values.<key>.
+		result = "deployment" + result
+	}
+	if slices.Contains(reservedValuesKeys, result) {
+		result += "Deployment"
+	}
+	return result
+}
+
+// extractKubeRbacProxy returns the image and resources of the kube-rbac-proxy sidecar, or nil when
+// the pod has none.
+func extractKubeRbacProxy(specMap map[string]any) *KubeRbacProxyConfig {
+	containers, found, err := unstructured.NestedFieldNoCopy(specMap, "containers")
+	if !found || err != nil {
+		return nil
+	}
+	containersList, ok := containers.([]any)
+	if !ok {
+		return nil
+	}
+	for _, c := range containersList {
+		container, ok := c.(map[string]any)
+		if !ok || container["name"] != common.KubeRbacProxyContainerName {
+			continue
+		}
+		extracted := make(map[string]any)
+		extractContainerImage(container, extracted)
+		extractContainerResources(container, extracted)
+
+		manager := convertToManagerConfig(extracted)
+		return &KubeRbacProxyConfig{Image: manager.Image, Resources: manager.Resources}
+	}
+	return nil
+}
+
 // RemoveExtractedVolumes removes custom volumes and mounts from the deployment manifest.
 // Must be called after ExtractDeploymentConfig: extraction captures them in values.yaml first,
 // then this removes them from the manifest so they are injected only via Helm values at install time.
@@ -383,20 +531,19 @@ func extractContainerImage(container map[string]any, config map[string]any) {
 		return
 	}
 
-	repository := imageValue
+	// A digest pins the image, so the tag only defaults to latest for tag-based images.
+	repository, digest, pinned := strings.Cut(imageValue, "@")
 	tag := ""
-
-	// Digest-pinned images stay whole in repository; tag-based images are split.
-	if !strings.Contains(imageValue, "@") {
+	if !pinned {
 		tag = "latest"
-		lastColon := strings.LastIndex(imageValue, ":")
-		lastSlash := strings.LastIndex(imageValue, "/")
-		if lastColon != -1 && lastColon > lastSlash {
-			repository = imageValue[:lastColon]
-			if lastColon+1 < len(imageValue) {
-				tag = imageValue[lastColon+1:]
-			}
+	}
+	lastColon := strings.LastIndex(repository, ":")
+	lastSlash := strings.LastIndex(repository, "/")
+	if lastColon != -1 && lastColon > lastSlash {
+		if lastColon+1 < len(repository) {
+			tag = repository[lastColon+1:]
 		}
+		repository = repository[:lastColon]
 	}
 
 	pullPolicy, _, err := unstructured.NestedString(container, "imagePullPolicy")
@@ -407,6 +554,7 @@ func extractContainerImage(container map[string]any, config map[string]any) {
 	config["image"] = map[string]any{
 		"repository": repository,
 		"tag":        tag,
+		"digest":     digest,
 		"pullPolicy": pullPolicy,
 	}
 }
@@ -451,6 +599,11 @@ func extractContainerArgs(container map[string]any, config map[string]any) {
 			}
 			continue
 		}
+		// Leader election is templated from manager.leaderElection.enabled. The arg itself is filtered out.
+		if enabled, ok := ParseLeaderElectArg(strArg); ok {
+			config["leaderElection"] = enabled
+			continue
+		}
 		if strings.Contains(strArg, "--webhook-cert-path") ||
 			strings.Contains(strArg, "--metrics-cert-path") {
 			continue
@@ -463,6 +616,23 @@ func extractContainerArgs(container map[string]any, config map[string]any) {
 	}
 }
 
+// ParseLeaderElectArg reports whether arg is the --leader-elect flag and the value it sets.
+// A bare --leader-elect enables leader election.
+func ParseLeaderElectArg(arg string) (enabled, ok bool) {
+	if arg == "--leader-elect" {
+		return true, true
+	}
+	value, found := strings.CutPrefix(arg, "--leader-elect=")
+	if !found {
+		return false, false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, false
+	}
+	return enabled, true
+}
+
 // ExtractPortFromArg extracts the port number from bind-address arguments like "--metrics-bind-address=:8443".
 func ExtractPortFromArg(arg string) int {
 	parts := strings.Split(arg, "=")
@@ -611,6 +781,26 @@ func extractDeploymentStrategy(deployment *unstructured.Unstructured, config map
 	config["strategy"] = strategy
 }
 
+// extractDeploymentProgressDeadlineSeconds extracts the progressDeadlineSeconds from the deployment spec.
+func extractDeploymentProgressDeadlineSeconds(deployment *unstructured.Unstructured, config map[string]any) {
+	deadline, found, err := unstructured.NestedInt64(deployment.Object, "spec", "progressDeadlineSeconds")
+	if !found || err != nil {
+		return
+	}
+
+	config["progressDeadlineSeconds"] = int(deadline)
+}
+
+// extractDeploymentMinReadySeconds extracts the minReadySeconds from the deployment spec.
+func extractDeploymentMinReadySeconds(deployment *unstructured.Unstructured, config map[string]any) {
+	minReady, found, err := unstructured.NestedInt64(deployment.Object, "spec", "minReadySeconds")
+	if !found || err != nil {
+		return
+	}
+
+	config["minReadySeconds"] = int(minReady)
+}
+
 // extractPriorityClassName extracts the priorityClassName from the pod spec.
 func extractPriorityClassName(specMap map[string]any, config map[string]any) {
 	priorityClassName, found, err := unstructured.NestedString(specMap, "priorityClassName")
@@ -621,6 +811,19 @@ func extractPriorityClassName(specMap map[string]any, config map[string]any) {
 	config["priorityClassName"] = priorityClassName
 }
 
+// extractHostNetwork extracts hostNetwork and, when it is enabled, the accompanying dnsPolicy from the pod spec.
+func extractHostNetwork(specMap map[string]any, config map[string]any) {
+	hostNetwork, found, err := unstructured.NestedBool(specMap, "hostNetwork")
+	if !found || err != nil || !hostNetwork {
+		return
+	}
+
+	config["hostNetwork"] = true
+	if dnsPolicy, found, err := unstructured.NestedString(specMap, "dnsPolicy"); found && err == nil && dnsPolicy != "" {
+		config["dnsPolicy"] = dnsPolicy
+	}
+}
+
 // extractTopologySpreadConstraints extracts the topologySpreadConstraints from the pod spec.
 func extractTopologySpreadConstraints(specMap map[string]any, config map[string]any) {
 	topologySpreadConstraints, found, err := unstructured.NestedFieldNoCopy(specMap, "topologySpreadConstraints")
@@ -648,6 +851,16 @@ func extractTerminationGracePeriodSeconds(specMap map[string]any, config map[str
 	}
 }
 
+// extractAutomountServiceAccountToken extracts the automountServiceAccountToken from the pod spec.
+func extractAutomountServiceAccountToken(specMap map[string]any, config map[string]any) {
+	automount, found, err := unstructured.NestedBool(specMap, "automountServiceAccountToken")
+	if !found || err != nil {
+		return
+	}
+
+	config["automountServiceAccountToken"] = automount
+}
+
 // isWebhookPortName reports whether name identifies a webhook port.
 func isWebhookPortName(name string) bool {
 	name = strings.ToLower(name)
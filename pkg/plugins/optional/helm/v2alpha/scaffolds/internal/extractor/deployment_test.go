@@ -138,6 +138,43 @@ var _ = Describe("DeploymentExtractor", func() {
 			Expect(result.Manager.Replicas).NotTo(BeNil())
 			Expect(*result.Manager.Replicas).To(Equal(3))
 		})
+
+		It("should extract automountServiceAccountToken from the pod spec", func() {
+			result, err := extractor.ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Manager.AutomountServiceAccountToken).To(BeNil())
+
+			podSpec := deployment.Object["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+			podSpec["automountServiceAccountToken"] = false
+			result, err = extractor.ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Manager.AutomountServiceAccountToken).To(HaveValue(BeFalse()))
+		})
+
+		It("should return nil when progressDeadlineSeconds is not set", func() {
+			result, err := extractor.ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Manager.ProgressDeadlineSeconds).To(BeNil())
+		})
+
+		It("should extract progressDeadlineSeconds value", func() {
+			deployment.Object["spec"].(map[string]any)["progressDeadlineSeconds"] = int64(900)
+			result, err := extractor.ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Manager.ProgressDeadlineSeconds).NotTo(BeNil())
+			Expect(*result.Manager.ProgressDeadlineSeconds).To(Equal(900))
+		})
+
+		It("should extract minReadySeconds value", func() {
+			result, err := extractor.ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Manager.MinReadySeconds).To(BeNil())
+
+			deployment.Object["spec"].(map[string]any)["minReadySeconds"] = int64(15)
+			result, err = extractor.ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Manager.MinReadySeconds).To(HaveValue(Equal(15)))
+		})
 	})
 
 	Describe("findManagerContainer", func() {
@@ -417,6 +454,173 @@ var _ = Describe("DeploymentExtractor", func() {
 		)
 	})
 
+	Describe("Image extraction", func() {
+		DescribeTable("should split the manager image into repository, tag and digest",
+			func(image, repository, tag, digest string) {
+				deployment := makeDeployment(deploymentOpts{
+					containers: []map[string]any{{keyName: valManager, keyImage: image}},
+				})
+
+				config, err := (&DeploymentExtractor{}).ExtractDeploymentConfig(deployment)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Manager.Image.Repository).To(Equal(repository))
+				Expect(config.Manager.Image.Tag).To(Equal(tag))
+				Expect(config.Manager.Image.Digest).To(Equal(digest))
+				Expect(config.Manager.Image.PullPolicy).To(Equal("IfNotPresent"))
+			},
+			Entry("tag", valControllerImage, "controller", "latest", ""),
+			Entry("no tag", "controller", "controller", "latest", ""),
+			Entry("registry with port", "registry.local:5000/team/controller:v1.2.0",
+				"registry.local:5000/team/controller", "v1.2.0", ""),
+			Entry("digest", "example.com/controller@sha256:abc123",
+				"example.com/controller", "", "sha256:abc123"),
+			Entry("tag and digest", "example.com/controller:v1.2.0@sha256:abc123",
+				"example.com/controller", "v1.2.0", "sha256:abc123"),
+		)
+	})
+
+	Describe("kube-rbac-proxy extraction", func() {
+		It("should extract the sidecar image and resources", func() {
+			deployment := makeDeployment(deploymentOpts{
+				containers: []map[string]any{
+					{keyName: valManager, keyImage: valControllerImage},
+					{
+						keyName:           "kube-rbac-proxy",
+						keyImage:          "quay.io/brancz/kube-rbac-proxy:v0.16.0",
+						"imagePullPolicy": "Always",
+						"resources":       map[string]any{"limits": map[string]any{"cpu": "500m"}},
+					},
+				},
+			})
+
+			config, err := (&DeploymentExtractor{}).ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.KubeRbacProxy).NotTo(BeNil())
+			Expect(config.KubeRbacProxy.Image).To(Equal(ImageConfig{
+				Repository: "quay.io/brancz/kube-rbac-proxy", Tag: "v0.16.0", PullPolicy: "Always",
+			}))
+			Expect(config.KubeRbacProxy.Resources).To(HaveKey("limits"))
+			Expect(config.Manager.Image.Repository).To(Equal("controller"))
+		})
+
+		It("should leave KubeRbacProxy nil without the sidecar", func() {
+			deployment := makeDeployment(deploymentOpts{
+				containers: []map[string]any{
+					{keyName: valSidecar, keyImage: valSidecarImage},
+					{keyName: valManager, keyImage: valControllerImage},
+				},
+			})
+
+			config, err := (&DeploymentExtractor{}).ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.KubeRbacProxy).To(BeNil())
+		})
+	})
+
+	Describe("DeploymentValuesKey", func() {
+		DescribeTable("should derive a values key from the Deployment name",
+			func(name, expected string) {
+				Expect(DeploymentValuesKey("test-project", name)).To(Equal(expected))
+			},
+			Entry("prefixed name", "test-project-some-operator", "someOperator"),
+			Entry("name without the prefix", "worker", "worker"),
+			Entry("dots and underscores", "test-project-cache.v2_sync", "cacheV2Sync"),
+			Entry("leading digit", "test-project-2nd-worker", "deployment2ndWorker"),
+			Entry("reserved key", "test-project-metrics", "metricsDeployment"),
+		)
+	})
+
+	Describe("ExtractDeploymentValues", func() {
+		It("should extract replicas, image and resources of each Deployment", func() {
+			deployment := makeDeployment(deploymentOpts{
+				containers: []map[string]any{{
+					keyName:           "worker",
+					keyImage:          "example.com/worker:v1.0.0",
+					"imagePullPolicy": "Always",
+					"resources":       map[string]any{"limits": map[string]any{"cpu": "200m"}},
+				}},
+			})
+			deployment.SetName("test-project-worker")
+			Expect(unstructured.SetNestedField(deployment.Object, int64(2), "spec", "replicas")).To(Succeed())
+
+			values := (&DeploymentExtractor{}).ExtractDeploymentValues(
+				[]*unstructured.Unstructured{deployment}, "test-project")
+			Expect(values).To(HaveLen(1))
+			Expect(values[0].Name).To(Equal("test-project-worker"))
+			Expect(values[0].Key).To(Equal("worker"))
+			Expect(values[0].Replicas).To(HaveValue(Equal(2)))
+			Expect(values[0].Image).To(Equal(ImageConfig{
+				Repository: "example.com/worker", Tag: "v1.0.0", PullPolicy: "Always",
+			}))
+			Expect(values[0].Resources).To(HaveKey("limits"))
+		})
+	})
+
+	Describe("Args extraction", func() {
+		It("should keep flags the chart does not template in manager.args, in order", func() {
+			deployment := makeDeployment(deploymentOpts{
+				containers: []map[string]any{
+					{
+						keyName:  valManager,
+						keyImage: valControllerImage,
+						"args": []any{
+							"--metrics-bind-address=:8443",
+							"--zap-log-level=debug",
+							"--health-probe-bind-address=:8081",
+							"--zap-devel",
+							"--webhook-cert-path=/tmp/k8s-webhook-server/serving-certs",
+						},
+					},
+				},
+			})
+
+			config, err := (&DeploymentExtractor{}).ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Manager.Args).To(Equal([]any{"--zap-log-level=debug", "--zap-devel"}))
+		})
+	})
+
+	Describe("Leader election extraction", func() {
+		DescribeTable("should move --leader-elect out of args into LeaderElection",
+			func(arg string, expected bool) {
+				deployment := makeDeployment(deploymentOpts{
+					containers: []map[string]any{
+						{
+							keyName:  valManager,
+							keyImage: valControllerImage,
+							"args":   []any{arg, "--zap-devel"},
+						},
+					},
+				})
+
+				config, err := (&DeploymentExtractor{}).ExtractDeploymentConfig(deployment)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Manager.LeaderElection).NotTo(BeNil())
+				Expect(*config.Manager.LeaderElection).To(Equal(expected))
+				Expect(config.Manager.Args).To(Equal([]any{"--zap-devel"}))
+			},
+			Entry("bare flag", "--leader-elect", true),
+			Entry("explicit true", "--leader-elect=true", true),
+			Entry("explicit false", "--leader-elect=false", false),
+		)
+
+		It("should leave LeaderElection unset when the manager has no --leader-elect arg", func() {
+			deployment := makeDeployment(deploymentOpts{
+				containers: []map[string]any{
+					{
+						keyName:  valManager,
+						keyImage: valControllerImage,
+						"args":   []any{"--zap-devel"},
+					},
+				},
+			})
+
+			config, err := (&DeploymentExtractor{}).ExtractDeploymentConfig(deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Manager.LeaderElection).To(BeNil())
+		})
+	})
+
 	Describe("Webhook port extraction", func() {
 		It("should extract webhook port from --webhook-port argument in deployment args", func() {
 			deployment := makeDeployment(deploymentOpts{
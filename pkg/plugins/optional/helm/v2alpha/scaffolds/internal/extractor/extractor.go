@@ -59,6 +59,7 @@ type Extraction struct {
 type ResourceSet struct {
 	Namespace                 *unstructured.Unstructured
 	Deployment                *unstructured.Unstructured
+	ExtraDeployments          []*unstructured.Unstructured
 	Services                  []*unstructured.Unstructured
 	CustomResourceDefinitions []*unstructured.Unstructured
 	ServiceAccount            *unstructured.Unstructured
@@ -88,6 +89,10 @@ func (e *Extractor) Extract(resources *ResourceSet, projectName string) (*Extrac
 	if resources.Deployment != nil {
 		e.deploymentExtractor.RemoveExtractedVolumes(resources.Deployment)
 	}
+	if len(resources.ExtraDeployments) > 0 {
+		values.Deployments = e.deploymentExtractor.ExtractDeploymentValues(
+			resources.ExtraDeployments, metadata.DetectedPrefix)
+	}
 
 	return &Extraction{
 		Metadata: metadata,
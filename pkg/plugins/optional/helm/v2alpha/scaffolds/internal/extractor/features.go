@@ -20,6 +20,8 @@ import (
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
 )
 
 // FeaturesExtractor detects features from resources.
@@ -27,7 +29,9 @@ type FeaturesExtractor struct{}
 
 // FeatureSet represents detected features in the resources.
 // It includes flags for CRDs, webhooks, metrics, Prometheus, cert-manager,
-// NetworkPolicies, NetworkPolicy traffic paths, and cluster-scoped RBAC.
+// NetworkPolicies, NetworkPolicy traffic paths, cluster-scoped RBAC, ConfigMaps, Jobs,
+// ValidatingAdmissionPolicies, Gateway API routes, LimitRanges and ResourceQuotas, PriorityClasses,
+// DaemonSets and StatefulSets.
 // It also includes port configurations and multi-namespace RBAC mappings.
 type FeatureSet struct {
 	HasCRDs                 bool
@@ -39,9 +43,18 @@ type FeatureSet struct {
 	HasMetricsNetworkPolicy bool
 	HasWebhookNetworkPolicy bool
 	HasClusterScopedRBAC    bool
+	HasConfigMaps           bool
+	HasJobs                 bool
+	HasAdmissionPolicies    bool
+	HasGateway              bool
+	HasGovernance           bool
+	HasPriorityClass        bool
+	HasDaemonSets           bool
+	HasStatefulSets         bool
 	WebhookPort             int
 	MetricsPort             int
 	HealthProbePort         int
+	PriorityClassValue      int
 	RoleNamespaces          map[string]string
 }
 
@@ -107,6 +120,30 @@ func (f *FeaturesExtractor) DetectFeatures(resources *ResourceSet, namePrefix, m
 		}
 	}
 
+	for _, obj := range resources.Other {
+		switch obj.GetKind() {
+		case common.KindConfigMap:
+			features.HasConfigMaps = true
+		case common.KindJob, common.KindCronJob:
+			features.HasJobs = true
+		case common.KindValidatingAdmissionPolicy, common.KindValidatingAdmissionPolicyBinding:
+			features.HasAdmissionPolicies = true
+		case common.KindGateway, common.KindHTTPRoute:
+			if strings.HasPrefix(obj.GetAPIVersion(), common.APIGroupGateway+"/") {
+				features.HasGateway = true
+			}
+		case common.KindLimitRange, common.KindResourceQuota:
+			features.HasGovernance = true
+		case common.KindPriorityClass:
+			features.HasPriorityClass = true
+			features.PriorityClassValue, _ = toInt(obj.Object["value"])
+		case common.KindDaemonSet:
+			features.HasDaemonSets = true
+		case common.KindStatefulSet:
+			features.HasStatefulSets = true
+		}
+	}
+
 	// The health probe port is defined on the manager container's --health-probe-bind-address arg.
 	if resources.Deployment != nil {
 		if port := extractHealthProbePortFromDeployment(resources.Deployment); port > 0 {
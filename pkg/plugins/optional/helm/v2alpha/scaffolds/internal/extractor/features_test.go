@@ -143,4 +143,150 @@ var _ = Describe("FeaturesExtractor", func() {
 			Expect(features.HealthProbePort).To(Equal(9091))
 		})
 	})
+
+	Describe("DetectFeatures ConfigMaps", func() {
+		It("should detect ConfigMaps among the uncategorized resources", func() {
+			configMap := &unstructured.Unstructured{}
+			configMap.SetKind("ConfigMap")
+			configMap.SetName("test-project-manager-config")
+
+			features := featuresExtractor.DetectFeatures(
+				&ResourceSet{Other: []*unstructured.Unstructured{configMap}}, "test-project", "test-system")
+
+			Expect(features.HasConfigMaps).To(BeTrue())
+		})
+
+		It("should not report ConfigMaps when there are none", func() {
+			Expect(detect(nil).HasConfigMaps).To(BeFalse())
+		})
+	})
+
+	Describe("DetectFeatures Jobs", func() {
+		It("should detect Jobs and CronJobs among the uncategorized resources", func() {
+			for _, kind := range []string{"Job", "CronJob"} {
+				job := &unstructured.Unstructured{}
+				job.SetKind(kind)
+				job.SetName("test-project-migrate")
+
+				features := featuresExtractor.DetectFeatures(
+					&ResourceSet{Other: []*unstructured.Unstructured{job}}, "test-project", "test-system")
+
+				Expect(features.HasJobs).To(BeTrue(), kind)
+			}
+		})
+
+		It("should not report Jobs when there are none", func() {
+			Expect(detect(nil).HasJobs).To(BeFalse())
+		})
+	})
+
+	Describe("DetectFeatures admission policies", func() {
+		It("should detect ValidatingAdmissionPolicies and their bindings among the uncategorized resources", func() {
+			for _, kind := range []string{"ValidatingAdmissionPolicy", "ValidatingAdmissionPolicyBinding"} {
+				policy := &unstructured.Unstructured{}
+				policy.SetKind(kind)
+				policy.SetName("test-project-require-labels")
+
+				features := featuresExtractor.DetectFeatures(
+					&ResourceSet{Other: []*unstructured.Unstructured{policy}}, "test-project", "test-system")
+
+				Expect(features.HasAdmissionPolicies).To(BeTrue(), kind)
+			}
+		})
+
+		It("should not report admission policies when there are none", func() {
+			Expect(detect(nil).HasAdmissionPolicies).To(BeFalse())
+		})
+	})
+
+	Describe("DetectFeatures Gateway API", func() {
+		It("should detect Gateway API Gateways and HTTPRoutes among the uncategorized resources", func() {
+			for _, kind := range []string{"Gateway", "HTTPRoute"} {
+				route := &unstructured.Unstructured{}
+				route.SetAPIVersion("gateway.networking.k8s.io/v1")
+				route.SetKind(kind)
+				route.SetName("test-project-metrics")
+
+				features := featuresExtractor.DetectFeatures(
+					&ResourceSet{Other: []*unstructured.Unstructured{route}}, "test-project", "test-system")
+
+				Expect(features.HasGateway).To(BeTrue(), kind)
+			}
+		})
+
+		It("should not report a Gateway from another API group", func() {
+			gateway := &unstructured.Unstructured{}
+			gateway.SetAPIVersion("networking.istio.io/v1")
+			gateway.SetKind("Gateway")
+			gateway.SetName("test-project-gateway")
+
+			features := featuresExtractor.DetectFeatures(
+				&ResourceSet{Other: []*unstructured.Unstructured{gateway}}, "test-project", "test-system")
+
+			Expect(features.HasGateway).To(BeFalse())
+		})
+	})
+
+	Describe("DetectFeatures governance", func() {
+		It("should detect LimitRanges and ResourceQuotas among the uncategorized resources", func() {
+			for _, kind := range []string{"LimitRange", "ResourceQuota"} {
+				governance := &unstructured.Unstructured{}
+				governance.SetAPIVersion("v1")
+				governance.SetKind(kind)
+				governance.SetName("test-project-limits")
+
+				features := featuresExtractor.DetectFeatures(
+					&ResourceSet{Other: []*unstructured.Unstructured{governance}}, "test-project", "test-system")
+
+				Expect(features.HasGovernance).To(BeTrue(), kind)
+			}
+		})
+
+		It("should not report governance resources when there are none", func() {
+			Expect(detect(nil).HasGovernance).To(BeFalse())
+		})
+	})
+
+	Describe("DetectFeatures PriorityClass", func() {
+		It("should detect a PriorityClass and its value among the uncategorized resources", func() {
+			priorityClass := &unstructured.Unstructured{Object: map[string]any{"value": 1000000}}
+			priorityClass.SetAPIVersion("scheduling.k8s.io/v1")
+			priorityClass.SetKind("PriorityClass")
+			priorityClass.SetName("test-project-high-priority")
+
+			features := featuresExtractor.DetectFeatures(
+				&ResourceSet{Other: []*unstructured.Unstructured{priorityClass}}, "test-project", "test-system")
+
+			Expect(features.HasPriorityClass).To(BeTrue())
+			Expect(features.PriorityClassValue).To(Equal(1000000))
+		})
+	})
+
+	Describe("DetectFeatures DaemonSets", func() {
+		It("should detect DaemonSets among the uncategorized resources", func() {
+			daemonSet := &unstructured.Unstructured{}
+			daemonSet.SetKind("DaemonSet")
+			daemonSet.SetName("test-project-node-manager")
+
+			features := featuresExtractor.DetectFeatures(
+				&ResourceSet{Other: []*unstructured.Unstructured{daemonSet}}, "test-project", "test-system")
+
+			Expect(features.HasDaemonSets).To(BeTrue())
+			Expect(detect(nil).HasDaemonSets).To(BeFalse())
+		})
+	})
+
+	Describe("DetectFeatures StatefulSets", func() {
+		It("should detect StatefulSets among the uncategorized resources", func() {
+			statefulSet := &unstructured.Unstructured{}
+			statefulSet.SetKind("StatefulSet")
+			statefulSet.SetName("test-project-state")
+
+			features := featuresExtractor.DetectFeatures(
+				&ResourceSet{Other: []*unstructured.Unstructured{statefulSet}}, "test-project", "test-system")
+
+			Expect(features.HasStatefulSets).To(BeTrue())
+			Expect(detect(nil).HasStatefulSets).To(BeFalse())
+		})
+	})
 })
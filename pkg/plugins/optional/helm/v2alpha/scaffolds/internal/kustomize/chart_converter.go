@@ -33,19 +33,29 @@ type ChartConverter struct {
 	detectedPrefix string
 	chartName      string
 	outputDir      string
+	// keepNamespace adds the Namespace of the kustomize output to the chart, templated by the
+	// templater as configured by templater.Options.KeepNamespace.
+	keepNamespace bool
 
 	categorizer *ResourceCategorizer
 	templater   *templater.Templater
 	generator   *ChartGenerator
 }
 
-// NewChartConverter creates a new chart converter.
+// NewChartConverter creates a new chart converter templating the resources as configured by opts.
 func NewChartConverter(
 	resources *ParsedResources, detectedPrefix, chartName, managerNamespace, outputDir string,
-	roleNamespaces map[string]string,
+	roleNamespaces map[string]string, opts templater.Options,
 ) *ChartConverter {
 	categorizer := NewResourceCategorizer(resources)
-	t := templater.NewTemplater(detectedPrefix, chartName, managerNamespace, roleNamespaces)
+	t := templater.NewTemplater(detectedPrefix, chartName, managerNamespace, roleNamespaces, opts)
+	if len(resources.ExtraDeployments) > 0 {
+		keys := make(map[string]string, len(resources.ExtraDeployments))
+		for _, deployment := range resources.ExtraDeployments {
+			keys[deployment.GetName()] = extractor.DeploymentValuesKey(detectedPrefix, deployment.GetName())
+		}
+		t.SetDeploymentValuesKeys(keys)
+	}
 	chartGenerator := NewChartGenerator(t, detectedPrefix)
 
 	return &ChartConverter{
@@ -53,6 +63,7 @@ func NewChartConverter(
 		detectedPrefix: detectedPrefix,
 		chartName:      chartName,
 		outputDir:      outputDir,
+		keepNamespace:  opts.KeepNamespace,
 		categorizer:    categorizer,
 		templater:      t,
 		generator:      chartGenerator,
@@ -62,6 +73,9 @@ func NewChartConverter(
 // GetChartBuilders converts resources to machinery.Builders for chart template files.
 func (c *ChartConverter) GetChartBuilders() []machinery.Builder {
 	resourceGroups := c.categorizer.CategorizeByFunction()
+	if c.keepNamespace && c.resources.Namespace != nil {
+		resourceGroups["namespace"] = []*unstructured.Unstructured{c.resources.Namespace}
+	}
 
 	for groupName, resources := range resourceGroups {
 		resourceGroups[groupName] = dedupeResources(resources)
@@ -151,6 +165,12 @@ func convertValuesConfigToMap(vc extractor.ValuesConfig) map[string]any {
 	if m.PriorityClassName != "" {
 		config["priorityClassName"] = m.PriorityClassName
 	}
+	if m.HostNetwork {
+		config["hostNetwork"] = true
+	}
+	if m.DNSPolicy != "" {
+		config["dnsPolicy"] = m.DNSPolicy
+	}
 	if m.TopologySpreadConstraints != nil {
 		config["topologySpreadConstraints"] = m.TopologySpreadConstraints
 	}
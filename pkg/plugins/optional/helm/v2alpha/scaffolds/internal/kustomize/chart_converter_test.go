@@ -26,6 +26,7 @@ import (
 
 	"sigs.k8s.io/kubebuilder/v4/pkg/machinery"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/extractor"
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/kustomize/templater"
 )
 
 const (
@@ -88,6 +89,7 @@ var _ = Describe("ChartConverter", func() {
 		// Create converter
 		converter = NewChartConverter(
 			resources, testProjectName, testProjectName, testNamespaceTestSystem, "dist", make(map[string]string),
+			templater.Options{},
 		)
 	})
 
@@ -130,6 +132,26 @@ var _ = Describe("ChartConverter", func() {
 			Expect(exists).To(BeTrue())
 		})
 
+		It("should add the Namespace to the chart only with KeepNamespace", func() {
+			namespace := &unstructured.Unstructured{}
+			namespace.SetAPIVersion("v1")
+			namespace.SetKind("Namespace")
+			namespace.SetName(testNamespaceTestSystem)
+			resources.Namespace = namespace
+
+			Expect(converter.GetChartBuilders()).NotTo(ContainElement(
+				HaveField("RelativePath", "namespace/namespace.yaml")))
+
+			keeping := NewChartConverter(
+				resources, testProjectName, testProjectName, testNamespaceTestSystem, "dist", make(map[string]string),
+				templater.Options{KeepNamespace: true},
+			)
+			Expect(keeping.GetChartBuilders()).To(ContainElement(And(
+				HaveField("RelativePath", "namespace/namespace.yaml"),
+				HaveField("Content", ContainSubstring("name: {{ .Release.Namespace }}")),
+			)))
+		})
+
 		It("should deduplicate identical resources within a group", func() {
 			// Prepare two identical Services in the metrics group
 			metricsSvc1 := &unstructured.Unstructured{}
@@ -215,7 +237,7 @@ var _ = Describe("ChartConverter", func() {
 
 			args, ok := config[testYAMLFieldArgs].([]any)
 			Expect(ok).To(BeTrue())
-			Expect(args).To(ContainElement("--leader-elect"))
+			Expect(args).NotTo(ContainElement("--leader-elect"))
 			Expect(args).To(ContainElement("--custom-flag=value"))
 			Expect(args).NotTo(ContainElement("--metrics-bind-address=:8443"))
 			Expect(args).NotTo(ContainElement("--health-probe-bind-address=:8081"))
@@ -514,6 +536,39 @@ var _ = Describe("ChartConverter", func() {
 			Expect(config["priorityClassName"]).To(Equal("high-priority"))
 		})
 
+		It("should extract hostNetwork and dnsPolicy from pod spec", func() {
+			containers := []any{
+				map[string]any{testYAMLFieldName: testContainerNameManager, testYAMLFieldImage: testContainerImageController},
+			}
+			err := unstructured.SetNestedSlice(resources.Deployment.Object, containers, "spec", "template", "spec", "containers")
+			Expect(err).NotTo(HaveOccurred())
+			err = unstructured.SetNestedField(resources.Deployment.Object, true, "spec", "template", "spec", "hostNetwork")
+			Expect(err).NotTo(HaveOccurred())
+			err = unstructured.SetNestedField(
+				resources.Deployment.Object, "ClusterFirstWithHostNet", "spec", "template", "spec", "dnsPolicy")
+			Expect(err).NotTo(HaveOccurred())
+
+			config := extractDeploymentConfig(resources.Deployment)
+
+			Expect(config).To(HaveKeyWithValue("hostNetwork", true))
+			Expect(config).To(HaveKeyWithValue("dnsPolicy", "ClusterFirstWithHostNet"))
+		})
+
+		It("should not extract dnsPolicy when hostNetwork is disabled", func() {
+			containers := []any{
+				map[string]any{testYAMLFieldName: testContainerNameManager, testYAMLFieldImage: testContainerImageController},
+			}
+			err := unstructured.SetNestedSlice(resources.Deployment.Object, containers, "spec", "template", "spec", "containers")
+			Expect(err).NotTo(HaveOccurred())
+			err = unstructured.SetNestedField(resources.Deployment.Object, "Default", "spec", "template", "spec", "dnsPolicy")
+			Expect(err).NotTo(HaveOccurred())
+
+			config := extractDeploymentConfig(resources.Deployment)
+
+			Expect(config).NotTo(HaveKey("hostNetwork"))
+			Expect(config).NotTo(HaveKey("dnsPolicy"))
+		})
+
 		It("should extract topologySpreadConstraints from pod spec", func() {
 			// Set up deployment with topologySpreadConstraints
 			containers := []any{
@@ -646,7 +701,40 @@ var _ = Describe("ChartConverter", func() {
 			// Verify Helm templates are applied
 			Expect(contentStr).To(ContainSubstring("{{ .Release.Namespace }}"))
 			Expect(contentStr).To(ContainSubstring("app.kubernetes.io/name:"))
-			Expect(contentStr).To(ContainSubstring("app.kubernetes.io/managed-by:"))
+			Expect(contentStr).To(ContainSubstring(`{{- include "test-project.labels" . | nindent 4 }}`))
+		})
+
+		It("should add a config checksum of the extras ConfigMaps to the manager pod template", func() {
+			configMap := &unstructured.Unstructured{}
+			configMap.SetAPIVersion("v1")
+			configMap.SetKind("ConfigMap")
+			configMap.SetName("test-project-manager-config")
+			configMap.SetNamespace(testNamespaceTestSystem)
+			configMap.Object["data"] = map[string]any{"log-level": "info"}
+			resources.Other = []*unstructured.Unstructured{configMap}
+
+			resources.Deployment.SetName("test-project-controller-manager")
+			resources.Deployment.SetLabels(map[string]string{"control-plane": "controller-manager"})
+			err := unstructured.SetNestedField(resources.Deployment.Object, map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]any{"kubectl.kubernetes.io/default-container": "manager"},
+				},
+				"spec": map[string]any{
+					"containers": []any{map[string]any{"name": "manager", "image": "controller:latest"}},
+				},
+			}, "spec", "template")
+			Expect(err).NotTo(HaveOccurred())
+
+			scaffold := machinery.NewScaffold(fs)
+			Expect(scaffold.Execute(converter.GetChartBuilders()...)).To(Succeed())
+
+			exists, err := afero.Exists(fs.FS, "dist/chart/templates/extras/manager-config.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			content, err := afero.ReadFile(fs.FS, "dist/chart/templates/manager/manager.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring("      annotations:\n        checksum/config: " +
+				`{{ include (print $.Template.BasePath "/extras/manager-config.yaml") . | sha256sum }}` + "\n"))
 		})
 
 		It("should place custom Service in extras directory", func() {
@@ -816,12 +904,10 @@ var _ = Describe("ChartConverter", func() {
 			Expect(err).NotTo(HaveOccurred())
 			contentStr := string(content)
 
-			// Verify all standard Helm labels are present
+			// Verify the standard Helm labels helper is included next to the name label
 			Expect(contentStr).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(contentStr).To(ContainSubstring("app.kubernetes.io/instance: {{ .Release.Name }}"))
-			Expect(contentStr).To(ContainSubstring("app.kubernetes.io/managed-by: {{ .Release.Service }}"))
-			Expect(contentStr).To(ContainSubstring(
-				`helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}`))
+			Expect(contentStr).To(ContainSubstring(`{{- include "test-project.labels" . | nindent 4 }}`))
+			Expect(contentStr).NotTo(ContainSubstring("app.kubernetes.io/managed-by:"))
 		})
 
 		It("should not place webhook or metrics services in extras", func() {
@@ -19,6 +19,8 @@ package kustomize
 import (
 	"bytes"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -61,6 +63,8 @@ func (g *ChartGenerator) GenerateChart(
 	managerNamespace := ""
 	if g.templater != nil {
 		managerNamespace = g.templater.GetManagerNamespace()
+		g.templater.SetConfigMapTemplates(
+			g.templatesGen.configMapTemplates(resourceGroups, g.detectedPrefix, managerNamespace))
 	}
 	return &ChartFiles{
 		TemplateFiles: g.templatesGen.Generate(resourceGroups, g.templater, g.detectedPrefix, managerNamespace),
@@ -88,19 +92,29 @@ func (g *TemplatesGenerator) Generate(
 		if g.shouldSplitFiles(groupName) {
 			for i, resource := range resources {
 				templatedYAML := g.templateResource(resource, t)
+				// Resources the chart does not ship are templated to nothing.
+				if strings.TrimSpace(templatedYAML) == "" {
+					continue
+				}
 				filename := g.generateFileName(resource, i, groupName, detectedPrefix, managerNamespace)
 				path := fmt.Sprintf("%s/%s", groupName, filename)
 				templates[path] = templatedYAML
 			}
 		} else {
 			var buf bytes.Buffer
-			for i, resource := range resources {
-				if i > 0 {
+			for _, resource := range resources {
+				templatedYAML := g.templateResource(resource, t)
+				if strings.TrimSpace(templatedYAML) == "" {
+					continue
+				}
+				if buf.Len() > 0 {
 					buf.WriteString("---\n")
 				}
-				templatedYAML := g.templateResource(resource, t)
 				buf.WriteString(templatedYAML)
 			}
+			if buf.Len() == 0 {
+				continue
+			}
 			path := fmt.Sprintf("%s/%s.yaml", groupName, groupName)
 			templates[path] = buf.String()
 		}
@@ -109,6 +123,30 @@ func (g *TemplatesGenerator) Generate(
 	return templates
 }
 
+// configMapTemplates returns the paths, as returned by Generate, of the templates holding ConfigMaps.
+func (g *TemplatesGenerator) configMapTemplates(
+	resourceGroups map[string][]*unstructured.Unstructured, detectedPrefix string, managerNamespace string,
+) []string {
+	var paths []string
+	for groupName, resources := range resourceGroups {
+		for i, resource := range resources {
+			if resource.GetKind() != "ConfigMap" {
+				continue
+			}
+			path := fmt.Sprintf("%s/%s.yaml", groupName, groupName)
+			if g.shouldSplitFiles(groupName) {
+				path = fmt.Sprintf("%s/%s", groupName,
+					g.generateFileName(resource, i, groupName, detectedPrefix, managerNamespace))
+			}
+			if !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
 func (g *TemplatesGenerator) templateResource(
 	resource *unstructured.Unstructured,
 	t *templater.Templater,
@@ -128,7 +166,36 @@ func (g *TemplatesGenerator) templateResource(
 	if t == nil {
 		return yamlContent
 	}
-	return t.ApplyHelmSubstitutions(yamlContent, resource)
+	templated, err := t.ApplyHelmSubstitutionsE(yamlContent, resource)
+	if err != nil {
+		slog.Warn("Some Helm substitutions could not be applied; please review the generated template",
+			"kind", resource.GetKind(), "name", resource.GetName(), "error", err)
+	}
+	if report := t.Report(); report != nil {
+		slog.Info("Applied Helm substitutions",
+			"kind", resource.GetKind(), "name", resource.GetName(), "steps", changedSteps(report))
+	}
+	if err := t.Validate(templated); err != nil {
+		slog.Warn("Generated Helm template may be invalid; please review it",
+			"kind", resource.GetKind(), "name", resource.GetName(), "error", err)
+	}
+	if _, err := t.LintHardcodedNames(templated); err != nil {
+		slog.Warn("Generated Helm template keeps names that are not scoped to the release; please review it",
+			"kind", resource.GetKind(), "name", resource.GetName(), "error", err)
+	}
+	return templated
+}
+
+// changedSteps returns the sorted names of the substitution steps report marks as changed.
+func changedSteps(report map[string]bool) []string {
+	steps := make([]string, 0, len(report))
+	for step, changed := range report {
+		if changed {
+			steps = append(steps, step)
+		}
+	}
+	slices.Sort(steps)
+	return steps
 }
 
 func (g *TemplatesGenerator) shouldSplitFiles(groupName string) bool {
@@ -17,6 +17,9 @@ limitations under the License.
 package appliers
 
 import (
+	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -38,6 +41,22 @@ func SubstituteCertManagerReferences(
 			yamlContent, hardcodedIssuerRef, ResourceNameTemplate(chartName, "selfsigned-issuer"))
 	}
 
+	if kind == common.KindIssuer {
+		yamlContent = TemplateIssuerSpec(yamlContent)
+	}
+
+	if kind == common.KindCertificate {
+		yamlContent = TemplateCertificateValuesFields(yamlContent)
+	}
+
+	if kind == common.KindCertificate && isMetricsCertificate(resource.GetName()) {
+		yamlContent = TemplateCertificateSecretName(yamlContent, ".Values.metrics.certSecretName")
+	}
+	if kind == common.KindCertificate && isWebhookCertificate(resource.GetName()) {
+		yamlContent = TemplateCertificateSecretName(yamlContent, ".Values.webhook.certSecretName")
+		yamlContent = TemplateCertificateExtraDNSNames(yamlContent, ".Values.webhook.extraDNSNames")
+	}
+
 	if kind == common.KindValidatingWebhook || kind == common.KindMutatingWebhook || kind == common.KindCRD {
 		hardcodedService := "name: " + detectedPrefix + "-webhook-service"
 		templatedService := "name: " + ResourceNameTemplate(chartName, "webhook-service")
@@ -56,3 +75,139 @@ func SubstituteCertManagerAnnotations(detectedPrefix, chartName, yamlContent str
 	yamlContent = strings.ReplaceAll(yamlContent, hardcodedMetricsCert, ResourceNameTemplate(chartName, "metrics-certs"))
 	return yamlContent
 }
+
+// certificateSecretNamePattern matches a literal Certificate spec.secretName
+var certificateSecretNamePattern = regexp.MustCompile(`(?m)^(\s*)secretName:\s*["']?([^"'\s{]+)["']?\s*$`)
+
+// TemplateCertificateSecretName templates the Secret a Certificate writes to from valuesKey, defaulting
+// to the name found in the manifest, so it stays in sync with the pod volume that mounts it.
+func TemplateCertificateSecretName(yamlContent, valuesKey string) string {
+	return certificateSecretNamePattern.ReplaceAllStringFunc(yamlContent, func(match string) string {
+		groups := certificateSecretNamePattern.FindStringSubmatch(match)
+		return fmt.Sprintf("%ssecretName: {{ %s | default %q }}", groups[1], valuesKey, groups[2])
+	})
+}
+
+// TemplateCertificateExtraDNSNames appends the names listed in valuesKey to the dnsNames of a
+// Certificate, e.g. the host of an ingress in front of the Service the certificate is issued for.
+func TemplateCertificateExtraDNSNames(yamlContent, valuesKey string) string {
+	if strings.Contains(yamlContent, valuesKey) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	dnsNames := slices.Index(lines, "  dnsNames:")
+	if dnsNames < 0 {
+		return yamlContent
+	}
+	end := dnsNames + 1
+	for end < len(lines) && strings.HasPrefix(lines[end], "  - ") {
+		end++
+	}
+
+	// Names are quoted so a wildcard such as *.example.com is not read as a YAML alias.
+	block := []string{
+		"  {{- range " + valuesKey + " }}",
+		"  - {{ . | quote }}",
+		"  {{- end }}",
+	}
+	return strings.Join(slices.Insert(lines, end, block...), "\n")
+}
+
+// certificateValuesFields are the Certificate spec fields that can be set from .Values.certManager.
+var certificateValuesFields = []string{"ipAddresses", "subject"}
+
+// TemplateCertificateValuesFields sets the ipAddresses and subject of a Certificate from
+// .Values.certManager when they are set there. A field already in the manifest is rendered when
+// values leave it unset; a missing field is only rendered from values.
+func TemplateCertificateValuesFields(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	spec := slices.Index(lines, common.YamlKeySpec)
+	if spec < 0 {
+		return yamlContent
+	}
+
+	// Missing fields are inserted in order at the top of spec.
+	insertAt := spec + 1
+	for _, field := range certificateValuesFields {
+		valuePath := ".Values.certManager." + field
+		if strings.Contains(yamlContent, valuePath) {
+			continue
+		}
+		block := []string{
+			"  {{- with " + valuePath + " }}",
+			"  " + field + ":",
+			"    {{- toYaml . | nindent 4 }}",
+		}
+
+		start := slices.IndexFunc(lines[spec+1:], func(line string) bool {
+			return line == "  "+field+":" || strings.HasPrefix(line, "  "+field+": ")
+		})
+		if start < 0 {
+			block = append(block, "  {{- end }}")
+			lines = slices.Insert(lines, insertAt, block...)
+			insertAt += len(block)
+			continue
+		}
+
+		start += spec + 1
+		end := start + 1
+		for ; end < len(lines); end++ {
+			_, indent := LeadingWhitespace(lines[end])
+			trimmed := strings.TrimSpace(lines[end])
+			if trimmed != "" && (indent < 2 || (indent == 2 && !strings.HasPrefix(trimmed, "- "))) {
+				break
+			}
+		}
+		block = append(append(append(block, "  {{- else }}"), lines[start:end]...), "  {{- end }}")
+		lines = slices.Replace(lines, start, end, block...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TemplateIssuerSpec replaces the spec of an Issuer with .Values.certManager.issuerSpec when it is set,
+// e.g. a CA issuer referencing a Secret or an ACME issuer. The spec from the manifest, the self-signed
+// issuer of the scaffold, is rendered otherwise.
+func TemplateIssuerSpec(yamlContent string) string {
+	const valuePath = ".Values.certManager.issuerSpec"
+	if strings.Contains(yamlContent, valuePath) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	spec := slices.Index(lines, common.YamlKeySpec)
+	if spec < 0 {
+		return yamlContent
+	}
+	end := spec + 1
+	for ; end < len(lines); end++ {
+		_, indent := LeadingWhitespace(lines[end])
+		if strings.TrimSpace(lines[end]) != "" && indent == 0 {
+			break
+		}
+	}
+	for end > spec+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	if end == spec+1 {
+		return yamlContent
+	}
+
+	block := []string{
+		"  {{- with " + valuePath + " }}",
+		"  {{- toYaml . | nindent 2 }}",
+		"  {{- else }}",
+	}
+	block = append(append(block, lines[spec+1:end]...), "  {{- end }}")
+	return strings.Join(slices.Replace(lines, spec+1, end, block...), "\n")
+}
+
+// isMetricsCertificate reports whether name is the metrics server Certificate.
+func isMetricsCertificate(name string) bool {
+	return strings.HasSuffix(name, "-metrics-certs") || strings.HasSuffix(name, "-metrics-cert")
+}
+
+// isWebhookCertificate reports whether name is the webhook serving Certificate.
+func isWebhookCertificate(name string) bool {
+	return strings.HasSuffix(name, "-serving-cert")
+}
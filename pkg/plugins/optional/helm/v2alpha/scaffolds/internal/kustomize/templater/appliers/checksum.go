@@ -0,0 +1,125 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ConfigChecksumAnnotation is the pod template annotation holding the checksum of the chart config.
+const ConfigChecksumAnnotation = "checksum/config"
+
+// RestartedAtAnnotation is the pod template annotation kubectl rollout restart sets.
+const RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// forceRestartValuesPath is the values flag stamping the manager pod template with the render time.
+const forceRestartValuesPath = ".Values.manager.forceRestart"
+
+// AddConfigChecksumAnnotation adds a checksum/config annotation to the pod template of a workload,
+// hashing the rendered chart templates at templatePaths (relative to the chart templates
+// directory). A change to those templates, or to the values they render, then rolls the pods on
+// upgrade. Only the pod template metadata is changed; the workload metadata is left as is.
+func AddConfigChecksumAnnotation(yamlContent string, templatePaths []string) string {
+	if len(templatePaths) == 0 || strings.Contains(yamlContent, ConfigChecksumAnnotation+":") {
+		return yamlContent
+	}
+	return insertPodTemplateAnnotations(yamlContent, false, func(indent string) []string {
+		return []string{fmt.Sprintf("%s%s: %s", indent, ConfigChecksumAnnotation, configChecksum(templatePaths))}
+	})
+}
+
+// AddForceRestartAnnotation adds a kubectl.kubernetes.io/restartedAt annotation holding the render
+// time to the pod template of a workload when .Values.manager.forceRestart is true, so every install
+// and upgrade rolls the pods the way kubectl rollout restart does. Nothing is rendered when the flag
+// is false. The annotation is written by toYaml so the pod annotations from values do not omit it.
+func AddForceRestartAnnotation(yamlContent string) string {
+	if strings.Contains(yamlContent, forceRestartValuesPath) {
+		return yamlContent
+	}
+	return insertPodTemplateAnnotations(yamlContent, true, func(indent string) []string {
+		return []string{
+			fmt.Sprintf("%s{{- if %s }}", indent, forceRestartValuesPath),
+			fmt.Sprintf(`%s{{- toYaml (dict %q (now | date "2006-01-02T15:04:05Z07:00")) | nindent %d }}`,
+				indent, RestartedAtAnnotation, len(indent)),
+			indent + "{{- end }}",
+		}
+	})
+}
+
+// insertPodTemplateAnnotations inserts the lines built by annotations, given their indentation, at
+// the top of the pod template annotations of a workload, or at the bottom when last is set, creating
+// the annotations block when the pod template has none. Flow-style annotations are left alone.
+func insertPodTemplateAnnotations(yamlContent string, last bool, annotations func(indent string) []string) string {
+	lines := strings.Split(yamlContent, "\n")
+	template := slices.Index(lines, "  template:")
+	if template < 0 {
+		return yamlContent
+	}
+	metadata := -1
+	for i := template + 1; i < len(lines); i++ {
+		if _, indent := LeadingWhitespace(lines[i]); strings.TrimSpace(lines[i]) != "" && indent <= 2 {
+			break
+		}
+		if strings.TrimSpace(lines[i]) == "metadata:" {
+			metadata = i
+			break
+		}
+	}
+	if metadata < 0 {
+		return yamlContent
+	}
+
+	metadataIndent, metadataIndentLen := LeadingWhitespace(lines[metadata])
+	childIndent := metadataIndent + "  "
+	inserted := annotations(childIndent + "  ")
+	for i := metadata + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if _, indent := LeadingWhitespace(lines[i]); trimmed != "" && indent <= metadataIndentLen {
+			break
+		}
+		if lines[i] == childIndent+"annotations:" {
+			at := i + 1
+			for last && at < len(lines) &&
+				(strings.TrimSpace(lines[at]) == "" || strings.HasPrefix(lines[at], childIndent+" ")) {
+				at++
+			}
+			return strings.Join(slices.Insert(lines, at, inserted...), "\n")
+		}
+		if strings.HasPrefix(lines[i], childIndent+"annotations:") {
+			return yamlContent
+		}
+	}
+	inserted = append([]string{childIndent + "annotations:"}, inserted...)
+	return strings.Join(slices.Insert(lines, metadata+1, inserted...), "\n")
+}
+
+// configChecksum returns the template hashing the rendered templates at templatePaths. The
+// includes are chained with cat so the expression starts with include, which keeps it from
+// being escaped when the chart is templated again.
+func configChecksum(templatePaths []string) string {
+	includes := make([]string, len(templatePaths))
+	for i, path := range templatePaths {
+		includes[i] = fmt.Sprintf("include (print $.Template.BasePath %q) .", "/"+path)
+	}
+	expression := includes[0]
+	for _, include := range includes[1:] {
+		expression += " | cat (" + include + ")"
+	}
+	return "{{ " + expression + " | sha256sum }}"
+}
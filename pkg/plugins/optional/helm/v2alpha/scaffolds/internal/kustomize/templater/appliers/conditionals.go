@@ -19,6 +19,7 @@ package appliers
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -26,9 +27,36 @@ import (
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
 )
 
+// RBACClass decides how an RBAC resource is rendered.
+type RBACClass int
+
+const (
+	// RBACClassDefault applies the built-in rules for the scaffolded role names.
+	RBACClassDefault RBACClass = iota
+	// RBACClassHelper renders the resource only when rbac.helpers.enabled, like the admin, editor and
+	// viewer roles.
+	RBACClassHelper
+	// RBACClassEssential always renders the resource, like the manager and leader-election roles.
+	RBACClassEssential
+)
+
 // AddConditionalWrappers wraps resources with appropriate {{- if .Values.* }} conditionals.
 // Each resource type gets wrapped based on its purpose and dependencies.
 func AddConditionalWrappers(yamlContent string, resource *unstructured.Unstructured) string {
+	return AddConditionalWrappersClassified(yamlContent, resource, nil)
+}
+
+// AddConditionalWrappersClassified is AddConditionalWrappers with classifyRBAC deciding, by resource
+// name, which RBAC resources are helpers and which are essential. A nil classifyRBAC, or one returning
+// RBACClassDefault, keeps the built-in rules.
+func AddConditionalWrappersClassified(
+	yamlContent string, resource *unstructured.Unstructured, classifyRBAC func(name string) RBACClass,
+) string {
+	// Rendered kustomize output starts with a YAML key; a leading directive means it is already wrapped.
+	if strings.HasPrefix(yamlContent, "{{- if ") {
+		return yamlContent
+	}
+
 	kind := resource.GetKind()
 	apiVersion := resource.GetAPIVersion()
 	name := resource.GetName()
@@ -39,6 +67,7 @@ func AddConditionalWrappers(yamlContent string, resource *unstructured.Unstructu
 	case kind == common.KindCRD:
 		// Add resource-policy annotation to prevent deletion on helm uninstall
 		yamlContent = InjectCRDResourcePolicyAnnotation(yamlContent)
+		yamlContent = TemplateCRDConversion(yamlContent)
 		return fmt.Sprintf("{{- if .Values.crd.enabled }}\n%s{{- end }}\n", yamlContent)
 	case kind == common.KindCertificate && apiVersion == common.APIVersionCertManager:
 		return HandleCertificateConditionalWrappers(yamlContent, name)
@@ -57,9 +86,15 @@ func AddConditionalWrappers(yamlContent string, resource *unstructured.Unstructu
 		return fmt.Sprintf("{{- if .Values.networkPolicy.enabled }}\n%s\n{{- end }}", yamlContent)
 	case kind == common.KindServiceAccount, kind == common.KindRole, kind == common.KindClusterRole,
 		kind == common.KindRoleBinding, kind == common.KindClusterRoleBinding:
-		return HandleRBACConditionalWrappers(yamlContent, kind, name)
+		class := RBACClassDefault
+		if classifyRBAC != nil {
+			class = classifyRBAC(name)
+		}
+		return HandleRBACConditionalWrappers(yamlContent, kind, name, class)
 	case kind == common.KindValidatingWebhook || kind == common.KindMutatingWebhook:
 		yamlContent = MakeWebhookAnnotationsConditional(yamlContent)
+		yamlContent = TemplateWebhookCABundle(yamlContent)
+		yamlContent = TemplateWebhookPolicies(yamlContent)
 		return fmt.Sprintf("{{- if .Values.webhook.enabled }}\n%s{{- end }}\n", yamlContent)
 	case kind == common.KindService:
 		return HandleServiceConditionalWrappers(yamlContent, name)
@@ -73,16 +108,65 @@ func AddConditionalWrappers(yamlContent string, resource *unstructured.Unstructu
 			)
 		}
 		return yamlContent
+	case kind == common.KindJob || kind == common.KindCronJob:
+		return fmt.Sprintf("{{- if .Values.jobs.enabled }}\n%s\n{{- end }}", yamlContent)
+	case kind == common.KindValidatingAdmissionPolicy || kind == common.KindValidatingAdmissionPolicyBinding:
+		return fmt.Sprintf("{{- if .Values.admissionPolicy.enabled }}\n%s\n{{- end }}", yamlContent)
+	case IsGatewayAPIKind(kind, apiVersion):
+		return fmt.Sprintf("{{- if .Values.gateway.enabled }}\n%s\n{{- end }}", yamlContent)
+	case kind == common.KindLimitRange || kind == common.KindResourceQuota:
+		return fmt.Sprintf("{{- if .Values.governance.enabled }}\n%s\n{{- end }}", yamlContent)
+	case kind == common.KindPriorityClass:
+		yamlContent = TemplatePriorityClassValue(yamlContent)
+		return fmt.Sprintf("{{- if .Values.priorityClass.create }}\n%s\n{{- end }}", yamlContent)
 	default:
 		return yamlContent
 	}
 }
 
+// IsGatewayAPIKind reports whether kind and apiVersion name a Gateway API Gateway or HTTPRoute. The
+// group is checked because other projects, such as Istio, ship a Gateway kind of their own.
+func IsGatewayAPIKind(kind, apiVersion string) bool {
+	return (kind == common.KindGateway || kind == common.KindHTTPRoute) &&
+		strings.HasPrefix(apiVersion, common.APIGroupGateway+"/")
+}
+
+// priorityClassValuePattern matches the top-level value of a PriorityClass.
+var priorityClassValuePattern = regexp.MustCompile(`(?m)^value:\s*-?\d+\s*$`)
+
+// TemplatePriorityClassValue reads the value of a PriorityClass shipped with the chart from
+// priorityClass.value.
+func TemplatePriorityClassValue(yamlContent string) string {
+	return priorityClassValuePattern.ReplaceAllString(yamlContent, "value: {{ .Values.priorityClass.value }}")
+}
+
+// namespaceCreateCondition renders the Namespace only when namespace.create is set; the namespace map
+// is optional in values.yaml.
+const namespaceCreateCondition = "{{- if (.Values.namespace | default dict).create }}"
+
+// TemplateNamespaceResource keeps a Namespace from the kustomize output instead of dropping it: the
+// Namespace is named after the release namespace and rendered only when namespace.create is set, so
+// installs into an existing namespace leave it alone.
+func TemplateNamespaceResource(yamlContent string) string {
+	if strings.HasPrefix(yamlContent, "{{- if ") {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	for i, line := range lines {
+		indent, _ := LeadingWhitespace(line)
+		if strings.HasPrefix(strings.TrimSpace(line), "name:") && isChildOf(lines, i, common.YamlKeyMetadata) {
+			lines[i] = indent + "name: {{ .Release.Namespace }}"
+			break
+		}
+	}
+	return fmt.Sprintf("%s\n%s\n{{- end }}\n", namespaceCreateCondition, strings.Join(lines, "\n"))
+}
+
 // HandleCertificateConditionalWrappers handles conditional logic for Certificate resources.
 // Uses suffix matching to avoid false positives when project name contains "metrics".
 func HandleCertificateConditionalWrappers(yamlContent, name string) string {
-	isMetricsCert := strings.HasSuffix(name, "-metrics-certs") || strings.HasSuffix(name, "-metrics-cert")
-	if isMetricsCert {
+	if isMetricsCertificate(name) {
 		// Metrics certificates require certManager AND metrics.secure=true (TLS enabled)
 		return fmt.Sprintf(
 			"{{- if and .Values.certManager.enabled .Values.metrics.enabled .Values.metrics.secure }}\n%s{{- end }}\n",
@@ -105,8 +189,9 @@ func HandleServiceConditionalWrappers(yamlContent, name string) string {
 }
 
 // HandleRBACConditionalWrappers handles conditional logic for RBAC resources.
-// Uses suffix matching to avoid false positives when project name contains role types.
-func HandleRBACConditionalWrappers(yamlContent, kind, name string) string {
+// Uses suffix matching to avoid false positives when project name contains role types; class
+// overrides the helper and essential classification for the resource.
+func HandleRBACConditionalWrappers(yamlContent, kind, name string, class RBACClass) string {
 	// Helper roles (admin, editor, viewer) provide Kubernetes RBAC for custom resources.
 	// These allow cluster admins to grant different access levels to CRs without cluster-admin.
 	// Example: Grant namespace-scoped editor access to a team managing CRs in their namespace.
@@ -128,11 +213,23 @@ func HandleRBACConditionalWrappers(yamlContent, kind, name string) string {
 		yamlContent = MakeRBACKindConditional(yamlContent, kind)
 	}
 
+	switch class {
+	case RBACClassHelper:
+		isHelper = true
+	case RBACClassEssential:
+		return yamlContent
+	}
+
 	if isHelper {
 		return fmt.Sprintf("{{- if .Values.rbac.helpers.enabled }}\n%s{{- end }}\n", yamlContent)
 	}
-	// metrics-auth-role, metrics-reader, and metrics-auth-rolebinding all require secure metrics
-	if isMetricsAuthRole || isMetricsReader || isMetricsAuthBinding {
+	// External scrapers bind to metrics-reader, so it has its own toggle; unset, it follows the
+	// metrics-auth resources.
+	if isMetricsReader {
+		return fmt.Sprintf("%s\n%s{{- end }}\n", metricsReaderCondition, yamlContent)
+	}
+	// metrics-auth-role and metrics-auth-rolebinding require secure metrics
+	if isMetricsAuthRole || isMetricsAuthBinding {
 		return fmt.Sprintf("{{- if and .Values.metrics.enabled .Values.metrics.secure }}\n%s{{- end }}\n", yamlContent)
 	}
 	// Essential RBAC (manager, leader-election) - always created
@@ -142,6 +239,10 @@ func HandleRBACConditionalWrappers(yamlContent, kind, name string) string {
 // MakeRBACKindConditional adds conditional rendering for ClusterRole/ClusterRoleBinding
 // to switch between cluster-scoped and namespace-scoped based on .Values.rbac.namespaced.
 func MakeRBACKindConditional(yamlContent string, kind string) string {
+	if strings.Contains(yamlContent, ".Values.rbac.namespaced") {
+		return yamlContent
+	}
+
 	var replacements []struct{ old, new string }
 
 	if kind == common.KindClusterRole {
@@ -183,6 +284,40 @@ func MakeRBACKindConditional(yamlContent string, kind string) string {
 	return result
 }
 
+// TemplateCRDConversion makes the conversion webhook of a CRD conditional on .Values.webhook.enabled.
+// The strategy falls back to None and the webhook block, whose clientConfig points at the webhook
+// Service, is dropped when webhooks are disabled, since the API server rejects a webhook block
+// without the Webhook strategy and cannot reach a Service that is not deployed.
+func TemplateCRDConversion(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	conversion := slices.Index(lines, "  conversion:")
+	if conversion < 0 || strings.Contains(yamlContent, "{{- if .Values.webhook.enabled }}") {
+		return yamlContent
+	}
+
+	const childIndent = "    "
+	result := append([]string{}, lines[:conversion+1]...)
+	i := conversion + 1
+	for ; i < len(lines) && strings.HasPrefix(lines[i], childIndent); i++ {
+		switch lines[i] {
+		case childIndent + "strategy: Webhook":
+			result = append(result, childIndent+"strategy: {{ if .Values.webhook.enabled }}Webhook{{ else }}None{{ end }}")
+		case childIndent + "webhook:":
+			end := i + 1
+			for end < len(lines) && strings.HasPrefix(lines[end], childIndent+" ") {
+				end++
+			}
+			result = append(result, childIndent+"{{- if .Values.webhook.enabled }}")
+			result = append(result, lines[i:end]...)
+			result = append(result, childIndent+"{{- end }}")
+			i = end - 1
+		default:
+			result = append(result, lines[i])
+		}
+	}
+	return strings.Join(append(result, lines[i:]...), "\n")
+}
+
 // InjectCRDResourcePolicyAnnotation adds the helm.sh/resource-policy: keep annotation to CRDs.
 // This prevents Helm from deleting CRDs when the chart is uninstalled.
 func InjectCRDResourcePolicyAnnotation(yamlContent string) string {
@@ -251,15 +386,11 @@ func MakeWebhookAnnotationsConditional(yamlContent string) string {
 	if !strings.Contains(yamlContent, "cert-manager.io/inject-ca-from") {
 		return yamlContent
 	}
-	certManagerPattern := regexp.MustCompile(`(\s+)cert-manager\.io/inject-ca-from:\s*[^\n]+`)
+	certManagerPattern := regexp.MustCompile(`([ \t]+)cert-manager\.io/inject-ca-from:\s*[^\n]+`)
 	// Replace the cert-manager annotation with conditional wrapper
-	yamlContent = certManagerPattern.ReplaceAllStringFunc(yamlContent, func(match string) string {
+	yamlContent = wrapUnwrappedMatches(certManagerPattern, yamlContent, func(match string) string {
 		// Extract the indentation
-		indentMatch := regexp.MustCompile(`^(\s+)`).FindStringSubmatch(match)
-		indent := ""
-		if len(indentMatch) > 1 {
-			indent = indentMatch[1]
-		}
+		indent, _ := LeadingWhitespace(match)
 		// Extract the annotation line with proper indentation
 		annotationLine := strings.TrimSpace(match)
 		return fmt.Sprintf("%s{{- if .Values.certManager.enabled }}\n%s%s\n%s{{- end }}",
@@ -267,3 +398,144 @@ func MakeWebhookAnnotationsConditional(yamlContent string) string {
 	})
 	return yamlContent
 }
+
+// metricsReaderCondition renders the metrics-reader ClusterRole when metrics.reader.enabled is true,
+// or with secure metrics when metrics.reader.enabled is unset.
+const metricsReaderCondition = `{{- if ternary (.Values.metrics.reader | default dict).enabled ` +
+	`(and .Values.metrics.enabled .Values.metrics.secure) (hasKey (.Values.metrics.reader | default dict) "enabled") }}`
+
+// webhookCABundleCondition renders a user-supplied caBundle only when cert-manager does not inject one.
+const webhookCABundleCondition = "{{- if and (not .Values.certManager.enabled) .Values.webhook.caBundle }}"
+
+// TemplateWebhookCABundle templates the caBundle of every webhook clientConfig from
+// .Values.webhook.caBundle when cert-manager is disabled, so webhooks can trust a CA the user
+// brings. With cert-manager enabled the inject-ca-from annotation fills it instead, so the two are
+// mutually exclusive. A caBundle from the manifest is kept when no value replaces it.
+func TemplateWebhookCABundle(yamlContent string) string {
+	if strings.Contains(yamlContent, ".Values.webhook.caBundle") {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines)+3)
+	for i := 0; i < len(lines); i++ {
+		result = append(result, lines[i])
+		if strings.TrimSpace(lines[i]) != "clientConfig:" {
+			continue
+		}
+
+		_, indent := LeadingWhitespace(lines[i])
+		childIndent := strings.Repeat(" ", indent+2)
+		var existing []string
+		for j := i + 1; j < len(lines); j++ {
+			_, lineIndent := LeadingWhitespace(lines[j])
+			if strings.TrimSpace(lines[j]) != "" && lineIndent <= indent {
+				break
+			}
+			if strings.HasPrefix(lines[j], childIndent+"caBundle:") {
+				existing = append(existing, lines[j])
+				lines = slices.Delete(lines, j, j+1)
+				break
+			}
+		}
+
+		result = append(result,
+			childIndent+webhookCABundleCondition,
+			childIndent+"caBundle: {{ .Values.webhook.caBundle }}")
+		if len(existing) > 0 {
+			result = append(result, childIndent+"{{- else }}")
+			result = append(result, existing...)
+		}
+		result = append(result, childIndent+"{{- end }}")
+	}
+	return strings.Join(result, "\n")
+}
+
+// TemplateWebhookPolicies templates the matchPolicy and sideEffects of every entry of a webhook
+// configuration from .Values.webhook.matchPolicy and .Values.webhook.sideEffects. The values found
+// in the manifest are the defaults. An entry without matchPolicy gets one defaulting to Equivalent,
+// the API server default, placed in the alphabetical field order of the kustomize output. sideEffects
+// is required by admissionregistration.k8s.io/v1, so entries without it are left as they are.
+func TemplateWebhookPolicies(yamlContent string) string {
+	if strings.Contains(yamlContent, webhookMatchPolicyValuesPath) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	listLine, _ := findListField(lines, "webhooks:")
+	if listLine < 0 || listLine+1 >= len(lines) {
+		return yamlContent
+	}
+	_, itemIndent := LeadingWhitespace(lines[listLine+1])
+
+	result := slices.Clone(lines[:listLine+1])
+	// entry holds the fields of the webhook entry being read; it is flushed at the next entry.
+	var entry []string
+	flush := func() {
+		if len(entry) > 0 {
+			result = append(result, templateWebhookEntryPolicies(entry)...)
+		}
+		entry = nil
+	}
+	i := listLine + 1
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		_, indent := LeadingWhitespace(lines[i])
+		if trimmed != "" && (indent < itemIndent || (indent == itemIndent && !strings.HasPrefix(trimmed, "- "))) {
+			break
+		}
+		if indent == itemIndent && strings.HasPrefix(trimmed, "- ") {
+			flush()
+		}
+		entry = append(entry, lines[i])
+	}
+	flush()
+	return strings.Join(append(result, lines[i:]...), "\n")
+}
+
+const (
+	// webhookMatchPolicyValuesPath holds the matchPolicy of every webhook entry.
+	webhookMatchPolicyValuesPath = ".Values.webhook.matchPolicy"
+	// webhookSideEffectsValuesPath holds the sideEffects of every webhook entry.
+	webhookSideEffectsValuesPath = ".Values.webhook.sideEffects"
+)
+
+// templateWebhookEntryPolicies templates the matchPolicy and sideEffects of a single webhook entry,
+// given as its lines starting with the list item line.
+func templateWebhookEntryPolicies(entry []string) []string {
+	_, itemIndent := LeadingWhitespace(entry[0])
+	fieldIndent := strings.Repeat(" ", itemIndent+2)
+	policyLine := func(field, valuesPath, defaultValue string) string {
+		return fmt.Sprintf("%s%s: {{ %s | default %q }}", fieldIndent, field, valuesPath, defaultValue)
+	}
+
+	result := make([]string, 0, len(entry)+1)
+	hasMatchPolicy := false
+	insertAt := -1
+	for i, line := range entry {
+		if i > 0 && strings.HasPrefix(line, fieldIndent) && !strings.HasPrefix(line, fieldIndent+" ") {
+			key, value, _ := strings.Cut(strings.TrimSpace(line), ":")
+			value = strings.Trim(strings.TrimSpace(value), `"'`)
+			switch {
+			case key == "matchPolicy":
+				hasMatchPolicy = true
+				line = policyLine(key, webhookMatchPolicyValuesPath, value)
+			case key == "sideEffects":
+				line = policyLine(key, webhookSideEffectsValuesPath, value)
+			case insertAt < 0 && !strings.HasPrefix(key, "{{") && key > "matchPolicy":
+				insertAt = len(result)
+			}
+		}
+		result = append(result, line)
+	}
+	if hasMatchPolicy {
+		return result
+	}
+	if insertAt < 0 {
+		insertAt = len(result)
+		for insertAt > 1 && strings.TrimSpace(result[insertAt-1]) == "" {
+			insertAt--
+		}
+	}
+	return slices.Insert(result, insertAt, policyLine("matchPolicy", webhookMatchPolicyValuesPath, "Equivalent"))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// deploymentReplicasPattern matches the replicas of a Deployment spec.
+var deploymentReplicasPattern = regexp.MustCompile(`(?m)^  replicas:\s*(\d+)\s*$`)
+
+// TemplateExtraDeployment templates a Deployment shipped next to the manager from its own values
+// under valuesKey: the replicas and the image, pull policy and resources of its main container, found
+// like the manager container by the default-container annotation or the "manager" name, else the
+// first container. Every field defaults to the kustomize output, so the values are optional.
+func TemplateExtraDeployment(yamlContent, valuesKey string) string {
+	values := "(.Values." + valuesKey + " | default dict)"
+	if strings.Contains(yamlContent, values) {
+		return yamlContent
+	}
+
+	yamlContent = deploymentReplicasPattern.ReplaceAllString(yamlContent,
+		fmt.Sprintf(`  replicas: {{ dig "replicas" ${1} %s }}`, values))
+
+	start, end := FindContainerRange(yamlContent, GetDefaultContainerName(yamlContent))
+	if start < 0 {
+		start, end = FindContainerRange(yamlContent, "")
+	}
+	if start < 0 {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	for end > start && strings.TrimSpace(lines[end]) == "" {
+		end--
+	}
+	_, itemIndent := LeadingWhitespace(lines[start])
+	fieldIndent := strings.Repeat(" ", itemIndent+2)
+
+	// resourcesBlock renders the resources from values, which replace the kustomize output as a whole
+	// like the image, defaulting to scaffolded.
+	resourcesBlock := func(scaffolded string) []string {
+		return []string{
+			fmt.Sprintf(`%s{{- with dig "resources" %s %s }}`, fieldIndent, scaffolded, values),
+			fieldIndent + "resources:",
+			fieldIndent + "  {{- toYaml . | nindent " + strconv.Itoa(len(fieldIndent)+2) + " }}",
+			fieldIndent + "{{- end }}",
+		}
+	}
+
+	pullPolicy := ""
+	for _, line := range lines[start : end+1] {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), "imagePullPolicy:"); found {
+			pullPolicy = strings.TrimSpace(value)
+		}
+	}
+
+	container := make([]string, 0, end-start+8)
+	hasResources := false
+	for i := start; i <= end; i++ {
+		line := lines[i]
+		field := strings.TrimPrefix(strings.TrimSpace(line), "- ")
+		prefix := line[:len(line)-len(field)]
+		if _, indent := LeadingWhitespace(line); i != start && indent != len(fieldIndent) {
+			container = append(container, line)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(field, "image:"):
+			image := strings.Trim(strings.TrimSpace(strings.TrimPrefix(field, "image:")), `"'`)
+			container = append(container,
+				prefix+"image: "+extraDeploymentImage(image, values),
+				fmt.Sprintf(`%s{{- with dig "image" "pullPolicy" %q %s }}`, fieldIndent, pullPolicy, values),
+				fieldIndent+"imagePullPolicy: {{ . }}",
+				fieldIndent+"{{- end }}")
+		case strings.HasPrefix(field, "imagePullPolicy:"):
+			// Rendered from values next to the image.
+		case i != start && strings.HasPrefix(field, "resources:"):
+			block := []string{}
+			for i+1 <= end {
+				if _, indent := LeadingWhitespace(lines[i+1]); indent <= len(fieldIndent) &&
+					strings.TrimSpace(lines[i+1]) != "" {
+					break
+				}
+				i++
+				block = append(block, lines[i])
+			}
+			container = append(container, resourcesBlock("(fromYaml "+scaffoldedYAMLLiteral(block)+")")...)
+			hasResources = true
+		default:
+			container = append(container, line)
+		}
+	}
+	if !hasResources {
+		container = append(container, resourcesBlock("dict")...)
+	}
+
+	result := append([]string{}, lines[:start]...)
+	result = append(result, container...)
+	result = append(result, lines[end+1:]...)
+	return strings.Join(result, "\n")
+}
+
+// extraDeploymentImage templates image from the image settings in values, defaulting to its
+// repository, tag and digest. global.imageRegistry prefixes the repository, as for the manager image.
+func extraDeploymentImage(image, values string) string {
+	repository, digest, _ := strings.Cut(image, "@")
+	tag := ""
+	if lastColon := strings.LastIndex(repository, ":"); lastColon > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:lastColon], repository[lastColon+1:]
+	}
+	if tag == "" && digest == "" {
+		tag = "latest"
+	}
+
+	return fmt.Sprintf(`"{{ with (.Values.global | default dict).imageRegistry }}{{ . }}/{{ end }}`+
+		`{{ dig "image" "repository" %q %s }}`+
+		`{{- with dig "image" "digest" %q %s }}@{{ . }}{{- else }}:{{ dig "image" "tag" %q %s }}{{- end }}"`,
+		repository, values, digest, values, tag, values)
+}
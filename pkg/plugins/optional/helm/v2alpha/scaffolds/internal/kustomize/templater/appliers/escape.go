@@ -22,10 +22,19 @@ import (
 )
 
 var (
-	// templatePattern matches {{ }} syntax, including those wrapped across lines by yaml.Marshal
-	templatePattern = regexp.MustCompile(`(?s)\{\{(.*?)\}\}`)
+	// templatePattern matches {{ }} syntax, including those wrapped across lines by yaml.Marshal.
+	// Already-escaped literals ({{ "{{...}}" }}) are matched as a whole by the first alternative.
+	templatePattern = regexp.MustCompile(`(?s)\{\{ "\{\{(?:[^"\\]|\\.)*\}\}" \}\}|\{\{(.*?)\}\}`)
 	// newlineCollapsePattern matches newline followed by whitespace for collapsing yaml line wrapping
 	newlineCollapsePattern = regexp.MustCompile(`\n[ \t]+`)
+	// escapedLiteralPattern matches a template already escaped as {{ "{{...}}" }}
+	escapedLiteralPattern = regexp.MustCompile(`(?s)^\{\{ "\{\{.*\}\}" \}\}$`)
+	// helmScopePattern matches templates reading the current Helm scope or a variable, e.g. {{ . }} or {{ $v | quote }}
+	helmScopePattern = regexp.MustCompile(`^-?\s*(\.|\$[A-Za-z0-9_]*)(\s|$)`)
+	// controlDirectivePattern matches a line holding only a Helm control directive
+	controlDirectivePattern = regexp.MustCompile(`^\s*\{\{-?\s*(if|else|range|with|end)\b.*\}\}\s*$`)
+	// blockScalarHeaderPattern matches a mapping key or list item introducing a literal or folded block scalar
+	blockScalarHeaderPattern = regexp.MustCompile(`^\s*(?:- )?(?:[^#]*:\s+)?([|>][-+0-9]*)\s*$`)
 )
 
 // EscapeExistingTemplateSyntax escapes Go template syntax ({{ }}) in YAML to prevent
@@ -46,12 +55,25 @@ var (
 //
 // Smart detection:
 // Only escapes templates that DON'T start with Helm keywords:
-//   - .Release, .Values, .Chart (Helm built-ins)
-//   - include, if, with, range, toYaml (Helm functions)
+//   - .Release, .Values, .Chart (Helm built-ins), including parenthesized (.Values ...) pipelines
+//   - include, if, with, range, toYaml, dig, required (Helm functions)
+//
+// Inside Helm with/range blocks, {{ . }} and {{ $var }} are Helm scope references and are kept too,
+// and already-escaped literals are left alone, so escaping an escaped chart is a no-op.
 //
 // This means our Helm templates work normally while existing templates are preserved.
 func EscapeExistingTemplateSyntax(yamlContent string) string {
+	// Depth of Helm with/range blocks enclosing the current match; inside them "." and "$"
+	// refer to Helm scope, so those templates come from a previous templating pass.
+	scopeDepth := 0
+	var scopes []bool
+
 	yamlContent = templatePattern.ReplaceAllStringFunc(yamlContent, func(match string) string {
+		// Already-escaped literals from a previous pass are kept unchanged
+		if escapedLiteralPattern.MatchString(match) {
+			return match
+		}
+
 		// Extract content between {{ and }}
 		content := strings.TrimPrefix(match, "{{")
 		content = strings.TrimSuffix(content, "}}")
@@ -62,8 +84,11 @@ func EscapeExistingTemplateSyntax(yamlContent string) string {
 			"include ", "- include ",
 			".Release.", "- .Release.",
 			".Values.", "- .Values.",
+			"(.Values.", "- (.Values.",
 			".Chart.", "- .Chart.",
 			"toYaml ", "- toYaml ",
+			"dig ", "- dig ",
+			"required ", "- required ",
 			"if ", "- if ",
 			"end", "- end",
 			"end ", "- end ",
@@ -75,9 +100,13 @@ func EscapeExistingTemplateSyntax(yamlContent string) string {
 		// If it's a Helm template, keep it as-is
 		for _, pattern := range helmPatterns {
 			if strings.HasPrefix(trimmedContent, pattern) {
+				scopeDepth, scopes = trackHelmScope(strings.TrimPrefix(trimmedContent, "- "), scopeDepth, scopes)
 				return match
 			}
 		}
+		if scopeDepth > 0 && helmScopePattern.MatchString(trimmedContent) {
+			return match
+		}
 
 		// Otherwise, escape it to preserve as literal text
 		// Collapse any newline+indent that sigs.k8s.io/yaml may have introduced via line-wrapping.
@@ -99,31 +128,88 @@ func EscapeExistingTemplateSyntax(yamlContent string) string {
 	return yamlContent
 }
 
-// CollapseBlankLineAfterIf removes unwanted blank lines after {{- if ... }} and before {{- end }}.
-// This ensures clean formatting in generated templates by:
-//   - Removing blank line immediately after {{- if ... }}
-//   - Removing blank line immediately before {{- end }}
-func CollapseBlankLineAfterIf(yamlContent string) string {
-	lines := strings.Split(yamlContent, "\n")
-	if len(lines) == 0 {
-		return yamlContent
+// trackHelmScope updates the with/range nesting depth for a Helm action. scopes records, per open
+// block, whether it changes scope, so that the matching end only closes scope-changing blocks.
+func trackHelmScope(action string, depth int, scopes []bool) (int, []bool) {
+	switch {
+	case strings.HasPrefix(action, "with "), strings.HasPrefix(action, "range "):
+		return depth + 1, append(scopes, true)
+	case strings.HasPrefix(action, "if "):
+		return depth, append(scopes, false)
+	case strings.HasPrefix(action, "end"):
+		if len(scopes) == 0 {
+			return depth, scopes
+		}
+		if scopes[len(scopes)-1] {
+			depth--
+		}
+		return depth, scopes[:len(scopes)-1]
 	}
+	return depth, scopes
+}
+
+// CollapseBlankLinesAroundDirectives removes blank lines adjacent to standalone Helm control
+// directives ({{- if }}, {{- else }}, {{- range }}, {{- with }}, {{- end }}). Every blank line in a
+// run that touches a directive is dropped, so nested conditionals collapse in a single pass and
+// running it again is a no-op. Blank lines inside YAML block scalars (| and >) are content and are
+// always kept.
+func CollapseBlankLinesAroundDirectives(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	inBlockScalar := blockScalarLines(lines)
+
 	out := make([]string, 0, len(lines))
 	for i := 0; i < len(lines); i++ {
-		line := lines[i]
-		// If current line is an if, and next line is blank, skip the blank
-		if strings.Contains(line, "{{- if ") {
-			out = append(out, line)
-			if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
-				i++ // skip one blank line after if
-			}
+		if strings.TrimSpace(lines[i]) != "" || inBlockScalar[i] {
+			out = append(out, lines[i])
 			continue
 		}
-		// If current line is blank, and next line is an end, skip the blank
-		if strings.TrimSpace(line) == "" && i+1 < len(lines) && strings.Contains(lines[i+1], "{{- end }}") {
-			continue
+
+		runEnd := i
+		for runEnd < len(lines) && strings.TrimSpace(lines[runEnd]) == "" && !inBlockScalar[runEnd] {
+			runEnd++
+		}
+		// A run reaching the end of the content is the trailing newline and is kept.
+		atEnd := runEnd == len(lines)
+		afterDirective := i > 0 && controlDirectivePattern.MatchString(lines[i-1])
+		beforeDirective := !atEnd && controlDirectivePattern.MatchString(lines[runEnd])
+		if atEnd || (!afterDirective && !beforeDirective) {
+			out = append(out, lines[i:runEnd]...)
 		}
-		out = append(out, line)
+		i = runEnd - 1
 	}
 	return strings.Join(out, "\n")
 }
+
+// blockScalarLines marks the lines that belong to the body of a YAML block scalar, including
+// blank lines, so they are never treated as formatting whitespace.
+func blockScalarLines(lines []string) []bool {
+	marked := make([]bool, len(lines))
+	for i := 0; i < len(lines); i++ {
+		header := blockScalarHeaderPattern.FindStringSubmatch(lines[i])
+		if header == nil {
+			continue
+		}
+		_, headerIndent := LeadingWhitespace(lines[i])
+		next := i + 1
+		for ; next < len(lines); next++ {
+			if strings.TrimSpace(lines[next]) == "" {
+				continue
+			}
+			if _, indent := LeadingWhitespace(lines[next]); indent <= headerIndent {
+				break
+			}
+		}
+		// Trailing blank lines are only part of the value with "keep" chomping (|+ or >+).
+		end := next
+		if !strings.Contains(header[1], "+") {
+			for end > i+1 && strings.TrimSpace(lines[end-1]) == "" {
+				end--
+			}
+		}
+		for k := i + 1; k < end; k++ {
+			marked[k] = true
+		}
+		i = next - 1
+	}
+	return marked
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CollapseBlankLinesAroundDirectives", func() {
+	It("should collapse blank lines around deeply nested directives", func() {
+		input := `spec:
+  {{- if .Values.metrics.enable }}
+
+  {{- if and .Values.certManager.enable .Values.metrics.secure }}
+
+
+  {{- range .Values.manager.extraVolumes }}
+
+  - name: {{ .name }}
+
+  {{- end }}
+
+  {{- else }}
+
+  - name: plain
+
+  {{- end }}
+
+
+  {{- end }}
+status: {}`
+
+		Expect(CollapseBlankLinesAroundDirectives(input)).To(Equal(`spec:
+  {{- if .Values.metrics.enable }}
+  {{- if and .Values.certManager.enable .Values.metrics.secure }}
+  {{- range .Values.manager.extraVolumes }}
+  - name: {{ .name }}
+  {{- end }}
+  {{- else }}
+  - name: plain
+  {{- end }}
+  {{- end }}
+status: {}`))
+	})
+
+	It("should collapse blank lines around with blocks and non-trimming directives", func() {
+		input := `spec:
+  {{ with .Values.manager.nodeSelector }}
+
+  nodeSelector: {{ toYaml . | nindent 4 }}
+
+  {{ end }}
+  replicas: 1`
+
+		Expect(CollapseBlankLinesAroundDirectives(input)).To(Equal(`spec:
+  {{ with .Values.manager.nodeSelector }}
+  nodeSelector: {{ toYaml . | nindent 4 }}
+  {{ end }}
+  replicas: 1`))
+	})
+
+	It("should keep blank lines that do not touch a directive", func() {
+		input := "a: 1\n\nb: 2\n{{- if .Values.x }}\nc: 3\n{{- end }}\n"
+
+		Expect(CollapseBlankLinesAroundDirectives(input)).To(Equal(input))
+	})
+
+	It("should be idempotent", func() {
+		input := "spec:\n  {{- if .Values.a }}\n\n\n  {{- with .Values.b }}\n\n  b: {{ . }}\n\n  {{- end }}\n\n  {{- end }}\n"
+
+		once := CollapseBlankLinesAroundDirectives(input)
+		Expect(CollapseBlankLinesAroundDirectives(once)).To(Equal(once))
+	})
+
+	It("should not touch blank lines inside block scalars", func() {
+		input := `data:
+  {{- if .Values.config.enable }}
+  config.yaml: |
+    first: 1
+
+    second: 2
+  {{- end }}
+  keep.txt: |+
+    line
+
+  {{- if .Values.other }}
+  other: |-
+    a
+
+
+    b
+  {{- end }}`
+
+		Expect(CollapseBlankLinesAroundDirectives(input)).To(Equal(input))
+	})
+
+	It("should drop trailing blank lines of a clipped block scalar before a directive", func() {
+		input := "data:\n  script: |\n    echo hi\n\n  {{- end }}"
+
+		Expect(CollapseBlankLinesAroundDirectives(input)).To(Equal("data:\n  script: |\n    echo hi\n  {{- end }}"))
+	})
+})
+
+var _ = Describe("EscapeExistingTemplateSyntax", func() {
+	It("should escape scope references outside Helm with/range blocks", func() {
+		input := "description: Use {{ . }} or {{ $name }}"
+
+		Expect(EscapeExistingTemplateSyntax(input)).To(Equal(
+			`description: Use {{ "{{ . }}" }} or {{ "{{ $name }}" }}`))
+	})
+
+	It("should keep scope references inside Helm with/range blocks", func() {
+		input := `{{- with .Values.manager.image.pullPolicy }}
+imagePullPolicy: {{ . }}
+{{- end }}
+{{- range $k, $v := .Values.manager.envOverrides }}
+- name: {{ $k }}
+  value: {{ $v | quote }}
+{{- end }}
+after: {{ . }}`
+
+		Expect(EscapeExistingTemplateSyntax(input)).To(Equal(`{{- with .Values.manager.image.pullPolicy }}
+imagePullPolicy: {{ . }}
+{{- end }}
+{{- range $k, $v := .Values.manager.envOverrides }}
+- name: {{ $k }}
+  value: {{ $v | quote }}
+{{- end }}
+after: {{ "{{ . }}" }}`))
+	})
+
+	It("should leave already escaped templates unchanged", func() {
+		input := `default: "Branch: {{ .Spec.Branch }}" and {{ "quoted" }}`
+
+		once := EscapeExistingTemplateSyntax(input)
+		Expect(once).To(Equal(`default: "Branch: {{ "{{ .Spec.Branch }}" }}" and {{ "{{ \"quoted\" }}" }}`))
+		Expect(EscapeExistingTemplateSyntax(once)).To(Equal(once))
+	})
+})
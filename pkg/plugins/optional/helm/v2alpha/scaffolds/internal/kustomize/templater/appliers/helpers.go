@@ -17,8 +17,8 @@ limitations under the License.
 package appliers
 
 import (
-	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -41,6 +41,18 @@ func GetDefaultContainerName(yamlContent string) string {
 	return common.DefaultManagerContainerName
 }
 
+// StepRecorder receives the name of a substitution step and whether it changed the content.
+type StepRecorder func(step string, changed bool)
+
+// ApplyStep runs apply on yamlContent and, when record is set, reports whether it changed anything.
+func ApplyStep(record StepRecorder, step, yamlContent string, apply func(string) string) string {
+	result := apply(yamlContent)
+	if record != nil {
+		record(step, result != yamlContent)
+	}
+	return result
+}
+
 // LeadingWhitespace extracts the leading whitespace from a line.
 // Returns the whitespace string and its length in characters.
 func LeadingWhitespace(line string) (string, int) {
@@ -61,28 +73,52 @@ func IsManagerDeployment(resource *unstructured.Unstructured) bool {
 	return strings.Contains(resource.GetName(), "controller-manager")
 }
 
-// MakeYamlContent wraps a YAML block with a cert-manager conditional.
-// Shifts by 2 spaces to align with the child indent used by appendToListFromValues.
-func MakeYamlContent(match string) string {
-	return wrapBlock(match, "{{- if .Values.certManager.enabled }}")
+// podBearingKinds are the workload kinds whose spec embeds a pod template.
+var podBearingKinds = []string{
+	common.KindDeployment, common.KindStatefulSet, common.KindDaemonSet, common.KindJob, common.KindCronJob,
 }
 
-// wrapBlock wraps a YAML block match with the given Helm conditional string.
+// IsPodBearingKind reports whether resources of kind run containers from a pod template.
+func IsPodBearingKind(kind string) bool {
+	return slices.Contains(podBearingKinds, kind)
+}
+
+// wrapBlock wraps a YAML list item match with the given Helm conditional string, written at the
+// indent of the item. appendToListFromValues has already lined the items up under their key.
 func wrapBlock(match, condition string) string {
-	lines := strings.Split(match, "\n")
-	indent, _ := LeadingWhitespace(lines[0])
-	childIndent := indent + "  "
+	indent, _ := LeadingWhitespace(match)
+	return indent + condition + "\n" + match + "\n" + indent + "{{- end }}"
+}
+
+// wrapUnwrappedMatches replaces every match of pattern with wrap(match), skipping matches that
+// already sit directly below a {{- if }} wrapper so that re-templating a chart is a no-op.
+func wrapUnwrappedMatches(pattern *regexp.Regexp, yamlContent string, wrap func(string) string) string {
 	var result strings.Builder
-	fmt.Fprintf(&result, "%s%s\n", childIndent, condition)
-	for _, line := range lines {
-		result.WriteString("  ")
-		result.WriteString(line)
-		result.WriteByte('\n')
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(yamlContent, -1) {
+		result.WriteString(yamlContent[last:loc[0]])
+		match := yamlContent[loc[0]:loc[1]]
+		if hasWrapperAbove(yamlContent, loc[0]) {
+			result.WriteString(match)
+		} else {
+			result.WriteString(wrap(match))
+		}
+		last = loc[1]
 	}
-	fmt.Fprintf(&result, "%s{{- end }}", childIndent)
+	result.WriteString(yamlContent[last:])
 	return result.String()
 }
 
+// hasWrapperAbove reports whether the line preceding the line at offset is a {{- if }} directive.
+func hasWrapperAbove(yamlContent string, offset int) bool {
+	lineStart := strings.LastIndex(yamlContent[:offset], "\n")
+	if lineStart < 0 {
+		return false
+	}
+	above := yamlContent[:lineStart]
+	return strings.HasPrefix(strings.TrimSpace(above[strings.LastIndex(above, "\n")+1:]), "{{- if ")
+}
+
 const (
 	k8sObjectSpecField     = "spec"
 	k8sObjectTemplateField = "template"
@@ -102,7 +138,13 @@ var (
 // of the manager container in yamlContent.
 // Returns (-1, -1) when not found; callers use this to restrict substitutions to the manager only.
 func FindManagerContainerRange(yamlContent string) (int, int) {
-	name := GetDefaultContainerName(yamlContent)
+	return FindContainerRange(yamlContent, GetDefaultContainerName(yamlContent))
+}
+
+// FindContainerRange returns the 0-based inclusive line range [start, end] of the container called
+// name in the containers list of yamlContent, or of the first container when name is empty. It
+// returns (-1, -1) when there is none.
+func FindContainerRange(yamlContent, name string) (int, int) {
 	lines := strings.Split(yamlContent, "\n")
 
 	listLine, listIndent := findListField(lines, k8sContainersFieldName+":")
@@ -132,12 +174,16 @@ func FindManagerContainerRange(yamlContent string) (int, int) {
 		if found {
 			return itemStart, i - 1
 		}
+		// A directive between items, e.g. one guarding a sidecar, does not end the list.
+		if indent == listIndent && strings.HasPrefix(trimmed, "{{") {
+			continue
+		}
 		if indent < listIndent || !strings.HasPrefix(trimmed, "- ") {
 			break
 		}
 		itemStart = i
 		itemChildIndent = indent + 2
-		if trimmed == "- "+nameField {
+		if name == "" || trimmed == "- "+nameField {
 			found = true
 		}
 	}
@@ -76,6 +76,27 @@ var _ = Describe("FindManagerContainerRange", func() {
 		Expect(lines[start]).To(ContainSubstring("- args:"))
 	})
 
+	It("should find manager after a sidecar guarded by a directive", func() {
+		yaml := `spec:
+  template:
+    spec:
+      containers:
+      {{- if .Values.metrics.enabled }}
+      - name: kube-rbac-proxy
+        image: quay.io/brancz/kube-rbac-proxy:v0.18.0
+      {{- end }}
+      - name: manager
+        image: controller:latest`
+
+		start, end := FindManagerContainerRange(yaml)
+		Expect(start).To(Equal(8))
+		Expect(end).To(Equal(9))
+
+		start, end = FindContainerRange(yaml, "kube-rbac-proxy")
+		Expect(start).To(Equal(5))
+		Expect(end).To(Equal(6))
+	})
+
 	It("should find manager at index 1 when sidecar is first (name-first fields)", func() {
 		yaml := `spec:
   template:
@@ -534,3 +555,17 @@ spec:
 		Expect(rangeContent).To(ContainSubstring(".Values.manager.env"))
 	})
 })
+
+var _ = Describe("IsPodBearingKind", func() {
+	It("should report workload kinds with a pod template", func() {
+		for _, kind := range []string{"Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob"} {
+			Expect(IsPodBearingKind(kind)).To(BeTrue(), kind)
+		}
+	})
+
+	It("should not report kinds without a pod template", func() {
+		for _, kind := range []string{"Service", "ConfigMap", "ServiceAccount"} {
+			Expect(IsPodBearingKind(kind)).To(BeFalse(), kind)
+		}
+	})
+})
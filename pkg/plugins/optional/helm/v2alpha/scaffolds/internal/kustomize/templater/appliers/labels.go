@@ -17,6 +17,8 @@ limitations under the License.
 package appliers
 
 import (
+	"fmt"
+	"maps"
 	"regexp"
 	"slices"
 	"strconv"
@@ -30,147 +32,473 @@ import (
 const (
 	valuesServiceAccountLabels      = ".Values.serviceAccount.labels"
 	valuesServiceAccountAnnotations = ".Values.serviceAccount.annotations"
+	valuesNamespaceLabels           = "(.Values.namespace | default dict).labels"
+	valuesCommonLabels              = ".Values.commonLabels"
+	valuesCommonAnnotations         = ".Values.commonAnnotations"
 )
 
-// AddHelmLabelsAndAnnotations replaces kustomize managed-by labels with Helm equivalents.
+// metadataMapGuardPattern matches a directive at the metadata child level guarding a labels or
+// annotations block, capturing its action and pipeline, e.g. "  {{- with .Values.x.annotations }}".
+var metadataMapGuardPattern = regexp.MustCompile(`^  \{\{- (if|with) (.+?) \}\}$`)
+
+// AddHelmLabelsAndAnnotations replaces kustomize managed-by labels with managedBy (usually
+// {{ .Release.Service }}) and adds the standard Helm labels. An empty managedBy keeps the
+// managed-by labels from the kustomize output. Workload selectors are left as they are.
 func AddHelmLabelsAndAnnotations(
-	detectedPrefix, chartName string, yamlContent string, resource *unstructured.Unstructured,
+	detectedPrefix, chartName, managedBy string, yamlContent string, resource *unstructured.Unstructured,
 ) string {
-	// Replace app.kubernetes.io/managed-by: kustomize with Helm template
-	// Use regex to handle different whitespace patterns
-	managedByRegex := regexp.MustCompile(`(\s*)app\.kubernetes\.io/managed-by:\s+kustomize`)
-	yamlContent = managedByRegex.ReplaceAllString(yamlContent, "${1}app.kubernetes.io/managed-by: {{ .Release.Service }}")
-
+	// The name label follows the chart name everywhere, selectors included, so Services and
+	// NetworkPolicies keep matching the pods.
 	hardcodedNameLabel := "app.kubernetes.io/name: " + detectedPrefix
 	templatedNameLabel := "app.kubernetes.io/name: {{ include \"" + chartName + ".name\" . }}"
 	yamlContent = strings.ReplaceAll(yamlContent, hardcodedNameLabel, templatedNameLabel)
 
-	// Add standard Helm labels to labels sections, excluding selectors/matchLabels.
-	yamlContent = AddStandardHelmLabels(yamlContent, resource)
+	return KeepSelectorLabels(yamlContent, func(yamlContent string) string {
+		if managedBy != "" {
+			// Replace app.kubernetes.io/managed-by: kustomize with Helm template
+			// Use regex to handle different whitespace patterns
+			managedByRegex := regexp.MustCompile(`(\s*)app\.kubernetes\.io/managed-by:\s+kustomize`)
+			yamlContent = managedByRegex.ReplaceAllString(yamlContent,
+				"${1}app.kubernetes.io/managed-by: "+strings.ReplaceAll(managedBy, "$", "$$"))
+		}
 
-	return yamlContent
+		// Keep version labels in sync with the release; metadata labels get theirs from the helper below.
+		yamlContent = TemplateVersionLabels(yamlContent)
+
+		// Add the standard Helm labels to every metadata labels block.
+		return AddStandardHelmLabels(chartName, managedBy != "", yamlContent, resource)
+	})
 }
 
-// CheckExistingLabels checks if standard Helm labels already exist in a labels section.
-func CheckExistingLabels(lines []string, currentIndex int, indent string) (hasChart, hasInstance, hasManagedBy bool) {
-	// Look backward from current position (managed-by often appears before name in kustomize output)
-	for j := currentIndex - 1; j >= 0 && j >= currentIndex-10; j-- {
-		backLine := lines[j]
-		backTrimmed := strings.TrimSpace(backLine)
-		backIndent, _ := LeadingWhitespace(backLine)
+// selectorLabelsPlaceholder replaces the selector matchLabels of a workload while labels are injected.
+const selectorLabelsPlaceholder = "__kubebuilder_selector_labels__"
 
-		// Stop if we've moved out of the labels section
-		if backTrimmed == common.YamlKeyLabels {
-			break
+// KeepSelectorLabels runs inject and puts back the spec.selector.matchLabels of a workload exactly as
+// they were, so no label injection can change the immutable selector of a Deployment. The pod
+// template labels matched by the selector keep their values as well, otherwise the selector would
+// no longer match the pods; other pod template labels can still be added.
+func KeepSelectorLabels(yamlContent string, inject func(string) string) string {
+	lines := strings.Split(yamlContent, "\n")
+	header, end := findNestedBlock(lines, common.YamlKeySpec, "selector:", "matchLabels:")
+	if header < 0 || end == header+1 {
+		return inject(yamlContent)
+	}
+	selector := slices.Clone(lines[header+1 : end])
+	indent, _ := LeadingWhitespace(selector[0])
+	lines = slices.Replace(lines, header+1, end, indent+selectorLabelsPlaceholder)
+
+	lines = strings.Split(inject(strings.Join(lines, "\n")), "\n")
+	placeholder := slices.IndexFunc(lines, func(line string) bool {
+		return strings.TrimSpace(line) == selectorLabelsPlaceholder
+	})
+	if placeholder < 0 {
+		return strings.Join(lines, "\n")
+	}
+	lines = slices.Replace(lines, placeholder, placeholder+1, selector...)
+
+	selectorEntries := make(map[string]string, len(selector))
+	for _, entry := range selector {
+		if key, _, found := strings.Cut(strings.TrimSpace(entry), ":"); found && !strings.HasPrefix(key, "{{") {
+			selectorEntries[key] = strings.TrimSpace(entry)
 		}
-		if backTrimmed != "" && len(backIndent) < len(indent) {
-			break
+	}
+	header, end = findNestedBlock(lines, common.YamlKeySpec, common.YamlKeyTemplate, common.YamlKeyMetadata,
+		common.YamlKeyLabels)
+	if header < 0 {
+		return strings.Join(lines, "\n")
+	}
+	_, headerIndent := LeadingWhitespace(lines[header])
+	for i := header + 1; i < end; i++ {
+		key, _, _ := strings.Cut(strings.TrimSpace(lines[i]), ":")
+		if entry, matched := selectorEntries[key]; matched {
+			if _, indent := LeadingWhitespace(lines[i]); indent == headerIndent+2 {
+				lines[i] = strings.Repeat(" ", indent) + entry
+			}
 		}
+	}
+	return strings.Join(lines, "\n")
+}
 
-		if strings.Contains(backLine, common.LabelKeyHelmChart) {
-			hasChart = true
+// findNestedBlock returns the line of the key at path, each key a direct child of the previous one
+// starting at the top level, and the end of its block, or -1, -1 when the resource has no such key.
+func findNestedBlock(lines []string, path ...string) (int, int) {
+	depth := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "{{") {
+			continue
+		}
+		_, indent := LeadingWhitespace(line)
+		if indent%2 != 0 || indent/2 > depth {
+			continue
 		}
-		if strings.Contains(backLine, common.LabelKeyAppInstance) {
-			hasInstance = true
+		depth = indent / 2
+		if trimmed != path[depth] {
+			continue
 		}
-		if strings.Contains(backLine, common.LabelKeyAppManagedBy) {
-			hasManagedBy = true
+		if depth == len(path)-1 {
+			return i, metadataMapEnd(lines, i)
 		}
+		depth++
 	}
+	return -1, -1
+}
 
-	// Look ahead from current position
-	for j := currentIndex + 1; j < len(lines) && j < currentIndex+10; j++ {
-		nextLine := lines[j]
-		nextTrimmed := strings.TrimSpace(nextLine)
-		nextIndent, _ := LeadingWhitespace(nextLine)
+// standardHelmLabelKeys are the labels rendered by the <chart>.labels helper. Inline copies are
+// dropped from the labels blocks that include the helper so no key is rendered twice.
+var standardHelmLabelKeys = []string{
+	common.LabelKeyHelmChart, common.LabelKeyAppVersion, common.LabelKeyAppInstance, common.LabelKeyAppManagedBy,
+}
 
-		// Stop if we've moved to a new section
-		if nextTrimmed != "" && len(nextIndent) < len(indent) {
-			break
-		}
+// versionLabelPattern matches an app.kubernetes.io/version label with a literal value.
+var versionLabelPattern = regexp.MustCompile(`^(\s*)app\.kubernetes\.io/version:\s+[^{\s].*$`)
 
-		if strings.Contains(nextLine, common.LabelKeyHelmChart) {
-			hasChart = true
+// TemplateVersionLabels rewrites app.kubernetes.io/version labels to the chart appVersion so they
+// follow every release. Selectors are left as they are: a selector that changes between releases
+// would block upgrades of immutable fields such as a Deployment selector.
+func TemplateVersionLabels(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	selectorIndent := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "{{") {
+			continue
 		}
-		if strings.Contains(nextLine, common.LabelKeyAppInstance) {
-			hasInstance = true
+		_, indent := LeadingWhitespace(line)
+		if selectorIndent >= 0 && indent > selectorIndent {
+			continue
+		}
+		selectorIndent = -1
+		if trimmed == "matchLabels:" || trimmed == "selector:" || strings.HasSuffix(trimmed, "Selector:") {
+			selectorIndent = indent
+			continue
 		}
-		if strings.Contains(nextLine, common.LabelKeyAppManagedBy) {
-			hasManagedBy = true
+		if m := versionLabelPattern.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + common.LabelKeyAppVersion + " {{ .Chart.AppVersion | quote }}"
 		}
 	}
-
-	return hasChart, hasInstance, hasManagedBy
+	return strings.Join(lines, "\n")
 }
 
-// AddStandardHelmLabels adds standard Helm labels to all labels sections except selectors.
-func AddStandardHelmLabels(yamlContent string, _ *unstructured.Unstructured) string {
-	lines := strings.Split(yamlContent, "\n")
-	result := make([]string, 0, len(lines)+10) // Pre-allocate with extra space for added labels
-	inSelector := false
+// standardLabelsIncludePattern matches the include of the <chart>.labels helper.
+var standardLabelsIncludePattern = regexp.MustCompile(`^\{\{- include "[^"]+\.labels" \.`)
+
+// AddStandardHelmLabels includes the <chartName>.labels helper, which renders helm.sh/chart,
+// app.kubernetes.io/version, app.kubernetes.io/instance and app.kubernetes.io/managed-by, in every
+// metadata labels block, pod templates included. Other labels are kept, and a resource without
+// labels gets a labels block. Selectors are not labels blocks, so they stay immutable. When
+// helperManagedBy is false the helper does not render managed-by, so inline managed-by labels are kept.
+// A pod template also keeps the labels its workload selector matches, which the helper then omits.
+func AddStandardHelmLabels(
+	chartName string, helperManagedBy bool, yamlContent string, resource *unstructured.Unstructured,
+) string {
+	helperKeys := standardHelmLabelKeys
+	if !helperManagedBy {
+		helperKeys = slices.DeleteFunc(slices.Clone(helperKeys), func(key string) bool {
+			return key == common.LabelKeyAppManagedBy
+		})
+	}
+	if strings.Contains(yamlContent, `include "`+chartName+`.labels"`) {
+		return yamlContent
+	}
+	includeLine := func(indent int) string {
+		return fmt.Sprintf(`%s{{- include "%s.labels" . | nindent %d }}`, strings.Repeat(" ", indent), chartName, indent)
+	}
+	var selectorKeys []string
+	if resource != nil {
+		matchLabels, _, _ := unstructured.NestedStringMap(resource.Object, "spec", "selector", "matchLabels")
+		selectorKeys = slices.Sorted(maps.Keys(matchLabels))
+	}
+	podHelperKeys := slices.DeleteFunc(slices.Clone(helperKeys), func(key string) bool {
+		return slices.Contains(selectorKeys, strings.TrimSuffix(key, ":"))
+	})
+	podIncludeLines := func(indent int) []string {
+		if len(podHelperKeys) == len(helperKeys) {
+			return []string{includeLine(indent)}
+		}
+		omitted := make([]string, 0, len(helperKeys)-len(podHelperKeys))
+		for _, key := range helperKeys {
+			if !slices.Contains(podHelperKeys, key) {
+				omitted = append(omitted, strconv.Quote(strings.TrimSuffix(key, ":")))
+			}
+		}
+		prefix := strings.Repeat(" ", indent)
+		return []string{
+			fmt.Sprintf(`%s{{- with omit (include "%s.labels" . | fromYaml) %s }}`,
+				prefix, chartName, strings.Join(omitted, " ")),
+			fmt.Sprintf("%s{{- toYaml . | nindent %d }}", prefix, indent),
+			prefix + "{{- end }}",
+		}
+	}
 
-	for i := range lines {
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines)+4)
+	metadataLine := -1
+	hasLabels := false
+	for i := 0; i < len(lines); i++ {
 		line := lines[i]
 		result = append(result, line)
+		if line == common.YamlKeyMetadata {
+			metadataLine = len(result) - 1
+		}
+		if strings.TrimSpace(line) != common.YamlKeyLabels || !isMetadataChild(lines, i) {
+			continue
+		}
 
-		// Track if we're in a selector section (matchLabels or spec.selector for Services)
-		trimmed := strings.TrimSpace(line)
-		isMatchLabels := trimmed == "matchLabels:"
-		isSelectorWithoutMatchLabels := trimmed == "selector:" && i+1 < len(lines) &&
-			!strings.Contains(lines[i+1], "matchLabels")
-		if isMatchLabels || isSelectorWithoutMatchLabels {
-			inSelector = true
-		}
-
-		// Exit selector section when we hit a line with less indentation
-		if inSelector && trimmed != "" && !strings.HasPrefix(trimmed, "app.kubernetes.io/") &&
-			!strings.HasPrefix(trimmed, "control-plane:") && strings.Contains(trimmed, ":") {
-			inSelector = false
-		}
-
-		// Add standard Helm labels to any labels section (metadata.labels, template.metadata.labels)
-		// but NOT to selectors (which must remain immutable)
-		if !inSelector && strings.Contains(line, common.LabelKeyAppName) {
-			indent, _ := LeadingWhitespace(line)
-
-			// Check if we're in a labels section by looking backwards
-			isInLabelsSection := false
-			for j := i - 1; j >= 0 && j >= i-5; j-- {
-				if strings.TrimSpace(lines[j]) == common.YamlKeyLabels {
-					isInLabelsSection = true
-					break
-				}
-				if strings.TrimSpace(lines[j]) == common.YamlKeyMetadata {
-					break
-				}
+		_, indent := LeadingWhitespace(line)
+		hasLabels = hasLabels || indent == 2
+		droppedKeys := helperKeys
+		if indent == 2 {
+			result = append(result, includeLine(indent+2))
+		} else {
+			droppedKeys = podHelperKeys
+			result = append(result, podIncludeLines(indent+2)...)
+		}
+		for ; i+1 < len(lines); i++ {
+			next := lines[i+1]
+			nextTrimmed := strings.TrimSpace(next)
+			_, nextIndent := LeadingWhitespace(next)
+			if nextTrimmed == "" || nextIndent <= indent {
+				break
 			}
-
-			if !isInLabelsSection {
+			if nextIndent == indent+2 && slices.ContainsFunc(droppedKeys, func(key string) bool {
+				return strings.HasPrefix(nextTrimmed, key)
+			}) {
 				continue
 			}
+			result = append(result, next)
+		}
+	}
 
-			// Check if standard labels already exist in this labels section
-			hasHelmChart, hasInstance, hasManagedBy := CheckExistingLabels(lines, i, indent)
+	if metadataLine >= 0 && !hasLabels {
+		result = slices.Insert(result, metadataLine+1, "  "+common.YamlKeyLabels, includeLine(4))
+	}
+	return strings.Join(result, "\n")
+}
 
-			// Add helm.sh/chart if it doesn't exist
-			if !hasHelmChart {
-				result = append(result,
-					indent+common.LabelKeyHelmChart+` {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}`)
-			}
+// AddCommonLabels merges .Values.commonLabels into every metadata labels block, pod templates
+// included, creating the resource labels block when it has none. Labels the block already sets win,
+// so the pod labels matched by selectors keep their values. It runs after the steps adding labels.
+func AddCommonLabels(yamlContent string) string {
+	if strings.Contains(yamlContent, valuesCommonLabels) {
+		return yamlContent
+	}
+	return KeepSelectorLabels(yamlContent, addCommonLabels)
+}
 
-			// Add app.kubernetes.io/instance if it doesn't exist
-			if !hasInstance {
-				result = append(result, indent+common.LabelKeyAppInstance+" {{ .Release.Name }}")
-			}
+func addCommonLabels(yamlContent string) string {
+	lines := strings.Split(addCommonMetadataMap(yamlContent, common.YamlKeyLabels, valuesCommonLabels), "\n")
+	result := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		result = append(result, lines[i])
+		_, indent := LeadingWhitespace(lines[i])
+		if indent == 2 || strings.TrimSpace(lines[i]) != common.YamlKeyLabels || !isMetadataChild(lines, i) {
+			continue
+		}
+		end := metadataMapEnd(lines, i)
+		result = append(result, lines[i+1:end]...)
+		result = appendHelmMapBlock(result, strings.Repeat(" ", indent+2), valuesCommonLabels,
+			extractKeysFromLines(lines[i:end]))
+		i = end - 1
+	}
+	return strings.Join(result, "\n")
+}
 
-			// Add app.kubernetes.io/managed-by if it doesn't exist (per Helm best practices)
-			if !hasManagedBy {
-				result = append(result, indent+common.LabelKeyAppManagedBy+" {{ .Release.Service }}")
-			}
+// AddCommonAnnotations merges .Values.commonAnnotations into the annotations of the resource
+// metadata, creating the block when the resource has none. Annotations the block already sets win.
+// It runs after the steps adding their own annotations, so it merges into the block they emitted.
+func AddCommonAnnotations(yamlContent string) string {
+	return addCommonMetadataMap(yamlContent, common.YamlKeyAnnotations, valuesCommonAnnotations)
+}
+
+// AddRevisionAnnotation merges the app.kubernetes.io/revision annotation rendered by the
+// revisionAnnotations helper from .Values.revision into the annotations of the resource metadata,
+// like AddCommonAnnotations. Nothing is rendered while revision is unset.
+func AddRevisionAnnotation(chartName, yamlContent string) string {
+	return addCommonMetadataMap(yamlContent, common.YamlKeyAnnotations,
+		fmt.Sprintf(`(include "%s.revisionAnnotations" . | fromYaml)`, chartName))
+}
+
+// AddProvenanceAnnotations merges the app.kubernetes.io/part-of and helm.sh/chart annotations
+// rendered by the provenanceAnnotations helper into the annotations of the resource metadata, like
+// AddCommonAnnotations.
+func AddProvenanceAnnotations(chartName, yamlContent string) string {
+	return addCommonMetadataMap(yamlContent, common.YamlKeyAnnotations,
+		fmt.Sprintf(`(include "%s.provenanceAnnotations" . | fromYaml)`, chartName))
+}
+
+// TemplateServiceSelectorLabels replaces the chart name label of a Service selector with the
+// selectorLabels helper, which adds the release instance, so the Service only selects the pods of
+// its own release. The pod templates already carry the instance label from the labels helper.
+// Selectors without the chart name label are left as they are.
+func TemplateServiceSelectorLabels(chartName, yamlContent string) string {
+	includeAction := fmt.Sprintf(`include "%s.selectorLabels" .`, chartName)
+	if strings.Contains(yamlContent, includeAction) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	header, end := findNestedBlock(lines, common.YamlKeySpec, "selector:")
+	if header < 0 {
+		return yamlContent
+	}
+	nameLabel := fmt.Sprintf(`app.kubernetes.io/name: {{ include "%s.name" . }}`, chartName)
+	nameLine := slices.IndexFunc(lines[header+1:end], func(line string) bool {
+		return strings.TrimSpace(line) == nameLabel
+	})
+	if nameLine < 0 {
+		return yamlContent
+	}
+
+	indent, indentLen := LeadingWhitespace(lines[header+1+nameLine])
+	selector := make([]string, 0, end-header-1)
+	for i, line := range lines[header+1 : end] {
+		switch {
+		case i == nameLine:
+			selector = append(selector, fmt.Sprintf("%s{{- %s | nindent %d }}", indent, includeAction, indentLen))
+		case !strings.HasPrefix(strings.TrimSpace(line), common.LabelKeyAppInstance):
+			selector = append(selector, line)
 		}
 	}
+	return strings.Join(slices.Replace(lines, header+1, end, selector...), "\n")
+}
 
-	return strings.Join(result, "\n")
+// addCommonMetadataMap merges valuePath into the mapKey block (labels: or annotations:) of the
+// top-level metadata. A literal block gets the values appended, omitting the keys it defines; a block
+// guarded by a directive is rendered when either its own condition or valuePath is set; a missing or
+// empty block is replaced by a block guarded by valuePath.
+func addCommonMetadataMap(yamlContent, mapKey, valuePath string) string {
+	if strings.Contains(yamlContent, valuePath) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	metadata := slices.Index(lines, common.YamlKeyMetadata)
+	if metadata < 0 {
+		return yamlContent
+	}
+	header, metadataEnd := -1, metadata+1
+	for metadataEnd < len(lines) && isMetadataBlockLine(lines, metadataEnd) {
+		_, indent := LeadingWhitespace(lines[metadataEnd])
+		if header < 0 && indent == 2 && isMetadataMapHeader(strings.TrimSpace(lines[metadataEnd]), mapKey) {
+			header = metadataEnd
+		}
+		metadataEnd++
+	}
+	if header < 0 {
+		for metadataEnd > metadata+1 && strings.TrimSpace(lines[metadataEnd-1]) == "" {
+			metadataEnd--
+		}
+		block := buildGuardedMetadataMapBlock(2, mapKey, valuePath)
+		return strings.Join(slices.Insert(lines, metadataEnd, block...), "\n")
+	}
+
+	end := metadataMapEnd(lines, header)
+	body := lines[header+1 : end]
+	if len(body) == 0 {
+		return strings.Join(slices.Replace(lines, header, header+1,
+			buildGuardedMetadataMapBlock(2, mapKey, valuePath)...), "\n")
+	}
+
+	guard := metadataMapGuardPattern.FindStringSubmatch(lines[header-1])
+	if guard == nil {
+		block := appendHelmMapBlock(nil, "    ", valuePath, extractKeysFromLines(lines[header:end]))
+		return strings.Join(slices.Insert(lines, end, block...), "\n")
+	}
+	if end >= len(lines) || lines[end] != "  {{- end }}" {
+		return yamlContent
+	}
+	// A block already merging several values renders each of them on its own, so it takes one more.
+	if guard[1] == "if" && strings.HasPrefix(guard[2], "or ") {
+		lines[header-1] = "  {{- if " + guard[2] + " " + valuePath + " }}"
+		return strings.Join(slices.Insert(lines, end,
+			"    {{- with "+valuePath+" }}",
+			"    {{- toYaml . | nindent 4 }}",
+			"    {{- end }}"), "\n")
+	}
+	// The own values become the dot of a with block, so the condition is not repeated in the body.
+	condition, inner := guard[2], slices.Clone(body)
+	for i, line := range inner {
+		inner[i] = strings.Replace(line, "toYaml "+condition+" ", "toYaml . ", 1)
+	}
+	action := "with"
+	if guard[1] == "if" && slices.ContainsFunc(inner, func(line string) bool {
+		return strings.Contains(line, " .Values.")
+	}) {
+		action, inner = "if", body
+	}
+	if strings.Contains(condition, " ") {
+		condition = "(" + condition + ")"
+	}
+	block := []string{
+		"  {{- if or " + condition + " " + valuePath + " }}",
+		"  " + mapKey,
+		"    {{- " + action + " " + guard[2] + " }}",
+	}
+	block = append(block, inner...)
+	block = append(block,
+		"    {{- end }}",
+		"    {{- with "+valuePath+" }}",
+		"    {{- toYaml . | nindent 4 }}",
+		"    {{- end }}")
+	return strings.Join(slices.Replace(lines, header-1, end, block...), "\n")
+}
+
+// isMetadataBlockLine reports whether lines[i] still belongs to a top-level metadata block: an indented
+// or blank line, or a Helm directive such as the rbac.namespaced guard of the namespace when indented
+// metadata fields follow it.
+func isMetadataBlockLine(lines []string, i int) bool {
+	if lines[i] == "" || strings.HasPrefix(lines[i], " ") {
+		return true
+	}
+	if !strings.HasPrefix(lines[i], "{{") {
+		return false
+	}
+	for i++; i < len(lines); i++ {
+		if lines[i] != "" && !strings.HasPrefix(lines[i], "{{") {
+			return strings.HasPrefix(lines[i], " ")
+		}
+	}
+	return false
+}
+
+// metadataMapEnd returns the index after the last line of the labels or annotations block whose
+// header is lines[header], trailing blank lines excluded.
+func metadataMapEnd(lines []string, header int) int {
+	_, headerIndent := LeadingWhitespace(lines[header])
+	end := header + 1
+	for end < len(lines) {
+		if _, indent := LeadingWhitespace(lines[end]); strings.TrimSpace(lines[end]) != "" && indent <= headerIndent {
+			break
+		}
+		end++
+	}
+	for end > header+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return end
+}
+
+// isMetadataChild reports whether lines[i] is a direct child of a metadata: key, skipping Helm
+// directive lines between them.
+func isMetadataChild(lines []string, i int) bool {
+	return isChildOf(lines, i, common.YamlKeyMetadata)
+}
+
+// isChildOf reports whether lines[i] is a direct child of the parent key (e.g. "clientConfig:"),
+// skipping Helm directive lines between them.
+func isChildOf(lines []string, i int, parent string) bool {
+	_, indent := LeadingWhitespace(lines[i])
+	for j := i - 1; j >= 0; j-- {
+		trimmed := strings.TrimSpace(lines[j])
+		if trimmed == "" || strings.HasPrefix(trimmed, "{{") {
+			continue
+		}
+		if _, parentIndent := LeadingWhitespace(lines[j]); parentIndent < indent {
+			return trimmed == parent
+		}
+	}
+	return false
 }
 
 // AddServiceAccountLabelsAndAnnotations makes the ServiceAccount metadata honor
@@ -214,6 +542,41 @@ func AddServiceAccountLabelsAndAnnotations(yamlContent string) string {
 	return strings.Join(merged, "\n")
 }
 
+// AddNamespaceLabels makes the metadata of a kept Namespace honor .Values.namespace.labels, e.g. the
+// pod-security.kubernetes.io labels enforcing a Pod Security Standard. Keys the labels block already
+// defines, such as managed-by and the standard Helm labels, are not overridden.
+func AddNamespaceLabels(yamlContent string) string {
+	if strings.Contains(yamlContent, valuesNamespaceLabels) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	merged := make([]string, 0, len(lines)+5)
+	metadataIndent := -1
+	metadataLineIndex := -1
+	hasLabels := false
+	for lineIndex := 0; lineIndex < len(lines); lineIndex++ {
+		switch trimmed := strings.TrimSpace(lines[lineIndex]); {
+		case trimmed == common.YamlKeyMetadata && metadataIndent < 0:
+			_, metadataIndent = LeadingWhitespace(lines[lineIndex])
+			metadataLineIndex = len(merged)
+			merged = append(merged, lines[lineIndex])
+		case isMetadataMapChildHeader(lines[lineIndex], common.YamlKeyLabels, metadataIndent):
+			merged, lineIndex = mergeMetadataMapBlock(
+				merged, lines, lineIndex, common.YamlKeyLabels, valuesNamespaceLabels)
+			hasLabels = true
+		default:
+			merged = append(merged, lines[lineIndex])
+		}
+	}
+
+	if !hasLabels && metadataLineIndex >= 0 {
+		merged = slices.Insert(merged, metadataLineIndex+1,
+			buildGuardedMetadataMapBlock(metadataIndent+2, common.YamlKeyLabels, valuesNamespaceLabels)...)
+	}
+	return strings.Join(merged, "\n")
+}
+
 // isMetadataMapHeader reports whether trimmed is the header for the given metadata map key
 // (for example "labels:"), including the inline empty-map form "labels: {}".
 func isMetadataMapHeader(trimmed, mapKey string) bool {
@@ -394,6 +757,12 @@ func extractKeysFromLines(lines []string) []string {
 		// Skip Helm template directives (e.g., "{{- if ... }}", "{{- end }}"),
 		// but still parse YAML key/value lines whose values contain templates.
 		if strings.HasPrefix(trimmed, "{{") {
+			// The standard labels helper renders its keys into the block.
+			if standardLabelsIncludePattern.MatchString(trimmed) {
+				for _, key := range standardHelmLabelKeys {
+					keys = append(keys, strings.TrimSuffix(key, ":"))
+				}
+			}
 			continue
 		}
 
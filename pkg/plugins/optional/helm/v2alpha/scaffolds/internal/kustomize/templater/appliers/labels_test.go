@@ -21,6 +21,8 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // countMetadataHeader counts how many times key (for example "labels:") appears as a standalone
@@ -419,3 +421,282 @@ var _ = Describe("AddCustomLabelsAndAnnotations", func() {
 		Expect(twice).To(Equal(once))
 	})
 })
+
+var _ = Describe("TemplateVersionLabels", func() {
+	It("should rewrite version labels to the chart appVersion outside selectors", func() {
+		content := `apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+spec:
+  groups:
+  - name: test-project
+    rules:
+    - alert: ManagerDown
+      labels:
+        app.kubernetes.io/version: v0.1.0
+        severity: critical
+  podSelector:
+    matchLabels:
+      app.kubernetes.io/version: v0.1.0
+  namespaceSelector:
+    app.kubernetes.io/version: v0.1.0`
+
+		result := TemplateVersionLabels(content)
+
+		Expect(result).To(ContainSubstring(
+			"      labels:\n        app.kubernetes.io/version: {{ .Chart.AppVersion | quote }}\n        severity: critical\n"))
+		Expect(result).To(ContainSubstring("    matchLabels:\n      app.kubernetes.io/version: v0.1.0\n"))
+		Expect(result).To(HaveSuffix("  namespaceSelector:\n    app.kubernetes.io/version: v0.1.0"))
+		Expect(TemplateVersionLabels(result)).To(Equal(result))
+	})
+})
+
+var _ = Describe("KeepSelectorLabels", func() {
+	const workload = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    tier: backend
+  name: test-project-worker
+spec:
+  selector:
+    matchLabels:
+      app.kubernetes.io/managed-by: kustomize
+      tier: backend
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/managed-by: kustomize
+        tier: backend
+        track: stable
+    spec:
+      containers:
+      - name: worker`
+
+	relabel := func(yamlContent string) string {
+		yamlContent = strings.ReplaceAll(yamlContent, "kustomize", "Helm")
+		yamlContent = strings.ReplaceAll(yamlContent, "backend", "frontend")
+		return strings.ReplaceAll(yamlContent, "stable", "canary")
+	}
+
+	It("should keep the selector and the pod labels it matches", func() {
+		result := KeepSelectorLabels(workload, relabel)
+
+		Expect(result).To(ContainSubstring(`  selector:
+    matchLabels:
+      app.kubernetes.io/managed-by: kustomize
+      tier: backend
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/managed-by: kustomize
+        tier: backend
+        track: canary
+`))
+		Expect(result).To(ContainSubstring("  labels:\n    tier: frontend\n"))
+	})
+
+	It("should only run the injection without a selector", func() {
+		content := "apiVersion: v1\nkind: Service\nspec:\n  selector:\n    tier: backend"
+		Expect(KeepSelectorLabels(content, relabel)).To(Equal(relabel(content)))
+	})
+})
+
+var _ = Describe("AddStandardHelmLabels", func() {
+	It("should keep the pod labels matched by the selector out of the helper", func() {
+		content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/instance: worker
+  name: test-project-worker
+spec:
+  selector:
+    matchLabels:
+      app.kubernetes.io/instance: worker
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/instance: worker`
+		resource := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"selector": map[string]any{
+				"matchLabels": map[string]any{"app.kubernetes.io/instance": "worker"},
+			}},
+		}}
+
+		result := AddStandardHelmLabels("test-project", true, content, resource)
+
+		Expect(result).To(ContainSubstring(`metadata:
+  labels:
+    {{- include "test-project.labels" . | nindent 4 }}
+  name: test-project-worker
+`))
+		Expect(result).To(HaveSuffix(`      labels:
+        {{- with omit (include "test-project.labels" . | fromYaml) "app.kubernetes.io/instance" }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        app.kubernetes.io/instance: worker`))
+	})
+})
+
+var _ = Describe("AddNamespaceLabels", func() {
+	It("should add a guarded labels block when the Namespace has none", func() {
+		content := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: test-project-system\n"
+
+		result := AddNamespaceLabels(content)
+
+		Expect(result).To(ContainSubstring(`metadata:
+  {{- with (.Values.namespace | default dict).labels }}
+  labels:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  name: test-project-system
+`))
+		Expect(AddNamespaceLabels(result)).To(Equal(result))
+	})
+
+	It("should merge namespace.labels into the existing labels without overriding them", func() {
+		content := `apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+    control-plane: controller-manager
+  name: test-project-system
+`
+
+		result := AddNamespaceLabels(content)
+
+		Expect(result).To(ContainSubstring(`    control-plane: controller-manager
+    {{- with (.Values.namespace | default dict).labels }}
+    {{- with omit . "app.kubernetes.io/managed-by" "control-plane" }}
+`))
+		Expect(countMetadataHeader(result, "labels:")).To(Equal(1))
+	})
+})
+
+var _ = Describe("AddCommonAnnotations", func() {
+	It("should add a guarded annotations block at the end of metadata, leaving pod annotations alone", func() {
+		content := `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: test-project-agent
+spec:
+  template:
+    metadata:
+      annotations:
+        example.com/scrape: "true"
+`
+
+		result := AddCommonAnnotations(content)
+
+		Expect(result).To(ContainSubstring(`  name: test-project-agent
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+`))
+		Expect(result).To(HaveSuffix("      annotations:\n        example.com/scrape: \"true\"\n"))
+		Expect(AddCommonAnnotations(result)).To(Equal(result))
+	})
+
+	It("should render a guarded annotations block when either its own values or commonAnnotations are set", func() {
+		content := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  {{- with .Values.serviceAccount.annotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  name: test-project-controller-manager
+`
+
+		result := AddCommonAnnotations(content)
+
+		Expect(result).To(ContainSubstring(`metadata:
+  {{- if or .Values.serviceAccount.annotations .Values.commonAnnotations }}
+  annotations:
+    {{- with .Values.serviceAccount.annotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- with .Values.commonAnnotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- end }}
+  name: test-project-controller-manager
+`))
+		Expect(countMetadataHeader(result, "annotations:")).To(Equal(1))
+	})
+
+	It("should replace an empty annotations block", func() {
+		content := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  annotations:\n  name: test-project-config\n"
+
+		result := AddCommonAnnotations(content)
+
+		Expect(result).To(Equal(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  name: test-project-config
+`))
+	})
+})
+
+var _ = Describe("AddCommonLabels", func() {
+	It("should add a guarded labels block when the resource has none", func() {
+		content := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test-project-config\n"
+
+		result := AddCommonLabels(content)
+
+		Expect(result).To(Equal(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-project-config
+  {{- with .Values.commonLabels }}
+  labels:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+`))
+		Expect(AddCommonLabels(result)).To(Equal(result))
+	})
+
+	It("should append to resource and pod template labels at their own indentation", func() {
+		content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
+spec:
+  template:
+    metadata:
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers: []
+`
+
+		result := AddCommonLabels(content)
+
+		Expect(result).To(ContainSubstring(`    control-plane: controller-manager
+    {{- with .Values.commonLabels }}
+    {{- with omit . "control-plane" }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- end }}
+  name: test-project-controller-manager
+`))
+		Expect(result).To(ContainSubstring(`        control-plane: controller-manager
+        {{- with .Values.commonLabels }}
+        {{- with omit . "control-plane" }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        {{- end }}
+    spec:
+`))
+		Expect(AddCommonLabels(result)).To(Equal(result))
+	})
+})
@@ -61,56 +61,118 @@ type customFieldsState struct {
 
 // TemplateDeploymentFields applies all Deployment-specific transformations.
 func TemplateDeploymentFields(detectedPrefix, chartName, yamlContent string) string {
-	yamlContent = templateReplicas(yamlContent)
-	yamlContent = templateImageReference(yamlContent)
-	yamlContent = TemplateServiceAccountNameInDeployment(detectedPrefix, chartName, yamlContent)
-	yamlContent = templateEnvironmentVariables(yamlContent)
-	yamlContent = templateImagePullSecrets(yamlContent)
-	yamlContent = templatePodSecurityContext(yamlContent)
-	yamlContent = templateContainerSecurityContext(yamlContent)
-	yamlContent = templateResources(yamlContent)
-	yamlContent = templateSecurityContexts(yamlContent)
-	yamlContent = templateVolumeMounts(yamlContent)
-	yamlContent = templateVolumes(yamlContent)
-	yamlContent = templateControllerManagerArgs(yamlContent)
-	yamlContent = templateBasicWithStatement(
-		yamlContent,
-		"nodeSelector",
-		"spec.template.spec",
-		".Values.manager.nodeSelector",
-	)
-	yamlContent = templateBasicWithStatement(
-		yamlContent,
-		"affinity",
-		"spec.template.spec",
-		".Values.manager.affinity",
-	)
-	yamlContent = templateBasicWithStatement(
-		yamlContent,
-		"tolerations",
-		"spec.template.spec",
-		".Values.manager.tolerations",
-	)
+	return TemplateDeploymentFieldsRecorded(detectedPrefix, chartName, yamlContent, nil)
+}
 
-	// Always emit these conditionals so users can enable them in values.yaml without regenerating.
-	yamlContent = templateBasicWithStatement(
-		yamlContent,
-		"strategy",
-		"spec",
-		".Values.manager.strategy",
-	)
-	yamlContent = templatePriorityClassName(yamlContent)
-	yamlContent = templateBasicWithStatement(
-		yamlContent,
-		"topologySpreadConstraints",
-		"spec.template.spec",
-		".Values.manager.topologySpreadConstraints",
-	)
-	yamlContent = templateTerminationGracePeriodSeconds(yamlContent)
+// TemplateDeploymentFieldsRecorded is TemplateDeploymentFields reporting each step to record.
+func TemplateDeploymentFieldsRecorded(detectedPrefix, chartName, yamlContent string, record StepRecorder) string {
+	withStatement := func(field, parentPath, valuePath string) func(string) string {
+		return func(content string) string {
+			return templateBasicWithStatement(content, field, parentPath, valuePath)
+		}
+	}
+	steps := []struct {
+		name  string
+		apply func(string) string
+	}{
+		{"templateReplicas", templateReplicas},
+		{"templateProgressDeadlineSeconds", templateProgressDeadlineSeconds},
+		{"templateMinReadySeconds", templateMinReadySeconds},
+		{"templateImageReference", templateImageReference},
+		{"TemplateServiceAccountNameInDeployment", func(content string) string {
+			return TemplateServiceAccountNameInDeployment(detectedPrefix, chartName, content)
+		}},
+		{"templateEnvironmentVariables", templateEnvironmentVariables},
+		{"templateImagePullSecrets", templateImagePullSecrets},
+		{"templatePodSecurityContext", templatePodSecurityContext},
+		{"templateContainerSecurityContext", templateContainerSecurityContext},
+		{"insertManagerArgs", insertManagerArgs},
+		{"templateLifecycle", templateLifecycle},
+		{"templateTerminationMessagePolicy", templateTerminationMessagePolicy},
+		{"templateResources", func(content string) string {
+			return templateResources(chartName, content)
+		}},
+		{"templateSecurityContexts", templateSecurityContexts},
+		{"templateVolumeMounts", templateVolumeMounts},
+		{"templateVolumes", templateVolumes},
+		{"templateScratchVolumes", templateScratchVolumes},
+		{"templateControllerManagerArgs", templateControllerManagerArgs},
+		{"templateNodeSelector", withStatement("nodeSelector", "spec.template.spec", ".Values.manager.nodeSelector")},
+		// The helper falls back to a soft pod anti-affinity when manager.defaultAffinity is set.
+		{"templateAffinity", withStatement("affinity", "spec.template.spec",
+			fmt.Sprintf(`include "%s.managerAffinity" . | fromYaml`, chartName))},
+		// The helper merges manager.tolerations with the node failure tolerations.
+		{"templateTolerations", withStatement("tolerations", "spec.template.spec",
+			fmt.Sprintf(`include "%s.managerTolerations" . | fromYamlArray`, chartName))},
+		// Always emit these conditionals so users can enable them in values.yaml without regenerating.
+		{"templateStrategy", withStatement("strategy", "spec", ".Values.manager.strategy")},
+		{"templatePriorityClassName", func(content string) string {
+			return templatePriorityClassName(chartName, content)
+		}},
+		{"templateHostNetwork", templateHostNetwork},
+		{"templateTopologySpreadConstraints", withStatement(
+			"topologySpreadConstraints", "spec.template.spec", ".Values.manager.topologySpreadConstraints")},
+		{"templateTerminationGracePeriodSeconds", templateTerminationGracePeriodSeconds},
+		{"templateAutomountServiceAccountToken", templateAutomountServiceAccountToken},
+	}
+
+	for _, step := range steps {
+		yamlContent = ApplyStep(record, step.name, yamlContent, step.apply)
+	}
+	return yamlContent
+}
+
+// managerContainerStep is a named transformation of the manager container shared by the workloads
+// that run it.
+type managerContainerStep struct {
+	name  string
+	apply func(string) string
+}
+
+// managerContainerSteps returns the manager container transformations shared by the DaemonSet and
+// StatefulSet templaters.
+func managerContainerSteps(chartName string) []managerContainerStep {
+	return []managerContainerStep{
+		{"templateImageReference", templateImageReference},
+		{"templateEnvironmentVariables", templateEnvironmentVariables},
+		{"templateResources", func(content string) string {
+			return templateResources(chartName, content)
+		}},
+	}
+}
 
+// applyManagerContainerSteps runs the shared manager container steps followed by extra, reporting
+// each step to record. Workloads without a manager container are returned unchanged.
+func applyManagerContainerSteps(
+	chartName, yamlContent string, record StepRecorder, extra ...managerContainerStep,
+) string {
+	if start, _ := FindManagerContainerRange(yamlContent); start < 0 {
+		return yamlContent
+	}
+	for _, step := range append(managerContainerSteps(chartName), extra...) {
+		yamlContent = ApplyStep(record, step.name, yamlContent, step.apply)
+	}
 	return yamlContent
 }
 
+// TemplateDaemonSetFieldsRecorded applies the manager container transformations to a DaemonSet running
+// the manager, reporting each step to record. replicas and strategy do not apply to DaemonSets; the
+// update strategy comes from manager.updateStrategy instead.
+func TemplateDaemonSetFieldsRecorded(chartName, yamlContent string, record StepRecorder) string {
+	return applyManagerContainerSteps(chartName, yamlContent, record, managerContainerStep{"templateUpdateStrategy",
+		func(content string) string {
+			return templateBasicWithStatement(content, "updateStrategy", "spec", ".Values.manager.updateStrategy")
+		}})
+}
+
+// TemplateStatefulSetFieldsRecorded applies the manager container transformations to a StatefulSet
+// running the manager, reporting each step to record. Its volumeClaimTemplates take their size and
+// storage class from manager.persistence.
+func TemplateStatefulSetFieldsRecorded(chartName, yamlContent string, record StepRecorder) string {
+	return applyManagerContainerSteps(chartName, yamlContent, record,
+		managerContainerStep{"templateVolumeClaimTemplates", templateVolumeClaimTemplates})
+}
+
 // isManagerContainerPresent reports whether yamlContent contains the manager container by literal or templated name.
 func isManagerContainerPresent(yamlContent string) bool {
 	containerName := GetDefaultContainerName(yamlContent)
@@ -127,7 +189,67 @@ func templateReplicas(yamlContent string) string {
 	return replicasPattern.ReplaceAllString(yamlContent, "${1}replicas: {{ .Values.manager.replicas }}")
 }
 
+// templateProgressDeadlineSeconds templates the Deployment progressDeadlineSeconds, rewriting the value from
+// the kustomize output or inserting it as the first field of the Deployment spec. Kubernetes defaults it
+// to 600, so the template does too.
+func templateProgressDeadlineSeconds(yamlContent string) string {
+	return templateDeploymentSpecField(yamlContent, "progressDeadlineSeconds",
+		".Values.manager.progressDeadlineSeconds | default 600")
+}
+
+// templateMinReadySeconds templates the Deployment minReadySeconds, rewriting the value from the kustomize
+// output or inserting it as the first field of the Deployment spec, so a rollout can wait for new pods to
+// stay ready before moving on. Kubernetes defaults it to 0, so the template does too.
+func templateMinReadySeconds(yamlContent string) string {
+	return templateDeploymentSpecField(yamlContent, "minReadySeconds", ".Values.manager.minReadySeconds | default 0")
+}
+
+// templateDeploymentSpecField renders the Deployment spec field key from the pipeline, rewriting the value
+// from the kustomize output or inserting the field as the first one of the spec. Content already
+// templating the field is returned unchanged.
+func templateDeploymentSpecField(yamlContent, key, pipeline string) string {
+	valuesPath, _, _ := strings.Cut(pipeline, " ")
+	if strings.Contains(yamlContent, valuesPath) {
+		return yamlContent
+	}
+
+	field := key + ": {{ " + pipeline + " }}"
+	lines := strings.Split(yamlContent, "\n")
+	specAt := slices.Index(lines, common.YamlKeySpec)
+	if specAt < 0 || specAt+1 >= len(lines) {
+		return yamlContent
+	}
+	indentStr, indentLen := LeadingWhitespace(lines[specAt+1])
+	if indentLen == 0 {
+		return yamlContent
+	}
+
+	for i := specAt + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		_, lineIndent := LeadingWhitespace(lines[i])
+		if trimmed != "" && lineIndent < indentLen {
+			break
+		}
+		if lineIndent == indentLen && strings.HasPrefix(trimmed, key+":") {
+			lines[i] = indentStr + field
+			return strings.Join(lines, "\n")
+		}
+	}
+
+	newLines := append([]string{}, lines[:specAt+1]...)
+	newLines = append(newLines, indentStr+field)
+	newLines = append(newLines, lines[specAt+1:]...)
+	return strings.Join(newLines, "\n")
+}
+
+// AddCustomLabelsAndAnnotations merges .Values.manager.labels and annotations into the Deployment
+// metadata and .Values.manager.pod labels and annotations into the pod template. The selector and the
+// pod labels it matches are kept as they are.
 func AddCustomLabelsAndAnnotations(yamlContent string) string {
+	return KeepSelectorLabels(yamlContent, addCustomLabelsAndAnnotations)
+}
+
+func addCustomLabelsAndAnnotations(yamlContent string) string {
 	hasDeploymentLabels := strings.Contains(yamlContent, "{{- if .Values.manager.labels }}") ||
 		strings.Contains(yamlContent, "{{- with .Values.manager.labels }}")
 	hasDeploymentAnnotations := strings.Contains(yamlContent, "{{- if .Values.manager.annotations }}") ||
@@ -180,7 +302,7 @@ func AddCustomLabelsAndAnnotations(yamlContent string) string {
 }
 
 func templateEnvironmentVariables(yamlContent string) string {
-	if !isManagerContainerPresent(yamlContent) {
+	if !isManagerContainerPresent(yamlContent) || strings.Contains(yamlContent, WatchNamespaceValuesPath) {
 		return yamlContent
 	}
 
@@ -221,13 +343,16 @@ func templateEnvironmentVariables(yamlContent string) string {
 
 		childIndent := indentStr + "  "
 		childIndentWidth := strconv.Itoa(len(childIndent))
-		// Env list + envOverrides (CLI --set). Secret refs go in env list.
-		hasEnv := `{{- if or .Values.manager.env (and (kindIs "map" .Values.manager.envOverrides) ` +
-			`(not (empty .Values.manager.envOverrides))) }}`
-		block := make([]string, 0, 22)
+		// WATCH_NAMESPACE + env list + envOverrides (CLI --set). Secret refs go in env list.
+		// WATCH_NAMESPACE comes first so an entry with the same name in env or envOverrides wins.
+		hasEnv := `{{- if or ` + WatchNamespaceValuesPath + ` .Values.manager.env ` +
+			`(and (kindIs "map" .Values.manager.envOverrides) (not (empty .Values.manager.envOverrides))) }}`
+		block := make([]string, 0, 26)
 		block = append(block,
 			indentStr+"env:",
-			indentStr+hasEnv,
+			indentStr+hasEnv)
+		block = append(block, watchNamespaceEnv(childIndent)...)
+		block = append(block,
 			childIndent+`{{- if .Values.manager.env }}`,
 			childIndent+"{{- toYaml .Values.manager.env | nindent "+childIndentWidth+" }}",
 			childIndent+`{{- end }}`,
@@ -248,10 +373,51 @@ func templateEnvironmentVariables(yamlContent string) string {
 		return strings.Join(newLines, "\n")
 	}
 
-	return yamlContent
+	return addWatchNamespaceEnv(yamlContent, rangeStart, rangeEnd)
+}
+
+// watchNamespaceEnv returns the WATCH_NAMESPACE env entry, rendered only when
+// rbac.watchNamespace is set so the manager watches all namespaces by default.
+func watchNamespaceEnv(indent string) []string {
+	return []string{
+		indent + "{{- with " + WatchNamespaceValuesPath + " }}",
+		indent + "- name: WATCH_NAMESPACE",
+		indent + "  value: {{ . | quote }}",
+		indent + "{{- end }}",
+	}
 }
 
-func templateResources(yamlContent string) string {
+// addWatchNamespaceEnv gives a manager container without env an env list holding only
+// WATCH_NAMESPACE, placed before its image when it has one.
+func addWatchNamespaceEnv(yamlContent string, rangeStart, rangeEnd int) string {
+	if rangeStart < 0 {
+		return yamlContent
+	}
+	lines := strings.Split(yamlContent, "\n")
+	_, itemIndent := LeadingWhitespace(lines[rangeStart])
+	fieldIndent := strings.Repeat(" ", itemIndent+2)
+
+	insertAt := rangeEnd + 1
+	for i := rangeStart + 1; i <= rangeEnd; i++ {
+		if indent, _ := LeadingWhitespace(lines[i]); indent == fieldIndent && strings.HasPrefix(lines[i], indent+"image:") {
+			insertAt = i
+			break
+		}
+	}
+	for insertAt > rangeStart+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+
+	return strings.Join(slices.Insert(lines, insertAt,
+		fieldIndent+"{{- with "+WatchNamespaceValuesPath+" }}",
+		fieldIndent+"env:",
+		fieldIndent+"- name: WATCH_NAMESPACE",
+		fieldIndent+"  value: {{ . | quote }}",
+		fieldIndent+"{{- end }}",
+	), "\n")
+}
+
+func templateResources(chartName, yamlContent string) string {
 	if !isManagerContainerPresent(yamlContent) || !strings.Contains(yamlContent, "resources:") {
 		return yamlContent
 	}
@@ -293,10 +459,14 @@ func templateResources(yamlContent string) string {
 		childIndent := indentStr + "  "
 		childIndentWidth := strconv.Itoa(len(childIndent))
 
+		// manager.resources replaces the resources as a whole; while it is unset, the manager.resourcesProfile
+		// profile is merged over the scaffolded resources.
 		block := []string{
 			indentStr + "resources:",
-			childIndent + "{{- if .Values.manager.resources }}",
-			childIndent + "{{- toYaml .Values.manager.resources | nindent " + childIndentWidth + " }}",
+			childIndent + "{{- with .Values.manager.resources | default (mergeOverwrite (fromYaml " +
+				scaffoldedYAMLLiteral(lines[i+1:end]) + ") " +
+				"(include \"" + chartName + ".managerResourcesProfile\" $ | fromYaml)) }}",
+			childIndent + "{{- toYaml . | nindent " + childIndentWidth + " }}",
 			childIndent + "{{- else }}",
 			childIndent + "{}",
 			childIndent + "{{- end }}",
@@ -316,16 +486,89 @@ func templateSecurityContexts(yamlContent string) string {
 }
 
 func templateVolumeMounts(yamlContent string) string {
-	return appendToListFromValues(yamlContent, "volumeMounts:", ".Values.manager.extraVolumeMounts")
+	rangeStart, rangeEnd := FindManagerContainerRange(yamlContent)
+	return appendToListFromValues(yamlContent, "volumeMounts:", ".Values.manager.extraVolumeMounts", rangeStart, rangeEnd)
 }
 
 func templateVolumes(yamlContent string) string {
-	return appendToListFromValues(yamlContent, "volumes:", ".Values.manager.extraVolumes")
+	return appendToListFromValues(yamlContent, "volumes:", ".Values.manager.extraVolumes", -1, -1)
+}
+
+// scratchSizeLimitValuesPath is the optional sizeLimit of the emptyDir scratch volumes.
+const scratchSizeLimitValuesPath = ".Values.manager.scratchSizeLimit"
+
+// templateScratchVolumes templates the sizeLimit of every emptyDir volume in the manifest from
+// .Values.manager.scratchSizeLimit. A sizeLimit set in the manifest becomes the default; otherwise
+// none is rendered while the value is unset. The cert volumes are Secret-backed and never touched.
+func templateScratchVolumes(yamlContent string) string {
+	if strings.Contains(yamlContent, scratchSizeLimitValuesPath) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(lines[i]), "- ")
+		if trimmed != "emptyDir: {}" && trimmed != "emptyDir:" {
+			result = append(result, lines[i])
+			continue
+		}
+
+		// The fields of emptyDir are indented under its key, which follows the list item dash.
+		keyIndent := len(lines[i]) - len(strings.TrimLeft(lines[i], " -"))
+		childIndent := strings.Repeat(" ", keyIndent+2)
+		end := i + 1
+		for ; end < len(lines); end++ {
+			if _, indent := LeadingWhitespace(lines[end]); strings.TrimSpace(lines[end]) == "" || indent <= keyIndent {
+				break
+			}
+		}
+
+		if trimmed == "emptyDir: {}" {
+			// An empty emptyDir must still render as {} so the volume keeps its source.
+			result = append(result,
+				strings.TrimSuffix(lines[i], " {}"),
+				childIndent+"{{- with "+scratchSizeLimitValuesPath+" }}",
+				childIndent+"sizeLimit: {{ . }}",
+				childIndent+"{{- else }}",
+				childIndent+"{}",
+				childIndent+"{{- end }}",
+			)
+			continue
+		}
+
+		if end > i+1 {
+			childIndent, _ = LeadingWhitespace(lines[i+1])
+		}
+		result = append(result, lines[i])
+		hasSizeLimit := false
+		for _, line := range lines[i+1 : end] {
+			if value, found := strings.CutPrefix(strings.TrimSpace(line), "sizeLimit:"); found {
+				hasSizeLimit = true
+				indent, _ := LeadingWhitespace(line)
+				line = fmt.Sprintf("%ssizeLimit: {{ %s | default %q }}", indent, scratchSizeLimitValuesPath,
+					strings.Trim(strings.TrimSpace(value), `"'`))
+			}
+			result = append(result, line)
+		}
+		if !hasSizeLimit {
+			result = append(result,
+				childIndent+"{{- with "+scratchSizeLimitValuesPath+" }}",
+				childIndent+"sizeLimit: {{ . }}",
+				childIndent+"{{- end }}",
+			)
+		}
+		i = end - 1
+	}
+	return strings.Join(result, "\n")
 }
 
-// appendToListFromValues injects a values reference into a YAML list field.
+// appendToListFromValues injects a values reference into a YAML list field, looking only at lines
+// [rangeStart, rangeEnd] unless rangeStart is negative.
 // Replaces "key: []" with a conditional template; appends to "key:" with existing items.
-func appendToListFromValues(yamlContent string, keyColon string, valuesPath string) string {
+// Items written at the indent of the key are moved two spaces in so that they line up with the
+// values rendered after them and with the cert volumes wrapped later by wrapBlock.
+func appendToListFromValues(yamlContent, keyColon, valuesPath string, rangeStart, rangeEnd int) string {
 	if !strings.Contains(yamlContent, keyColon) {
 		return yamlContent
 	}
@@ -337,6 +580,9 @@ func appendToListFromValues(yamlContent string, keyColon string, valuesPath stri
 	keyEmpty := keyColon + " []"
 
 	for i := range lines {
+		if rangeStart >= 0 && (i < rangeStart || i > rangeEnd) {
+			continue
+		}
 		trimmed := strings.TrimSpace(lines[i])
 		indentStr, indentLen := LeadingWhitespace(lines[i])
 		childIndent := indentStr + "  "
@@ -368,10 +614,17 @@ func appendToListFromValues(yamlContent string, keyColon string, valuesPath stri
 				break
 			}
 			lineIndent := len(lines[end]) - len(strings.TrimLeft(lines[end], " \t"))
-			if lineIndent <= indentLen {
+			if lineIndent < indentLen || (lineIndent == indentLen && !strings.HasPrefix(tLine, "- ")) {
 				break
 			}
 		}
+		if end > i+1 {
+			if _, itemIndent := LeadingWhitespace(lines[i+1]); itemIndent == indentLen {
+				for j := i + 1; j < end; j++ {
+					lines[j] = "  " + lines[j]
+				}
+			}
+		}
 
 		block := []string{
 			childIndent + "{{- if " + valuesPath + " }}",
@@ -388,6 +641,10 @@ func appendToListFromValues(yamlContent string, keyColon string, valuesPath stri
 
 // templateImagePullSecrets injects imagePullSecrets; always emits the block so users can enable it in values.yaml.
 func templateImagePullSecrets(yamlContent string) string {
+	if strings.Contains(yamlContent, "{{- with .Values.manager.imagePullSecrets }}") {
+		return yamlContent
+	}
+
 	lines := strings.Split(yamlContent, "\n")
 
 	if strings.Contains(yamlContent, "imagePullSecrets:") {
@@ -493,11 +750,8 @@ func templatePodSecurityContext(yamlContent string) string {
 			}
 		}
 
-		if end >= len(lines) {
-			break
-		}
-
-		if !strings.HasPrefix(strings.TrimSpace(lines[end]), "serviceAccountName:") {
+		// The pod securityContext sits directly under the pod spec, wherever it is in the field order.
+		if !isChildOf(lines, i, "spec:") {
 			continue
 		}
 
@@ -508,14 +762,16 @@ func templatePodSecurityContext(yamlContent string) string {
 		childIndent := indentStr + "  "
 		childIndentWidth := strconv.Itoa(len(childIndent))
 
+		// Without a value the scaffolded securityContext is kept, so an unset value does not drop
+		// defaults such as the RuntimeDefault seccompProfile required by Pod Security Standards.
 		block := []string{
 			indentStr + "securityContext:",
 			childIndent + "{{- if .Values.manager.podSecurityContext }}",
 			childIndent + "{{- toYaml .Values.manager.podSecurityContext | nindent " + childIndentWidth + " }}",
 			childIndent + "{{- else }}",
-			childIndent + "{}",
-			childIndent + "{{- end }}",
 		}
+		block = append(block, scaffoldedFieldsOrEmpty(lines[i+1:end], childIndent)...)
+		block = append(block, childIndent+"{{- end }}")
 
 		newLines := append([]string{}, lines[:i]...)
 		newLines = append(newLines, block...)
@@ -526,23 +782,151 @@ func templatePodSecurityContext(yamlContent string) string {
 	return yamlContent
 }
 
-func templateContainerSecurityContext(yamlContent string) string {
-	if !isManagerContainerPresent(yamlContent) || !strings.Contains(yamlContent, "securityContext:") {
+// lifecycleValuesPath holds the lifecycle hooks of the manager container.
+const lifecycleValuesPath = ".Values.manager.lifecycle"
+
+// templateLifecycle renders the manager container lifecycle hooks from .Values.manager.lifecycle,
+// after the other fields of the container. A lifecycle in the manifest is kept in place while the
+// value is unset.
+func templateLifecycle(yamlContent string) string {
+	if strings.Contains(yamlContent, lifecycleValuesPath) {
+		return yamlContent
+	}
+	rangeStart, rangeEnd := FindManagerContainerRange(yamlContent)
+	if rangeStart < 0 {
 		return yamlContent
 	}
 
+	lines := strings.Split(yamlContent, "\n")
+	_, itemIndent := LeadingWhitespace(lines[rangeStart])
+	fieldIndent := strings.Repeat(" ", itemIndent+2)
+	childIndentWidth := strconv.Itoa(itemIndent + 4)
+
+	insertAt, end := rangeEnd+1, rangeEnd+1
+	for i := rangeStart + 1; i <= rangeEnd; i++ {
+		indent, _ := LeadingWhitespace(lines[i])
+		if indent != fieldIndent || strings.HasPrefix(strings.TrimSpace(lines[i]), "{{") {
+			continue
+		}
+		if strings.TrimSpace(lines[i]) == "lifecycle:" {
+			insertAt = i
+			for end = i + 1; end <= rangeEnd; end++ {
+				if _, lineIndent := LeadingWhitespace(lines[end]); lineIndent <= itemIndent+2 {
+					break
+				}
+			}
+			break
+		}
+	}
+	for insertAt == end && insertAt > rangeStart+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+		end--
+	}
+
+	block := []string{
+		fieldIndent + "{{- with " + lifecycleValuesPath + " }}",
+		fieldIndent + "lifecycle: {{- toYaml . | nindent " + childIndentWidth + " }}",
+	}
+	if end > insertAt {
+		// The manifest lifecycle stays the default.
+		block = []string{
+			fieldIndent + "{{- if " + lifecycleValuesPath + " }}",
+			fieldIndent + "lifecycle: {{- toYaml " + lifecycleValuesPath + " | nindent " + childIndentWidth + " }}",
+			fieldIndent + "{{- else }}",
+		}
+		block = append(block, lines[insertAt:end]...)
+	}
+	block = append(block, fieldIndent+"{{- end }}")
+
+	return strings.Join(slices.Replace(lines, insertAt, end, block...), "\n")
+}
+
+// terminationMessagePolicyValuesPath holds the terminationMessagePolicy of the manager container.
+const terminationMessagePolicyValuesPath = ".Values.manager.terminationMessagePolicy"
+
+// templateTerminationMessagePolicy templates the manager container terminationMessagePolicy from
+// .Values.manager.terminationMessagePolicy, e.g. FallbackToLogsOnError to report the last log lines
+// of a crash. The policy in the manifest, or File, the Kubernetes default, is the default. A
+// container without the field gets it after its other fields.
+func templateTerminationMessagePolicy(yamlContent string) string {
+	if strings.Contains(yamlContent, terminationMessagePolicyValuesPath) {
+		return yamlContent
+	}
 	rangeStart, rangeEnd := FindManagerContainerRange(yamlContent)
+	if rangeStart < 0 {
+		return yamlContent
+	}
 
 	lines := strings.Split(yamlContent, "\n")
-	for i := range lines {
-		if rangeStart >= 0 && (i < rangeStart || i > rangeEnd) {
+	_, itemIndent := LeadingWhitespace(lines[rangeStart])
+	fieldIndent := strings.Repeat(" ", itemIndent+2)
+
+	policy := "File"
+	insertAt := rangeEnd + 1
+	for insertAt > rangeStart+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+	for i := rangeStart + 1; i <= rangeEnd; i++ {
+		value, found := strings.CutPrefix(lines[i], fieldIndent+"terminationMessagePolicy:")
+		if !found {
 			continue
 		}
+		policy = strings.Trim(strings.TrimSpace(value), `"'`)
+		lines = slices.Delete(lines, i, i+1)
+		insertAt = i
+		break
+	}
+
+	return strings.Join(slices.Insert(lines, insertAt, fmt.Sprintf("%sterminationMessagePolicy: {{ %s | default %q }}",
+		fieldIndent, terminationMessagePolicyValuesPath, policy)), "\n")
+}
+
+// scaffoldedYAMLLiteral returns the scaffolded fields as a quoted template string, dedented so that
+// fromYaml reads them as a mapping.
+func scaffoldedYAMLLiteral(fields []string) string {
+	if len(fields) == 0 {
+		return `""`
+	}
+	indent, _ := LeadingWhitespace(fields[0])
+	dedented := make([]string, 0, len(fields))
+	for _, line := range fields {
+		dedented = append(dedented, strings.TrimPrefix(line, indent))
+	}
+	return strconv.Quote(strings.Join(dedented, "\n"))
+}
+
+// scaffoldedFieldsOrEmpty returns the fields of a scaffolded mapping, or an empty mapping when
+// there are none.
+func scaffoldedFieldsOrEmpty(fields []string, indent string) []string {
+	if len(fields) == 0 {
+		return []string{indent + "{}"}
+	}
+	return fields
+}
+
+func templateContainerSecurityContext(yamlContent string) string {
+	if !isManagerContainerPresent(yamlContent) || !strings.Contains(yamlContent, "securityContext:") {
+		return yamlContent
+	}
+
+	rangeStart, rangeEnd := FindManagerContainerRange(yamlContent)
+	if rangeStart < 0 {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	// The container securityContext is a field of the manager container item, wherever it is in
+	// the field order; deeper or shallower securityContext keys belong to something else.
+	_, itemIndent := LeadingWhitespace(lines[rangeStart])
+	for i := rangeStart; i <= rangeEnd; i++ {
 		if strings.TrimSpace(lines[i]) != "securityContext:" {
 			continue
 		}
 
 		indentStr, indentLen := LeadingWhitespace(lines[i])
+		if indentLen != itemIndent+2 {
+			continue
+		}
 		end := i + 1
 		for ; end < len(lines); end++ {
 			trimmed := strings.TrimSpace(lines[end])
@@ -555,14 +939,6 @@ func templateContainerSecurityContext(yamlContent string) string {
 			}
 		}
 
-		if end >= len(lines) {
-			break
-		}
-
-		if strings.HasPrefix(strings.TrimSpace(lines[end]), "serviceAccountName:") {
-			continue
-		}
-
 		lookAheadEnd := min(end+5, len(lines))
 		joined := strings.Join(lines[i:lookAheadEnd], "\n")
 		if strings.Contains(joined, ".Values.manager.securityContext") {
@@ -572,14 +948,15 @@ func templateContainerSecurityContext(yamlContent string) string {
 		childIndent := indentStr + "  "
 		childIndentWidth := strconv.Itoa(len(childIndent))
 
+		// Like the pod securityContext, an unset value keeps the scaffolded hardening.
 		block := []string{
 			indentStr + "securityContext:",
 			childIndent + "{{- if .Values.manager.securityContext }}",
 			childIndent + "{{- toYaml .Values.manager.securityContext | nindent " + childIndentWidth + " }}",
 			childIndent + "{{- else }}",
-			childIndent + "{}",
-			childIndent + "{{- end }}",
 		}
+		block = append(block, scaffoldedFieldsOrEmpty(lines[i+1:end], childIndent)...)
+		block = append(block, childIndent+"{{- end }}")
 
 		newLines := append([]string{}, lines[:i]...)
 		newLines = append(newLines, block...)
@@ -597,19 +974,21 @@ func templateControllerManagerArgs(yamlContent string) string {
 
 	rangeStart, rangeEnd := FindManagerContainerRange(yamlContent)
 
-	argsPattern := regexp.MustCompile(`(?m)([ \t]+)args:\n((?:[ \t]+-.*\n)+)`)
-	loc := argsPattern.FindStringSubmatchIndex(yamlContent)
+	// Comment lines inside the args list are part of the block so they are carried through.
+	argsPattern := regexp.MustCompile(`(?m)([ \t]+)args:\n((?:[ \t]+[-#].*\n)+)`)
+	// Sidecars, such as kube-rbac-proxy, may list their args before the manager.
+	var loc []int
+	for _, candidate := range argsPattern.FindAllStringSubmatchIndex(yamlContent, -1) {
+		matchLine := strings.Count(yamlContent[:candidate[0]], "\n")
+		if rangeStart < 0 || (matchLine >= rangeStart && matchLine <= rangeEnd) {
+			loc = candidate
+			break
+		}
+	}
 	if loc == nil {
 		return yamlContent
 	}
 
-	if rangeStart >= 0 {
-		matchLine := strings.Count(yamlContent[:loc[0]], "\n")
-		if matchLine < rangeStart || matchLine > rangeEnd {
-			return yamlContent
-		}
-	}
-
 	match := yamlContent[loc[0]:loc[1]]
 	if strings.Contains(match, ".Values.manager.args") {
 		return yamlContent
@@ -621,11 +1000,18 @@ func templateControllerManagerArgs(yamlContent string) string {
 	itemIndent := indent + "  "
 	lines := strings.Split(itemsBlock, "\n")
 	var (
-		metricsLine    string
-		metricsIndent  string
-		healthLine     string
-		webhookLine    string
-		preservedLines []string
+		metricsLine     string
+		metricsIndent   string
+		healthLine      string
+		webhookLine     string
+		leaderElect     bool
+		preservedLines  []string
+		metricsComments []string
+		healthComments  []string
+		webhookComments []string
+		leaderComments  []string
+		valuesComments  []string
+		pendingComments []string
 	)
 
 	for _, rawLine := range lines {
@@ -634,6 +1020,11 @@ func templateControllerManagerArgs(yamlContent string) string {
 		if trimmed == "" {
 			continue
 		}
+		// Comments stay attached to the arg that follows them.
+		if strings.HasPrefix(trimmed, "#") {
+			pendingComments = append(pendingComments, line)
+			continue
+		}
 
 		if itemIndent == indent+"  " {
 			if idx := strings.Index(line, "-"); idx > 0 {
@@ -644,20 +1035,31 @@ func templateControllerManagerArgs(yamlContent string) string {
 		switch {
 		case strings.Contains(trimmed, "--metrics-bind-address"):
 			metricsLine = line
+			metricsComments = pendingComments
 			if idx := strings.Index(line, "-"); idx > 0 {
 				metricsIndent = line[:idx]
 			}
 		case strings.Contains(trimmed, "--health-probe-bind-address"):
 			healthLine = line
+			healthComments = pendingComments
 		case strings.Contains(trimmed, "--webhook-port"):
 			webhookLine = line
+			webhookComments = pendingComments
+		case isLeaderElectArg(trimmed):
+			leaderElect = true
+			leaderComments = pendingComments
 		case strings.Contains(trimmed, "--webhook-cert-path"),
 			strings.Contains(trimmed, "--metrics-cert-path"):
+			preservedLines = append(preservedLines, pendingComments...)
 			preservedLines = append(preservedLines, line)
 		default:
-			// Remaining args will be handled through values.yaml
+			// Remaining args will be handled through values.yaml; their comments are kept above the range.
+			valuesComments = append(valuesComments, pendingComments...)
 		}
+		pendingComments = nil
 	}
+	// Trailing comments with no arg after them stay at the end of the list.
+	preservedLines = append(preservedLines, pendingComments...)
 
 	var builder strings.Builder
 	builder.WriteString(indent)
@@ -669,6 +1071,7 @@ func templateControllerManagerArgs(yamlContent string) string {
 		}
 		builder.WriteString(metricsIndent)
 		builder.WriteString("{{- if .Values.metrics.enabled }}\n")
+		writeLines(&builder, metricsComments)
 		builder.WriteString(metricsLine)
 		builder.WriteString("\n")
 		builder.WriteString(metricsIndent)
@@ -687,18 +1090,33 @@ func templateControllerManagerArgs(yamlContent string) string {
 		builder.WriteString("{{- end }}\n")
 	}
 	if healthLine != "" {
+		writeLines(&builder, healthComments)
 		builder.WriteString(healthLine)
 		builder.WriteString("\n")
 	}
 	if webhookLine != "" {
 		builder.WriteString(itemIndent)
 		builder.WriteString("{{- if .Values.webhook.enabled }}\n")
+		writeLines(&builder, webhookComments)
 		builder.WriteString(webhookLine)
 		builder.WriteString("\n")
 		builder.WriteString(itemIndent)
 		builder.WriteString("{{- end }}\n")
 	}
 
+	if leaderElect {
+		builder.WriteString(itemIndent)
+		// Leader election stays on unless manager.leaderElection.enabled is set to false.
+		builder.WriteString("{{- if or (not (hasKey (.Values.manager.leaderElection | default dict) \"enabled\")) " +
+			".Values.manager.leaderElection.enabled }}\n")
+		writeLines(&builder, leaderComments)
+		builder.WriteString(itemIndent)
+		builder.WriteString("- --leader-elect\n")
+		builder.WriteString(itemIndent)
+		builder.WriteString("{{- end }}\n")
+	}
+
+	writeLines(&builder, valuesComments)
 	builder.WriteString(itemIndent)
 	builder.WriteString("{{- range .Values.manager.args }}\n")
 	builder.WriteString(itemIndent)
@@ -706,17 +1124,112 @@ func templateControllerManagerArgs(yamlContent string) string {
 	builder.WriteString(itemIndent)
 	builder.WriteString("{{- end }}\n")
 
-	for _, line := range preservedLines {
+	writeLines(&builder, preservedLines)
+
+	newBlock := strings.TrimRight(builder.String(), "\n") + "\n"
+
+	return yamlContent[:loc[0]] + newBlock + yamlContent[loc[1]:]
+}
+
+// insertManagerArgs adds an args block rendering .Values.manager.args after the other fields of a manager
+// container without args, for controllers configured entirely through flags from values. The block renders
+// nothing while manager.args is empty. A container that already sets args, e.g. "args: []", is returned
+// unchanged; templateControllerManagerArgs templates an args list from the manifest.
+func insertManagerArgs(yamlContent string) string {
+	rangeStart, rangeEnd := FindManagerContainerRange(yamlContent)
+	if rangeStart < 0 {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	_, itemIndent := LeadingWhitespace(lines[rangeStart])
+	fieldIndent := strings.Repeat(" ", itemIndent+2)
+	for i := rangeStart; i <= rangeEnd && i < len(lines); i++ {
+		field := strings.TrimSpace(lines[i])
+		if i == rangeStart {
+			field = strings.TrimSpace(strings.TrimPrefix(field, "-"))
+		} else if indent, _ := LeadingWhitespace(lines[i]); indent != fieldIndent {
+			continue
+		}
+		if field == "args:" || strings.HasPrefix(field, "args: ") {
+			return yamlContent
+		}
+	}
+
+	insertAt := rangeEnd + 1
+	for insertAt > rangeStart+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+	return strings.Join(slices.Insert(lines, insertAt,
+		fieldIndent+"{{- if .Values.manager.args }}",
+		fieldIndent+"args:",
+		fieldIndent+"{{- range .Values.manager.args }}",
+		fieldIndent+"- {{ . }}",
+		fieldIndent+"{{- end }}",
+		fieldIndent+"{{- end }}",
+	), "\n")
+}
+
+// TemplateManagerArgsAsYAML renders the manager.args values with toYaml instead of ranging over
+// them, so Helm quotes each arg as needed, e.g. one holding spaces, ": " or " #".
+func TemplateManagerArgsAsYAML(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	for i := 0; i+2 < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "{{- range .Values.manager.args }}" ||
+			strings.TrimSpace(lines[i+1]) != "- {{ . }}" || strings.TrimSpace(lines[i+2]) != "{{- end }}" {
+			continue
+		}
+		indent, indentLen := LeadingWhitespace(lines[i])
+		lines[i] = indent + "{{- with .Values.manager.args }}"
+		lines[i+1] = fmt.Sprintf("%s{{- toYaml . | nindent %d }}", indent, indentLen)
+		break
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isLeaderElectArg reports whether an args list item sets the --leader-elect flag.
+func isLeaderElectArg(item string) bool {
+	arg := strings.Trim(strings.TrimSpace(strings.TrimPrefix(item, "-")), `"'`)
+	return arg == "--leader-elect" || strings.HasPrefix(arg, "--leader-elect=")
+}
+
+// writeLines writes each line followed by a newline.
+func writeLines(builder *strings.Builder, lines []string) {
+	for _, line := range lines {
 		builder.WriteString(line)
 		builder.WriteString("\n")
 	}
+}
 
-	newBlock := strings.TrimRight(builder.String(), "\n") + "\n"
+const (
+	// ManagerImageValuesPath holds the manager image settings in the default nested values layout.
+	ManagerImageValuesPath = ".Values.manager.image"
+	// FlatImageValuesPath holds the manager image settings in the flat values layout, e.g.
+	// --set image.tag=v1.2.0.
+	FlatImageValuesPath = ".Values.image"
+)
 
-	return yamlContent[:loc[0]] + newBlock + yamlContent[loc[1]:]
+// TemplateManagerImage templates the image of the manager container in any pod template, such as
+// a Job or DaemonSet that runs the manager image. Resources without a manager container are
+// returned unchanged.
+func TemplateManagerImage(yamlContent string) string {
+	return TemplateManagerImageFrom(yamlContent, ManagerImageValuesPath)
+}
+
+// TemplateManagerImageFrom is TemplateManagerImage reading repository, tag, digest and pullPolicy
+// under valuesPath instead of manager.image.
+func TemplateManagerImageFrom(yamlContent, valuesPath string) string {
+	if start, _ := FindManagerContainerRange(yamlContent); start < 0 {
+		return yamlContent
+	}
+	return templateImageReferenceFrom(yamlContent, valuesPath)
 }
 
 func templateImageReference(yamlContent string) string {
+	return templateImageReferenceFrom(yamlContent, ManagerImageValuesPath)
+}
+
+func templateImageReferenceFrom(yamlContent, valuesPath string) string {
 	if !isManagerContainerPresent(yamlContent) {
 		return yamlContent
 	}
@@ -733,7 +1246,8 @@ func templateImageReference(yamlContent string) string {
 			continue
 		}
 
-		if strings.Contains(lines[i], ".Values.manager.image.repository") {
+		// Either values layout counts as templated, so the image is only templated once.
+		if strings.Contains(lines[i], ".image.repository") {
 			return yamlContent
 		}
 
@@ -768,12 +1282,17 @@ func templateImageReference(yamlContent string) string {
 		lines = append(lines[:i+1], append(filtered, lines[end:]...)...)
 		end = i + 1 + len(filtered)
 
-		imageLine := indentStr + "image: \"{{ .Values.manager.image.repository | default \"controller\" }}" +
-			"{{- if not (contains \"@\" (.Values.manager.image.repository | default \"controller\")) }}" +
-			":{{ .Values.manager.image.tag | default .Chart.AppVersion }}{{- end }}\""
-		pullPolicyLineStart := indentStr + "{{- with .Values.manager.image.pullPolicy }}"
-		pullPolicyLine := indentStr + "imagePullPolicy: {{ . }}"
-		pullPolicyLineEnd := indentStr + "{{- end }}"
+		// global.imageRegistry prefixes the repository for mirrored registries (nil-safe when global is unset).
+		// A digest pins the image by content and takes precedence over the tag.
+		imageLine := indentStr + "image: \"" +
+			"{{ with (.Values.global | default dict).imageRegistry }}{{ . }}/{{ end }}" +
+			"{{ " + valuesPath + ".repository | default \"controller\" }}" +
+			"{{- if " + valuesPath + ".digest }}@{{ " + valuesPath + ".digest }}" +
+			"{{- else if not (contains \"@\" (" + valuesPath + ".repository | default \"controller\")) }}" +
+			":{{ " + valuesPath + ".tag | default .Chart.AppVersion }}{{- end }}\""
+		// An unset pullPolicy renders IfNotPresent rather than leaving the policy to the tag-dependent
+		// Kubernetes default.
+		pullPolicyLine := indentStr + "imagePullPolicy: {{ " + valuesPath + ".pullPolicy | default \"IfNotPresent\" }}"
 
 		remainder := lines[end:]
 		if len(remainder) > 0 && strings.HasPrefix(strings.TrimSpace(remainder[0]), "imagePullPolicy:") {
@@ -781,7 +1300,7 @@ func templateImageReference(yamlContent string) string {
 		}
 
 		newLines := append([]string{}, lines[:i]...)
-		newLines = append(newLines, imageLine, pullPolicyLineStart, pullPolicyLine, pullPolicyLineEnd)
+		newLines = append(newLines, imageLine, pullPolicyLine)
 		newLines = append(newLines, remainder...)
 		return strings.Join(newLines, "\n")
 	}
@@ -789,6 +1308,38 @@ func templateImageReference(yamlContent string) string {
 	return yamlContent
 }
 
+// UnquoteImageReference removes the double quotes around the image references templated from the
+// manager, sidecar and extra Deployment image values. A rendered reference holds no characters that
+// need quoting in YAML.
+func UnquoteImageReference(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	for i, line := range lines {
+		indent, _ := LeadingWhitespace(line)
+		value, found := strings.CutPrefix(strings.TrimSpace(line), "image: \"")
+		if !found || !strings.HasSuffix(value, "\"") ||
+			(!strings.Contains(value, ".image.repository") && !strings.Contains(value, `dig "image" "repository"`)) {
+			continue
+		}
+		lines[i] = indent + "image: " + strings.TrimSuffix(value, "\"")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RequireImageRepository renders the manager image references, in either values layout, with Helm's
+// required function instead of the "controller" default, so an install without image.repository fails
+// with a message naming the value instead of pulling a placeholder image.
+func RequireImageRepository(yamlContent string) string {
+	for _, valuesPath := range []string{ManagerImageValuesPath, FlatImageValuesPath} {
+		repository := valuesPath + ".repository"
+		defaulted := repository + ` | default "controller"`
+		message := strings.TrimPrefix(repository, ".Values.") + " is required"
+		yamlContent = strings.ReplaceAll(yamlContent, "{{ "+defaulted+" }}",
+			"{{ required "+strconv.Quote(message)+" "+repository+" }}")
+		yamlContent = strings.ReplaceAll(yamlContent, "("+defaulted+")", repository)
+	}
+	return yamlContent
+}
+
 func templateBasicWithStatement(
 	yamlContent string,
 	key string,
@@ -879,13 +1430,28 @@ func templateBasicWithStatement(
 	return strings.Join(newLines, "\n")
 }
 
-func templatePriorityClassName(yamlContent string) string {
+func templatePriorityClassName(chartName, yamlContent string) string {
 	if strings.Contains(yamlContent, ".Values.manager.priorityClassName") {
 		return yamlContent
 	}
 
 	lines := strings.Split(yamlContent, "\n")
 
+	// A class named after the project, templated with the resourceName helper by then, is the
+	// PriorityClass the chart ships: the pods use it while the chart creates it.
+	ownClass := regexp.MustCompile(`(?m)^(\s*)priorityClassName: (\{\{ include "` +
+		regexp.QuoteMeta(chartName) + `\.resourceName" .*\}\})\s*$`)
+	if ownClass.MatchString(yamlContent) {
+		return ownClass.ReplaceAllString(yamlContent,
+			"${1}{{- if (.Values.priorityClass | default dict).create }}\n"+
+				"${1}priorityClassName: ${2}\n"+
+				"${1}{{- else }}\n"+
+				"${1}{{- with .Values.manager.priorityClassName }}\n"+
+				"${1}priorityClassName: {{ . | quote }}\n"+
+				"${1}{{- end }}\n"+
+				"${1}{{- end }}")
+	}
+
 	if strings.Contains(yamlContent, "priorityClassName:") {
 		pattern := regexp.MustCompile(`(?m)^(\s*)priorityClassName:\s*"?([^"\n]*)"?\s*$`)
 		yamlContent = pattern.ReplaceAllString(yamlContent,
@@ -928,6 +1494,78 @@ func templatePriorityClassName(yamlContent string) string {
 	return strings.Join(newLines, "\n")
 }
 
+// templateHostNetwork injects hostNetwork and dnsPolicy into the pod spec, guarded so that neither
+// field is rendered unless .Values.manager.hostNetwork is enabled. Pods on the host network need
+// ClusterFirstWithHostNet to keep resolving cluster DNS names, so that is the dnsPolicy default.
+func templateHostNetwork(yamlContent string) string {
+	if strings.Contains(yamlContent, ".Values.manager.hostNetwork") {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+
+	var insertAt int
+	foundTemplate := false
+	for i := range lines {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == common.YamlKeyTemplate {
+			foundTemplate = true
+			continue
+		}
+		if foundTemplate && trimmed == common.YamlKeySpec {
+			insertAt = i + 1
+			break
+		}
+	}
+
+	if insertAt == 0 || insertAt >= len(lines) {
+		return yamlContent
+	}
+
+	_, indentLen := LeadingWhitespace(lines[insertAt])
+	indentStr := strings.Repeat(" ", indentLen)
+
+	// A hardcoded hostNetwork (and the dnsPolicy that goes with it) moves to values.yaml.
+	// A dnsPolicy set without hostNetwork is left alone.
+	podSpecEnd := len(lines)
+	hasHostNetwork := false
+	for i := insertAt; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		_, lineIndent := LeadingWhitespace(lines[i])
+		if trimmed != "" && lineIndent < indentLen {
+			podSpecEnd = i
+			break
+		}
+		if lineIndent == indentLen && strings.HasPrefix(trimmed, "hostNetwork:") {
+			hasHostNetwork = true
+		}
+	}
+
+	filtered := append([]string{}, lines[:insertAt]...)
+	for i := insertAt; i < podSpecEnd; i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		_, lineIndent := LeadingWhitespace(lines[i])
+		if hasHostNetwork && lineIndent == indentLen &&
+			(strings.HasPrefix(trimmed, "hostNetwork:") || strings.HasPrefix(trimmed, "dnsPolicy:")) {
+			continue
+		}
+		filtered = append(filtered, lines[i])
+	}
+	filtered = append(filtered, lines[podSpecEnd:]...)
+
+	block := []string{
+		indentStr + "{{- if .Values.manager.hostNetwork }}",
+		indentStr + "hostNetwork: {{ .Values.manager.hostNetwork }}",
+		indentStr + "dnsPolicy: {{ .Values.manager.dnsPolicy | default \"ClusterFirstWithHostNet\" }}",
+		indentStr + "{{- end }}",
+	}
+
+	newLines := append([]string{}, filtered[:insertAt]...)
+	newLines = append(newLines, block...)
+	newLines = append(newLines, filtered[insertAt:]...)
+	return strings.Join(newLines, "\n")
+}
+
 // templateTerminationGracePeriodSeconds injects terminationGracePeriodSeconds; uses hasKey to allow 0 values.
 func templateTerminationGracePeriodSeconds(yamlContent string) string {
 	if strings.Contains(yamlContent, ".Values.manager.terminationGracePeriodSeconds") {
@@ -975,6 +1613,60 @@ func templateTerminationGracePeriodSeconds(yamlContent string) string {
 	return strings.Join(newLines, "\n")
 }
 
+// templateAutomountServiceAccountToken sets automountServiceAccountToken on the pod spec when
+// .Values.manager.automountServiceAccountToken is set, true or false; uses hasKey so false is rendered.
+// A value from the kustomize output moves to values.yaml.
+func templateAutomountServiceAccountToken(yamlContent string) string {
+	if strings.Contains(yamlContent, ".Values.manager.automountServiceAccountToken") {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+
+	var insertAt int
+	foundTemplate := false
+	for i := range lines {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == common.YamlKeyTemplate {
+			foundTemplate = true
+			continue
+		}
+		if foundTemplate && trimmed == common.YamlKeySpec {
+			insertAt = i + 1
+			break
+		}
+	}
+
+	if insertAt == 0 || insertAt >= len(lines) {
+		return yamlContent
+	}
+
+	_, indentLen := LeadingWhitespace(lines[insertAt])
+	indentStr := strings.Repeat(" ", indentLen)
+
+	filtered := append([]string{}, lines[:insertAt]...)
+	for i := insertAt; i < len(lines); i++ {
+		_, lineIndent := LeadingWhitespace(lines[i])
+		if lineIndent == indentLen && strings.HasPrefix(strings.TrimSpace(lines[i]), "automountServiceAccountToken:") {
+			filtered = append(filtered, lines[i+1:]...)
+			break
+		}
+		filtered = append(filtered, lines[i])
+	}
+
+	block := []string{
+		indentStr + "{{- if and (hasKey .Values.manager \"automountServiceAccountToken\") " +
+			"(ne .Values.manager.automountServiceAccountToken nil) }}",
+		indentStr + "automountServiceAccountToken: {{ .Values.manager.automountServiceAccountToken }}",
+		indentStr + "{{- end }}",
+	}
+
+	newLines := append([]string{}, filtered[:insertAt]...)
+	newLines = append(newLines, block...)
+	newLines = append(newLines, filtered[insertAt:]...)
+	return strings.Join(newLines, "\n")
+}
+
 func handleDeploymentAnnotations(
 	state *customFieldsState, result []string, line, trimmed, indent string, indentLen int,
 ) []string {
@@ -1033,7 +1725,8 @@ func handlePodAnnotations(
 		parentIndent := strings.Repeat(" ", state.currentBlockIndent)
 		childIndent := detectChildIndent(result, parentIndent)
 
-		if len(existingKeys) == 0 {
+		// A block holding only template directives keeps its header.
+		if len(existingKeys) == 0 && strings.TrimSpace(result[len(result)-1]) == common.YamlKeyAnnotations {
 			result = result[:len(result)-1]
 			childIndentWidth := strconv.Itoa(len(childIndent))
 			result = append(result,
@@ -1292,12 +1985,13 @@ func detectChildIndent(lines []string, parentIndent string) string {
 }
 
 // MakeContainerArgsConditional makes webhook-cert-path and metrics-cert-path args conditional.
+// Args that are already wrapped are left untouched.
 func MakeContainerArgsConditional(yamlContent string) string {
-	// Make webhook-cert-path arg conditional on certManager.enabled
+	// Make webhook-cert-path arg conditional on a certificate being provided
 	if strings.Contains(yamlContent, "--webhook-cert-path") {
 		// Match only spaces/tabs for indent to avoid consuming the newline
 		webhookArgPattern := regexp.MustCompile(`([ \t]+)-\s*--webhook-cert-path=[^\n]*`)
-		yamlContent = webhookArgPattern.ReplaceAllStringFunc(yamlContent, func(match string) string {
+		yamlContent = wrapUnwrappedMatches(webhookArgPattern, yamlContent, func(match string) string {
 			indentMatch := regexp.MustCompile(`^(\s+)`).FindStringSubmatch(match)
 			indent := ""
 			if len(indentMatch) > 1 {
@@ -1305,8 +1999,8 @@ func MakeContainerArgsConditional(yamlContent string) string {
 			}
 
 			argLine := strings.TrimSpace(match)
-			return fmt.Sprintf("%s{{- if .Values.certManager.enabled }}\n%s%s\n%s{{- end }}",
-				indent, indent, argLine, indent)
+			return fmt.Sprintf("%s%s\n%s%s\n%s{{- end }}",
+				indent, webhookCertsCondition, indent, argLine, indent)
 		})
 	}
 
@@ -1314,7 +2008,7 @@ func MakeContainerArgsConditional(yamlContent string) string {
 	if strings.Contains(yamlContent, "--metrics-cert-path") {
 		// Match only spaces/tabs for indent to avoid consuming the newline
 		metricsArgPattern := regexp.MustCompile(`([ \t]+)-\s*--metrics-cert-path=[^\n]*`)
-		yamlContent = metricsArgPattern.ReplaceAllStringFunc(yamlContent, func(match string) string {
+		yamlContent = wrapUnwrappedMatches(metricsArgPattern, yamlContent, func(match string) string {
 			indentMatch := regexp.MustCompile(`^(\s+)`).FindStringSubmatch(match)
 			indent := ""
 			if len(indentMatch) > 1 {
@@ -1331,42 +2025,99 @@ func MakeContainerArgsConditional(yamlContent string) string {
 	return yamlContent
 }
 
-// MakeWebhookVolumesConditional makes webhook volumes conditional on certManager.enabled.
+// MakeWebhookVolumesConditional makes the webhook-certs volume conditional on webhookCertsCondition.
+// The volume is matched by name so a renamed Secret is still wrapped, and its secretName is
+// templated from .Values.webhook.certSecretName.
 func MakeWebhookVolumesConditional(yamlContent string) string {
-	// Make webhook volumes conditional on certManager.enabled
-	if strings.Contains(yamlContent, "webhook-certs") && strings.Contains(yamlContent, "secretName: webhook-server-cert") {
-		// Match only spaces/tabs for indent to avoid consuming the newline
-		volumePattern := regexp.MustCompile(`([ \t]+)-\s*name:\s*webhook-certs[\s\S]*?secretName:\s*webhook-server-cert`)
-		yamlContent = volumePattern.ReplaceAllStringFunc(yamlContent, MakeYamlContent)
+	if strings.Contains(yamlContent, "webhook-certs") {
+		yamlContent = templateCertVolume(yamlContent, "webhook-certs", ".Values.webhook.certSecretName",
+			wrapWebhookCerts)
 	}
-
 	return yamlContent
 }
 
-// MakeWebhookVolumeMountsConditional makes webhook volumeMounts conditional on certManager.enabled.
+// MakeWebhookVolumeMountsConditional makes webhook volumeMounts conditional on webhookCertsCondition.
 func MakeWebhookVolumeMountsConditional(yamlContent string) string {
-	// Make webhook volumeMounts conditional on certManager.enabled
 	webhookCertsPath := "/tmp/k8s-webhook-server/serving-certs"
 	if strings.Contains(yamlContent, "webhook-certs") && strings.Contains(yamlContent, webhookCertsPath) {
 		// Match only spaces/tabs for indent to avoid consuming the newline
 		mountPattern := regexp.MustCompile(
 			`([ \t]+)-\s*mountPath:\s*/tmp/k8s-webhook-server/serving-certs[\s\S]*?readOnly:\s*true`)
-		yamlContent = mountPattern.ReplaceAllStringFunc(yamlContent, MakeYamlContent)
+		yamlContent = wrapUnwrappedMatches(mountPattern, yamlContent, wrapWebhookCerts)
 	}
 
 	return yamlContent
 }
 
-// MakeMetricsVolumesConditional wraps metrics volumes with the metrics TLS conditional.
+// MakeMetricsVolumesConditional wraps the metrics-certs volume with the metrics TLS conditional.
+// The volume is matched by name so a renamed Secret is still wrapped, and its secretName is
+// templated from .Values.metrics.certSecretName.
 func MakeMetricsVolumesConditional(yamlContent string) string {
-	if strings.Contains(yamlContent, "metrics-certs") && strings.Contains(yamlContent, "secretName: metrics-server-cert") {
-		// [ \t]+ matches only spaces/tabs so the newline is not consumed by the regexp.
-		pattern := regexp.MustCompile(`([ \t]+)-\s*name:\s*metrics-certs[\s\S]*?secretName:\s*metrics-server-cert`)
-		yamlContent = wrapWithMetricsTLSConditional(pattern, yamlContent)
+	if strings.Contains(yamlContent, "metrics-certs") {
+		yamlContent = templateCertVolume(yamlContent, "metrics-certs", ".Values.metrics.certSecretName",
+			func(block string) string {
+				return wrapBlock(block, metricsTLSCondition)
+			})
 	}
 	return yamlContent
 }
 
+// templateCertVolume wraps the Secret-backed pod volume named volumeName with wrap and templates
+// its secretName from valuesKey, defaulting to the Secret name found in the manifest.
+func templateCertVolume(yamlContent, volumeName, valuesKey string, wrap func(string) string) string {
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "- name: "+volumeName {
+			result = append(result, lines[i])
+			continue
+		}
+
+		_, dashIndent := LeadingWhitespace(lines[i])
+		end := i + 1
+		for ; end < len(lines); end++ {
+			// A wrapper directive opens the next, already wrapped, volume.
+			trimmed := strings.TrimSpace(lines[end])
+			if trimmed == "" || strings.HasPrefix(trimmed, "{{-") {
+				break
+			}
+			if _, indent := LeadingWhitespace(lines[end]); indent <= dashIndent {
+				break
+			}
+		}
+
+		block := append([]string{}, lines[i:end]...)
+		isSecretVolume := false
+		for j, line := range block {
+			trimmed := strings.TrimSpace(line)
+			if !strings.HasPrefix(trimmed, "secretName:") {
+				continue
+			}
+			isSecretVolume = true
+			if strings.Contains(trimmed, "{{") {
+				continue
+			}
+			secretName := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "secretName:")), `"'`)
+			indent, _ := LeadingWhitespace(line)
+			block[j] = fmt.Sprintf("%ssecretName: {{ %s | default %q }}", indent, valuesKey, secretName)
+		}
+
+		switch {
+		case !isSecretVolume:
+			result = append(result, lines[i])
+			continue
+		case i > 0 && strings.HasPrefix(strings.TrimSpace(lines[i-1]), "{{- if "):
+			result = append(result, block...)
+		default:
+			result = append(result, strings.Split(wrap(strings.Join(block, "\n")), "\n")...)
+		}
+		i = end - 1
+	}
+
+	return strings.Join(result, "\n")
+}
+
 // MakeMetricsVolumeMountsConditional wraps metrics volumeMounts with the metrics TLS conditional.
 func MakeMetricsVolumeMountsConditional(yamlContent string) string {
 	metricsCertsPath := "/tmp/k8s-metrics-server/metrics-certs"
@@ -1379,9 +2130,20 @@ func MakeMetricsVolumeMountsConditional(yamlContent string) string {
 	return yamlContent
 }
 
+// webhookCertsCondition guards the webhook serving certificate mount, needed when cert-manager issues
+// the certificate or when the user brings their own along with webhook.caBundle.
+const webhookCertsCondition = "{{- if or .Values.certManager.enabled (.Values.webhook | default dict).caBundle }}"
+
+// wrapWebhookCerts wraps a YAML block with webhookCertsCondition.
+func wrapWebhookCerts(match string) string {
+	return wrapBlock(match, webhookCertsCondition)
+}
+
+// metricsTLSCondition guards resources only needed when metrics are served over cert-manager TLS.
+const metricsTLSCondition = "{{- if and .Values.certManager.enabled .Values.metrics.enabled .Values.metrics.secure }}"
+
 func wrapWithMetricsTLSConditional(pattern *regexp.Regexp, yamlContent string) string {
-	const metricsCondition = "{{- if and .Values.certManager.enabled .Values.metrics.enabled .Values.metrics.secure }}"
-	return pattern.ReplaceAllStringFunc(yamlContent, func(match string) string {
-		return wrapBlock(match, metricsCondition)
+	return wrapUnwrappedMatches(pattern, yamlContent, func(match string) string {
+		return wrapBlock(match, metricsTLSCondition)
 	})
 }
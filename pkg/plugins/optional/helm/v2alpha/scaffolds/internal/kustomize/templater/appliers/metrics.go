@@ -18,11 +18,18 @@ package appliers
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
+
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
 )
 
 // TemplateServiceMonitor applies all ServiceMonitor-specific transformations.
 func TemplateServiceMonitor(yamlContent string) string {
+	if strings.Contains(yamlContent, ".Values.metrics.secure") {
+		return yamlContent
+	}
+
 	yamlContent = regexp.MustCompile(`(\s*)port:\s*https`).
 		ReplaceAllString(yamlContent, `${1}port: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}`)
 
@@ -39,6 +46,160 @@ func TemplateServiceMonitor(yamlContent string) string {
 	return yamlContent
 }
 
+// Values paths of the settings added to every ServiceMonitor endpoint.
+const (
+	serviceMonitorAuthorization     = ".Values.prometheus.authorization"
+	serviceMonitorMetricRelabelings = ".Values.prometheus.metricRelabelings"
+	serviceMonitorRelabelings       = ".Values.prometheus.relabelings"
+)
+
+// TemplateServiceMonitorEndpoints adds the per-endpoint settings from values to every ServiceMonitor
+// endpoint: prometheus.authorization, e.g. credentials for a kube-rbac-proxy in front of the metrics
+// endpoint, and the prometheus.metricRelabelings and prometheus.relabelings rules. They are appended
+// after the fields from the kustomize output, tlsConfig included; relabeling rules already in the
+// kustomize output are kept and the rules from values follow them.
+func TemplateServiceMonitorEndpoints(yamlContent string) string {
+	yamlContent = appendToServiceMonitorEndpoints(yamlContent, "authorization:", serviceMonitorAuthorization)
+	yamlContent = appendToServiceMonitorEndpoints(yamlContent, "metricRelabelings:", serviceMonitorMetricRelabelings)
+	yamlContent = appendToServiceMonitorEndpoints(yamlContent, "relabelings:", serviceMonitorRelabelings)
+	return yamlContent
+}
+
+// appendToServiceMonitorEndpoints appends a block rendering valuesPath as key, when set, to the end of
+// every item of the spec.endpoints list. Content already rendering valuesPath is returned unchanged.
+func appendToServiceMonitorEndpoints(yamlContent, key, valuesPath string) string {
+	if strings.Contains(yamlContent, "{{- with "+valuesPath+" }}") {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines)+4)
+	endpointsIndent, itemIndent := -1, -1
+	// item holds the lines of the current endpoint; pending holds the blank lines seen inside it, so
+	// the block goes before them.
+	var item, pending []string
+	closeItem := func() {
+		result = append(result, appendToEndpoint(item, itemIndent, key, valuesPath)...)
+		item = nil
+		itemIndent = -1
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		_, indent := LeadingWhitespace(line)
+		if itemIndent >= 0 && trimmed == "" {
+			pending = append(pending, line)
+			continue
+		}
+		if itemIndent >= 0 && indent <= itemIndent {
+			closeItem()
+			if indent < endpointsIndent || indent == endpointsIndent && !strings.HasPrefix(trimmed, "- ") {
+				endpointsIndent = -1
+			}
+		}
+		if itemIndent >= 0 {
+			item = append(item, pending...)
+		} else {
+			result = append(result, pending...)
+		}
+		pending = nil
+
+		switch {
+		case trimmed == "endpoints:" && isChildOf(lines, i, common.YamlKeySpec):
+			endpointsIndent = indent
+		case endpointsIndent >= 0 && itemIndent < 0 && strings.HasPrefix(trimmed, "- ") && indent >= endpointsIndent:
+			itemIndent = indent
+		}
+		if itemIndent >= 0 {
+			item = append(item, line)
+		} else {
+			result = append(result, line)
+		}
+	}
+	if itemIndent >= 0 {
+		closeItem()
+	}
+	result = append(result, pending...)
+	return strings.Join(result, "\n")
+}
+
+// appendToEndpoint adds the block rendering valuesPath as key to the lines of one endpoint list item.
+// When the endpoint already sets key to a list, the items from values are rendered after the existing
+// ones; when it sets key to anything else, the endpoint is returned unchanged.
+func appendToEndpoint(item []string, itemIndent int, key, valuesPath string) []string {
+	fieldIndent := itemIndent + 2
+	for i, line := range item {
+		_, indent := LeadingWhitespace(line)
+		trimmed := strings.TrimSpace(line)
+		if i == 0 {
+			trimmed = strings.TrimPrefix(trimmed, "- ")
+			indent = fieldIndent
+		}
+		if indent != fieldIndent || trimmed != key {
+			continue
+		}
+
+		end := i + 1
+		for end < len(item) {
+			_, nextIndent := LeadingWhitespace(item[end])
+			nextTrimmed := strings.TrimSpace(item[end])
+			if nextTrimmed != "" && nextIndent < fieldIndent ||
+				nextIndent == fieldIndent && !strings.HasPrefix(nextTrimmed, "- ") {
+				break
+			}
+			end++
+		}
+		if end == i+1 || !strings.HasPrefix(strings.TrimSpace(item[i+1]), "- ") {
+			return item
+		}
+
+		indentStr := strings.Repeat(" ", fieldIndent)
+		extended := make([]string, 0, len(item)+3)
+		extended = append(extended, item[:end]...)
+		extended = append(extended,
+			indentStr+"{{- with "+valuesPath+" }}",
+			indentStr+"{{- toYaml . | nindent "+strconv.Itoa(fieldIndent)+" }}",
+			indentStr+"{{- end }}")
+		return append(extended, item[end:]...)
+	}
+	return append(item, buildGuardedMetadataMapBlock(fieldIndent, key, valuesPath)...)
+}
+
+// TemplateServiceMonitorNamespaceSelector scopes the ServiceMonitor to the release namespace with a
+// spec.namespaceSelector, so a Prometheus operator watching the whole cluster still only scrapes the
+// metrics Service of this release. The selector goes before spec.selector, or at the end of spec when
+// there is none; a namespaceSelector already in the kustomize output is kept as it is.
+func TemplateServiceMonitorNamespaceSelector(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	specStart, specEnd := findNestedBlock(lines, common.YamlKeySpec)
+	if specStart < 0 {
+		return yamlContent
+	}
+	if start, _ := findNestedBlock(lines, common.YamlKeySpec, "namespaceSelector:"); start >= 0 {
+		return yamlContent
+	}
+
+	_, specIndent := LeadingWhitespace(lines[specStart])
+	fieldIndent := specIndent + 2
+	insertAt := specEnd
+	for i := specStart + 1; i < specEnd; i++ {
+		_, indent := LeadingWhitespace(lines[i])
+		if indent == fieldIndent && strings.TrimSpace(lines[i]) == "selector:" {
+			insertAt = i
+			break
+		}
+	}
+
+	indentStr := strings.Repeat(" ", fieldIndent)
+	result := make([]string, 0, len(lines)+3)
+	result = append(result, lines[:insertAt]...)
+	result = append(result,
+		indentStr+"namespaceSelector:",
+		indentStr+"  matchNames:",
+		indentStr+"  - {{ .Release.Namespace }}")
+	result = append(result, lines[insertAt:]...)
+	return strings.Join(result, "\n")
+}
+
 // MakeServiceMonitorTLSConditional wraps ServiceMonitor tlsConfig fields with appropriate conditionals.
 // Adds metrics.secure wrapper and cert-manager conditionals around cert fields when found.
 func MakeServiceMonitorTLSConditional(yamlContent string) string {
@@ -111,12 +272,15 @@ func wrapTLSConfigBlock(tlsBlock []string, baseIndent int) []string {
 }
 
 // MakeServiceMonitorBearerTokenConditional makes bearer token conditional on metrics.secure.
+// Prometheus rejects an endpoint setting both a bearer token file and authorization, so the bearer
+// token is also left out when prometheus.authorization is set.
 func MakeServiceMonitorBearerTokenConditional(yamlContent string) string {
 	// Keep the dash outside the conditional - the list item always exists (with path/port/scheme)
 	// Only the bearerTokenFile field itself is conditional on secure mode
 	listItemPattern := regexp.MustCompile(`(?m)^(\s*)-\s+bearerTokenFile:\s*([^\n]+)`)
 	yamlContent = listItemPattern.ReplaceAllString(yamlContent,
-		`$1- {{- if .Values.metrics.secure }}`+"\n"+`$1  bearerTokenFile: $2`+"\n"+`$1  {{- end }}`)
+		`$1- {{- if and .Values.metrics.secure (not `+serviceMonitorAuthorization+`) }}`+"\n"+
+			`$1  bearerTokenFile: $2`+"\n"+`$1  {{- end }}`)
 
 	return yamlContent
 }
@@ -246,9 +410,13 @@ func wrapCertManagerFields(certFields, nonCertLines []string, baseIndent int) []
 		}
 	}
 
-	// Wrap cert fields with cert-manager conditional and template insecureSkipVerify
+	// Wrap cert fields with cert-manager conditional and template insecureSkipVerify.
+	// The Secret follows metrics.certSecretName like the Certificate and the manager volume.
 	result = append(result, indentStr+"{{- if .Values.certManager.enabled }}")
-	result = append(result, certFields...)
+	for _, line := range certFields {
+		result = append(result, strings.Replace(line, "name: metrics-server-cert",
+			`name: {{ .Values.metrics.certSecretName | default "metrics-server-cert" }}`, 1))
+	}
 	result = append(result, indentStr+"insecureSkipVerify: false")
 	result = append(result, indentStr+"{{- else }}")
 	result = append(result, indentStr+"insecureSkipVerify: true")
@@ -269,3 +437,85 @@ func convertInsecureSkipVerify(lines []string) []string {
 	}
 	return result
 }
+
+// kubeRbacProxyValuesPath holds the settings of the kube-rbac-proxy sidecar.
+const kubeRbacProxyValuesPath = ".Values.kubeRbacProxy"
+
+// kubeRbacProxyUpstreamPattern matches the kube-rbac-proxy --upstream arg pointing at the manager
+// metrics server in the same pod.
+var kubeRbacProxyUpstreamPattern = regexp.MustCompile(`(--upstream=https?://(?:127\.0\.0\.1|localhost)):([0-9]+)`)
+
+// TemplateKubeRbacProxy templates the kube-rbac-proxy sidecar that older scaffolds run in front of the
+// metrics endpoint: the image, pull policy and resources come from kubeRbacProxy in values, and the
+// container is only rendered with metrics.enabled, like the metrics endpoint it protects. Pods without
+// the sidecar are returned unchanged.
+func TemplateKubeRbacProxy(yamlContent string) string {
+	start, end := FindContainerRange(yamlContent, common.KubeRbacProxyContainerName)
+	if start < 0 || strings.Contains(yamlContent, kubeRbacProxyValuesPath) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	for end > start && strings.TrimSpace(lines[end]) == "" {
+		end--
+	}
+	itemIndent, _ := LeadingWhitespace(lines[start])
+	fieldIndent := itemIndent + "  "
+	imagePath := kubeRbacProxyValuesPath + ".image"
+
+	container := []string{itemIndent + "{{- if .Values.metrics.enabled }}"}
+	for i := start; i <= end; i++ {
+		line := lines[i]
+		field := strings.TrimPrefix(strings.TrimSpace(line), "- ")
+		prefix := line[:len(line)-len(field)]
+		if _, indent := LeadingWhitespace(line); i != start && indent != len(fieldIndent) {
+			container = append(container, line)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(field, "image:"):
+			// global.imageRegistry prefixes the repository, as for the manager image.
+			container = append(container,
+				prefix+"image: \"{{ with (.Values.global | default dict).imageRegistry }}{{ . }}/{{ end }}"+
+					"{{ "+imagePath+".repository }}"+
+					"{{- if "+imagePath+".digest }}@{{ "+imagePath+".digest }}"+
+					"{{- else }}:{{ "+imagePath+".tag }}{{- end }}\"",
+				fieldIndent+"{{- with "+imagePath+".pullPolicy }}",
+				fieldIndent+"imagePullPolicy: {{ . }}",
+				fieldIndent+"{{- end }}")
+		case strings.HasPrefix(field, "imagePullPolicy:"):
+			// Rendered from values next to the image.
+		case i != start && strings.HasPrefix(field, "resources:"):
+			for i+1 <= end {
+				if _, indent := LeadingWhitespace(lines[i+1]); indent <= len(fieldIndent) &&
+					strings.TrimSpace(lines[i+1]) != "" {
+					break
+				}
+				i++
+			}
+			container = append(container,
+				fieldIndent+"{{- with "+kubeRbacProxyValuesPath+".resources }}",
+				fieldIndent+"resources:",
+				fieldIndent+"  {{- toYaml . | nindent "+strconv.Itoa(len(fieldIndent)+2)+" }}",
+				fieldIndent+"{{- end }}")
+		default:
+			container = append(container, line)
+		}
+	}
+	container = append(container, itemIndent+"{{- end }}")
+	// The proxy forwards to the manager metrics server, whose port TemplatePorts moves to metrics.port.
+	if match := metricsBindPortPattern.FindStringSubmatch(yamlContent); match != nil {
+		for i, line := range container {
+			upstream := kubeRbacProxyUpstreamPattern.FindStringSubmatch(line)
+			if upstream != nil && upstream[2] == match[1] {
+				container[i] = kubeRbacProxyUpstreamPattern.ReplaceAllString(line, "${1}:{{ .Values.metrics.port }}")
+			}
+		}
+	}
+
+	result := append([]string{}, lines[:start]...)
+	result = append(result, container...)
+	result = append(result, lines[end+1:]...)
+	return strings.Join(result, "\n")
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TemplateServiceMonitorEndpoints", func() {
+	It("should append the settings from values to every endpoint", func() {
+		input := `spec:
+  endpoints:
+  - path: /metrics
+    port: https
+  - path: /debug
+    port: debug
+  selector:
+    matchLabels:
+      control-plane: controller-manager`
+
+		Expect(TemplateServiceMonitorEndpoints(input)).To(Equal(`spec:
+  endpoints:
+  - path: /metrics
+    port: https
+    {{- with .Values.prometheus.authorization }}
+    authorization:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with .Values.prometheus.metricRelabelings }}
+    metricRelabelings:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with .Values.prometheus.relabelings }}
+    relabelings:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+  - path: /debug
+    port: debug
+    {{- with .Values.prometheus.authorization }}
+    authorization:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with .Values.prometheus.metricRelabelings }}
+    metricRelabelings:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with .Values.prometheus.relabelings }}
+    relabelings:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+  selector:
+    matchLabels:
+      control-plane: controller-manager`))
+	})
+
+	It("should render the relabelings from values after the ones already set", func() {
+		input := `spec:
+  endpoints:
+  - metricRelabelings:
+    - action: keep
+      regex: controller_runtime_.*
+      sourceLabels:
+      - __name__
+    port: https
+    relabelings:
+    - action: replace
+      targetLabel: team`
+
+		Expect(TemplateServiceMonitorEndpoints(input)).To(Equal(`spec:
+  endpoints:
+  - metricRelabelings:
+    - action: keep
+      regex: controller_runtime_.*
+      sourceLabels:
+      - __name__
+    {{- with .Values.prometheus.metricRelabelings }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    port: https
+    relabelings:
+    - action: replace
+      targetLabel: team
+    {{- with .Values.prometheus.relabelings }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- with .Values.prometheus.authorization }}
+    authorization:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}`))
+	})
+
+	It("should keep an authorization already set on the endpoint", func() {
+		input := `spec:
+  endpoints:
+  - authorization:
+      credentials:
+        key: token
+        name: scrape-token
+    port: https`
+
+		Expect(TemplateServiceMonitorEndpoints(input)).To(Equal(input + `
+    {{- with .Values.prometheus.metricRelabelings }}
+    metricRelabelings:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with .Values.prometheus.relabelings }}
+    relabelings:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}`))
+	})
+
+	It("should be idempotent", func() {
+		input := `spec:
+  endpoints:
+  - port: https`
+
+		once := TemplateServiceMonitorEndpoints(input)
+		Expect(TemplateServiceMonitorEndpoints(once)).To(Equal(once))
+	})
+})
+
+var _ = Describe("TemplateServiceMonitorNamespaceSelector", func() {
+	It("should add the release namespace selector before spec.selector", func() {
+		input := `spec:
+  endpoints:
+  - port: https
+  selector:
+    matchLabels:
+      control-plane: controller-manager`
+
+		Expect(TemplateServiceMonitorNamespaceSelector(input)).To(Equal(`spec:
+  endpoints:
+  - port: https
+  namespaceSelector:
+    matchNames:
+    - {{ .Release.Namespace }}
+  selector:
+    matchLabels:
+      control-plane: controller-manager`))
+	})
+
+	It("should append the selector to spec when it has no selector", func() {
+		input := `spec:
+  endpoints:
+  - port: https`
+
+		Expect(TemplateServiceMonitorNamespaceSelector(input)).To(Equal(input + `
+  namespaceSelector:
+    matchNames:
+    - {{ .Release.Namespace }}`))
+	})
+
+	It("should keep a namespaceSelector already set", func() {
+		input := `spec:
+  endpoints:
+  - port: https
+  namespaceSelector:
+    any: true`
+
+		Expect(TemplateServiceMonitorNamespaceSelector(input)).To(Equal(input))
+	})
+})
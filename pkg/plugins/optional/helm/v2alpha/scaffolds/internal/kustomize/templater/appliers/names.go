@@ -90,35 +90,69 @@ func SubstituteNamespace(
 	return yamlContent
 }
 
-// SubstituteCertificateDNSNames replaces hardcoded DNS names in certificates with proper service templates.
+// SubstituteCertificateDNSNames templates the Service names in a Certificate's dnsNames and
+// commonName. Each entry
+// keeps pointing at the Service it names: a host such as <prefix>-webhook-service.<namespace>.svc
+// becomes the resourceName of that Service, whatever the Certificate is called, so projects with
+// several Certificates get every one of them right. Hosts that do not name a chart Service are kept.
 func SubstituteCertificateDNSNames(
 	detectedPrefix, chartName string, yamlContent string, resource *unstructured.Unstructured,
 ) string {
 	name := resource.GetName()
 
-	isMetricsCert := strings.HasSuffix(name, "-metrics-certs") || strings.HasSuffix(name, "-metrics-cert")
-	isServingCert := strings.HasSuffix(name, "-serving-cert")
-
-	if isMetricsCert {
-		metricsServiceTemplate := "{{ include \"" + chartName + ".resourceName\" " +
-			"(dict \"suffix\" \"controller-manager-metrics-service\" \"context\" $) }}"
-		metricsServiceFQDN := metricsServiceTemplate + ".{{ include \"" + chartName + ".namespaceName\" $ }}.svc"
-		metricsServiceFQDNCluster := metricsServiceTemplate +
-			".{{ include \"" + chartName + ".namespaceName\" $ }}.svc.cluster.local"
-
-		yamlContent = strings.ReplaceAll(yamlContent, "SERVICE_NAME.SERVICE_NAMESPACE.svc", metricsServiceFQDN)
-		yamlContent = strings.ReplaceAll(yamlContent,
-			"SERVICE_NAME.SERVICE_NAMESPACE.svc.cluster.local", metricsServiceFQDNCluster)
-
-		hardcodedMetricsService := detectedPrefix + "-controller-manager-metrics-service"
-		yamlContent = strings.ReplaceAll(yamlContent, hardcodedMetricsService, metricsServiceTemplate)
-	} else if isServingCert {
-		hardcodedWebhookServiceShort := detectedPrefix + "-webhook-service"
-		webhookServiceTemplate := ResourceNameTemplate(chartName, "webhook-service")
-		yamlContent = strings.ReplaceAll(yamlContent, hardcodedWebhookServiceShort, webhookServiceTemplate)
+	// The metrics Certificate from the default scaffold carries placeholders when the kustomize
+	// replacements were not applied; they do not name a Service, so resolve them by the
+	// Certificate name.
+	if strings.HasSuffix(name, "-metrics-certs") || strings.HasSuffix(name, "-metrics-cert") {
+		metricsServiceTemplate := ResourceNameTemplate(chartName, "controller-manager-metrics-service")
+		namespaceTemplate := "{{ include \"" + chartName + ".namespaceName\" $ }}"
+		yamlContent = strings.ReplaceAll(yamlContent, "SERVICE_NAME.SERVICE_NAMESPACE.svc",
+			metricsServiceTemplate+"."+namespaceTemplate+".svc")
 	}
 
-	return yamlContent
+	lines := strings.Split(yamlContent, "\n")
+	dnsNamesIndent := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		_, indent := LeadingWhitespace(line)
+		if trimmed == "dnsNames:" {
+			dnsNamesIndent = indent
+			continue
+		}
+		if host, ok := strings.CutPrefix(trimmed, "commonName: "); ok {
+			lines[i] = strings.Replace(line, host, certificateServiceHost(detectedPrefix, chartName, host), 1)
+			continue
+		}
+		if dnsNamesIndent < 0 || trimmed == "" {
+			continue
+		}
+		if indent < dnsNamesIndent || (indent == dnsNamesIndent && !strings.HasPrefix(trimmed, "- ")) {
+			dnsNamesIndent = -1
+			continue
+		}
+		host, ok := strings.CutPrefix(trimmed, "- ")
+		if !ok {
+			continue
+		}
+		if serviceHost := certificateServiceHost(detectedPrefix, chartName, host); serviceHost != host {
+			lines[i] = strings.Replace(line, host, serviceHost, 1)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// certificateServiceHost templates the Service name leading a dnsNames host, e.g.
+// <prefix>-webhook-service.<namespace>.svc, with the resourceName helper.
+func certificateServiceHost(detectedPrefix, chartName, host string) string {
+	service, domain, _ := strings.Cut(host, ".")
+	suffix, ok := strings.CutPrefix(service, detectedPrefix+"-")
+	if !ok || suffix == "" {
+		return host
+	}
+	if domain == "" {
+		return ResourceNameTemplate(chartName, suffix)
+	}
+	return ResourceNameTemplate(chartName, suffix) + "." + domain
 }
 
 // ResourceNameTemplate creates a Helm template for a resource name with 63-char safety.
@@ -0,0 +1,130 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// topLevelKeyPattern matches a mapping key at the top level of a resource, e.g. "kind: Role".
+	topLevelKeyPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.-]*):(\s|$)`)
+	// topLevelOpenerPattern matches a Helm directive at the top level opening a block.
+	topLevelOpenerPattern = regexp.MustCompile(`^\{\{-?\s*(if|with|range)\b`)
+	// topLevelEndPattern matches a Helm {{- end }} at the top level.
+	topLevelEndPattern = regexp.MustCompile(`^\{\{-?\s*end\b`)
+)
+
+// canonicalTopLevelKeys lists the keys OrderTopLevelKeys moves to the front, in order.
+var canonicalTopLevelKeys = []string{"apiVersion", "kind", "metadata", "spec"}
+
+// OrderTopLevelKeys moves the top-level keys of a templated resource into the order of
+// canonicalTopLevelKeys; other keys follow in their original order. The kustomize output sorts keys
+// alphabetically, so a ConfigMap reads apiVersion, data, kind, metadata; reordered it reads like a
+// hand-written manifest and stays stable across scaffold runs. Each key moves with everything nested
+// under it, and a directive guarding top-level keys, such as the Role or ClusterRole kind switch,
+// moves with its whole block, ordered by the first key it guards. The directives wrapping the whole
+// resource stay in place; content whose top-level directives do not balance is returned unchanged.
+func OrderTopLevelKeys(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	isTopLevelDirective := func(line string) bool {
+		return strings.HasPrefix(line, "{{")
+	}
+
+	start := 0
+	for start < len(lines) && (strings.TrimSpace(lines[start]) == "" || isTopLevelDirective(lines[start])) {
+		start++
+	}
+	end := len(lines)
+	for end > start && (strings.TrimSpace(lines[end-1]) == "" || isTopLevelDirective(lines[end-1])) {
+		end--
+	}
+	if start == end || !topLevelKeyPattern.MatchString(lines[start]) {
+		return yamlContent
+	}
+
+	// guardedKey returns the top-level key guarded by the directive at index i, or "" when the
+	// directive guards content nested under the current key.
+	guardedKey := func(i int) string {
+		for j := i + 1; j < end; j++ {
+			if strings.TrimSpace(lines[j]) == "" || isTopLevelDirective(lines[j]) {
+				continue
+			}
+			if match := topLevelKeyPattern.FindStringSubmatch(lines[j]); match != nil {
+				return match[1]
+			}
+			return ""
+		}
+		return ""
+	}
+
+	type keyBlock struct {
+		key   string
+		lines []string
+	}
+	var blocks []keyBlock
+	depth, groupDepth := 0, 0
+	for i := start; i < end; i++ {
+		line := lines[i]
+		switch {
+		case topLevelOpenerPattern.MatchString(line):
+			if groupDepth == 0 {
+				if key := guardedKey(i); key != "" {
+					blocks = append(blocks, keyBlock{key: key})
+					groupDepth = depth + 1
+				}
+			}
+			depth++
+		case topLevelEndPattern.MatchString(line):
+			depth--
+			if depth < 0 {
+				return yamlContent
+			}
+		case groupDepth == 0:
+			if match := topLevelKeyPattern.FindStringSubmatch(line); match != nil {
+				blocks = append(blocks, keyBlock{key: match[1]})
+			}
+		}
+		blocks[len(blocks)-1].lines = append(blocks[len(blocks)-1].lines, line)
+		if groupDepth > depth {
+			groupDepth = 0
+		}
+	}
+	if depth != 0 {
+		return yamlContent
+	}
+
+	rank := func(key string) int {
+		if i := slices.Index(canonicalTopLevelKeys, key); i >= 0 {
+			return i
+		}
+		return len(canonicalTopLevelKeys)
+	}
+	slices.SortStableFunc(blocks, func(a, b keyBlock) int {
+		return rank(a.key) - rank(b.key)
+	})
+
+	ordered := make([]string, 0, len(lines))
+	ordered = append(ordered, lines[:start]...)
+	for _, block := range blocks {
+		ordered = append(ordered, block.lines...)
+	}
+	ordered = append(ordered, lines[end:]...)
+	return strings.Join(ordered, "\n")
+}
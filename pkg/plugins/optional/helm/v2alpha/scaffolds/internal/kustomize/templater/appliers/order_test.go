@@ -0,0 +1,70 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OrderTopLevelKeys", func() {
+	It("should keep the directives wrapping the resource in place", func() {
+		input := `{{- if .Values.jobs.enabled }}
+apiVersion: batch/v1
+spec:
+  template:
+    spec:
+{{- if .Values.jobs.debug }}
+      restartPolicy: Never
+{{- end }}
+kind: Job
+metadata:
+  name: migrate
+{{- end }}
+`
+		Expect(OrderTopLevelKeys(input)).To(Equal(`{{- if .Values.jobs.enabled }}
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+{{- if .Values.jobs.debug }}
+      restartPolicy: Never
+{{- end }}
+{{- end }}
+`))
+	})
+
+	It("should leave content with unbalanced top-level directives unchanged", func() {
+		input := `apiVersion: v1
+data:
+  key: value
+{{- end }}
+kind: ConfigMap
+metadata:
+  name: config
+`
+		Expect(OrderTopLevelKeys(input)).To(Equal(input))
+	})
+
+	It("should leave content that does not start with a key unchanged", func() {
+		input := "# comment\ndata: {}\nkind: ConfigMap\n"
+		Expect(OrderTopLevelKeys(input)).To(Equal(input))
+	})
+})
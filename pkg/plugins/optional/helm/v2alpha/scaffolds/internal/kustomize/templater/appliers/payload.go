@@ -0,0 +1,187 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// payloadKeys are the top-level Secret and ConfigMap fields holding user data.
+var payloadKeys = []string{"data:", "stringData:", "binaryData:"}
+
+// payloadPlaceholder replaces a payload block while the other appliers run.
+const payloadPlaceholder = "__kubebuilder_data_payload_%d__"
+
+// ExtractDataPayload replaces the data, stringData and binaryData blocks of a Secret or ConfigMap
+// with placeholders so the label, name and namespace substitutions cannot rewrite the user's
+// payload. The removed blocks are returned in order for RestoreDataPayload.
+func ExtractDataPayload(yamlContent string) (string, []string) {
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines))
+	var payload []string
+
+	for i := 0; i < len(lines); i++ {
+		key := strings.TrimRight(lines[i], " \t")
+		if !slices.Contains(payloadKeys, key) {
+			result = append(result, lines[i])
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && (lines[end] == "" || strings.HasPrefix(lines[end], " ")) {
+			end++
+		}
+		// Keep trailing blank lines outside the block so the document layout is unchanged.
+		for end > i+1 && lines[end-1] == "" {
+			end--
+		}
+
+		result = append(result, fmt.Sprintf("%s "+payloadPlaceholder, key, len(payload)))
+		payload = append(payload, strings.Join(lines[i:end], "\n"))
+		i = end - 1
+	}
+
+	return strings.Join(result, "\n"), payload
+}
+
+// RestoreDataPayload puts back the blocks removed by ExtractDataPayload.
+func RestoreDataPayload(yamlContent string, payload []string) string {
+	for i, block := range payload {
+		key, _, _ := strings.Cut(block, "\n")
+		key = strings.TrimRight(key, " \t")
+		placeholder := fmt.Sprintf("%s "+payloadPlaceholder, key, i)
+		yamlContent = strings.Replace(yamlContent, placeholder, block, 1)
+	}
+	return yamlContent
+}
+
+// blockScalarPlaceholder replaces the body of a block scalar while the other appliers run.
+const blockScalarPlaceholder = "__kubebuilder_block_scalar_%d__"
+
+// blockScalarPlaceholderPattern matches a placeholder line left by ExtractBlockScalars.
+var blockScalarPlaceholderPattern = regexp.MustCompile(`^(\s*)__kubebuilder_block_scalar_(\d+)__$`)
+
+// ExtractBlockScalars replaces the body of every literal (|) or folded (>) block scalar, such as a
+// multiline annotation or a CRD description, with a placeholder line so the line-based
+// substitutions cannot rewrite or re-indent text that merely looks like YAML. The header line stays
+// in place. The removed bodies are returned in order for RestoreBlockScalars.
+func ExtractBlockScalars(yamlContent string) (string, []string) {
+	lines := strings.Split(yamlContent, "\n")
+	marked := blockScalarLines(lines)
+	result := make([]string, 0, len(lines))
+	var bodies []string
+
+	for i := 0; i < len(lines); i++ {
+		if !marked[i] {
+			result = append(result, lines[i])
+			continue
+		}
+
+		end, bodyIndent := i, ""
+		for ; end < len(lines) && marked[end]; end++ {
+			if indent, _ := LeadingWhitespace(lines[end]); bodyIndent == "" && strings.TrimSpace(lines[end]) != "" {
+				bodyIndent = indent
+			}
+		}
+		if bodyIndent == "" {
+			// A body of blank lines only has no indentation to hold a placeholder.
+			result = append(result, lines[i:end]...)
+		} else {
+			result = append(result, bodyIndent+fmt.Sprintf(blockScalarPlaceholder, len(bodies)))
+			bodies = append(bodies, strings.Join(lines[i:end], "\n"))
+		}
+		i = end - 1
+	}
+
+	return strings.Join(result, "\n"), bodies
+}
+
+// RestoreBlockScalars puts back the block scalar bodies removed by ExtractBlockScalars. A body whose
+// placeholder was moved to another indentation, e.g. nested under a new key, is shifted with it.
+func RestoreBlockScalars(yamlContent string, bodies []string) string {
+	lines := strings.Split(yamlContent, "\n")
+	for i, line := range lines {
+		match := blockScalarPlaceholderPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		index, err := strconv.Atoi(match[2])
+		if err != nil || index >= len(bodies) {
+			continue
+		}
+
+		body := strings.Split(bodies[index], "\n")
+		first := slices.IndexFunc(body, func(line string) bool { return strings.TrimSpace(line) != "" })
+		if _, bodyIndent := LeadingWhitespace(body[first]); len(match[1]) != bodyIndent {
+			shift := len(match[1]) - bodyIndent
+			for j, bodyLine := range body {
+				if strings.TrimSpace(bodyLine) == "" {
+					continue
+				}
+				if shift > 0 {
+					body[j] = strings.Repeat(" ", shift) + bodyLine
+				} else {
+					body[j] = bodyLine[-shift:]
+				}
+			}
+		}
+		lines[i] = strings.Join(body, "\n")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TemplateConfigMapData merges .Values.config into a ConfigMap data block. Keys set in values are
+// rendered first, and each key from the manifest is kept only when values do not override it.
+func TemplateConfigMapData(block string) string {
+	lines := strings.Split(block, "\n")
+	if strings.TrimRight(lines[0], " \t") != "data:" || len(lines) < 2 || strings.Contains(block, ".Values.config") {
+		return block
+	}
+
+	indent, _ := LeadingWhitespace(lines[1])
+	result := []string{
+		lines[0],
+		indent + "{{- with .Values.config }}",
+		fmt.Sprintf("%s{{- toYaml . | nindent %d }}", indent, len(indent)),
+		indent + "{{- end }}",
+	}
+
+	for i := 1; i < len(lines); {
+		end := i + 1
+		for end < len(lines) && !isConfigMapDataKey(lines[end], indent) {
+			end++
+		}
+
+		key, _, _ := strings.Cut(strings.TrimSpace(lines[i]), ":")
+		result = append(result,
+			fmt.Sprintf("%s{{- if not (hasKey (.Values.config | default dict) %q) }}", indent, strings.Trim(key, `"'`)))
+		result = append(result, lines[i:end]...)
+		result = append(result, indent+"{{- end }}")
+		i = end
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// isConfigMapDataKey reports whether line starts a new entry at the data block's key indentation.
+func isConfigMapDataKey(line, indent string) bool {
+	return strings.HasPrefix(line, indent) && len(line) > len(indent) && line[len(indent)] != ' '
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExtractDataPayload", func() {
+	const secret = `apiVersion: v1
+data:
+  tls.crt: |
+    TFMwdExTMUNSVWRKVGlCRFJWSlVTVVpKUTBGVVJTMHRMUzB0Q2c9PQ==
+    Cg==
+kind: Secret
+metadata:
+  name: test-project-bootstrap
+stringData:
+  name: test-project
+type: Opaque
+`
+
+	It("should replace the payload blocks with placeholders", func() {
+		stripped, payload := ExtractDataPayload(secret)
+
+		Expect(payload).To(HaveLen(2))
+		Expect(payload[0]).To(HavePrefix("data:\n  tls.crt: |\n"))
+		Expect(payload[1]).To(Equal("stringData:\n  name: test-project"))
+		Expect(stripped).NotTo(ContainSubstring("tls.crt"))
+		Expect(stripped).To(ContainSubstring("metadata:\n  name: test-project-bootstrap\n"))
+	})
+
+	It("should restore the original document", func() {
+		stripped, payload := ExtractDataPayload(secret)
+
+		Expect(RestoreDataPayload(stripped, payload)).To(Equal(secret))
+	})
+
+	It("should leave inline empty payloads alone", func() {
+		input := "apiVersion: v1\ndata: {}\nkind: Secret\n"
+
+		stripped, payload := ExtractDataPayload(input)
+
+		Expect(payload).To(BeEmpty())
+		Expect(stripped).To(Equal(input))
+	})
+})
+
+var _ = Describe("ExtractBlockScalars", func() {
+	const service = `apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    example.com/runbook: |-
+      labels:
+        app.kubernetes.io/managed-by: kustomize
+
+      Scaled by the platform team.
+    example.com/owner: platform
+  name: test-project-extra-service
+`
+
+	It("should replace each body with a placeholder at the body indentation", func() {
+		stripped, bodies := ExtractBlockScalars(service)
+
+		Expect(bodies).To(HaveLen(1))
+		Expect(bodies[0]).To(HavePrefix("      labels:\n"))
+		Expect(stripped).To(ContainSubstring("    example.com/runbook: |-\n      __kubebuilder_block_scalar_0__\n" +
+			"    example.com/owner: platform\n"))
+		Expect(stripped).NotTo(ContainSubstring("managed-by"))
+	})
+
+	It("should restore the original document", func() {
+		stripped, bodies := ExtractBlockScalars(service)
+
+		Expect(RestoreBlockScalars(stripped, bodies)).To(Equal(service))
+	})
+
+	It("should shift a body whose placeholder moved", func() {
+		_, bodies := ExtractBlockScalars(service)
+
+		restored := RestoreBlockScalars("  runbook: |-\n    __kubebuilder_block_scalar_0__\n", bodies)
+
+		Expect(restored).To(Equal("  runbook: |-\n    labels:\n      app.kubernetes.io/managed-by: kustomize\n\n" +
+			"    Scaled by the platform team.\n"))
+	})
+})
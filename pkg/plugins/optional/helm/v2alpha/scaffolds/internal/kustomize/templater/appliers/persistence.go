@@ -0,0 +1,89 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const persistenceValuesPath = "(.Values.manager.persistence | default dict)"
+
+// templateVolumeClaimTemplates templates the storage size and storageClassName of every StatefulSet
+// volumeClaimTemplate from manager.persistence. The values from the manifest are kept as defaults;
+// a claim without storageClassName only gets one when manager.persistence.storageClassName is set.
+func templateVolumeClaimTemplates(yamlContent string) string {
+	if strings.Contains(yamlContent, ".Values.manager.persistence") {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	start := slices.Index(lines, "  volumeClaimTemplates:")
+	if start < 0 {
+		return yamlContent
+	}
+	end := start + 1
+	for ; end < len(lines); end++ {
+		trimmed := strings.TrimSpace(lines[end])
+		if trimmed == "" {
+			continue
+		}
+		_, indent := LeadingWhitespace(lines[end])
+		if indent < 2 || (indent == 2 && !strings.HasPrefix(trimmed, "- ")) {
+			break
+		}
+	}
+
+	result := make([]string, 0, len(lines)+3)
+	result = append(result, lines[:start+1]...)
+	// specLine is the index in result of the current claim's spec: line, until its storageClassName is seen.
+	specLine := -1
+	specIndent := ""
+	insertStorageClass := func() {
+		if specLine < 0 {
+			return
+		}
+		childIndent := specIndent + "  "
+		result = slices.Insert(result, specLine+1,
+			childIndent+"{{- with "+persistenceValuesPath+".storageClassName }}",
+			childIndent+"storageClassName: {{ . }}",
+			childIndent+"{{- end }}")
+		specLine = -1
+	}
+	for _, line := range lines[start+1 : end] {
+		trimmed := strings.TrimSpace(line)
+		indentStr, indent := LeadingWhitespace(line)
+		switch {
+		case indent == 2 && strings.HasPrefix(trimmed, "- "):
+			insertStorageClass()
+		case indent == 4 && trimmed == "spec:":
+			specLine, specIndent = len(result), indentStr
+		case indent == 6 && strings.HasPrefix(trimmed, "storageClassName: "):
+			specLine = -1
+			line = fmt.Sprintf("%sstorageClassName: {{ %s.storageClassName | default %q }}",
+				indentStr, persistenceValuesPath, strings.TrimSpace(strings.TrimPrefix(trimmed, "storageClassName:")))
+		case strings.HasPrefix(trimmed, "storage: "):
+			line = fmt.Sprintf("%sstorage: {{ %s.size | default %q }}",
+				indentStr, persistenceValuesPath, strings.TrimSpace(strings.TrimPrefix(trimmed, "storage:")))
+		}
+		result = append(result, line)
+	}
+	insertStorageClass()
+	result = append(result, lines[end:]...)
+	return strings.Join(result, "\n")
+}
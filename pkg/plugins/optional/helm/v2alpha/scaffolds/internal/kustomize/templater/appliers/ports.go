@@ -17,7 +17,9 @@ limitations under the License.
 package appliers
 
 import (
+	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -25,6 +27,12 @@ import (
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
 )
 
+// webhookServiceValuesPath is the optional values map configuring the webhook Service.
+const webhookServiceValuesPath = "(.Values.webhook.service | default dict)"
+
+// webhookServicePortPattern matches a literal Service port.
+var webhookServicePortPattern = regexp.MustCompile(`(?m)^(\s*(?:- )?)port:\s*(\d+)\s*$`)
+
 // TemplatePorts templates port numbers for Services, Deployments, and NetworkPolicies using values.yaml.
 func TemplatePorts(yamlContent string, resource *unstructured.Unstructured) string {
 	resourceName := resource.GetName()
@@ -55,15 +63,15 @@ func TemplatePorts(yamlContent string, resource *unstructured.Unstructured) stri
 			return yamlContent
 		}
 
-		// Replace containerPort for webhook-server with template (matches any numeric port)
-		if strings.Contains(yamlContent, "webhook-server") {
-			yamlContent = regexp.MustCompile(`(?m)(\s*- )?containerPort:\s*\d+(\s*\n\s*name:\s*webhook-server)`).
-				ReplaceAllString(yamlContent, "${1}containerPort: {{ .Values.webhook.port }}${2}")
-		}
-
 		// Replace targetPort with webhook.port template (matches any numeric port)
 		yamlContent = regexp.MustCompile(`(\s*)targetPort:\s*\d+`).
 			ReplaceAllString(yamlContent, "${1}targetPort: {{ .Values.webhook.port }}")
+
+		// The Service port the API server calls, defaulting to the one in the manifest
+		if resourceKind == common.KindService {
+			yamlContent = webhookServicePortPattern.ReplaceAllString(yamlContent,
+				"${1}port: {{ "+webhookServiceValuesPath+".port | default ${2} }}")
+		}
 	}
 
 	// Template metrics ports
@@ -83,13 +91,19 @@ func TemplatePorts(yamlContent string, resource *unstructured.Unstructured) stri
 		// Template port name based on metrics.secure (http vs https)
 		// This ensures Service and ServiceMonitor use the correct scheme
 		if resource.GetKind() == common.KindService {
-			yamlContent = regexp.MustCompile(`(\s*)- name:\s*https(\s+port:)`).
-				ReplaceAllString(yamlContent, `${1}- name: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}${2}`)
+			yamlContent = templateMetricsServicePort(yamlContent)
 		}
 	}
 
+	if resourceKind == common.KindValidatingWebhook || resourceKind == common.KindMutatingWebhook ||
+		resourceKind == common.KindCRD {
+		yamlContent = TemplateWebhookClientConfigPort(yamlContent)
+	}
+
 	// Template port-related arguments in Deployment
 	if resource.GetKind() == common.KindDeployment {
+		yamlContent = templateContainerPorts(yamlContent)
+
 		// Replace --metrics-bind-address with templated port
 		// Supports :PORT, HOST:PORT, and IPv6 [::1]:PORT formats
 		yamlContent = regexp.MustCompile(`--metrics-bind-address=(\[[^\]]*\]|[^\s:]*):([0-9]+)`).
@@ -105,11 +119,138 @@ func TemplatePorts(yamlContent string, resource *unstructured.Unstructured) stri
 	return yamlContent
 }
 
+// metricsAppProtocolValuesPath is the optional appProtocol of the metrics Service port.
+const metricsAppProtocolValuesPath = ".Values.metrics.appProtocol"
+
+// metricsPortNameTemplate names the metrics Service port after the scheme it serves.
+const metricsPortNameTemplate = "{{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"
+
+// templateMetricsServicePort names the first metrics Service port https or http following
+// metrics.secure, so service meshes such as Istio and Linkerd classify its traffic, and sets its
+// appProtocol from metrics.appProtocol. An appProtocol in the manifest becomes the default.
+func templateMetricsServicePort(yamlContent string) string {
+	if strings.Contains(yamlContent, metricsAppProtocolValuesPath) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "ports:" && i+1 < len(lines) &&
+			strings.HasPrefix(strings.TrimSpace(lines[i+1]), "- ") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return yamlContent
+	}
+
+	itemIndent, itemIndentLen := LeadingWhitespace(lines[start])
+	fieldIndent := itemIndent + "  "
+	end := start + 1
+	for end < len(lines) {
+		if _, indent := LeadingWhitespace(lines[end]); strings.TrimSpace(lines[end]) == "" || indent <= itemIndentLen {
+			break
+		}
+		end++
+	}
+
+	appProtocol := metricsAppProtocolValuesPath
+	var fields []string
+	for i := start; i < end; i++ {
+		field := strings.TrimSpace(lines[i])
+		if i == start {
+			field = strings.TrimSpace(strings.TrimPrefix(field, "-"))
+		}
+		key, value, _ := strings.Cut(field, ":")
+		switch key {
+		case "name":
+			continue
+		case "appProtocol":
+			manifestProtocol := strings.Trim(strings.TrimSpace(value), `"'`)
+			appProtocol = fmt.Sprintf("%s | default %q", metricsAppProtocolValuesPath, manifestProtocol)
+			continue
+		}
+		fields = append(fields, fieldIndent+field)
+	}
+
+	item := []string{
+		itemIndent + "- name: " + metricsPortNameTemplate,
+		fieldIndent + "{{- with " + appProtocol + " }}",
+		fieldIndent + "appProtocol: {{ . }}",
+		fieldIndent + "{{- end }}",
+	}
+	item = append(item, fields...)
+
+	newLines := append([]string{}, lines[:start]...)
+	newLines = append(newLines, item...)
+	newLines = append(newLines, lines[end:]...)
+	return strings.Join(newLines, "\n")
+}
+
+// metricsBindPortPattern captures the port of a literal --metrics-bind-address arg.
+var metricsBindPortPattern = regexp.MustCompile(`--metrics-bind-address=(?:\[[^\]]*\]|[^\s:]*):([0-9]+)`)
+
+// templateContainerPorts points the manager container ports at the values serving them: the
+// "webhook-server" port at webhook.port, the "health" port at manager.healthProbe.port and the
+// metrics port, named "metrics" or matching --metrics-bind-address, at metrics.port. The metrics
+// port is only rendered with metrics.enabled, like the metrics server it exposes.
+// Must run before the --metrics-bind-address arg is templated.
+func templateContainerPorts(yamlContent string) string {
+	yamlContent = regexp.MustCompile(`(?m)(\s*- )?containerPort:\s*\d+(\s*\n\s*name:\s*webhook-server)`).
+		ReplaceAllString(yamlContent, "${1}containerPort: {{ .Values.webhook.port }}${2}")
+	yamlContent = regexp.MustCompile(`(?m)(\s*- )?containerPort:\s*\d+(\s*\n\s*name:\s*health\b)`).
+		ReplaceAllString(yamlContent, "${1}containerPort: {{ .Values.manager.healthProbe.port }}${2}")
+
+	rangeStart, rangeEnd := FindManagerContainerRange(yamlContent)
+	if rangeStart < 0 {
+		return yamlContent
+	}
+	metricsPort := ""
+	if match := metricsBindPortPattern.FindStringSubmatch(yamlContent); match != nil {
+		metricsPort = match[1]
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines)+2)
+	result = append(result, lines[:rangeStart]...)
+	for i := rangeStart; i <= rangeEnd; i++ {
+		port, isPort := strings.CutPrefix(strings.TrimSpace(lines[i]), "- containerPort: ")
+		if !isPort || strings.Contains(port, "{{") {
+			result = append(result, lines[i])
+			continue
+		}
+		indent, itemIndent := LeadingWhitespace(lines[i])
+		end := i + 1
+		name := ""
+		for ; end <= rangeEnd; end++ {
+			if _, lineIndent := LeadingWhitespace(lines[end]); lineIndent <= itemIndent {
+				break
+			}
+			if value, ok := strings.CutPrefix(strings.TrimSpace(lines[end]), "name: "); ok {
+				name = value
+			}
+		}
+		if name != "metrics" && (metricsPort == "" || port != metricsPort) {
+			result = append(result, lines[i])
+			continue
+		}
+		item := slices.Clone(lines[i:end])
+		item[0] = indent + "- containerPort: {{ .Values.metrics.port }}"
+		result = append(result, indent+"{{- if .Values.metrics.enabled }}")
+		result = append(result, item...)
+		result = append(result, indent+"{{- end }}")
+		i = end - 1
+	}
+	result = append(result, lines[rangeEnd+1:]...)
+	return strings.Join(result, "\n")
+}
+
 // templateHealthProbePort templates the manager health probe port so it can be
 // configured from values.yaml, mirroring how metrics and webhook ports are handled.
-// It rewrites the four places the port appears in the manager Deployment: the
-// --health-probe-bind-address arg, the "health" containerPort, and the liveness
-// and readiness httpGet ports.
+// It rewrites the --health-probe-bind-address arg and the liveness and readiness
+// httpGet ports and schemes; templateContainerPorts handles the "health" containerPort.
 func templateHealthProbePort(yamlContent string) string {
 	const healthPortTemplate = "{{ .Values.manager.healthProbe.port }}"
 
@@ -117,13 +258,89 @@ func templateHealthProbePort(yamlContent string) string {
 	yamlContent = regexp.MustCompile(`--health-probe-bind-address=(\[[^\]]*\]|[^\s:]*):([0-9]+)`).
 		ReplaceAllString(yamlContent, "--health-probe-bind-address=$1:"+healthPortTemplate)
 
-	// containerPort for the port named "health"
-	yamlContent = regexp.MustCompile(`(?m)(\s*- )?containerPort:\s*\d+(\s*\n\s*name:\s*health\b)`).
-		ReplaceAllString(yamlContent, "${1}containerPort: "+healthPortTemplate+"${2}")
-
 	// liveness (/healthz) and readiness (/readyz) httpGet ports
 	yamlContent = regexp.MustCompile(`(path:\s*/(?:healthz|readyz)[ \t]*\n\s*port:\s*)\d+`).
 		ReplaceAllString(yamlContent, "${1}"+healthPortTemplate)
 
-	return yamlContent
+	return templateHealthProbeScheme(yamlContent)
+}
+
+// templateHealthProbeScheme sets the scheme of the liveness (/healthz) and readiness (/readyz)
+// httpGet probes to manager.healthProbe.scheme, so a health endpoint served over TLS can be probed
+// with HTTPS. The default is the scheme in the manifest, or HTTP, the scheme Kubernetes uses when
+// none is set. The scheme follows the port, as in the sorted kustomize output.
+func templateHealthProbeScheme(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines)+2)
+	for i := 0; i < len(lines); i++ {
+		result = append(result, lines[i])
+		path := strings.TrimSpace(lines[i])
+		if (path != "path: /healthz" && path != "path: /readyz") || i+1 >= len(lines) {
+			continue
+		}
+		indent, _ := LeadingWhitespace(lines[i])
+		if !strings.HasPrefix(lines[i+1], indent+"port: ") {
+			continue
+		}
+		i++
+		result = append(result, lines[i])
+
+		scheme := "HTTP"
+		if i+1 < len(lines) {
+			if value, ok := strings.CutPrefix(lines[i+1], indent+"scheme: "); ok {
+				if strings.Contains(value, "{{") {
+					continue
+				}
+				scheme = strings.TrimSpace(value)
+				i++
+			}
+		}
+		result = append(result, fmt.Sprintf("%sscheme: {{ .Values.manager.healthProbe.scheme | default %q }}",
+			indent, scheme))
+	}
+	return strings.Join(result, "\n")
+}
+
+// TemplateWebhookClientConfigPort sets the port of every clientConfig.service pointing at the webhook
+// Service, in webhook configurations and CRD conversion webhooks, to webhook.service.port so the API
+// server keeps calling the port the Service exposes. The default is the port in the manifest, or 443,
+// the port the API server uses when none is set.
+func TemplateWebhookClientConfigPort(yamlContent string) string {
+	lines := strings.Split(yamlContent, "\n")
+	result := make([]string, 0, len(lines)+1)
+	for i := 0; i < len(lines); i++ {
+		result = append(result, lines[i])
+		if strings.TrimSpace(lines[i]) != "service:" || !isChildOf(lines, i, "clientConfig:") {
+			continue
+		}
+
+		_, indent := LeadingWhitespace(lines[i])
+		end := i + 1
+		for end < len(lines) {
+			_, lineIndent := LeadingWhitespace(lines[end])
+			if strings.TrimSpace(lines[end]) != "" && lineIndent <= indent {
+				break
+			}
+			end++
+		}
+		block := slices.Clone(lines[i+1 : end])
+		if !strings.Contains(strings.Join(block, "\n"), "webhook-service") ||
+			strings.Contains(strings.Join(block, "\n"), webhookServiceValuesPath) {
+			continue
+		}
+
+		childIndent := strings.Repeat(" ", indent+2)
+		defaultPort := "443"
+		block = slices.DeleteFunc(block, func(line string) bool {
+			port, ok := strings.CutPrefix(line, childIndent+"port: ")
+			if ok {
+				defaultPort = strings.TrimSpace(port)
+			}
+			return ok
+		})
+		block = append(block, childIndent+"port: {{ "+webhookServiceValuesPath+".port | default "+defaultPort+" }}")
+		result = append(result, block...)
+		i = end - 1
+	}
+	return strings.Join(result, "\n")
 }
@@ -18,11 +18,17 @@ package appliers
 
 import (
 	"regexp"
+	"slices"
 	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
 )
 
 // This file contains RBAC and ServiceAccount name/enable transformations:
 //  - SubstituteRBACValues: Role and RoleBinding name templating
+//  - TemplateWatchNamespace: Role and RoleBinding namespace from rbac.watchNamespace
 //  - TemplateServiceAccountNameInBindings: SA name in RoleBinding/ClusterRoleBinding subjects
 //  - TemplateServiceAccountNameInDeployment: SA name in Deployment spec
 //  - TemplateServiceAccount: ServiceAccount orchestration (labels+annotations, name, conditional)
@@ -48,6 +54,38 @@ func SubstituteRBACValues(detectedPrefix, chartName, yamlContent string) string
 	return yamlContent
 }
 
+// WatchNamespaceValuesPath is the value naming the namespace the manager watches. It scopes the
+// namespaced RBAC and sets the manager's WATCH_NAMESPACE env var.
+const WatchNamespaceValuesPath = ".Values.rbac.watchNamespace"
+
+// TemplateWatchNamespace deploys namespaced RBAC to .Values.rbac.watchNamespace, falling back to
+// the release namespace, for managers that run in one namespace and watch another. Only the
+// metadata namespace of Roles and RoleBindings (including ClusterRoles and ClusterRoleBindings
+// rendered as such by rbac.namespaced) is changed: binding subjects stay in the release namespace
+// with the ServiceAccount, and the leader election Role and RoleBinding stay with the lease.
+func TemplateWatchNamespace(yamlContent string, resource *unstructured.Unstructured) string {
+	switch resource.GetKind() {
+	case common.KindRole, common.KindRoleBinding, common.KindClusterRole, common.KindClusterRoleBinding:
+	default:
+		return yamlContent
+	}
+	name := resource.GetName()
+	if strings.HasSuffix(name, "leader-election-role") || strings.HasSuffix(name, "leader-election-rolebinding") ||
+		strings.Contains(yamlContent, WatchNamespaceValuesPath) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "namespace: {{ .Release.Namespace }}" || !isMetadataChild(lines, i) {
+			continue
+		}
+		indent, _ := LeadingWhitespace(line)
+		lines[i] = indent + "namespace: {{ " + WatchNamespaceValuesPath + " | default .Release.Namespace }}"
+	}
+	return strings.Join(lines, "\n")
+}
+
 // TemplateServiceAccountNameInBindings templates SA name in RoleBinding/ClusterRoleBinding subjects.
 func TemplateServiceAccountNameInBindings(detectedPrefix, chartName, yamlContent string) string {
 	replacement := `{{ include "` + chartName + `.serviceAccountName" . }}`
@@ -97,12 +135,54 @@ func TemplateServiceAccountNameInDeployment(detectedPrefix, chartName, yamlConte
 
 // TemplateServiceAccount applies all ServiceAccount-specific transformations.
 func TemplateServiceAccount(detectedPrefix, chartName, yamlContent string) string {
+	if strings.Contains(yamlContent, ".Values.serviceAccount.enabled") {
+		return yamlContent
+	}
 	yamlContent = AddServiceAccountLabelsAndAnnotations(yamlContent)
+	yamlContent = TemplateServiceAccountAutomountToken(yamlContent)
 	yamlContent = TemplateServiceAccountName(detectedPrefix, chartName, yamlContent)
 	yamlContent = WrapServiceAccountWithEnabledConditional(yamlContent)
 	return yamlContent
 }
 
+// TemplateServiceAccountAutomountToken sets automountServiceAccountToken on the ServiceAccount when
+// .Values.serviceAccount.automountServiceAccountToken is set, true or false. A value from the kustomize
+// output is kept as the fallback.
+func TemplateServiceAccountAutomountToken(yamlContent string) string {
+	if strings.Contains(yamlContent, ".Values.serviceAccount.automountServiceAccountToken") {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	insertAt := -1
+	var fallback []string
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "apiVersion:") && insertAt < 0:
+			insertAt = i + 1
+		case strings.HasPrefix(line, "automountServiceAccountToken:"):
+			insertAt = i
+			fallback = []string{"{{- else }}", line}
+			lines = slices.Delete(lines, i, i+1)
+		}
+		if fallback != nil {
+			break
+		}
+	}
+	if insertAt < 0 {
+		return yamlContent
+	}
+
+	block := []string{
+		"{{- if and (hasKey .Values.serviceAccount \"automountServiceAccountToken\") " +
+			"(ne .Values.serviceAccount.automountServiceAccountToken nil) }}",
+		"automountServiceAccountToken: {{ .Values.serviceAccount.automountServiceAccountToken }}",
+	}
+	block = append(block, fallback...)
+	block = append(block, "{{- end }}")
+	return strings.Join(slices.Insert(lines, insertAt, block...), "\n")
+}
+
 // TemplateServiceAccountName replaces SA name with serviceAccountName helper.
 func TemplateServiceAccountName(detectedPrefix, chartName, yamlContent string) string {
 	replacement := `${1}name: {{ include "` + chartName + `.serviceAccountName" . }}`
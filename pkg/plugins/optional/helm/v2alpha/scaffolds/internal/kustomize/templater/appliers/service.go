@@ -0,0 +1,154 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appliers
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
+)
+
+// serviceAnnotationKeyPattern matches an annotation entry under metadata.annotations.
+var serviceAnnotationKeyPattern = regexp.MustCompile(`^    ([a-zA-Z0-9._/-]+):(\s|$)`)
+
+// serviceValuesPath returns the values map configuring the metrics or webhook Service, or "" for
+// other Services. The map is optional in values.yaml, so it defaults to an empty dict.
+func serviceValuesPath(resource *unstructured.Unstructured) string {
+	if resource.GetKind() != common.KindService {
+		return ""
+	}
+	// Use suffix matching to avoid false positives when project name contains "metrics" or "webhook"
+	name := resource.GetName()
+	switch {
+	case strings.HasSuffix(name, "-metrics-service"):
+		return "(.Values.metrics.service | default dict)"
+	case strings.HasSuffix(name, "-webhook-service"):
+		return "(.Values.webhook.service | default dict)"
+	}
+	return ""
+}
+
+// TemplateServiceAnnotations merges metrics.service.annotations or webhook.service.annotations
+// into the metadata of the matching Service. Annotations from kustomize are kept and take
+// precedence over values with the same key.
+func TemplateServiceAnnotations(yamlContent string, resource *unstructured.Unstructured) string {
+	servicePath := serviceValuesPath(resource)
+	if servicePath == "" {
+		return yamlContent
+	}
+	valuePath := servicePath + ".annotations"
+	if strings.Contains(yamlContent, valuePath) {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	metadataStart := slices.Index(lines, "metadata:")
+	if metadataStart < 0 {
+		return yamlContent
+	}
+	metadataEnd := metadataStart + 1
+	for metadataEnd < len(lines) && (lines[metadataEnd] == "" || strings.HasPrefix(lines[metadataEnd], " ")) {
+		metadataEnd++
+	}
+
+	annotationsStart := -1
+	for i := metadataStart + 1; i < metadataEnd; i++ {
+		if lines[i] == "  annotations:" {
+			annotationsStart = i
+			break
+		}
+	}
+
+	var block []string
+	insertAt := metadataEnd
+	if annotationsStart < 0 {
+		block = []string{
+			"  {{- with " + valuePath + " }}",
+			"  annotations:",
+			"    {{- toYaml . | nindent 4 }}",
+			"  {{- end }}",
+		}
+	} else {
+		var existingKeys []string
+		insertAt = annotationsStart + 1
+		for insertAt < metadataEnd && strings.HasPrefix(lines[insertAt], "    ") {
+			if m := serviceAnnotationKeyPattern.FindStringSubmatch(lines[insertAt]); m != nil {
+				existingKeys = append(existingKeys, m[1])
+			}
+			insertAt++
+		}
+		block = appendHelmMapBlock(nil, "    ", valuePath, existingKeys)
+	}
+
+	result := make([]string, 0, len(lines)+len(block))
+	result = append(result, lines[:insertAt]...)
+	result = append(result, block...)
+	result = append(result, lines[insertAt:]...)
+	return strings.Join(result, "\n")
+}
+
+// exposedServiceTypes are the Service types reachable from outside the cluster.
+const exposedServiceTypes = `(list "LoadBalancer" "NodePort")`
+
+// TemplateMetricsServiceExposure templates the metrics Service type from metrics.service.type and,
+// for LoadBalancer and NodePort Services, adds metrics.service.externalTrafficPolicy and
+// metrics.service.loadBalancerSourceRanges. The type defaults to the one in the manifest.
+func TemplateMetricsServiceExposure(yamlContent string, resource *unstructured.Unstructured) string {
+	if resource.GetKind() != common.KindService || !strings.HasSuffix(resource.GetName(), "-metrics-service") {
+		return yamlContent
+	}
+	servicePath := serviceValuesPath(resource)
+	if strings.Contains(yamlContent, servicePath+".type") {
+		return yamlContent
+	}
+
+	lines := strings.Split(yamlContent, "\n")
+	specStart := slices.Index(lines, "spec:")
+	if specStart < 0 {
+		return yamlContent
+	}
+
+	serviceType := "ClusterIP"
+	result := make([]string, 0, len(lines)+10)
+	for i, line := range lines {
+		if i > specStart && strings.HasPrefix(line, "  type: ") {
+			serviceType = strings.TrimSpace(strings.TrimPrefix(line, "  type: "))
+			continue
+		}
+		result = append(result, line)
+	}
+
+	typeValue := fmt.Sprintf("%s.type | default %q", servicePath, serviceType)
+	block := []string{
+		"  type: {{ " + typeValue + " }}",
+		"  {{- if has (" + typeValue + ") " + exposedServiceTypes + " }}",
+		"  {{- with " + servicePath + ".externalTrafficPolicy }}",
+		"  externalTrafficPolicy: {{ . }}",
+		"  {{- end }}",
+		"  {{- with " + servicePath + ".loadBalancerSourceRanges }}",
+		"  loadBalancerSourceRanges:",
+		"    {{- toYaml . | nindent 4 }}",
+		"  {{- end }}",
+		"  {{- end }}",
+	}
+	return strings.Join(slices.Insert(result, specStart+1, block...), "\n")
+}
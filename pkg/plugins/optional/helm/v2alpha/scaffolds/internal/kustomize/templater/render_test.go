@@ -0,0 +1,79 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templater
+
+import (
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+
+	charttemplates "sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/templates/chart-templates"
+)
+
+const renderedTemplateName = "templates/rendered.yaml"
+
+// renderTemplate renders a templated snippet with the Helm engine so tests can assert on the
+// output a user would get, not only on the template text. The default chart helpers are
+// available to the snippet.
+func renderTemplate(templated string, values map[string]any) (string, error) {
+	return renderTemplateWithHelpers(charttemplates.HelpersContent(testProjectName), templated, values)
+}
+
+// renderTemplateWithHelpers is renderTemplate with the given _helpers.tpl content, e.g. the helpers
+// generated by a Templater for its Options.
+func renderTemplateWithHelpers(helpers, templated string, values map[string]any) (string, error) {
+	rendered, err := renderChart(map[string]string{
+		"templates/_helpers.tpl": helpers,
+		renderedTemplateName:     templated,
+	}, values)
+	if err != nil {
+		return "", err
+	}
+	return rendered[renderedTemplateName], nil
+}
+
+// renderChart renders the chart templates, keyed by their path in the chart (e.g.
+// "templates/_helpers.tpl"), and returns the output keyed by the same paths.
+func renderChart(templates map[string]string, values map[string]any) (map[string]string, error) {
+	testChart := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       testProjectName,
+			Version:    "0.1.0",
+			AppVersion: "0.1.0",
+		},
+	}
+	for name, data := range templates {
+		testChart.Templates = append(testChart.Templates, &chart.File{Name: name, Data: []byte(data)})
+	}
+
+	renderValues, err := chartutil.ToRenderValues(testChart, values,
+		chartutil.ReleaseOptions{Name: "my-release", Namespace: "my-namespace"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := engine.Render(testChart, renderValues)
+	if err != nil {
+		return nil, err
+	}
+	output := make(map[string]string, len(templates))
+	for name := range templates {
+		output[name] = rendered[testProjectName+"/"+name]
+	}
+	return output, nil
+}
@@ -17,10 +17,18 @@ limitations under the License.
 package templater
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"sigs.k8s.io/kubebuilder/v4/pkg/machinery"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/kustomize/templater/appliers"
+	charttemplates "sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/templates/chart-templates"
 )
 
 // TemplatedResource represents a single templated resource.
@@ -43,59 +51,258 @@ type Templater struct {
 	chartName        string
 	managerNamespace string
 	roleNamespaces   map[string]string
+	// configMapTemplates are the chart templates, relative to the templates directory, holding the
+	// ConfigMaps hashed into the manager pod template's checksum/config annotation.
+	configMapTemplates []string
+	// deploymentValuesKeys maps the Deployments shipped next to the manager, by name, to the values key
+	// holding their settings.
+	deploymentValuesKeys map[string]string
+	// report records which steps changed the last resource; nil unless reporting is enabled.
+	report map[string]bool
+	// options are the Options the Templater was created with.
+	options Options
+}
+
+// Options configures a Templater. The zero value keeps the default Helm behavior.
+type Options struct {
+	// RecordReport makes every ApplyHelmSubstitutions call record which substitution steps changed
+	// the resource, available afterwards from Report.
+	RecordReport bool
+	// ManagedBy replaces {{ .Release.Service }} as the app.kubernetes.io/managed-by label value, e.g.
+	// for a GitOps tool that expects its own name there.
+	ManagedBy string
+	// KeepManagedBy leaves the app.kubernetes.io/managed-by labels from the kustomize output as they
+	// are and keeps the label out of the standard labels helper. It takes precedence over ManagedBy.
+	KeepManagedBy bool
+	// FlatValues reads the manager image from the top-level image key (image.repository, image.tag,
+	// ...) instead of manager.image, which is shorter to override with helm --set.
+	FlatValues bool
+	// HelperRBAC names RBAC resources, as in the kustomize output, that are rendered only when
+	// rbac.helpers.enabled, in addition to the scaffolded admin, editor and viewer roles.
+	HelperRBAC []string
+	// EssentialRBAC names RBAC resources, as in the kustomize output, that are always rendered, even
+	// when their name matches a helper or metrics role. It takes precedence over HelperRBAC.
+	EssentialRBAC []string
+	// KeepNamespace keeps the Namespace from the kustomize output, named after the release namespace
+	// and rendered only when namespace.create is set, instead of dropping it. namespace.labels adds
+	// labels to it.
+	KeepNamespace bool
+	// OmitCertManager drops the cert-manager Certificates and Issuers, rendering them as nothing
+	// instead of behind certManager.enabled, for charts that never ship cert-manager resources.
+	OmitCertManager bool
+	// OmitCRDs drops the CustomResourceDefinitions, rendering them as nothing instead of behind
+	// crd.enabled, for charts whose CRDs are installed by a separate chart or operator.
+	OmitCRDs bool
+	// CanonicalKeyOrder moves the top-level keys of every templated resource into the order apiVersion,
+	// kind, metadata, spec, followed by the other keys, instead of the alphabetical kustomize order.
+	CanonicalKeyOrder bool
+	// UnquotedImage renders the templated image references without the surrounding double quotes,
+	// for yamllint configurations that reject quoted strings.
+	UnquotedImage bool
+	// RequireImageRepository renders the manager image repository with Helm's required function
+	// instead of defaulting it to "controller", for distributions that must set image.repository.
+	RequireImageRepository bool
+	// ArgsAsYAML renders the manager.args values with toYaml instead of one "- {{ . }}" item per
+	// arg, so args holding spaces or YAML special characters are quoted by Helm.
+	ArgsAsYAML bool
+	// RevisionAnnotation adds an app.kubernetes.io/revision annotation set to .Values.revision to every
+	// templated resource, for GitOps tooling tracking deploys. Nothing is rendered while revision is unset.
+	RevisionAnnotation bool
+	// ProvenanceAnnotations adds app.kubernetes.io/part-of, set to .Values.partOf or the project name,
+	// and helm.sh/chart annotations to every templated resource, for catalogs tracking where resources
+	// come from.
+	ProvenanceAnnotations bool
+	// ReleaseSelectorLabels makes Service selectors match the release instance through the
+	// selectorLabels helper, so two releases of the chart in one namespace do not select each other's
+	// pods. Workload selectors are immutable and stay as they are.
+	ReleaseSelectorLabels bool
 }
 
+// NewTemplater creates a Templater configured by opts.
 func NewTemplater(
-	detectedPrefix, chartName, managerNamespace string, roleNamespaces map[string]string,
+	detectedPrefix, chartName, managerNamespace string, roleNamespaces map[string]string, opts Options,
 ) *Templater {
-	return &Templater{
+	t := &Templater{
 		detectedPrefix:   detectedPrefix,
 		chartName:        chartName,
 		managerNamespace: managerNamespace,
 		roleNamespaces:   roleNamespaces,
+		options:          opts,
+	}
+	if opts.RecordReport {
+		t.report = map[string]bool{}
+	}
+	return t
+}
+
+// classifyRBAC applies HelperRBAC and EssentialRBAC to the RBAC resource called name.
+func (t *Templater) classifyRBAC(name string) appliers.RBACClass {
+	switch {
+	case slices.Contains(t.options.EssentialRBAC, name):
+		return appliers.RBACClassEssential
+	case slices.Contains(t.options.HelperRBAC, name):
+		return appliers.RBACClassHelper
+	default:
+		return appliers.RBACClassDefault
+	}
+}
+
+// Report returns, for the last ApplyHelmSubstitutions call, whether each substitution step
+// changed the content, keyed by step name. It returns nil when reporting was not enabled.
+func (t *Templater) Report() map[string]bool {
+	if t.report == nil {
+		return nil
+	}
+	return maps.Clone(t.report)
+}
+
+// recorder returns the StepRecorder feeding the report, or nil when reporting is disabled.
+func (t *Templater) recorder() appliers.StepRecorder {
+	if t.report == nil {
+		return nil
+	}
+	return func(step string, changed bool) {
+		t.report[step] = t.report[step] || changed
 	}
 }
 
+// SetConfigMapTemplates sets the chart templates holding the ConfigMaps, relative to the chart
+// templates directory (e.g. "extras/manager-config.yaml"). When set, the manager Deployment pod
+// template gets a checksum/config annotation over them so config changes roll the manager pods.
+func (t *Templater) SetConfigMapTemplates(paths []string) {
+	t.configMapTemplates = slices.Clone(paths)
+}
+
+// SetDeploymentValuesKeys sets, by Deployment name, the values keys of the Deployments shipped next to
+// the manager, e.g. when a project runs several controllers. Each of them reads its replicas, image
+// and resources from .Values.<key> instead of being copied as is; the manager keeps .Values.manager.
+func (t *Templater) SetDeploymentValuesKeys(keys map[string]string) {
+	t.deploymentValuesKeys = maps.Clone(keys)
+}
+
+// templatePodTemplateChecksum adds the checksum/config annotation to the pod template of the
+// manager Deployment when the chart ships ConfigMaps.
+func (t *Templater) templatePodTemplateChecksum(yamlContent string) string {
+	return appliers.AddConfigChecksumAnnotation(yamlContent, t.configMapTemplates)
+}
+
+// managedBy returns the app.kubernetes.io/managed-by label value written by the substitutions, or
+// "" when the labels from the kustomize output are kept.
+func (t *Templater) managedBy() string {
+	switch {
+	case t.options.KeepManagedBy:
+		return ""
+	case t.options.ManagedBy != "":
+		return t.options.ManagedBy
+	}
+	return "{{ .Release.Service }}"
+}
+
+// GenerateHelpers returns the _helpers.tpl content defining the helpers the templated resources
+// include, such as <chartName>.name, <chartName>.resourceName and <chartName>.namespaceName. It is
+// the file the chart scaffold writes, so a Templater used on its own can ship a self-consistent chart.
+// The labels helper follows the managed-by Options, and the annotation helpers are defined when the
+// Options include them.
+func (t *Templater) GenerateHelpers() string {
+	helpers := &charttemplates.HelmHelpers{
+		ProjectNameMixin:      machinery.ProjectNameMixin{ProjectName: t.chartName},
+		ManagedBy:             t.options.ManagedBy,
+		OmitManagedBy:         t.options.KeepManagedBy,
+		RevisionAnnotation:    t.options.RevisionAnnotation,
+		ProvenanceAnnotations: t.options.ProvenanceAnnotations,
+	}
+	return helpers.Content()
+}
+
 // GetManagerNamespace returns the manager namespace.
 func (t *Templater) GetManagerNamespace() string {
 	return t.managerNamespace
 }
 
 // ApplyHelmSubstitutions applies Helm template syntax to a single resource.
-// This is the main transformation orchestrator that coordinates all template substitutions.
+// It is ApplyHelmSubstitutionsE without the error, kept for callers that only need the output.
 func (t *Templater) ApplyHelmSubstitutions(yamlContent string, resource *unstructured.Unstructured) string {
-	yamlContent = appliers.EscapeExistingTemplateSyntax(yamlContent)
-	yamlContent = appliers.AddConditionalWrappers(yamlContent, resource)
-	yamlContent = appliers.SubstituteProjectNames(yamlContent, resource)
-	yamlContent = appliers.SubstituteNamespace(
-		t.detectedPrefix, t.chartName, t.managerNamespace, t.roleNamespaces, yamlContent, resource)
-	yamlContent = appliers.SubstituteCertManagerReferences(t.detectedPrefix, t.chartName, yamlContent, resource)
-	yamlContent = appliers.SubstituteResourceNamesWithPrefix(t.detectedPrefix, t.chartName, yamlContent, resource)
-	yamlContent = appliers.AddHelmLabelsAndAnnotations(t.detectedPrefix, t.chartName, yamlContent, resource)
-	yamlContent = appliers.SubstituteRBACValues(t.detectedPrefix, t.chartName, yamlContent)
-	if resource.GetKind() == common.KindServiceAccount {
-		yamlContent = appliers.TemplateServiceAccount(t.detectedPrefix, t.chartName, yamlContent)
-	}
-	if resource.GetKind() == common.KindDeployment && appliers.IsManagerDeployment(resource) {
-		yamlContent = appliers.AddCustomLabelsAndAnnotations(yamlContent)
-		yamlContent = appliers.TemplateDeploymentFields(t.detectedPrefix, t.chartName, yamlContent)
-		yamlContent = appliers.MakeContainerArgsConditional(yamlContent)
-		yamlContent = appliers.MakeWebhookVolumeMountsConditional(yamlContent)
-		yamlContent = appliers.MakeWebhookVolumesConditional(yamlContent)
-		yamlContent = appliers.MakeMetricsVolumeMountsConditional(yamlContent)
-		yamlContent = appliers.MakeMetricsVolumesConditional(yamlContent)
-	}
-	if resource.GetKind() == common.KindService ||
-		resource.GetKind() == common.KindDeployment ||
-		resource.GetKind() == common.KindNetworkPolicy {
-		yamlContent = appliers.TemplatePorts(yamlContent, resource)
-	}
-	if resource.GetKind() == common.KindServiceMonitor {
-		yamlContent = appliers.TemplateServiceMonitor(yamlContent)
-	}
-	yamlContent = appliers.CollapseBlankLineAfterIf(yamlContent)
-
-	return yamlContent
+	templated, _ := t.ApplyHelmSubstitutionsE(yamlContent, resource)
+	return templated
+}
+
+// ApplyHelmSubstitutionsE applies Helm template syntax to a single resource.
+// This is the main transformation orchestrator: it normalizes the input, runs the configured
+// ResourceTransformers in order and cleans up the result. The templated output is always
+// returned; the error reports substitutions that were expected but could not be applied, such as
+// a manager Deployment without its manager container.
+func (t *Templater) ApplyHelmSubstitutionsE(
+	yamlContent string, resource *unstructured.Unstructured,
+) (string, error) {
+	// The appliers scan line by line on "\n"; normalize CRLF so no "\r" leaks into the templates.
+	yamlContent = strings.ReplaceAll(yamlContent, "\r\n", "\n")
+	isManagerDeployment := resource.GetKind() == common.KindDeployment && appliers.IsManagerDeployment(resource)
+	var managerErr error
+	if isManagerDeployment {
+		managerErr = checkManagerContainer(yamlContent)
+	}
+	if t.report != nil {
+		clear(t.report)
+	}
+	record := t.recorder()
+	yamlContent = appliers.ApplyStep(record, "EscapeExistingTemplateSyntax", yamlContent,
+		appliers.EscapeExistingTemplateSyntax)
+	var dataPayload []string
+	if resource.GetKind() == common.KindSecret || resource.GetKind() == common.KindConfigMap {
+		// Secret and ConfigMap data is opaque to the chart: keep it out of the label, annotation and
+		// name rewrites.
+		yamlContent, dataPayload = appliers.ExtractDataPayload(yamlContent)
+	}
+	// Block scalar bodies are text, not YAML: keep them out of the line-based substitutions.
+	yamlContent, blockScalars := appliers.ExtractBlockScalars(yamlContent)
+	for i, transformer := range t.DefaultTransformers() {
+		yamlContent = appliers.ApplyStep(record, transformerName(transformer, i), yamlContent,
+			func(content string) string { return transformer.Transform(content, resource) })
+	}
+	yamlContent = appliers.RestoreBlockScalars(yamlContent, blockScalars)
+	for i, block := range dataPayload {
+		// Namespace references in the payload (e.g. service DNS names) still follow the release.
+		block = appliers.SubstituteNamespace(
+			t.detectedPrefix, t.chartName, t.managerNamespace, t.roleNamespaces, block, resource)
+		if resource.GetKind() == common.KindConfigMap {
+			block = appliers.TemplateConfigMapData(block)
+		}
+		dataPayload[i] = block
+	}
+	yamlContent = appliers.RestoreDataPayload(yamlContent, dataPayload)
+	if t.options.CanonicalKeyOrder {
+		yamlContent = appliers.ApplyStep(record, "OrderTopLevelKeys", yamlContent, appliers.OrderTopLevelKeys)
+	}
+	if t.options.RequireImageRepository {
+		yamlContent = appliers.ApplyStep(record, "RequireImageRepository", yamlContent, appliers.RequireImageRepository)
+	}
+	if t.options.UnquotedImage {
+		yamlContent = appliers.ApplyStep(record, "UnquoteImageReference", yamlContent, appliers.UnquoteImageReference)
+	}
+	yamlContent = appliers.ApplyStep(record, "CollapseBlankLinesAroundDirectives", yamlContent,
+		appliers.CollapseBlankLinesAroundDirectives)
+
+	imageValuesPath := appliers.ManagerImageValuesPath
+	if t.options.FlatValues {
+		imageValuesPath = appliers.FlatImageValuesPath
+	}
+	if isManagerDeployment && managerErr == nil && !strings.Contains(yamlContent, imageValuesPath+".repository") {
+		managerErr = errors.New("the manager container image was not templated")
+	}
+	if managerErr != nil {
+		return yamlContent, fmt.Errorf("deployment %q: %w", resource.GetName(), managerErr)
+	}
+	return yamlContent, nil
+}
+
+// checkManagerContainer reports an error when the manager Deployment has no container matching
+// the default container name, since every manager.* value is applied to that container.
+func checkManagerContainer(yamlContent string) error {
+	if start, _ := appliers.FindManagerContainerRange(yamlContent); start < 0 {
+		return fmt.Errorf("no container named %q found; manager values will not be applied",
+			appliers.GetDefaultContainerName(yamlContent))
+	}
+	return nil
 }
 
 // templatePorts is a wrapper for testing purposes, exposing the appliers.TemplatePorts function
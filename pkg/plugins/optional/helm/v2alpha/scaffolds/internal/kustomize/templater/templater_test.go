@@ -17,12 +17,15 @@ limitations under the License.
 package templater
 
 import (
+	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -39,6 +42,13 @@ const (
 	expectedIssuerName = `name: {{ include "test-project.resourceName" (dict "suffix" "selfsigned-issuer" "context" $) }}`
 
 	k8sSpecField = "spec"
+
+	// expectedManagerImageLine is the templated image reference emitted for the manager container.
+	expectedManagerImageLine = `image: "{{ with (.Values.global | default dict).imageRegistry }}{{ . }}/{{ end }}` +
+		`{{ .Values.manager.image.repository | default "controller" }}` +
+		`{{- if .Values.manager.image.digest }}@{{ .Values.manager.image.digest }}` +
+		`{{- else if not (contains "@" (.Values.manager.image.repository | default "controller")) }}` +
+		`:{{ .Values.manager.image.tag | default .Chart.AppVersion }}{{- end }}"`
 )
 
 var _ = Describe("Templater", func() {
@@ -53,8 +63,6 @@ var _ = Describe("Templater", func() {
 		}
 	})
 
-	// No global labels injection is performed by v2-alpha
-
 	Context("basic template processing", func() {
 		It("should replace kustomize managed-by labels with Helm equivalents", func() {
 			deploymentResource := &unstructured.Unstructured{}
@@ -79,8 +87,9 @@ spec:
 
 			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should replace kustomize managed-by with Helm template
-			Expect(result).To(ContainSubstring("app.kubernetes.io/managed-by: {{ .Release.Service }}"))
+			// Should replace kustomize managed-by with the standard Helm labels helper
+			Expect(result).To(ContainSubstring("  labels:\n    {{- include \"test-project.labels\" . | nindent 4 }}\n"))
+			Expect(result).NotTo(ContainSubstring("managed-by: kustomize"))
 			// Should replace app.kubernetes.io/name with chart name template
 			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
 			Expect(result).To(ContainSubstring("control-plane: controller-manager"))
@@ -88,7 +97,7 @@ spec:
 			// Should substitute namespace
 			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
 
-			// Should NOT add extra Helm metadata injection
+			// Should only include the chart-scoped helper
 			Expect(result).NotTo(ContainSubstring(`{{- include "chart.labels"`))
 			Expect(result).NotTo(ContainSubstring(`{{- include "chart.annotations"`))
 		})
@@ -206,16 +215,160 @@ spec:
 			Expect(result).To(ContainSubstring("{{- range .Values.manager.args }}"))
 			Expect(result).NotTo(ContainSubstring("BUSYBOX_IMAGE"))
 			Expect(result).NotTo(ContainSubstring("MEMCACHED_IMAGE"))
+			Expect(result).To(ContainSubstring(expectedManagerImageLine))
 			Expect(result).To(ContainSubstring(
-				`image: "{{ .Values.manager.image.repository | default "controller" }}` +
-					`{{- if not (contains "@" (.Values.manager.image.repository | default "controller")) }}` +
-					`:{{ .Values.manager.image.tag | default .Chart.AppVersion }}{{- end }}"`))
-			Expect(result).To(ContainSubstring(`{{- with .Values.manager.image.pullPolicy }}
-        imagePullPolicy: {{ . }}
-        {{- end }}`))
+				`imagePullPolicy: {{ .Values.manager.image.pullPolicy | default "IfNotPresent" }}`))
 			Expect(result).NotTo(ContainSubstring("controller:latest"))
 		})
 
+		It("should carry comments in the args list through to the template", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        # Serve metrics over HTTPS
+        - --metrics-bind-address=:8443
+        # Probes are served on a dedicated port
+        - --health-probe-bind-address=:8081
+        # Only one replica reconciles at a time
+        - --leader-elect
+        # Certificates are mounted by cert-manager
+        - --webhook-cert-path=/tmp/k8s-webhook-server/serving-certs
+        # Trailing note
+        command:
+        - /manager
+        image: controller:latest
+        name: manager`
+
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
+
+			Expect(result).To(ContainSubstring(`{{- if .Values.metrics.enabled }}
+        # Serve metrics over HTTPS
+        - --metrics-bind-address=:{{ .Values.metrics.port }}`))
+			Expect(result).To(ContainSubstring(`        # Probes are served on a dedicated port
+        - --health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}`))
+			Expect(result).To(ContainSubstring(`        {{- if or (not (hasKey (.Values.manager.leaderElection | default dict) "enabled")) .Values.manager.leaderElection.enabled }}
+        # Only one replica reconciles at a time
+        - --leader-elect
+        {{- end }}`))
+			Expect(result).To(ContainSubstring(`        # Certificates are mounted by cert-manager
+        {{- if or .Values.certManager.enabled (.Values.webhook | default dict).caBundle }}
+        - --webhook-cert-path=/tmp/k8s-webhook-server/serving-certs
+        {{- end }}
+        # Trailing note
+        command:`))
+		})
+
+		It("should render --leader-elect unless manager.leaderElection.enabled is false", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --leader-elect
+        - --health-probe-bind-address=:8081
+        command:
+        - /manager
+        name: manager`
+
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
+			Expect(result).To(ContainSubstring(`        {{- if or (not (hasKey (.Values.manager.leaderElection | default dict) "enabled")) .Values.manager.leaderElection.enabled }}
+        - --leader-elect
+        {{- end }}`))
+			Expect(strings.Count(result, "- --leader-elect")).To(Equal(1))
+
+			render := func(manager map[string]any) string {
+				GinkgoHelper()
+				manager["healthProbe"] = map[string]any{"port": 8081}
+				rendered, err := renderTemplate(result, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
+			Expect(render(map[string]any{})).To(ContainSubstring("- --leader-elect\n"))
+			Expect(render(map[string]any{"leaderElection": map[string]any{"enabled": true}})).To(
+				ContainSubstring("- --leader-elect\n"))
+			Expect(render(map[string]any{"leaderElection": map[string]any{"enabled": false}})).NotTo(
+				ContainSubstring("--leader-elect"))
+		})
+
+		It("should serve metrics over https or plain http following metrics.secure", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --metrics-bind-address=:8443
+        - --health-probe-bind-address=:8081
+        - --metrics-cert-path=/tmp/k8s-metrics-server/metrics-certs
+        command:
+        - /manager
+        name: manager
+        volumeMounts:
+        - mountPath: /tmp/k8s-metrics-server/metrics-certs
+          name: metrics-certs
+          readOnly: true
+      volumes:
+      - name: metrics-certs
+        secret:
+          secretName: metrics-server-cert`
+
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
+
+			render := func(secure, certManager bool) string {
+				GinkgoHelper()
+				rendered, err := renderTemplate(result, map[string]any{
+					"manager":     map[string]any{"healthProbe": map[string]any{"port": 8081}},
+					"metrics":     map[string]any{"enabled": true, "secure": secure, "port": 8443},
+					"certManager": map[string]any{"enabled": certManager},
+					"rbac":        map[string]any{},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
+
+			secure := render(true, true)
+			Expect(secure).To(ContainSubstring("- --metrics-bind-address=:8443\n"))
+			Expect(secure).NotTo(ContainSubstring("--metrics-secure=false"))
+			Expect(secure).To(ContainSubstring("- --metrics-cert-path=/tmp/k8s-metrics-server/metrics-certs\n"))
+			Expect(secure).To(ContainSubstring("- mountPath: /tmp/k8s-metrics-server/metrics-certs\n"))
+			Expect(secure).To(ContainSubstring("- name: metrics-certs\n"))
+
+			insecure := render(false, true)
+			Expect(insecure).To(ContainSubstring("- --metrics-bind-address=:8443\n"))
+			Expect(insecure).To(ContainSubstring("- --metrics-secure=false\n"))
+			Expect(insecure).NotTo(ContainSubstring("--metrics-cert-path"))
+			Expect(insecure).NotTo(ContainSubstring("metrics-certs"))
+
+			// Without cert-manager, controller-runtime serves the secure metrics with a self-signed certificate.
+			selfSigned := render(true, false)
+			Expect(selfSigned).To(ContainSubstring("- --metrics-bind-address=:8443\n"))
+			Expect(selfSigned).NotTo(ContainSubstring("--metrics-secure=false"))
+			Expect(selfSigned).NotTo(ContainSubstring("--metrics-cert-path"))
+			Expect(selfSigned).NotTo(ContainSubstring("metrics-certs"))
+		})
+
 		It("should not template a webhook port when the project has no webhook", func() {
 			deploymentResource := &unstructured.Unstructured{}
 			deploymentResource.SetAPIVersion("apps/v1")
@@ -263,7 +416,8 @@ spec:
 			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
 			// Should have conditional blocks for webhook certs
-			Expect(result).To(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+			Expect(result).To(ContainSubstring(
+				"{{- if or .Values.certManager.enabled (.Values.webhook | default dict).caBundle }}"))
 			Expect(result).To(ContainSubstring("mountPath: /tmp/k8s-webhook-server/serving-certs"))
 
 			// Should have conditional blocks for metrics certs
@@ -448,7 +602,7 @@ spec:
 			Expect(result).NotTo(ContainSubstring("type: RollingUpdate"))
 		})
 
-		It("should template priorityClassName", func() {
+		It("should insert progressDeadlineSeconds next to replicas and strategy", func() {
 			deploymentResource := &unstructured.Unstructured{}
 			deploymentResource.SetAPIVersion("apps/v1")
 			deploymentResource.SetKind("Deployment")
@@ -457,20 +611,37 @@ spec:
 			content := `apiVersion: apps/v1
 kind: Deployment
 spec:
+  replicas: 1
+  strategy:
+    type: Recreate
   template:
     spec:
-      priorityClassName: high-priority
       containers:
       - name: manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.priorityClassName }}"))
-			Expect(result).To(ContainSubstring("priorityClassName: {{ . | quote }}"))
-			Expect(result).NotTo(ContainSubstring("priorityClassName: high-priority"))
+			Expect(result).To(ContainSubstring(`
+  progressDeadlineSeconds: {{ .Values.manager.progressDeadlineSeconds | default 600 }}
+  replicas: {{ .Values.manager.replicas }}
+`))
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.strategy }}"))
+			Expect(strings.Count(result, "progressDeadlineSeconds")).To(Equal(2))
+
+			for value, expected := range map[any]string{nil: "600", 1200: "1200"} {
+				manager := map[string]any{"replicas": 1}
+				if value != nil {
+					manager["progressDeadlineSeconds"] = value
+				}
+				rendered, err := renderTemplate(
+					"progressDeadlineSeconds: {{ .Values.manager.progressDeadlineSeconds | default 600 }}",
+					map[string]any{"manager": manager})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rendered).To(Equal("progressDeadlineSeconds: " + expected))
+			}
 		})
 
-		It("should template topologySpreadConstraints", func() {
+		It("should rewrite progressDeadlineSeconds from the kustomize output", func() {
 			deploymentResource := &unstructured.Unstructured{}
 			deploymentResource.SetAPIVersion("apps/v1")
 			deploymentResource.SetKind("Deployment")
@@ -479,23 +650,22 @@ spec:
 			content := `apiVersion: apps/v1
 kind: Deployment
 spec:
+  progressDeadlineSeconds: 900
+  replicas: 1
   template:
     spec:
-      topologySpreadConstraints:
-      - maxSkew: 1
-        topologyKey: kubernetes.io/hostname
-        whenUnsatisfiable: DoNotSchedule
       containers:
       - name: manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.topologySpreadConstraints }}"))
-			Expect(result).To(ContainSubstring("topologySpreadConstraints: {{ toYaml . | nindent"))
-			Expect(result).NotTo(ContainSubstring("maxSkew: 1"))
+			Expect(result).To(ContainSubstring(
+				"\n  progressDeadlineSeconds: {{ .Values.manager.progressDeadlineSeconds | default 600 }}\n"))
+			Expect(result).NotTo(ContainSubstring("progressDeadlineSeconds: 900"))
+			Expect(strings.Count(result, "progressDeadlineSeconds")).To(Equal(2))
 		})
 
-		It("should template terminationGracePeriodSeconds with nil check", func() {
+		It("should insert minReadySeconds as the first Deployment spec field", func() {
 			deploymentResource := &unstructured.Unstructured{}
 			deploymentResource.SetAPIVersion("apps/v1")
 			deploymentResource.SetKind("Deployment")
@@ -504,24 +674,39 @@ spec:
 			content := `apiVersion: apps/v1
 kind: Deployment
 spec:
+  replicas: 1
+  strategy:
+    type: Recreate
   template:
     spec:
-      serviceAccountName: controller-manager
-      terminationGracePeriodSeconds: 10
       containers:
       - name: manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			Expect(result).To(ContainSubstring(
-				"{{- if and (hasKey .Values.manager \"terminationGracePeriodSeconds\")"))
-			Expect(result).To(ContainSubstring("(ne .Values.manager.terminationGracePeriodSeconds nil)"))
-			Expect(result).To(ContainSubstring(
-				"terminationGracePeriodSeconds: {{ .Values.manager.terminationGracePeriodSeconds }}"))
-			Expect(result).NotTo(ContainSubstring("terminationGracePeriodSeconds: 10"))
+			Expect(result).To(ContainSubstring(`spec:
+  minReadySeconds: {{ .Values.manager.minReadySeconds | default 0 }}
+  progressDeadlineSeconds: {{ .Values.manager.progressDeadlineSeconds | default 600 }}
+  replicas: {{ .Values.manager.replicas }}
+  {{- with .Values.manager.strategy }}
+`))
+			Expect(strings.Count(result, "minReadySeconds")).To(Equal(2))
+			Expect(templater.ApplyHelmSubstitutions(result, deploymentResource)).To(Equal(result))
+
+			for value, expected := range map[any]string{nil: "0", 10: "10"} {
+				manager := map[string]any{}
+				if value != nil {
+					manager["minReadySeconds"] = value
+				}
+				rendered, err := renderTemplate(
+					"minReadySeconds: {{ .Values.manager.minReadySeconds | default 0 }}",
+					map[string]any{"manager": manager})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rendered).To(Equal("minReadySeconds: " + expected))
+			}
 		})
 
-		It("should template terminationGracePeriodSeconds zero value", func() {
+		It("should rewrite minReadySeconds from the kustomize output", func() {
 			deploymentResource := &unstructured.Unstructured{}
 			deploymentResource.SetAPIVersion("apps/v1")
 			deploymentResource.SetKind("Deployment")
@@ -530,679 +715,732 @@ spec:
 			content := `apiVersion: apps/v1
 kind: Deployment
 spec:
+  minReadySeconds: 15
+  replicas: 1
   template:
     spec:
-      serviceAccountName: controller-manager
-      terminationGracePeriodSeconds: 0
       containers:
       - name: manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should use hasKey to support 0 values
-			Expect(result).To(ContainSubstring("hasKey .Values.manager \"terminationGracePeriodSeconds\""))
-			Expect(result).To(ContainSubstring(
-				"terminationGracePeriodSeconds: {{ .Values.manager.terminationGracePeriodSeconds }}"))
-			Expect(result).NotTo(ContainSubstring("terminationGracePeriodSeconds: 0"))
+			Expect(result).To(ContainSubstring(`
+  progressDeadlineSeconds: {{ .Values.manager.progressDeadlineSeconds | default 600 }}
+  minReadySeconds: {{ .Values.manager.minReadySeconds | default 0 }}
+  replicas: {{ .Values.manager.replicas }}
+`))
+			Expect(result).NotTo(ContainSubstring("minReadySeconds: 15"))
+			Expect(strings.Count(result, "minReadySeconds")).To(Equal(2))
 		})
-	})
-
-	Context("conditional wrapping", func() {
-		It("should add metrics conditional for ServiceMonitor resources", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
 
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor`
+		It("should not template minReadySeconds outside the manager Deployment", func() {
+			statefulSet := &unstructured.Unstructured{}
+			statefulSet.SetAPIVersion("apps/v1")
+			statefulSet.SetKind("StatefulSet")
+			statefulSet.SetName("test-project-controller-manager")
 
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+			content := `apiVersion: apps/v1
+kind: StatefulSet
+spec:
+  minReadySeconds: 15
+  template:
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager`
 
-			// Should be wrapped with prometheus enabled conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.prometheus.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			result := templater.ApplyHelmSubstitutions(content, statefulSet)
+			Expect(result).To(ContainSubstring("  minReadySeconds: 15\n"))
+			Expect(result).NotTo(ContainSubstring(".Values.manager.minReadySeconds"))
 		})
 
-		It("should add networkPolicy conditional for NetworkPolicy resources", func() {
-			networkPolicyResource := &unstructured.Unstructured{}
-			networkPolicyResource.SetAPIVersion("networking.k8s.io/v1")
-			networkPolicyResource.SetKind("NetworkPolicy")
-			networkPolicyResource.SetName("allow-metrics-traffic")
+		It("should not add progressDeadlineSeconds to other workloads", func() {
+			daemonSetResource := &unstructured.Unstructured{}
+			daemonSetResource.SetAPIVersion("apps/v1")
+			daemonSetResource.SetKind("DaemonSet")
+			daemonSetResource.SetName("test-project-node-agent")
 
-			content := `apiVersion: networking.k8s.io/v1
-kind: NetworkPolicy
+			content := `apiVersion: apps/v1
+kind: DaemonSet
 metadata:
-  name: allow-metrics-traffic
-  namespace: test-system
+  name: test-project-node-agent
 spec:
-  podSelector:
-    matchLabels:
-      control-plane: controller-manager`
+  template:
+    spec:
+      containers:
+      - name: manager`
 
-			result := templater.ApplyHelmSubstitutions(content, networkPolicyResource)
+			result := templater.ApplyHelmSubstitutions(content, daemonSetResource)
 
-			Expect(result).To(ContainSubstring("{{- if .Values.networkPolicy.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			Expect(result).NotTo(ContainSubstring("progressDeadlineSeconds"))
 		})
 
-		It("should add webhook conditional for webhook NetworkPolicy resources", func() {
-			networkPolicyResource := &unstructured.Unstructured{}
-			networkPolicyResource.SetAPIVersion("networking.k8s.io/v1")
-			networkPolicyResource.SetKind("NetworkPolicy")
-			networkPolicyResource.SetName("allow-webhook-traffic")
+		It("should template priorityClassName", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: networking.k8s.io/v1
-kind: NetworkPolicy
-metadata:
-  name: allow-webhook-traffic
-  namespace: test-system
+			content := `apiVersion: apps/v1
+kind: Deployment
 spec:
-  podSelector:
-    matchLabels:
-      control-plane: controller-manager`
+  template:
+    spec:
+      priorityClassName: high-priority
+      containers:
+      - name: manager`
 
-			result := templater.ApplyHelmSubstitutions(content, networkPolicyResource)
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			Expect(result).To(ContainSubstring("{{- if and .Values.networkPolicy.enabled .Values.webhook.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.priorityClassName }}"))
+			Expect(result).To(ContainSubstring("priorityClassName: {{ . | quote }}"))
+			Expect(result).NotTo(ContainSubstring("priorityClassName: high-priority"))
 		})
 
-		It("should not wrap NetworkPolicy with wrong apiVersion", func() {
-			networkPolicyResource := &unstructured.Unstructured{}
-			networkPolicyResource.SetAPIVersion("acme.io/v1")
-			networkPolicyResource.SetKind("NetworkPolicy")
-			networkPolicyResource.SetName("custom-policy")
+		It("should guard hostNetwork and dnsPolicy behind manager.hostNetwork", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: acme.io/v1
-kind: NetworkPolicy
-metadata:
-  name: custom-policy`
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager`
 
-			result := templater.ApplyHelmSubstitutions(content, networkPolicyResource)
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			Expect(result).NotTo(ContainSubstring("{{- if .Values.networkPolicy.enabled }}"))
+			hostNetworkBlock := "      {{- if .Values.manager.hostNetwork }}\n" +
+				"      hostNetwork: {{ .Values.manager.hostNetwork }}\n" +
+				"      dnsPolicy: {{ .Values.manager.dnsPolicy | default \"ClusterFirstWithHostNet\" }}\n" +
+				"      {{- end }}\n"
+			Expect(result).To(ContainSubstring(hostNetworkBlock))
+
+			By("rendering nothing when hostNetwork is unset or false")
+			for _, manager := range []map[string]any{{}, {"hostNetwork": false}} {
+				rendered, err := renderTemplate(hostNetworkBlock, map[string]any{"manager": manager})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rendered).NotTo(ContainSubstring("hostNetwork"))
+				Expect(rendered).NotTo(ContainSubstring("dnsPolicy"))
+			}
+
+			By("rendering hostNetwork with the host-network DNS policy when enabled")
+			rendered, err := renderTemplate(hostNetworkBlock, map[string]any{"manager": map[string]any{"hostNetwork": true}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(ContainSubstring("      hostNetwork: true\n      dnsPolicy: ClusterFirstWithHostNet\n"))
+
+			By("honoring an explicit dnsPolicy")
+			rendered, err = renderTemplate(hostNetworkBlock, map[string]any{
+				"manager": map[string]any{"hostNetwork": true, "dnsPolicy": "Default"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(ContainSubstring("dnsPolicy: Default"))
 		})
 
-		It("should template ServiceMonitor port and scheme based on metrics.secure", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+		It("should template the sizeLimit of emptyDir volumes from manager.scratchSizeLimit", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor
+			content := `apiVersion: apps/v1
+kind: Deployment
 spec:
-  endpoints:
-  - port: https
-    scheme: https`
+  template:
+    spec:
+      containers:
+      - name: manager
+      volumes:
+      - emptyDir: {}
+        name: scratch
+      - emptyDir:
+          medium: Memory
+          sizeLimit: 64Mi
+        name: cache
+      - name: metrics-certs
+        secret:
+          secretName: metrics-server-cert`
 
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Port should be templated to conditional
-			Expect(result).To(ContainSubstring("port: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"))
-			Expect(result).NotTo(ContainSubstring("port: https"))
+			Expect(result).To(ContainSubstring(`        - emptyDir:
+            {{- with .Values.manager.scratchSizeLimit }}
+            sizeLimit: {{ . }}
+            {{- else }}
+            {}
+            {{- end }}
+          name: scratch
+`))
+			Expect(result).To(ContainSubstring(
+				"            sizeLimit: {{ .Values.manager.scratchSizeLimit | default \"64Mi\" }}\n"))
+			Expect(result).To(ContainSubstring("secretName: {{ .Values.metrics.certSecretName | default"))
+			Expect(strings.Count(result, "scratchSizeLimit")).To(Equal(2))
+			Expect(templater.ApplyHelmSubstitutions(result, deploymentResource)).To(Equal(result))
+
+			volumes := func(manager map[string]any) []any {
+				GinkgoHelper()
+				manager["image"] = map[string]any{"repository": "controller"}
+				rendered, err := renderTemplate(result, map[string]any{
+					"manager":     manager,
+					"rbac":        map[string]any{},
+					"certManager": map[string]any{"enabled": false},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				var deployment map[string]any
+				Expect(yaml.Unmarshal([]byte(rendered), &deployment)).To(Succeed())
+				spec, _, _ := unstructured.NestedMap(deployment, "spec", "template", "spec")
+				return spec["volumes"].([]any)
+			}
 
-			// Scheme should be templated to conditional
-			Expect(result).To(ContainSubstring("scheme: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"))
-			Expect(result).NotTo(ContainSubstring("scheme: https"))
+			By("keeping the manifest limits while the value is unset")
+			rendered := volumes(map[string]any{})
+			Expect(rendered[0]).To(Equal(map[string]any{"name": "scratch", "emptyDir": map[string]any{}}))
+			Expect(rendered[1]).To(HaveKeyWithValue("emptyDir", map[string]any{"medium": "Memory", "sizeLimit": "64Mi"}))
+
+			By("applying the value to every emptyDir volume")
+			rendered = volumes(map[string]any{"scratchSizeLimit": "1Gi"})
+			Expect(rendered[0]).To(HaveKeyWithValue("emptyDir", map[string]any{"sizeLimit": "1Gi"}))
+			Expect(rendered[1]).To(HaveKeyWithValue("emptyDir", map[string]any{"medium": "Memory", "sizeLimit": "1Gi"}))
 		})
 
-		It("should wrap ServiceMonitor bearerTokenFile with metrics.secure conditional", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+		It("should render the manager container lifecycle hooks from manager.lifecycle", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor
+			content := `apiVersion: apps/v1
+kind: Deployment
 spec:
-  endpoints:
-  - bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
-    port: https`
+  template:
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager
+      - image: sidecar:latest
+        name: sidecar`
 
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// BearerTokenFile should be wrapped with conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.metrics.secure }}"))
-			Expect(result).To(ContainSubstring("bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			Expect(result).To(ContainSubstring(`        {{- end }}
+        {{- with .Values.manager.lifecycle }}
+        lifecycle: {{- toYaml . | nindent 10 }}
+        {{- end }}
+`))
+			Expect(templater.ApplyHelmSubstitutions(result, deploymentResource)).To(Equal(result))
+
+			containers := func(values map[string]any) []any {
+				GinkgoHelper()
+				rendered, err := renderTemplate(result, values)
+				Expect(err).NotTo(HaveOccurred())
+				var deployment map[string]any
+				Expect(yaml.Unmarshal([]byte(rendered), &deployment)).To(Succeed())
+				found, _, _ := unstructured.NestedSlice(deployment, "spec", "template", "spec", "containers")
+				return found
+			}
+			values := func(manager map[string]any) map[string]any {
+				manager["image"] = map[string]any{"repository": "controller"}
+				return map[string]any{"manager": manager, "rbac": map[string]any{}}
+			}
+
+			By("rendering no lifecycle while the value is unset")
+			Expect(containers(values(map[string]any{}))[0]).NotTo(HaveKey("lifecycle"))
+
+			By("rendering a preStop exec hook on the manager container only")
+			preStop := map[string]any{"exec": map[string]any{"command": []any{"sleep", "5"}}}
+			rendered := containers(values(map[string]any{"lifecycle": map[string]any{"preStop": preStop}}))
+			Expect(rendered[0]).To(HaveKeyWithValue("lifecycle", map[string]any{"preStop": preStop}))
+			Expect(rendered[1]).NotTo(HaveKey("lifecycle"))
 		})
 
-		It("should wrap ServiceMonitor tlsConfig with metrics.secure conditional", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+		It("should keep a manifest lifecycle while manager.lifecycle is unset", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor
+			content := `apiVersion: apps/v1
+kind: Deployment
 spec:
-  endpoints:
-  - port: https
-    scheme: https
-    tlsConfig:
-      insecureSkipVerify: true`
-
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+  template:
+    spec:
+      containers:
+      - image: controller:latest
+        lifecycle:
+          preStop:
+            exec:
+              command: ["sleep", "10"]
+        name: manager`
 
-			// TLS config should be wrapped with conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.metrics.secure }}"))
-			Expect(result).To(ContainSubstring("tlsConfig:"))
-			Expect(result).To(ContainSubstring("insecureSkipVerify: true"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should have port and scheme templated too
-			Expect(result).To(ContainSubstring("port: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"))
-			Expect(result).To(ContainSubstring("scheme: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"))
+			Expect(result).To(ContainSubstring(`        {{- if .Values.manager.lifecycle }}
+        lifecycle: {{- toYaml .Values.manager.lifecycle | nindent 10 }}
+        {{- else }}
+        lifecycle:
+          preStop:
+            exec:
+              command: ["sleep", "10"]
+        {{- end }}
+        name: manager`))
+			Expect(templater.ApplyHelmSubstitutions(result, deploymentResource)).To(Equal(result))
 		})
 
-		It("should wrap ServiceMonitor with certManager.enabled conditional when using default cert-manager secret", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+		It("should add terminationMessagePolicy to the manager container with the File default", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor
+			content := `apiVersion: apps/v1
+kind: Deployment
 spec:
-  endpoints:
-  - port: https
-    scheme: https
-    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
-    tlsConfig:
-      serverName: service.namespace.svc
-      insecureSkipVerify: false
-      ca:
-        secret:
-          name: metrics-server-cert
-          key: ca.crt
-      cert:
-        secret:
-          name: metrics-server-cert
-          key: tls.crt
-      keySecret:
-        name: metrics-server-cert
-        key: tls.key`
-
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+  template:
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager
+      - image: sidecar:latest
+        name: sidecar`
 
-			// Should have cert-manager conditional (using default cert-manager secret)
-			Expect(result).To(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should preserve secret names
-			Expect(result).To(ContainSubstring("name: metrics-server-cert"))
+			Expect(result).To(ContainSubstring(
+				"        terminationMessagePolicy: {{ .Values.manager.terminationMessagePolicy | default \"File\" }}\n" +
+					"      - image: sidecar:latest\n"))
+			Expect(strings.Count(result, "terminationMessagePolicy:")).To(Equal(1))
+			Expect(templater.ApplyHelmSubstitutions(result, deploymentResource)).To(Equal(result))
+
+			containers := func(manager map[string]any) []any {
+				GinkgoHelper()
+				manager["image"] = map[string]any{"repository": "controller"}
+				rendered, err := renderTemplate(result, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				var deployment map[string]any
+				Expect(yaml.Unmarshal([]byte(rendered), &deployment)).To(Succeed())
+				found, _, _ := unstructured.NestedSlice(deployment, "spec", "template", "spec", "containers")
+				return found
+			}
+			Expect(containers(map[string]any{})[0]).To(HaveKeyWithValue("terminationMessagePolicy", "File"))
+			Expect(containers(map[string]any{"terminationMessagePolicy": "FallbackToLogsOnError"})[0]).To(
+				HaveKeyWithValue("terminationMessagePolicy", "FallbackToLogsOnError"))
 
-			// Should have else branch with insecureSkipVerify
-			Expect(result).To(ContainSubstring("{{- else }}"))
-			Expect(result).To(ContainSubstring("insecureSkipVerify: true"))
+			By("defaulting to the policy set in the manifest")
+			withPolicy := templater.ApplyHelmSubstitutions(strings.Replace(content, "        name: manager",
+				"        name: manager\n        terminationMessagePolicy: FallbackToLogsOnError", 1), deploymentResource)
+			Expect(withPolicy).To(ContainSubstring(
+				"terminationMessagePolicy: {{ .Values.manager.terminationMessagePolicy | default \"FallbackToLogsOnError\" }}"))
+			Expect(strings.Count(withPolicy, "terminationMessagePolicy:")).To(Equal(1))
 		})
 
-		It("should preserve custom cert secrets without cert-manager conditional", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+		It("should move hardcoded hostNetwork and dnsPolicy into the guarded block", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			// Custom certs from Vault/manual secrets - NOT cert-manager
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor
+			content := `apiVersion: apps/v1
+kind: Deployment
 spec:
-  endpoints:
-  - port: https
-    scheme: https
-    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
-    tlsConfig:
-      serverName: custom-service.namespace.svc
-      insecureSkipVerify: false
-      ca:
-        secret:
-          name: vault-ca-secret
-          key: ca.crt
-      cert:
-        secret:
-          name: vault-client-cert
-          key: tls.crt
-      keySecret:
-        name: vault-client-key
-        key: tls.key`
-
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
-
-			// Should NOT have cert-manager conditional (custom secrets)
-			Expect(result).NotTo(ContainSubstring("{{- if .Values.certManager.enabled }}"))
-			Expect(result).NotTo(ContainSubstring("{{- else }}"))
+  template:
+    spec:
+      containers:
+      - name: manager
+      dnsPolicy: ClusterFirstWithHostNet
+      hostNetwork: true`
 
-			// Custom secret names should be preserved as-is
-			Expect(result).To(ContainSubstring("name: vault-ca-secret"))
-			Expect(result).To(ContainSubstring("name: vault-client-cert"))
-			Expect(result).To(ContainSubstring("name: vault-client-key"))
-			Expect(result).To(ContainSubstring("insecureSkipVerify: false"))
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should still have metrics.secure wrapper
-			Expect(result).To(ContainSubstring("{{- if .Values.metrics.secure }}"))
+			Expect(result).To(ContainSubstring("{{- if .Values.manager.hostNetwork }}"))
+			Expect(result).NotTo(ContainSubstring("hostNetwork: true"))
+			Expect(result).NotTo(ContainSubstring("dnsPolicy: ClusterFirstWithHostNet"))
 		})
 
-		It("should NOT add cert-manager conditional when ServiceMonitor only has insecureSkipVerify", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+		It("should template topologySpreadConstraints", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor
+			content := `apiVersion: apps/v1
+kind: Deployment
 spec:
-  endpoints:
-  - port: https
-    scheme: https
-    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
-    tlsConfig:
-      insecureSkipVerify: true`
-
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
-
-			// Should NOT have cert-manager conditional (no cert-manager fields detected)
-			Expect(result).NotTo(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+  template:
+    spec:
+      topologySpreadConstraints:
+      - maxSkew: 1
+        topologyKey: kubernetes.io/hostname
+        whenUnsatisfiable: DoNotSchedule
+      containers:
+      - name: manager`
 
-			// Should preserve insecureSkipVerify: true as-is
-			Expect(result).To(ContainSubstring("insecureSkipVerify: true"))
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should still have metrics.secure wrapper
-			Expect(result).To(ContainSubstring("{{- if .Values.metrics.secure }}"))
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.topologySpreadConstraints }}"))
+			Expect(result).To(ContainSubstring("topologySpreadConstraints: {{ toYaml . | nindent"))
+			Expect(result).NotTo(ContainSubstring("maxSkew: 1"))
 		})
 
-		It("should convert insecureSkipVerify: false to true when no cert-manager fields present", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+		It("should template terminationGracePeriodSeconds with nil check", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			// Invalid config: insecureSkipVerify: false without certs
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor
+			content := `apiVersion: apps/v1
+kind: Deployment
 spec:
-  endpoints:
-  - port: https
-    scheme: https
-    tlsConfig:
-      insecureSkipVerify: false`
-
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+  template:
+    spec:
+      serviceAccountName: controller-manager
+      terminationGracePeriodSeconds: 10
+      containers:
+      - name: manager`
 
-			// Should convert to insecureSkipVerify: true (can't have false without certs)
-			Expect(result).To(ContainSubstring("insecureSkipVerify: true"))
-			Expect(result).NotTo(ContainSubstring("insecureSkipVerify: false"))
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should NOT have cert-manager conditional (no cert-manager fields)
-			Expect(result).NotTo(ContainSubstring("{{- if .Values.certManager.enabled }}"))
-		})
+			Expect(result).To(ContainSubstring(
+				"{{- if and (hasKey .Values.manager \"terminationGracePeriodSeconds\")"))
+			Expect(result).To(ContainSubstring("(ne .Values.manager.terminationGracePeriodSeconds nil)"))
+			Expect(result).To(ContainSubstring(
+				"terminationGracePeriodSeconds: {{ .Values.manager.terminationGracePeriodSeconds }}"))
+			Expect(result).NotTo(ContainSubstring("terminationGracePeriodSeconds: 10"))
+		})
 
-		It("should add metrics conditional for metrics services", func() {
-			serviceResource := &unstructured.Unstructured{}
-			serviceResource.SetAPIVersion("v1")
-			serviceResource.SetKind("Service")
-			serviceResource.SetName("test-project-controller-manager-metrics-service")
+		It("should set the pod automountServiceAccountToken only when configured", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: v1
-kind: Service
-metadata:
-  name: test-project-controller-manager-metrics-service`
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+      serviceAccountName: controller-manager`
 
-			result := templater.ApplyHelmSubstitutions(content, serviceResource)
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should be wrapped with metrics enabled conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.metrics.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			block := "      {{- if and (hasKey .Values.manager \"automountServiceAccountToken\") " +
+				"(ne .Values.manager.automountServiceAccountToken nil) }}\n" +
+				"      automountServiceAccountToken: {{ .Values.manager.automountServiceAccountToken }}\n" +
+				"      {{- end }}\n"
+			Expect(result).To(ContainSubstring("    spec:\n" + block))
+
+			By("rendering nothing when the value is unset")
+			rendered, err := renderTemplate(block, map[string]any{"manager": map[string]any{}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).NotTo(ContainSubstring("automountServiceAccountToken"))
+
+			By("rendering true and false as set")
+			for _, automount := range []bool{true, false} {
+				rendered, err := renderTemplate(block, map[string]any{
+					"manager": map[string]any{"automountServiceAccountToken": automount},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rendered).To(ContainSubstring(fmt.Sprintf("\n      automountServiceAccountToken: %t\n", automount)))
+			}
 		})
 
-		It("should add cert-manager conditional for Certificate resources", func() {
-			certResource := &unstructured.Unstructured{}
-			certResource.SetAPIVersion("cert-manager.io/v1")
-			certResource.SetKind("Certificate")
-			certResource.SetName("test-project-serving-cert")
+		It("should move a hardcoded pod automountServiceAccountToken into the guarded block", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: cert-manager.io/v1
-kind: Certificate
-metadata:
-  name: test-project-serving-cert`
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      automountServiceAccountToken: false
+      containers:
+      - name: manager`
 
-			result := templater.ApplyHelmSubstitutions(content, certResource)
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should be wrapped with certManager enabled conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.certManager.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			Expect(result).NotTo(ContainSubstring("automountServiceAccountToken: false"))
+			Expect(strings.Count(result, "automountServiceAccountToken:")).To(Equal(1))
 		})
 
-		It("should add combined conditionals for metrics certificates", func() {
-			certResource := &unstructured.Unstructured{}
-			certResource.SetAPIVersion("cert-manager.io/v1")
-			certResource.SetKind("Certificate")
-			certResource.SetName("test-project-metrics-certs")
+		It("should template terminationGracePeriodSeconds zero value", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
 
-			content := `apiVersion: cert-manager.io/v1
-kind: Certificate
-metadata:
-  name: test-project-metrics-certs`
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      serviceAccountName: controller-manager
+      terminationGracePeriodSeconds: 0
+      containers:
+      - name: manager`
 
-			result := templater.ApplyHelmSubstitutions(content, certResource)
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
 
-			// Should be wrapped with certManager, metrics, AND metrics.secure conditionals
-			// Metrics certs only needed when using HTTPS (metrics.secure=true)
+			// Should use hasKey to support 0 values
+			Expect(result).To(ContainSubstring("hasKey .Values.manager \"terminationGracePeriodSeconds\""))
 			Expect(result).To(ContainSubstring(
-				"{{- if and .Values.certManager.enabled .Values.metrics.enabled .Values.metrics.secure }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+				"terminationGracePeriodSeconds: {{ .Values.manager.terminationGracePeriodSeconds }}"))
+			Expect(result).NotTo(ContainSubstring("terminationGracePeriodSeconds: 0"))
 		})
+	})
 
-		It("should add kind conditionals to essential ClusterRole resources", func() {
-			// Test essential RBAC
-			clusterRoleResource := &unstructured.Unstructured{}
-			clusterRoleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			clusterRoleResource.SetKind("ClusterRole")
-			clusterRoleResource.SetName("test-project-manager-role")
+	Context("image reference templating", func() {
+		var deploymentResource *unstructured.Unstructured
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+		const imageDeployment = `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-manager-role`
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        image: controller:latest
+        imagePullPolicy: IfNotPresent
+        name: manager`
 
-			result := templater.ApplyHelmSubstitutions(content, clusterRoleResource)
+		// renderImageWithValues templates the Deployment and renders only its image line with the given values.
+		renderImageWithValues := func(values map[string]any) string {
+			result := templater.ApplyHelmSubstitutions(imageDeployment, deploymentResource)
+			var imageLine string
+			for line := range strings.SplitSeq(result, "\n") {
+				if strings.HasPrefix(strings.TrimSpace(line), "image:") {
+					imageLine = strings.TrimSpace(line)
+				}
+			}
+			Expect(imageLine).NotTo(BeEmpty())
 
-			// Should NOT have rbac.create conditional (always created)
-			Expect(result).NotTo(ContainSubstring("{{- if .Values.rbac.create }}"))
-			// Should have kind conditional for namespace-scoped support
-			Expect(result).To(ContainSubstring("{{- if .Values.rbac.namespaced }}"))
-		})
+			rendered, err := renderTemplate(imageLine, values)
+			Expect(err).NotTo(HaveOccurred())
+			return rendered
+		}
 
-		It("should add webhook conditional for webhook services", func() {
-			serviceResource := &unstructured.Unstructured{}
-			serviceResource.SetAPIVersion("v1")
-			serviceResource.SetKind("Service")
-			serviceResource.SetName("test-project-webhook-service")
+		renderImage := func(imageValues map[string]any) string {
+			return renderImageWithValues(map[string]any{"manager": map[string]any{"image": imageValues}})
+		}
 
-			webhookContent := `apiVersion: v1
-kind: Service
-metadata:
-  name: test-project-webhook-service`
+		BeforeEach(func() {
+			deploymentResource = &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+		})
 
-			webhookResult := templater.ApplyHelmSubstitutions(webhookContent, serviceResource)
+		It("should emit a digest-aware image reference", func() {
+			result := templater.ApplyHelmSubstitutions(imageDeployment, deploymentResource)
 
-			// Should wrap webhook service with webhook.enabled conditional
-			Expect(webhookResult).To(ContainSubstring("{{- if .Values.webhook.enabled }}"))
-			Expect(webhookResult).To(ContainSubstring("{{- end }}"))
+			Expect(result).To(ContainSubstring(expectedManagerImageLine))
+			Expect(result).NotTo(ContainSubstring("controller:latest"))
 		})
 
-		It("should add webhook conditional for webhook configurations", func() {
-			mutatingWebhookResource := &unstructured.Unstructured{}
-			mutatingWebhookResource.SetAPIVersion("admissionregistration.k8s.io/v1")
-			mutatingWebhookResource.SetKind("MutatingWebhookConfiguration")
-			mutatingWebhookResource.SetName("test-project-mutating-webhook-configuration")
+		It("should render repository:tag when no digest is set", func() {
+			rendered := renderImage(map[string]any{"repository": "example.com/operator", "tag": "v1.2.3"})
 
-			content := `apiVersion: admissionregistration.k8s.io/v1
-kind: MutatingWebhookConfiguration
-metadata:
-  name: test-project-mutating-webhook-configuration`
+			Expect(rendered).To(Equal(`image: "example.com/operator:v1.2.3"`))
+		})
 
-			result := templater.ApplyHelmSubstitutions(content, mutatingWebhookResource)
+		It("should default the tag to the chart appVersion when no digest is set", func() {
+			rendered := renderImage(map[string]any{"repository": "example.com/operator"})
 
-			// Webhook configurations should be conditional on webhook.enabled
-			Expect(result).To(ContainSubstring("{{- if .Values.webhook.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			Expect(rendered).To(Equal(`image: "example.com/operator:0.1.0"`))
 		})
 
-		It("should add webhook conditional for validating webhook configurations", func() {
-			validatingWebhookResource := &unstructured.Unstructured{}
-			validatingWebhookResource.SetAPIVersion("admissionregistration.k8s.io/v1")
-			validatingWebhookResource.SetKind("ValidatingWebhookConfiguration")
-			validatingWebhookResource.SetName("test-project-validating-webhook-configuration")
+		It("should render repository@digest and ignore the tag when a digest is set", func() {
+			rendered := renderImage(map[string]any{
+				"repository": "example.com/operator",
+				"tag":        "v1.2.3",
+				"digest":     "sha256:0123456789abcdef",
+			})
 
-			content := `apiVersion: admissionregistration.k8s.io/v1
-kind: ValidatingWebhookConfiguration
-metadata:
-  annotations:
-    cert-manager.io/inject-ca-from: test-project-system/test-project-serving-cert
-  name: test-project-validating-webhook-configuration`
+			Expect(rendered).To(Equal(`image: "example.com/operator@sha256:0123456789abcdef"`))
+		})
 
-			result := templater.ApplyHelmSubstitutions(content, validatingWebhookResource)
+		It("should keep a digest embedded in the repository as-is", func() {
+			rendered := renderImage(map[string]any{"repository": "example.com/operator@sha256:0123456789abcdef"})
 
-			// Webhook configurations should be wrapped with webhook.enabled
-			Expect(result).To(ContainSubstring("{{- if .Values.webhook.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
-			// Cert-manager annotation should still be conditional on certManager.enabled
-			Expect(result).To(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+			Expect(rendered).To(Equal(`image: "example.com/operator@sha256:0123456789abcdef"`))
 		})
 
-		It("should add crd.enabled conditional and resource-policy annotation for CRDs", func() {
-			crdResource := &unstructured.Unstructured{}
-			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
-			crdResource.SetKind("CustomResourceDefinition")
-			crdResource.SetName("guestbooks.example.com")
+		It("should not prefix the repository when global.imageRegistry is unset", func() {
+			rendered := renderImageWithValues(map[string]any{
+				"global":  map[string]any{"imageRegistry": ""},
+				"manager": map[string]any{"image": map[string]any{"repository": "operator", "tag": "v1"}},
+			})
 
-			content := `apiVersion: apiextensions.k8s.io/v1
-kind: CustomResourceDefinition
-metadata:
-  name: guestbooks.example.com
-spec:
-  group: example.com`
+			Expect(rendered).To(Equal(`image: "operator:v1"`))
+		})
 
-			result := templater.ApplyHelmSubstitutions(content, crdResource)
+		It("should not fail when the global section is absent", func() {
+			rendered := renderImageWithValues(map[string]any{
+				"manager": map[string]any{"image": map[string]any{"repository": "operator", "tag": "v1"}},
+			})
 
-			// Should be wrapped with crd.enabled conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.crd.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
-			// Should have resource-policy annotation for helm uninstall protection
-			Expect(result).To(ContainSubstring("{{- if .Values.crd.keep }}"))
-			Expect(result).To(ContainSubstring(`"helm.sh/resource-policy": keep`))
-			// Injected annotations should use 2-space indentation matching sigs.k8s.io/yaml output.
-			// annotations: at 2-space indent, values at 4-space indent.
-			expectedAnnotations := "  annotations:\n" +
-				"    {{- if .Values.crd.keep }}\n" +
-				"    \"helm.sh/resource-policy\": keep\n" +
-				"    {{- end }}"
-			Expect(result).To(ContainSubstring(expectedAnnotations))
+			Expect(rendered).To(Equal(`image: "operator:v1"`))
 		})
 
-		It("should add resource-policy annotation to CRDs that already have annotations", func() {
-			crdResource := &unstructured.Unstructured{}
-			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
-			crdResource.SetKind("CustomResourceDefinition")
-			crdResource.SetName("configs.example.com")
+		It("should prefix the repository with global.imageRegistry when set", func() {
+			rendered := renderImageWithValues(map[string]any{
+				"global":  map[string]any{"imageRegistry": "mirror.example.com"},
+				"manager": map[string]any{"image": map[string]any{"repository": "operator", "tag": "v1"}},
+			})
 
-			content := `apiVersion: apiextensions.k8s.io/v1
-kind: CustomResourceDefinition
-metadata:
-  annotations:
-    controller-gen.kubebuilder.io/version: v0.17.0
-  name: configs.example.com
-spec:
-  group: example.com`
+			Expect(rendered).To(Equal(`image: "mirror.example.com/operator:v1"`))
+		})
 
-			result := templater.ApplyHelmSubstitutions(content, crdResource)
+		It("should combine global.imageRegistry with a digest", func() {
+			rendered := renderImageWithValues(map[string]any{
+				"global": map[string]any{"imageRegistry": "mirror.example.com"},
+				"manager": map[string]any{"image": map[string]any{
+					"repository": "operator",
+					"digest":     "sha256:0123456789abcdef",
+				}},
+			})
 
-			// Should be wrapped with crd.enabled conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.crd.enabled }}"))
-			// Should have resource-policy annotation
-			Expect(result).To(ContainSubstring("{{- if .Values.crd.keep }}"))
-			Expect(result).To(ContainSubstring(`"helm.sh/resource-policy": keep`))
-			// Should preserve existing annotation
-			Expect(result).To(ContainSubstring("controller-gen.kubebuilder.io/version"))
-			// Injected annotation should be at same indent as existing annotations (4 spaces)
-			expectedAnnotations := "  annotations:\n" +
-				"    {{- if .Values.crd.keep }}\n" +
-				"    \"helm.sh/resource-policy\": keep\n" +
-				"    {{- end }}\n" +
-				"    controller-gen.kubebuilder.io/version"
-			Expect(result).To(ContainSubstring(expectedAnnotations))
+			Expect(rendered).To(Equal(`image: "mirror.example.com/operator@sha256:0123456789abcdef"`))
 		})
+	})
 
-		It("should add manager.enabled conditional for manager Deployments", func() {
-			deploymentResource := &unstructured.Unstructured{}
-			deploymentResource.SetAPIVersion("apps/v1")
-			deploymentResource.SetKind("Deployment")
-			deploymentResource.SetName("test-project-controller-manager")
-
-			content := `apiVersion: apps/v1
+	Context("idempotency", func() {
+		const fullDeployment = `apiVersion: apps/v1
 kind: Deployment
 metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+    app.kubernetes.io/name: test-project
+    control-plane: controller-manager
   name: test-project-controller-manager
   namespace: test-project-system
 spec:
-  replicas: 1`
-
-			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
-
-			// Should be wrapped with manager.enabled conditional that defaults to true when key is absent
-			expectedConditional := `{{- if or (not (hasKey .Values.manager "enabled")) (.Values.manager.enabled) }}`
-			Expect(result).To(ContainSubstring(expectedConditional))
-			Expect(result).To(ContainSubstring("{{- end }}"))
-		})
-
-		It("should not add manager.enabled conditional for non-manager Deployments", func() {
-			deploymentResource := &unstructured.Unstructured{}
-			deploymentResource.SetAPIVersion("apps/v1")
-			deploymentResource.SetKind("Deployment")
-			deploymentResource.SetName("other-deployment")
+  replicas: 1
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: test-project
+      control-plane: controller-manager
+  template:
+    metadata:
+      annotations:
+        example.com/greeting: Hello {{ .Name }}
+        kubectl.kubernetes.io/default-container: manager
+      labels:
+        app.kubernetes.io/name: test-project
+        control-plane: controller-manager
+    spec:
+      containers:
+      - args:
+        - --metrics-bind-address=:8443
+        - --leader-elect
+        - --health-probe-bind-address=:8081
+        - --metrics-cert-path=/tmp/k8s-metrics-server/metrics-certs
+        - --webhook-cert-path=/tmp/k8s-webhook-server/serving-certs
+        command:
+        - /manager
+        image: controller:latest
+        imagePullPolicy: IfNotPresent
+        name: manager
+        ports:
+        - containerPort: 9443
+          name: webhook-server
+          protocol: TCP
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+        securityContext:
+          allowPrivilegeEscalation: false
+        volumeMounts:
+        - mountPath: /tmp/k8s-metrics-server/metrics-certs
+          name: metrics-certs
+          readOnly: true
+        - mountPath: /tmp/k8s-webhook-server/serving-certs
+          name: webhook-certs
+          readOnly: true
+      imagePullSecrets:
+      - name: regcred
+      securityContext:
+        runAsNonRoot: true
+      serviceAccountName: test-project-controller-manager
+      terminationGracePeriodSeconds: 10
+      volumes:
+      - name: metrics-certs
+        secret:
+          items:
+          - key: ca.crt
+            path: ca.crt
+          secretName: metrics-server-cert
+      - name: webhook-certs
+        secret:
+          secretName: webhook-server-cert
+`
 
-			content := `apiVersion: apps/v1
-kind: Deployment
+		const webhookConfiguration = `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
 metadata:
-  name: other-deployment
-  namespace: test-project-system
-spec:
-  replicas: 1`
-
-			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
-
-			// Should NOT be wrapped with manager.enabled conditional
-			expectedConditional := `{{- if or (not (hasKey .Values.manager "enabled")) (.Values.manager.enabled) }}`
-			Expect(result).NotTo(ContainSubstring(expectedConditional))
-			Expect(result).NotTo(ContainSubstring(".Values.manager.enabled"))
-		})
-	})
-
-	Context("helper RBAC wrapping", func() {
-		It("should add rbac.helpers conditional for helper RBAC roles", func() {
-			clusterRoleResource := &unstructured.Unstructured{}
-			clusterRoleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			clusterRoleResource.SetKind("ClusterRole")
-			clusterRoleResource.SetName("test-project-memcached-editor-role")
+  annotations:
+    cert-manager.io/inject-ca-from: test-project-system/test-project-serving-cert
+  name: test-project-validating-webhook-configuration
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    service:
+      name: test-project-webhook-service
+      namespace: test-project-system
+      path: /validate-example-com-v1-memcached
+  failurePolicy: Fail
+  name: vmemcached-v1.kb.io
+  sideEffects: None
+`
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  name: test-project-memcached-editor-role`
+		It("should be stable when applied to an already templated Deployment", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+			deploymentResource.SetLabels(map[string]string{"control-plane": "controller-manager"})
 
-			result := templater.ApplyHelmSubstitutions(content, clusterRoleResource)
+			once := templater.ApplyHelmSubstitutions(fullDeployment, deploymentResource)
+			twice := templater.ApplyHelmSubstitutions(once, deploymentResource)
 
-			// Should be wrapped with rbac.helpers conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.rbac.helpers.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			Expect(twice).To(Equal(once))
+			Expect(strings.Count(once,
+				"{{- if or .Values.certManager.enabled (.Values.webhook | default dict).caBundle }}")).To(Equal(3))
+			Expect(strings.Count(once,
+				"{{- if and .Values.certManager.enabled .Values.metrics.enabled .Values.metrics.secure }}")).To(Equal(3))
+			Expect(once).To(ContainSubstring(`{{ "{{ .Name }}" }}`))
 		})
 
-		It("should add rbac.helpers conditional for helper ClusterRoleBindings", func() {
-			bindingResource := &unstructured.Unstructured{}
-			bindingResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			bindingResource.SetKind("ClusterRoleBinding")
-			bindingResource.SetName("test-project-memcached-viewer-rolebinding")
-
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: test-project-memcached-viewer-rolebinding`
+		It("should be stable when applied to an already templated webhook configuration", func() {
+			webhookResource := &unstructured.Unstructured{}
+			webhookResource.SetAPIVersion("admissionregistration.k8s.io/v1")
+			webhookResource.SetKind("ValidatingWebhookConfiguration")
+			webhookResource.SetName("test-project-validating-webhook-configuration")
 
-			result := templater.ApplyHelmSubstitutions(content, bindingResource)
+			once := templater.ApplyHelmSubstitutions(webhookConfiguration, webhookResource)
+			twice := templater.ApplyHelmSubstitutions(once, webhookResource)
 
-			// Should be wrapped with rbac.helpers conditional
-			Expect(result).To(ContainSubstring("{{- if .Values.rbac.helpers.enabled }}"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			Expect(twice).To(Equal(once))
+			Expect(strings.Count(once, "{{- if .Values.webhook.enabled }}")).To(Equal(1))
+			Expect(strings.Count(once, "{{- if .Values.certManager.enabled }}")).To(Equal(1))
 		})
 	})
 
-	Context("chart.fullname templating", func() {
-		It("should template resource names with test-project.resourceName for proper truncation", func() {
-			serviceAccountResource := &unstructured.Unstructured{}
-			serviceAccountResource.SetAPIVersion("v1")
-			serviceAccountResource.SetKind("ServiceAccount")
-			serviceAccountResource.SetName("test-project-controller-manager")
-
-			content := `apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: test-project-controller-manager
-  namespace: test-project-system`
-
-			result := templater.ApplyHelmSubstitutions(content, serviceAccountResource)
-
-			// Should template with test-project.serviceAccountName helper
-			expected := `name: {{ include "test-project.serviceAccountName" . }}`
-			Expect(result).To(ContainSubstring(expected))
-			Expect(result).NotTo(ContainSubstring("name: test-project-controller-manager"))
-		})
-		It("should template ServiceMonitor name with test-project.resourceName for proper truncation", func() {
-			serviceMonitorResource := &unstructured.Unstructured{}
-			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			serviceMonitorResource.SetKind("ServiceMonitor")
-			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
-
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: ServiceMonitor
-metadata:
-  name: test-project-controller-manager-metrics-monitor`
-
-			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
-
-			// Should template with test-project.resourceName which handles 63-char truncation
-			expected := `name: {{ include "test-project.resourceName" ` +
-				`(dict "suffix" "controller-manager-metrics-monitor" "context" $) }}`
-			Expect(result).To(ContainSubstring(expected))
-			Expect(result).NotTo(ContainSubstring("name: test-project-controller-manager-metrics-monitor"))
-		})
-
-		It("should template secretRef name inside envFrom", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
-
-			content := `apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: test-project-controller-manager
-  namespace: test-project-system
-spec:
-  template:
-    spec:
-      containers:
-      - name: manager
-        envFrom:
-        - secretRef:
-            name: test-project-manager-secrets`
-
-			result := templater.ApplyHelmSubstitutions(content, deployment)
-
-			// secretRef.name inside envFrom must be templated, not left as a hardcoded string
-			expectedSecretRef := `name: {{ include "test-project.resourceName" ` +
-				`(dict "suffix" "manager-secrets" "context" $) }}`
-			Expect(result).To(ContainSubstring(expectedSecretRef))
-			Expect(result).NotTo(ContainSubstring("name: test-project-manager-secrets"))
-		})
+	Context("standard Helm labels", func() {
+		const includeLabels = `{{- include "test-project.labels" . | nindent %d }}`
 
-		It("should template configMapRef name inside envFrom", func() {
+		It("should include the labels helper in Deployment and pod template labels, not selectors", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
@@ -1211,1212 +1449,6547 @@ spec:
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+    app.kubernetes.io/name: test-project
+    control-plane: controller-manager
   name: test-project-controller-manager
-  namespace: test-project-system
 spec:
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: test-project
+      control-plane: controller-manager
   template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: test-project
+        control-plane: controller-manager
     spec:
       containers:
-      - name: manager
-        envFrom:
-        - configMapRef:
-            name: test-project-manager-config`
-
+      - command:
+        - /manager
+        image: controller:latest
+        name: manager
+`
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// configMapRef.name inside envFrom must be templated
-			expectedConfigMapRef := `name: {{ include "test-project.resourceName" ` +
-				`(dict "suffix" "manager-config" "context" $) }}`
-			Expect(result).To(ContainSubstring(expectedConfigMapRef))
-			Expect(result).NotTo(ContainSubstring("name: test-project-manager-config"))
+			Expect(result).To(ContainSubstring("  labels:\n    " + fmt.Sprintf(includeLabels, 4) +
+				"\n    app.kubernetes.io/name: {{ include \"test-project.name\" . }}\n    control-plane: controller-manager\n"))
+			Expect(result).To(ContainSubstring("      labels:\n        " + fmt.Sprintf(includeLabels, 8) + "\n"))
+			Expect(result).To(ContainSubstring("    matchLabels:\n      app.kubernetes.io/name: " +
+				"{{ include \"test-project.name\" . }}\n      control-plane: controller-manager\n"))
+			Expect(strings.Count(result, `include "test-project.labels"`)).To(Equal(2))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/managed-by:"))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
 		})
-	})
 
-	Context("app.kubernetes.io/name label templating", func() {
-		It("should template app.kubernetes.io/name for Deployment", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+		It("should render the standard labels on a Service without duplicating existing ones", func() {
+			service := &unstructured.Unstructured{}
+			service.SetAPIVersion("v1")
+			service.SetKind("Service")
+			service.SetName("test-project-extra-service")
 
-			content := `apiVersion: apps/v1
-kind: Deployment
+			content := `apiVersion: v1
+kind: Service
 metadata:
   labels:
+    app.kubernetes.io/instance: old
     app.kubernetes.io/name: test-project
-    control-plane: controller-manager`
-
-			result := templater.ApplyHelmSubstitutions(content, deployment)
-
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+  name: test-project-extra-service
+spec:
+  ports:
+  - port: 80
+  selector:
+    app.kubernetes.io/name: test-project
+`
+			templated := templater.ApplyHelmSubstitutions(content, service)
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl": templater.GenerateHelpers(),
+				"templates/service.yaml": templated,
+			}, map[string]any{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/service.yaml"]).To(ContainSubstring(`  labels:
+    helm.sh/chart: test-project-0.1.0
+    app.kubernetes.io/version: "0.1.0"
+    app.kubernetes.io/instance: my-release
+    app.kubernetes.io/managed-by: Helm
+    app.kubernetes.io/name: test-project
+  name: my-release-test-project-extra-service
+`))
+			Expect(rendered["templates/service.yaml"]).To(ContainSubstring(`  selector:
+    app.kubernetes.io/name: test-project
+`))
 		})
 
-		It("should template app.kubernetes.io/name for Service", func() {
+		It("should render the version label of a Service from the chart appVersion", func() {
 			service := &unstructured.Unstructured{}
 			service.SetAPIVersion("v1")
 			service.SetKind("Service")
-			service.SetName("test-project-webhook-service")
+			service.SetName("test-project-extra-service")
 
 			content := `apiVersion: v1
 kind: Service
 metadata:
   labels:
-    app.kubernetes.io/name: test-project`
+    app.kubernetes.io/name: test-project
+    app.kubernetes.io/version: v0.0.1
+  name: test-project-extra-service
+spec:
+  ports:
+  - port: 80
+  selector:
+    app.kubernetes.io/name: test-project
+    app.kubernetes.io/version: v0.0.1
+`
+			templated := templater.ApplyHelmSubstitutions(content, service)
+			Expect(strings.Count(templated, "v0.0.1")).To(Equal(1))
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl": templater.GenerateHelpers(),
+				"templates/service.yaml": templated,
+			}, map[string]any{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/service.yaml"]).To(ContainSubstring("    app.kubernetes.io/version: \"0.1.0\"\n"))
+			Expect(rendered["templates/service.yaml"]).To(ContainSubstring(
+				"  selector:\n    app.kubernetes.io/name: test-project\n    app.kubernetes.io/version: v0.0.1\n"))
+			Expect(strings.Count(rendered["templates/service.yaml"], "app.kubernetes.io/version")).To(Equal(2))
+		})
+
+		It("should add a labels block to a CRD without labels", func() {
+			crd := &unstructured.Unstructured{}
+			crd.SetAPIVersion("apiextensions.k8s.io/v1")
+			crd.SetKind("CustomResourceDefinition")
+			crd.SetName("widgets.example.com")
 
-			result := templater.ApplyHelmSubstitutions(content, service)
+			content := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v0.18.0
+  name: widgets.example.com
+spec:
+  group: example.com
+`
+			result := templater.ApplyHelmSubstitutions(content, crd)
 
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+			Expect(result).To(ContainSubstring("metadata:\n  labels:\n    " + fmt.Sprintf(includeLabels, 4) +
+				"\n    {{- with .Values.commonLabels }}\n"))
+			Expect(strings.Count(result, "labels:")).To(Equal(1))
 		})
+	})
 
-		It("should template app.kubernetes.io/name for ServiceAccount", func() {
-			sa := &unstructured.Unstructured{}
-			sa.SetAPIVersion("v1")
-			sa.SetKind("ServiceAccount")
-			sa.SetName("test-project-controller-manager")
-
-			content := `apiVersion: v1
-kind: ServiceAccount
+	Context("RBAC classification", func() {
+		role := func(name string) (string, *unstructured.Unstructured) {
+			resource := &unstructured.Unstructured{}
+			resource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			resource.SetKind("Role")
+			resource.SetName(name)
+			return `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
 metadata:
-  labels:
-    app.kubernetes.io/name: test-project`
+  name: ` + name + `
+rules:
+- apiGroups:
+  - example.com
+  resources:
+  - widgets
+  verbs:
+  - get`, resource
+		}
 
-			result := templater.ApplyHelmSubstitutions(content, sa)
+		It("should keep the built-in rules by default", func() {
+			defaults := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
 
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+			auditor, auditorResource := role("test-project-auditor-role")
+			Expect(defaults.ApplyHelmSubstitutions(auditor, auditorResource)).NotTo(
+				ContainSubstring(".Values.rbac.helpers.enabled"))
+
+			viewer, viewerResource := role("test-project-widget-viewer-role")
+			Expect(defaults.ApplyHelmSubstitutions(viewer, viewerResource)).To(
+				HavePrefix("{{- if .Values.rbac.helpers.enabled }}\n"))
 		})
 
-		It("should template app.kubernetes.io/name for ClusterRole", func() {
-			clusterRole := &unstructured.Unstructured{}
-			clusterRole.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			clusterRole.SetKind("ClusterRole")
-			clusterRole.SetName("test-project-manager-role")
+		It("should render roles listed in HelperRBAC only with rbac.helpers.enabled", func() {
+			custom := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{HelperRBAC: []string{"test-project-auditor-role"}})
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  labels:
-    app.kubernetes.io/name: test-project`
+			auditor, auditorResource := role("test-project-auditor-role")
+			Expect(custom.ApplyHelmSubstitutions(auditor, auditorResource)).To(
+				HavePrefix("{{- if .Values.rbac.helpers.enabled }}\n"))
+		})
 
-			result := templater.ApplyHelmSubstitutions(content, clusterRole)
+		It("should always render roles listed in EssentialRBAC, even with a helper role name", func() {
+			custom := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{
+				HelperRBAC:    []string{"test-project-widget-viewer-role"},
+				EssentialRBAC: []string{"test-project-widget-viewer-role"},
+			})
 
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+			viewer, viewerResource := role("test-project-widget-viewer-role")
+			result := custom.ApplyHelmSubstitutions(viewer, viewerResource)
+			Expect(result).NotTo(ContainSubstring(".Values.rbac.helpers.enabled"))
+			Expect(result).To(ContainSubstring("kind: Role\n"))
 		})
+	})
 
-		It("should template app.kubernetes.io/name for Role", func() {
-			role := &unstructured.Unstructured{}
-			role.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			role.SetKind("Role")
-			role.SetName("test-project-leader-election-role")
-
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: Role
+	Context("watch namespace", func() {
+		binding := func(name string) (string, *unstructured.Unstructured) {
+			resource := &unstructured.Unstructured{}
+			resource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			resource.SetKind("RoleBinding")
+			resource.SetName(name)
+			return `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
 metadata:
-  labels:
-    app.kubernetes.io/name: test-project`
+  name: ` + name + `
+  namespace: test-project-system
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: test-project-widget-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager
+  namespace: test-project-system
+`, resource
+		}
 
-			result := templater.ApplyHelmSubstitutions(content, role)
+		render := func(content string, resource *unstructured.Unstructured, values map[string]any) string {
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl":     templater.GenerateHelpers(),
+				"templates/rolebinding.yaml": templater.ApplyHelmSubstitutions(content, resource),
+			}, values)
+			Expect(err).NotTo(HaveOccurred())
+			return rendered["templates/rolebinding.yaml"]
+		}
 
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		It("should deploy Roles and RoleBindings to the release namespace by default", func() {
+			content, resource := binding("test-project-widget-rolebinding")
+			rendered := render(content, resource, map[string]any{
+				"rbac":           map[string]any{},
+				"serviceAccount": map[string]any{"enabled": true},
+			})
+			Expect(rendered).To(ContainSubstring("  namespace: my-namespace\nroleRef:"))
+			Expect(rendered).NotTo(ContainSubstring("test-project-system"))
 		})
 
-		It("should template app.kubernetes.io/name for RoleBinding", func() {
-			rb := &unstructured.Unstructured{}
-			rb.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			rb.SetKind("RoleBinding")
-			rb.SetName("test-project-leader-election-rolebinding")
+		It("should deploy Roles and RoleBindings to rbac.watchNamespace when set", func() {
+			content, resource := binding("test-project-widget-rolebinding")
+			rendered := render(content, resource, map[string]any{
+				"rbac":           map[string]any{"watchNamespace": "watched"},
+				"serviceAccount": map[string]any{"enabled": true},
+			})
+			Expect(rendered).To(ContainSubstring("  namespace: watched\nroleRef:"))
+			By("keeping the subject on the ServiceAccount in the release namespace")
+			Expect(rendered).To(MatchRegexp(`subjects:\n(.*\n)*  namespace: my-namespace`))
+		})
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: RoleBinding
-metadata:
-  labels:
-    app.kubernetes.io/name: test-project`
+		Context("WATCH_NAMESPACE env var", func() {
+			var deployment *unstructured.Unstructured
 
-			result := templater.ApplyHelmSubstitutions(content, rb)
+			BeforeEach(func() {
+				deployment = &unstructured.Unstructured{}
+				deployment.SetAPIVersion("apps/v1")
+				deployment.SetKind("Deployment")
+				deployment.SetName("test-project-controller-manager")
+			})
 
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+			renderManager := func(content string, rbac map[string]any) string {
+				GinkgoHelper()
+				rendered, err := renderTemplate(templater.ApplyHelmSubstitutions(content, deployment), map[string]any{
+					"manager": map[string]any{"image": map[string]any{"repository": "controller"}},
+					"rbac":    rbac,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
+
+			const withoutEnv = `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        image: controller:latest
+        name: manager`
+
+			It("should only set WATCH_NAMESPACE when rbac.watchNamespace is set", func() {
+				Expect(renderManager(withoutEnv, map[string]any{})).NotTo(ContainSubstring("env:"))
+				Expect(renderManager(withoutEnv, map[string]any{"watchNamespace": "watched"})).To(ContainSubstring(`
+        env:
+        - name: WATCH_NAMESPACE
+          value: "watched"
+        image: "controller:0.1.0"`))
+			})
+
+			It("should set WATCH_NAMESPACE ahead of the manager.env entries", func() {
+				content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        env:
+        - name: POD_NAMESPACE
+          value: default
+        image: controller:latest
+        name: manager`
+				templated := templater.ApplyHelmSubstitutions(content, deployment)
+				Expect(strings.Count(templated, "        env:\n")).To(Equal(1))
+
+				rendered, err := renderTemplate(templated, map[string]any{
+					"manager": map[string]any{
+						"image": map[string]any{"repository": "controller"},
+						"env":   []any{map[string]any{"name": "POD_NAMESPACE", "value": "default"}},
+					},
+					"rbac": map[string]any{"watchNamespace": "watched"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rendered).To(ContainSubstring(`
+        env:
+          - name: WATCH_NAMESPACE
+            value: "watched"
+          - name: POD_NAMESPACE
+            value: default
+`))
+				Expect(renderManager(content, map[string]any{})).To(ContainSubstring("env:\n          []\n"))
+			})
 		})
 
-		It("should template app.kubernetes.io/name for ClusterRoleBinding", func() {
-			crb := &unstructured.Unstructured{}
-			crb.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			crb.SetKind("ClusterRoleBinding")
-			crb.SetName("test-project-manager-rolebinding")
+		It("should keep the leader election RoleBinding with the lease", func() {
+			content, resource := binding("test-project-leader-election-rolebinding")
+			Expect(templater.ApplyHelmSubstitutions(content, resource)).NotTo(
+				ContainSubstring(".Values.rbac.watchNamespace"))
+		})
 
+		It("should not change cluster-scoped RBAC", func() {
+			resource := &unstructured.Unstructured{}
+			resource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			resource.SetKind("ClusterRoleBinding")
+			resource.SetName("test-project-metrics-auth-rolebinding")
 			content := `apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
+metadata:
+  name: test-project-metrics-auth-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: test-project-metrics-auth-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager
+  namespace: test-project-system
+`
+			Expect(templater.ApplyHelmSubstitutions(content, resource)).NotTo(
+				ContainSubstring(".Values.rbac.watchNamespace"))
+		})
+	})
+
+	Context("flat values layout", func() {
+		var deployment *unstructured.Unstructured
+
+		const managerDeployment = `apiVersion: apps/v1
+kind: Deployment
 metadata:
   labels:
-    app.kubernetes.io/name: test-project`
+    control-plane: controller-manager
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        image: controller:latest
+        imagePullPolicy: IfNotPresent
+        name: manager`
 
-			result := templater.ApplyHelmSubstitutions(content, crb)
+		BeforeEach(func() {
+			deployment = &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+			deployment.SetLabels(map[string]string{"control-plane": "controller-manager"})
+		})
 
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		// renderImage renders the templated image and imagePullPolicy lines of the manager container.
+		renderImage := func(templated string, values map[string]any) string {
+			GinkgoHelper()
+			lines := strings.Split(templated, "\n")
+			start := slices.IndexFunc(lines, func(line string) bool {
+				return strings.HasPrefix(strings.TrimSpace(line), "image: ")
+			})
+			Expect(start).To(BeNumerically(">=", 0))
+			rendered, err := renderTemplate(strings.Join(lines[start:start+2], "\n"), values)
+			Expect(err).NotTo(HaveOccurred())
+			return rendered
+		}
+
+		It("should read the manager image from manager.image by default", func() {
+			nested := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
+
+			result := nested.ApplyHelmSubstitutions(managerDeployment, deployment)
+			Expect(result).To(ContainSubstring("{{ .Values.manager.image.repository"))
+			Expect(result).To(ContainSubstring(
+				`imagePullPolicy: {{ .Values.manager.image.pullPolicy | default "IfNotPresent" }}`))
+			Expect(result).NotTo(ContainSubstring(".Values.image."))
+
+			Expect(renderImage(result, map[string]any{
+				"manager": map[string]any{"image": map[string]any{"repository": "example.com/op", "tag": "v1.2.0"}},
+			})).To(ContainSubstring(`image: "example.com/op:v1.2.0"`))
 		})
 
-		It("should template app.kubernetes.io/name for Certificate", func() {
-			cert := &unstructured.Unstructured{}
-			cert.SetAPIVersion("cert-manager.io/v1")
-			cert.SetKind("Certificate")
-			cert.SetName("test-project-serving-cert")
+		It("should default the image pull policy to IfNotPresent", func() {
+			result := templater.ApplyHelmSubstitutions(managerDeployment, deployment)
+			Expect(result).To(ContainSubstring(
+				`        imagePullPolicy: {{ .Values.manager.image.pullPolicy | default "IfNotPresent" }}` + "\n"))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+
+			Expect(renderImage(result, map[string]any{
+				"manager": map[string]any{"image": map[string]any{"repository": "example.com/op"}},
+			})).To(HaveSuffix("imagePullPolicy: IfNotPresent"))
+			Expect(renderImage(result, map[string]any{
+				"manager": map[string]any{"image": map[string]any{"repository": "example.com/op", "pullPolicy": "Always"}},
+			})).To(HaveSuffix("imagePullPolicy: Always"))
+		})
+
+		It("should read the manager image from the top-level image key with FlatValues", func() {
+			flat := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{FlatValues: true})
+
+			result, err := flat.ApplyHelmSubstitutionsE(managerDeployment, deployment)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(ContainSubstring("{{ .Values.image.repository"))
+			Expect(result).To(ContainSubstring(":{{ .Values.image.tag | default .Chart.AppVersion }}"))
+			Expect(result).To(ContainSubstring(`imagePullPolicy: {{ .Values.image.pullPolicy | default "IfNotPresent" }}`))
+			Expect(result).NotTo(ContainSubstring(".Values.manager.image."))
+			Expect(strings.Count(result, "image: ")).To(Equal(1))
+			Expect(flat.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+
+			rendered := renderImage(result, map[string]any{
+				"image": map[string]any{"repository": "example.com/op", "tag": "v1.2.0", "pullPolicy": "Always"},
+			})
+			Expect(rendered).To(ContainSubstring(`image: "example.com/op:v1.2.0"`))
+			Expect(rendered).To(ContainSubstring("imagePullPolicy: Always"))
+		})
 
-			content := `apiVersion: cert-manager.io/v1
-kind: Certificate
-metadata:
-  labels:
-    app.kubernetes.io/name: test-project`
+		It("should quote the image reference by default", func() {
+			result := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{}).
+				ApplyHelmSubstitutions(managerDeployment, deployment)
 
-			result := templater.ApplyHelmSubstitutions(content, cert)
+			Expect(result).To(ContainSubstring("        " + expectedManagerImageLine + "\n"))
+		})
 
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		It("should leave the image reference unquoted with UnquotedImage", func() {
+			unquoted := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{UnquotedImage: true})
+
+			result := unquoted.ApplyHelmSubstitutions(managerDeployment, deployment)
+			Expect(result).To(ContainSubstring("        image: {{ with (.Values.global | default dict).imageRegistry }}"))
+			Expect(result).To(ContainSubstring(":{{ .Values.manager.image.tag | default .Chart.AppVersion }}{{- end }}\n"))
+			Expect(result).NotTo(ContainSubstring(`image: "`))
+			Expect(unquoted.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+
+			Expect(renderImage(result, map[string]any{
+				"manager": map[string]any{"image": map[string]any{"repository": "example.com/op", "digest": "sha256:abc"}},
+			})).To(ContainSubstring("image: example.com/op@sha256:abc\n"))
 		})
 
-		It("should template app.kubernetes.io/name for Issuer", func() {
-			issuer := &unstructured.Unstructured{}
-			issuer.SetAPIVersion("cert-manager.io/v1")
-			issuer.SetKind("Issuer")
-			issuer.SetName("test-project-selfsigned-issuer")
+		It("should require the image repository with RequireImageRepository", func() {
+			strict := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{RequireImageRepository: true})
 
-			content := `apiVersion: cert-manager.io/v1
-kind: Issuer
-metadata:
-  labels:
-    app.kubernetes.io/name: test-project`
+			result := strict.ApplyHelmSubstitutions(managerDeployment, deployment)
+			Expect(result).To(ContainSubstring(
+				`{{ required "manager.image.repository is required" .Values.manager.image.repository }}`))
+			Expect(result).NotTo(ContainSubstring(`default "controller"`))
+			Expect(strict.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
 
-			result := templater.ApplyHelmSubstitutions(content, issuer)
+			Expect(renderImage(result, map[string]any{
+				"manager": map[string]any{"image": map[string]any{"repository": "example.com/op", "tag": "v1.2.0"}},
+			})).To(ContainSubstring(`image: "example.com/op:v1.2.0"` + "\n"))
 
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+			_, err := renderTemplate(result, map[string]any{
+				"manager": map[string]any{"image": map[string]any{"tag": "v1.2.0"}},
+				"rbac":    map[string]any{},
+			})
+			Expect(err).To(MatchError(ContainSubstring("manager.image.repository is required")))
 		})
 
-		It("should handle label already templated without breaking", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+		It("should default the image repository without RequireImageRepository", func() {
+			result := templater.ApplyHelmSubstitutions(managerDeployment, deployment)
+			Expect(result).NotTo(ContainSubstring("required"))
 
-			content := `apiVersion: apps/v1
+			Expect(renderImage(result, map[string]any{
+				"manager": map[string]any{"image": map[string]any{"tag": "v1.2.0"}},
+			})).To(ContainSubstring(`image: "controller:v1.2.0"` + "\n"))
+		})
+
+		It("should require the flat image repository with FlatValues and RequireImageRepository", func() {
+			result := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{FlatValues: true, RequireImageRepository: true}).ApplyHelmSubstitutions(managerDeployment, deployment)
+			Expect(result).To(ContainSubstring(`{{ required "image.repository is required" .Values.image.repository }}`))
+		})
+	})
+
+	Context("manager args", func() {
+		var deployment *unstructured.Unstructured
+
+		const argsDeployment = `apiVersion: apps/v1
 kind: Deployment
 metadata:
   labels:
-    app.kubernetes.io/name: {{ include "test-project.name" . }}
-    control-plane: controller-manager`
-
-			result := templater.ApplyHelmSubstitutions(content, deployment)
-
-			// Should keep the template as-is
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).ToNot(ContainSubstring("app.kubernetes.io/name: test-project"))
-		})
+    control-plane: controller-manager
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --zap-devel
+        command:
+        - /manager
+        image: controller:latest
+        name: manager
+`
 
-		It("should template multiple occurrences in same resource", func() {
-			deployment := &unstructured.Unstructured{}
+		BeforeEach(func() {
+			deployment = &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
+			deployment.SetLabels(map[string]string{"control-plane": "controller-manager"})
+		})
+
+		// renderArgs renders the args block of the manager container and returns the parsed list.
+		renderArgs := func(templated string, args []any) ([]string, error) {
+			GinkgoHelper()
+			lines := strings.Split(templated, "\n")
+			start := slices.IndexFunc(lines, func(line string) bool { return strings.TrimSpace(line) == "- args:" })
+			end := slices.IndexFunc(lines, func(line string) bool { return strings.TrimSpace(line) == "command:" })
+			Expect(start).To(BeNumerically(">=", 0))
+			Expect(end).To(BeNumerically(">", start))
+			rendered, err := renderTemplate(strings.Join(lines[start:end], "\n"),
+				map[string]any{"manager": map[string]any{"args": args}})
+			Expect(err).NotTo(HaveOccurred())
+
+			var containers []struct {
+				Args []string `json:"args"`
+			}
+			if err := yaml.Unmarshal([]byte(rendered), &containers); err != nil {
+				return nil, err
+			}
+			Expect(containers).To(HaveLen(1))
+			return containers[0].Args, nil
+		}
 
-			content := `apiVersion: apps/v1
+		args := []any{"--zap-encoder=console", "--zap-log-prefix=manager: leader election"}
+
+		It("should range over manager.args by default", func() {
+			result := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{}).
+				ApplyHelmSubstitutions(argsDeployment, deployment)
+
+			Expect(result).To(ContainSubstring(`        {{- range .Values.manager.args }}
+        - {{ . }}
+        {{- end }}`))
+			_, err := renderArgs(result, args)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should render manager.args with toYaml with ArgsAsYAML", func() {
+			asYAML := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{ArgsAsYAML: true})
+
+			result := asYAML.ApplyHelmSubstitutions(argsDeployment, deployment)
+			Expect(result).To(ContainSubstring(`        {{- with .Values.manager.args }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}`))
+			Expect(result).NotTo(ContainSubstring("range .Values.manager.args"))
+			Expect(asYAML.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+
+			rendered, err := renderArgs(result, args)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal([]string{"--zap-encoder=console", "--zap-log-prefix=manager: leader election"}))
+
+			rendered, err = renderArgs(result, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(BeEmpty())
+		})
+
+		It("should add an args block from manager.args when the manager container has no args", func() {
+			noArgs := strings.Replace(argsDeployment, "      - args:\n        - --zap-devel\n        command:",
+				"      - command:", 1)
+			result := templater.ApplyHelmSubstitutions(noArgs, deployment)
+
+			Expect(result).To(ContainSubstring(`        name: manager
+        {{- if .Values.manager.args }}
+        args:
+        {{- range .Values.manager.args }}
+        - {{ . }}
+        {{- end }}
+        {{- end }}`))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+
+			container := func(args []any) map[string]any {
+				GinkgoHelper()
+				manager := map[string]any{"image": map[string]any{"repository": "controller"}, "args": args}
+				rendered, err := renderTemplate(result, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				var parsed map[string]any
+				Expect(yaml.Unmarshal([]byte(rendered), &parsed)).To(Succeed())
+				containers, _, _ := unstructured.NestedSlice(parsed, "spec", "template", "spec", "containers")
+				Expect(containers).To(HaveLen(1))
+				return containers[0].(map[string]any)
+			}
+			Expect(container([]any{"--foo"})).To(HaveKeyWithValue("args", []any{"--foo"}))
+			Expect(container(nil)).NotTo(HaveKey("args"))
+
+			By("leaving an explicit empty args list alone")
+			emptyArgs := strings.Replace(argsDeployment, "      - args:\n        - --zap-devel\n",
+				"      - args: []\n", 1)
+			withEmpty := templater.ApplyHelmSubstitutions(emptyArgs, deployment)
+			Expect(withEmpty).To(ContainSubstring("args: []"))
+			Expect(withEmpty).NotTo(ContainSubstring(".Values.manager.args"))
+		})
+	})
+
+	Context("kube-rbac-proxy sidecar", func() {
+		const proxyDeployment = `apiVersion: apps/v1
 kind: Deployment
 metadata:
   labels:
-    app.kubernetes.io/name: test-project
+    control-plane: controller-manager
+  name: test-project-controller-manager
+  namespace: test-project-system
 spec:
+  replicas: 1
   selector:
     matchLabels:
-      app.kubernetes.io/name: test-project
+      control-plane: controller-manager
   template:
     metadata:
+      annotations:
+        kubectl.kubernetes.io/default-container: manager
       labels:
-        app.kubernetes.io/name: test-project`
-
-			result := templater.ApplyHelmSubstitutions(content, deployment)
-
-			// All three should be templated
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
-			// Count occurrences - should be 3
-			count := strings.Count(result, "app.kubernetes.io/name: {{ include \"test-project.name\" . }}")
-			Expect(count).To(Equal(3))
+        control-plane: controller-manager
+    spec:
+      containers:
+      - args:
+        - --secure-listen-address=0.0.0.0:8443
+        - --upstream=http://127.0.0.1:8080/
+        - --logtostderr=true
+        - --v=0
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.16.0
+        name: kube-rbac-proxy
+        ports:
+        - containerPort: 8443
+          name: https
+          protocol: TCP
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+          requests:
+            cpu: 5m
+            memory: 64Mi
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+      - args:
+        - --health-probe-bind-address=:8081
+        - --metrics-bind-address=127.0.0.1:8080
+        - --leader-elect
+        command:
+        - /manager
+        image: controller:latest
+        name: manager
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+          requests:
+            cpu: 10m
+            memory: 64Mi
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+      securityContext:
+        runAsNonRoot: true
+      serviceAccountName: test-project-controller-manager
+      terminationGracePeriodSeconds: 10
+`
+
+		var deployment *unstructured.Unstructured
+
+		BeforeEach(func() {
+			deployment = &unstructured.Unstructured{}
+			Expect(yaml.Unmarshal([]byte(proxyDeployment), &deployment.Object)).To(Succeed())
+		})
+
+		// renderContainers renders the templated Deployment and returns its containers by name.
+		renderContainers := func(templated string, metricsEnabled bool) map[string]map[string]any {
+			GinkgoHelper()
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl": templater.GenerateHelpers(),
+				"templates/manager.yaml": templated,
+			}, map[string]any{
+				"manager": map[string]any{
+					"replicas":    1,
+					"image":       map[string]any{"repository": "controller", "tag": "v1.0.0"},
+					"healthProbe": map[string]any{"port": 8081},
+				},
+				"metrics":        map[string]any{"enabled": metricsEnabled, "port": 9090, "secure": true},
+				"rbac":           map[string]any{},
+				"serviceAccount": map[string]any{"enabled": true},
+				"kubeRbacProxy": map[string]any{
+					"image": map[string]any{
+						"repository": "quay.io/brancz/kube-rbac-proxy", "tag": "v0.18.0", "pullPolicy": "Always",
+					},
+					"resources": map[string]any{"limits": map[string]any{"cpu": "100m"}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var renderedDeployment struct {
+				Spec struct {
+					Template struct {
+						Spec struct {
+							Containers []map[string]any `json:"containers"`
+						} `json:"spec"`
+					} `json:"template"`
+				} `json:"spec"`
+			}
+			Expect(yaml.Unmarshal([]byte(rendered["templates/manager.yaml"]), &renderedDeployment)).To(Succeed())
+			containers := map[string]map[string]any{}
+			for _, container := range renderedDeployment.Spec.Template.Spec.Containers {
+				containers[container["name"].(string)] = container
+			}
+			return containers
+		}
+
+		It("should template the sidecar image and resources and guard it with metrics.enabled", func() {
+			result := templater.ApplyHelmSubstitutions(proxyDeployment, deployment)
+
+			Expect(result).To(ContainSubstring(`      {{- if .Values.metrics.enabled }}
+      - args:
+        - --secure-listen-address=0.0.0.0:8443
+        - --upstream=http://127.0.0.1:{{ .Values.metrics.port }}/`))
+			Expect(result).To(ContainSubstring(`{{ .Values.kubeRbacProxy.image.repository }}`))
+			Expect(result).To(ContainSubstring(`        {{- with .Values.kubeRbacProxy.resources }}
+        resources:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}`))
+			Expect(result).NotTo(ContainSubstring("gcr.io/kubebuilder/kube-rbac-proxy"))
+			Expect(result).To(ContainSubstring("{{- range .Values.manager.args }}"))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+
+			containers := renderContainers(result, true)
+			Expect(containers).To(HaveLen(2))
+			proxy := containers["kube-rbac-proxy"]
+			Expect(proxy["image"]).To(Equal("quay.io/brancz/kube-rbac-proxy:v0.18.0"))
+			Expect(proxy["imagePullPolicy"]).To(Equal("Always"))
+			Expect(proxy["resources"]).To(Equal(map[string]any{"limits": map[string]any{"cpu": "100m"}}))
+			Expect(proxy["args"]).To(ContainElement("--upstream=http://127.0.0.1:9090/"))
+			Expect(containers["manager"]["image"]).To(Equal("controller:v1.0.0"))
+			Expect(containers["manager"]["args"]).To(ContainElement("--metrics-bind-address=127.0.0.1:9090"))
+
+			containers = renderContainers(result, false)
+			Expect(containers).To(HaveLen(1))
+			Expect(containers).To(HaveKey("manager"))
 		})
 	})
 
-	Context("existing Go template syntax escaping", func() {
-		It("should escape existing Go template syntax in CRD samples", func() {
-			crdResource := &unstructured.Unstructured{}
-			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
-			crdResource.SetKind("CustomResourceDefinition")
-			crdResource.SetName("changetransferpolicies.promoter.argoproj.io")
+	Context("Deployments shipped next to the manager", func() {
+		const workerDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-worker
+  namespace: test-project-system
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - args:
+        - --queue=default
+        image: example.com/worker:v1.0.0
+        imagePullPolicy: Always
+        name: worker
+        resources:
+          limits:
+            cpu: 200m
+      - image: example.com/log-shipper:v2
+        name: log-shipper
+`
+		const operatorDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-some-operator
+  namespace: test-project-system
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - image: example.com/operator@sha256:abc123
+        name: operator
+`
 
-			content := `apiVersion: apiextensions.k8s.io/v1
-kind: CustomResourceDefinition
+		var multi *Templater
+
+		newDeployment := func(content string) *unstructured.Unstructured {
+			deployment := &unstructured.Unstructured{}
+			Expect(yaml.Unmarshal([]byte(content), &deployment.Object)).To(Succeed())
+			return deployment
+		}
+
+		BeforeEach(func() {
+			multi = NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
+			multi.SetDeploymentValuesKeys(map[string]string{
+				"test-project-worker":        "worker",
+				"test-project-some-operator": "someOperator",
+			})
+		})
+
+		It("should read each Deployment from its own values key", func() {
+			worker := multi.ApplyHelmSubstitutions(workerDeployment, newDeployment(workerDeployment))
+			operator := multi.ApplyHelmSubstitutions(operatorDeployment, newDeployment(operatorDeployment))
+
+			Expect(worker).To(ContainSubstring(`  replicas: {{ dig "replicas" 2 (.Values.worker | default dict) }}`))
+			Expect(worker).To(ContainSubstring(
+				`{{ dig "image" "repository" "example.com/worker" (.Values.worker | default dict) }}`))
+			Expect(worker).To(ContainSubstring(
+				`        {{- with dig "image" "pullPolicy" "Always" (.Values.worker | default dict) }}
+        imagePullPolicy: {{ . }}
+        {{- end }}`))
+			Expect(worker).To(ContainSubstring(
+				`{{- with dig "resources" (fromYaml "limits:\n  cpu: 200m") (.Values.worker | default dict) }}`))
+			Expect(worker).To(ContainSubstring("      - image: example.com/log-shipper:v2\n"))
+			Expect(worker).NotTo(ContainSubstring("someOperator"))
+
+			Expect(operator).To(ContainSubstring(`(.Values.someOperator | default dict)`))
+			Expect(operator).To(ContainSubstring(`{{- with dig "resources" dict (.Values.someOperator | default dict) }}`))
+			Expect(operator).NotTo(ContainSubstring(".Values.worker"))
+
+			for _, templated := range []string{worker, operator} {
+				Expect(templated).NotTo(ContainSubstring(".Values.manager"))
+				Expect(templater.Validate(templated)).To(Succeed())
+			}
+			Expect(multi.ApplyHelmSubstitutions(worker, newDeployment(workerDeployment))).To(Equal(worker))
+		})
+
+		It("should default to the kustomize output and follow the values", func() {
+			render := func(content string, values map[string]any) map[string]any {
+				GinkgoHelper()
+				templated := multi.ApplyHelmSubstitutions(content, newDeployment(content))
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl":    multi.GenerateHelpers(),
+					"templates/deployment.yaml": templated,
+				}, values)
+				Expect(err).NotTo(HaveOccurred())
+				object := map[string]any{}
+				Expect(yaml.Unmarshal([]byte(rendered["templates/deployment.yaml"]), &object)).To(Succeed())
+				return object
+			}
+			container := func(object map[string]any, index int) map[string]any {
+				GinkgoHelper()
+				containers, found, err := unstructured.NestedSlice(object, "spec", "template", "spec", "containers")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				return containers[index].(map[string]any)
+			}
+
+			defaults := render(workerDeployment, map[string]any{})
+			Expect(defaults["spec"]).To(HaveKeyWithValue("replicas", BeNumerically("==", 2)))
+			Expect(container(defaults, 0)).To(HaveKeyWithValue("image", "example.com/worker:v1.0.0"))
+			Expect(container(defaults, 0)).To(HaveKeyWithValue("imagePullPolicy", "Always"))
+			Expect(container(defaults, 0)).To(HaveKeyWithValue("resources",
+				map[string]any{"limits": map[string]any{"cpu": "200m"}}))
+			Expect(container(render(operatorDeployment, map[string]any{}), 0)).
+				To(HaveKeyWithValue("image", "example.com/operator@sha256:abc123"))
+
+			overridden := render(workerDeployment, map[string]any{
+				"worker": map[string]any{
+					"replicas":  3,
+					"image":     map[string]any{"repository": "mirror.local/worker", "tag": "v1.1.0"},
+					"resources": map[string]any{"requests": map[string]any{"cpu": "50m"}},
+				},
+				"someOperator": map[string]any{"replicas": 5},
+			})
+			Expect(overridden["spec"]).To(HaveKeyWithValue("replicas", BeNumerically("==", 3)))
+			Expect(container(overridden, 0)).To(HaveKeyWithValue("image", "mirror.local/worker:v1.1.0"))
+			Expect(container(overridden, 0)).To(HaveKeyWithValue("resources",
+				map[string]any{"requests": map[string]any{"cpu": "50m"}}))
+			Expect(container(overridden, 1)).To(HaveKeyWithValue("image", "example.com/log-shipper:v2"))
+		})
+	})
+
+	Context("name overrides", func() {
+		const resources = `apiVersion: v1
+kind: ServiceAccount
 metadata:
-  name: changetransferpolicies.promoter.argoproj.io
+  name: test-project-controller-manager
+  namespace: test-project-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-webhook-service
+  namespace: test-project-system
 spec:
-  names:
-    kind: ChangeTransferPolicy
-  versions:
-  - name: v1alpha1
-    schema:
-      openAPIV3Schema:
-        properties:
-          spec:
-            properties:
-              pullRequest:
-                properties:
-                  template:
-                    properties:
-                      description:
-                        default: "Promoting {{ .ChangeTransferPolicy.Spec.ActiveBranch }}"
-                        type: string
-                      title:
-                        default: "Promote {{ trunc 5 .ChangeTransferPolicy.Status.Proposed.Dry.Sha }}"
-                        type: string`
+  ports:
+  - port: 443
+    targetPort: 9443
+  selector:
+    control-plane: controller-manager
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-serving-cert
+  namespace: test-project-system
+spec:
+  dnsNames:
+  - test-project-webhook-service.test-project-system.svc
+  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer
+  secretName: webhook-server-cert
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  annotations:
+    cert-manager.io/inject-ca-from: test-project-system/test-project-serving-cert
+  name: test-project-validating-webhook-configuration
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    service:
+      name: test-project-webhook-service
+      namespace: test-project-system
+      path: /validate-example-com-v1-guestbook
+  name: vguestbook-v1.kb.io
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: test-project-manager-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: test-project-manager-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager
+  namespace: test-project-system
+`
 
-			result := templater.ApplyHelmSubstitutions(content, crdResource)
+		render := func(values map[string]any) string {
+			GinkgoHelper()
+			templated := map[string]string{"templates/_helpers.tpl": templater.GenerateHelpers()}
+			for i, document := range strings.Split(resources, "---\n") {
+				resource := &unstructured.Unstructured{}
+				Expect(yaml.Unmarshal([]byte(document), &resource.Object)).To(Succeed())
+				templated[fmt.Sprintf("templates/resource-%d.yaml", i)] = templater.ApplyHelmSubstitutions(document, resource)
+			}
+			rendered, err := renderChart(templated, values)
+			Expect(err).NotTo(HaveOccurred())
+			var output strings.Builder
+			for i := range strings.Count(resources, "---\n") + 1 {
+				output.WriteString(rendered[fmt.Sprintf("templates/resource-%d.yaml", i)] + "\n---\n")
+			}
+			return output.String()
+		}
 
-			// Existing {{ }} should be escaped to {{ "{{ ... }}" }}
-			Expect(result).To(ContainSubstring(`{{ "{{ .ChangeTransferPolicy.Spec.ActiveBranch }}" }}`),
-				"existing template syntax should be escaped")
-			Expect(result).To(ContainSubstring(`{{ "{{ trunc 5 .ChangeTransferPolicy.Status.Proposed.Dry.Sha }}" }}`),
-				"function calls in templates should be escaped")
+		It("should name every resource and reference after fullnameOverride", func() {
+			rendered := render(map[string]any{
+				"fullnameOverride": "custom",
+				"certManager":      map[string]any{"enabled": true},
+				"webhook":          map[string]any{"enabled": true, "port": 9443},
+				"serviceAccount":   map[string]any{"enabled": true},
+				"rbac":             map[string]any{},
+			})
 
-			// Should NOT have unescaped Go template syntax (which would break Helm)
-			// We check that all ChangeTransferPolicy references are properly wrapped
-			// Pattern checks for: default: "...<text>{{ .ChangeTransferPolicy" (not escaped)
-			// The properly escaped version is: default: "...{{ "{{ .ChangeTransferPolicy..." }}"
-			Expect(result).NotTo(MatchRegexp(`default:\s+"[^{]*\{\{\s*\.ChangeTransferPolicy`),
-				"unescaped Go templates should not exist in default values")
+			for _, name := range []string{
+				"custom-controller-manager", "custom-webhook-service", "custom-serving-cert",
+				"custom-validating-webhook-configuration", "custom-manager-rolebinding",
+			} {
+				Expect(rendered).To(ContainSubstring("  name: " + name + "\n"))
+			}
+			Expect(rendered).To(ContainSubstring("  - custom-webhook-service.my-namespace.svc\n"))
+			Expect(rendered).To(ContainSubstring("    name: custom-selfsigned-issuer\n"))
+			Expect(rendered).To(ContainSubstring("cert-manager.io/inject-ca-from: my-namespace/custom-serving-cert\n"))
+			Expect(rendered).To(ContainSubstring("      name: custom-webhook-service\n"))
+			Expect(rendered).To(ContainSubstring("  name: custom-manager-role\n"))
+			Expect(rendered).To(ContainSubstring("- kind: ServiceAccount\n  name: custom-controller-manager\n"))
+			Expect(rendered).NotTo(MatchRegexp(`name: (my-release|test-project)`))
+		})
+
+		It("should add nameOverride to the release name", func() {
+			rendered := render(map[string]any{
+				"nameOverride":   "renamed",
+				"certManager":    map[string]any{"enabled": true},
+				"webhook":        map[string]any{"enabled": true, "port": 9443},
+				"serviceAccount": map[string]any{"enabled": true},
+				"rbac":           map[string]any{},
+			})
+
+			Expect(rendered).To(ContainSubstring("  name: my-release-renamed-webhook-service\n"))
+			Expect(rendered).To(ContainSubstring("- kind: ServiceAccount\n  name: my-release-renamed-controller-manager\n"))
 		})
+	})
 
-		It("should escape multiple template expressions on the same line", func() {
-			crdResource := &unstructured.Unstructured{}
-			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
-			crdResource.SetKind("CustomResourceDefinition")
-			crdResource.SetName("policies.example.com")
+	Context("Deployment selector", func() {
+		const selector = `  selector:
+    matchLabels:
+      app.kubernetes.io/managed-by: kustomize
+      app.kubernetes.io/version: v1.0.0
+      control-plane: worker
+      tier: backend
+`
+		const workerDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+    control-plane: worker
+  name: test-project-worker
+  namespace: test-project-system
+spec:
+` + selector + `  template:
+    metadata:
+      labels:
+        app.kubernetes.io/managed-by: kustomize
+        app.kubernetes.io/version: v1.0.0
+        control-plane: worker
+        tier: backend
+    spec:
+      containers:
+      - image: example.com/worker:v1.0.0
+        name: worker
+`
 
-			content := `apiVersion: apiextensions.k8s.io/v1
-kind: CustomResourceDefinition
+		var worker *unstructured.Unstructured
+
+		BeforeEach(func() {
+			worker = &unstructured.Unstructured{}
+			Expect(yaml.Unmarshal([]byte(workerDeployment), &worker.Object)).To(Succeed())
+		})
+
+		It("should keep matchLabels byte-identical to the kustomize output", func() {
+			result := templater.ApplyHelmSubstitutions(workerDeployment, worker)
+
+			Expect(result).To(ContainSubstring("spec:\n" + selector + "  template:\n"))
+			Expect(result).To(ContainSubstring(`{{- with omit (include "test-project.labels" . | fromYaml) ` +
+				`"app.kubernetes.io/version" "app.kubernetes.io/managed-by" }}`))
+			Expect(templater.ApplyHelmSubstitutions(result, worker)).To(Equal(result))
+		})
+
+		It("should render pod labels matching the selector whatever the common labels", func() {
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl":    templater.GenerateHelpers(),
+				"templates/deployment.yaml": templater.ApplyHelmSubstitutions(workerDeployment, worker),
+			}, map[string]any{"commonLabels": map[string]any{"tier": "frontend", "team": "platform"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := map[string]any{}
+			Expect(yaml.Unmarshal([]byte(rendered["templates/deployment.yaml"]), &deployment)).To(Succeed())
+			matchLabels, _, err := unstructured.NestedStringMap(deployment, "spec", "selector", "matchLabels")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matchLabels).To(Equal(map[string]string{
+				"app.kubernetes.io/managed-by": "kustomize",
+				"app.kubernetes.io/version":    "v1.0.0",
+				"control-plane":                "worker",
+				"tier":                         "backend",
+			}))
+			podLabels, _, err := unstructured.NestedStringMap(deployment, "spec", "template", "metadata", "labels")
+			Expect(err).NotTo(HaveOccurred())
+			for key, value := range matchLabels {
+				Expect(podLabels).To(HaveKeyWithValue(key, value))
+			}
+			Expect(podLabels).To(HaveKeyWithValue("team", "platform"))
+			Expect(podLabels).To(HaveKeyWithValue("app.kubernetes.io/instance", "my-release"))
+		})
+	})
+
+	Context("managed-by label", func() {
+		var service *unstructured.Unstructured
+
+		const labeledService = `apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+    app.kubernetes.io/name: test-project
+  name: test-project-extra-service
 spec:
-  versions:
-  - schema:
-      openAPIV3Schema:
-        properties:
-          spec:
-            properties:
-              message:
-                default: "From {{ .Source.Branch }} to {{ .Target.Branch }}"`
+  ports:
+  - port: 80
+  selector:
+    app.kubernetes.io/managed-by: kustomize
+    app.kubernetes.io/name: test-project
+`
+
+		BeforeEach(func() {
+			service = &unstructured.Unstructured{}
+			service.SetAPIVersion("v1")
+			service.SetKind("Service")
+			service.SetName("test-project-extra-service")
+		})
+
+		render := func(t *Templater) string {
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl": t.GenerateHelpers(),
+				"templates/service.yaml": t.ApplyHelmSubstitutions(labeledService, service),
+			}, map[string]any{})
+			Expect(err).NotTo(HaveOccurred())
+			return rendered["templates/service.yaml"]
+		}
+
+		It("should set managed-by to the Helm release service by default", func() {
+			defaults := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
+
+			result := defaults.ApplyHelmSubstitutions(labeledService, service)
+			Expect(result).NotTo(ContainSubstring("managed-by: kustomize"))
+			Expect(result).To(ContainSubstring("    app.kubernetes.io/managed-by: {{ .Release.Service }}\n"))
+
+			rendered := render(defaults)
+			Expect(strings.Count(rendered, "app.kubernetes.io/managed-by: Helm")).To(Equal(2))
+		})
+
+		It("should set managed-by to a fixed value when configured", func() {
+			fixed := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{ManagedBy: "argocd"})
+
+			rendered := render(fixed)
+			Expect(strings.Count(rendered, "app.kubernetes.io/managed-by: argocd")).To(Equal(2))
+			Expect(rendered).NotTo(ContainSubstring("managed-by: Helm"))
+		})
+
+		It("should keep the kustomize managed-by labels when the rewrite is skipped", func() {
+			keep := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{KeepManagedBy: true, ManagedBy: "argocd"})
+
+			result := keep.ApplyHelmSubstitutions(labeledService, service)
+			Expect(result).NotTo(ContainSubstring(".Release.Service"))
+			Expect(keep.ApplyHelmSubstitutions(result, service)).To(Equal(result))
+
+			rendered := render(keep)
+			Expect(strings.Count(rendered, "app.kubernetes.io/managed-by: kustomize")).To(Equal(2))
+			Expect(rendered).NotTo(ContainSubstring("managed-by: Helm"))
+			Expect(rendered).NotTo(ContainSubstring("managed-by: argocd"))
+			Expect(rendered).To(ContainSubstring("    app.kubernetes.io/instance: my-release\n"))
+		})
+	})
+
+	Context("canonical key order", func() {
+		configMapYAML := `apiVersion: v1
+data:
+  log-level: info
+kind: ConfigMap
+metadata:
+  name: test-project-manager-config
+  namespace: test-project-system
+`
+		configMap := &unstructured.Unstructured{}
+		configMap.SetAPIVersion("v1")
+		configMap.SetKind("ConfigMap")
+		configMap.SetName("test-project-manager-config")
+		configMap.SetNamespace(testProjectSystemNamespace)
+
+		It("should keep the kustomize key order by default", func() {
+			result := templater.ApplyHelmSubstitutions(configMapYAML, configMap)
+			Expect(result).To(HavePrefix("apiVersion: v1\ndata:\n"))
+		})
+
+		It("should order the top-level keys apiVersion, kind, metadata first when enabled", func() {
+			ordered := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{CanonicalKeyOrder: true})
+
+			result := ordered.ApplyHelmSubstitutions(configMapYAML, configMap)
+			Expect(result).To(Equal(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    {{- include "test-project.labels" . | nindent 4 }}
+    {{- with .Values.commonLabels }}
+    {{- with omit . "helm.sh/chart" "app.kubernetes.io/version" "app.kubernetes.io/instance" "app.kubernetes.io/managed-by" }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- end }}
+  name: {{ include "test-project.resourceName" (dict "suffix" "manager-config" "context" $) }}
+  namespace: {{ .Release.Namespace }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+data:
+  {{- with .Values.config }}
+  {{- toYaml . | nindent 2 }}
+  {{- end }}
+  {{- if not (hasKey (.Values.config | default dict) "log-level") }}
+  log-level: info
+  {{- end }}`))
+			Expect(ordered.ApplyHelmSubstitutions(result, configMap)).To(Equal(result))
+		})
+
+		It("should move a guarded kind with its whole conditional block", func() {
+			ordered := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{CanonicalKeyOrder: true})
+			clusterRole := &unstructured.Unstructured{}
+			clusterRole.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			clusterRole.SetKind("ClusterRole")
+			clusterRole.SetName("test-project-manager-role")
+
+			result := ordered.ApplyHelmSubstitutions(`aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      example.com/aggregate: "true"
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-project-manager-role
+rules: []
+`, clusterRole)
+
+			Expect(result).To(HavePrefix(`apiVersion: rbac.authorization.k8s.io/v1
+{{- if .Values.rbac.namespaced }}
+kind: Role
+{{- else }}
+kind: ClusterRole
+{{- end }}
+metadata:
+`))
+			Expect(result).To(ContainSubstring(`
+aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      example.com/aggregate: "true"
+rules: []
+`))
+		})
+	})
+
+	Context("Namespace", func() {
+		namespaceYAML := `apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+    app.kubernetes.io/name: test-project
+    control-plane: controller-manager
+  name: test-project-system
+`
+		namespace := &unstructured.Unstructured{}
+		namespace.SetAPIVersion("v1")
+		namespace.SetKind("Namespace")
+		namespace.SetName(testProjectSystemNamespace)
+
+		render := func(t *Templater, values map[string]any) string {
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl":   t.GenerateHelpers(),
+				"templates/namespace.yaml": t.ApplyHelmSubstitutions(namespaceYAML, namespace),
+			}, values)
+			Expect(err).NotTo(HaveOccurred())
+			return rendered["templates/namespace.yaml"]
+		}
+
+		It("should drop the Namespace by default", func() {
+			defaults := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
+
+			Expect(strings.TrimSpace(defaults.ApplyHelmSubstitutions(namespaceYAML, namespace))).To(BeEmpty())
+		})
+
+		It("should keep the Namespace behind namespace.create when KeepNamespace is set", func() {
+			keep := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{KeepNamespace: true})
+
+			result := keep.ApplyHelmSubstitutions(namespaceYAML, namespace)
+			Expect(result).To(HavePrefix("{{- if (.Values.namespace | default dict).create }}\n"))
+			Expect(result).To(ContainSubstring("  name: {{ .Release.Namespace }}\n"))
+			Expect(result).NotTo(ContainSubstring(testProjectSystemNamespace))
+			Expect(keep.ApplyHelmSubstitutions(result, namespace)).To(Equal(result))
+
+			Expect(strings.TrimSpace(render(keep, map[string]any{}))).To(BeEmpty())
+
+			rendered := render(keep, map[string]any{"namespace": map[string]any{"create": true}})
+			Expect(rendered).To(ContainSubstring("kind: Namespace\n"))
+			Expect(rendered).To(ContainSubstring("  name: my-namespace\n"))
+			Expect(rendered).To(ContainSubstring("    app.kubernetes.io/managed-by: Helm\n"))
+		})
+
+		It("should add namespace.labels to the kept Namespace without overriding managed-by", func() {
+			keep := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{KeepNamespace: true})
+
+			result := keep.ApplyHelmSubstitutions(namespaceYAML, namespace)
+			Expect(result).To(ContainSubstring("{{- with (.Values.namespace | default dict).labels }}"))
+			Expect(keep.ApplyHelmSubstitutions(result, namespace)).To(Equal(result))
+
+			rendered := render(keep, map[string]any{"namespace": map[string]any{
+				"create": true,
+				"labels": map[string]any{
+					"pod-security.kubernetes.io/enforce": "restricted",
+					"app.kubernetes.io/managed-by":       "someone-else",
+				},
+			}})
+			var parsed map[string]any
+			Expect(yaml.Unmarshal([]byte(rendered), &parsed)).To(Succeed())
+			labels := parsed["metadata"].(map[string]any)["labels"].(map[string]any)
+			Expect(labels).To(HaveKeyWithValue("pod-security.kubernetes.io/enforce", "restricted"))
+			Expect(labels).To(HaveKeyWithValue("app.kubernetes.io/managed-by", "Helm"))
+			Expect(labels).To(HaveKeyWithValue("control-plane", "controller-manager"))
+		})
+	})
+
+	Context("OmitCertManager", func() {
+		const certificateYAML = `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-serving-cert
+  namespace: test-project-system
+spec:
+  dnsNames:
+  - test-project-webhook-service.test-project-system.svc
+  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer
+  secretName: webhook-server-cert
+`
+		const issuerYAML = `apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: test-project-selfsigned-issuer
+  namespace: test-project-system
+spec:
+  selfSigned: {}
+`
+
+		var omit *Templater
+
+		BeforeEach(func() {
+			omit = NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{OmitCertManager: true})
+		})
+
+		resource := func(apiVersion, kind, name string) *unstructured.Unstructured {
+			r := &unstructured.Unstructured{}
+			r.SetAPIVersion(apiVersion)
+			r.SetKind(kind)
+			r.SetName(name)
+			return r
+		}
+
+		It("should template Certificates and Issuers to nothing", func() {
+			certificate := resource("cert-manager.io/v1", "Certificate", "test-project-serving-cert")
+			issuer := resource("cert-manager.io/v1", "Issuer", "test-project-selfsigned-issuer")
+
+			Expect(omit.ApplyHelmSubstitutions(certificateYAML, certificate)).To(BeEmpty())
+			Expect(omit.ApplyHelmSubstitutions(issuerYAML, issuer)).To(BeEmpty())
+
+			Expect(templater.ApplyHelmSubstitutions(certificateYAML, certificate)).To(
+				HavePrefix("{{- if .Values.certManager.enabled }}"))
+			Expect(templater.ApplyHelmSubstitutions(issuerYAML, issuer)).To(
+				HavePrefix("{{- if .Values.certManager.enabled }}"))
+		})
+
+		It("should keep kinds named like cert-manager's from other API groups", func() {
+			certificate := resource("example.com/v1", "Certificate", "test-project-serving-cert")
+
+			result := omit.ApplyHelmSubstitutions(
+				strings.Replace(certificateYAML, "cert-manager.io/v1", "example.com/v1", 1), certificate)
+
+			Expect(result).To(ContainSubstring("kind: Certificate\n"))
+		})
+	})
+
+	Context("OmitCRDs", func() {
+		const crdYAML = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+`
+
+		crd := &unstructured.Unstructured{}
+		crd.SetAPIVersion("apiextensions.k8s.io/v1")
+		crd.SetKind("CustomResourceDefinition")
+		crd.SetName("widgets.example.com")
+
+		It("should wrap CRDs in crd.enabled by default", func() {
+			result := templater.ApplyHelmSubstitutions(crdYAML, crd)
+
+			Expect(result).To(HavePrefix("{{- if .Values.crd.enabled }}\n"))
+			Expect(result).To(ContainSubstring("kind: CustomResourceDefinition\n"))
+		})
+
+		It("should template CRDs to nothing with the option", func() {
+			omit := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{OmitCRDs: true})
+
+			Expect(omit.ApplyHelmSubstitutions(crdYAML, crd)).To(BeEmpty())
+		})
+	})
+
+	Context("GenerateHelpers", func() {
+		It("should define every helper the templated resources include", func() {
+			certificate := &unstructured.Unstructured{}
+			certificate.SetAPIVersion("cert-manager.io/v1")
+			certificate.SetKind("Certificate")
+			certificate.SetName("test-project-metrics-certs")
+
+			content := `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+    app.kubernetes.io/name: test-project
+  name: test-project-metrics-certs
+  namespace: test-project-system
+spec:
+  dnsNames:
+  - SERVICE_NAME.SERVICE_NAMESPACE.svc
+  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer
+  secretName: metrics-server-cert
+`
+			templated := templater.ApplyHelmSubstitutions(content, certificate)
+			Expect(templated).To(ContainSubstring(`include "test-project.namespaceName"`))
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl":     templater.GenerateHelpers(),
+				"templates/certificate.yaml": templated,
+			}, map[string]any{
+				"certManager": map[string]any{"enabled": true},
+				"metrics":     map[string]any{"enabled": true, "secure": true},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/certificate.yaml"]).To(ContainSubstring(
+				"  - my-release-test-project-controller-manager-metrics-service.my-namespace.svc\n"))
+			Expect(rendered["templates/certificate.yaml"]).To(ContainSubstring(
+				"    name: my-release-test-project-selfsigned-issuer\n"))
+			Expect(rendered["templates/certificate.yaml"]).To(ContainSubstring(
+				"    app.kubernetes.io/name: test-project\n"))
+		})
+	})
+
+	Context("ApplyHelmSubstitutionsE", func() {
+		var deploymentResource *unstructured.Unstructured
+
+		BeforeEach(func() {
+			deploymentResource = &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+			deploymentResource.SetLabels(map[string]string{"control-plane": "controller-manager"})
+		})
+
+		deploymentWithContainer := func(name string) string {
+			return `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        image: controller:latest
+        name: ` + name + `
+`
+		}
+
+		It("should not report an error for a regular manager Deployment", func() {
+			result, err := templater.ApplyHelmSubstitutionsE(deploymentWithContainer("manager"), deploymentResource)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(templater.ApplyHelmSubstitutions(deploymentWithContainer("manager"), deploymentResource)))
+		})
+
+		It("should report an error for a manager Deployment without the manager container", func() {
+			result, err := templater.ApplyHelmSubstitutionsE(deploymentWithContainer("controller"), deploymentResource)
+
+			Expect(err).To(MatchError(And(
+				ContainSubstring(`deployment "test-project-controller-manager"`),
+				ContainSubstring(`no container named "manager" found`))))
+			Expect(result).To(ContainSubstring("replicas: {{ .Values.manager.replicas }}"))
+			Expect(result).To(ContainSubstring("image: controller:latest"))
+		})
+
+		It("should follow the default-container annotation", func() {
+			content := strings.Replace(deploymentWithContainer("controller"), "  template:\n",
+				"  template:\n    metadata:\n      annotations:\n        kubectl.kubernetes.io/default-container: controller\n", 1)
+
+			_, err := templater.ApplyHelmSubstitutionsE(content, deploymentResource)
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should not report errors for other kinds", func() {
+			serviceResource := &unstructured.Unstructured{}
+			serviceResource.SetAPIVersion("v1")
+			serviceResource.SetKind("Service")
+			serviceResource.SetName("test-project-webhook-service")
+
+			_, err := templater.ApplyHelmSubstitutionsE(`apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-webhook-service
+`, serviceResource)
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("block scalars", func() {
+		It("should keep a ConfigMap block mentioning managed-by unchanged", func() {
+			configMap := &unstructured.Unstructured{}
+			configMap.SetAPIVersion("v1")
+			configMap.SetKind("ConfigMap")
+			configMap.SetName("test-project-manager-config")
+			block := `  controller_manager_config.yaml: |
+    metadata:
+      labels:
+        app.kubernetes.io/managed-by: kustomize
+        app.kubernetes.io/name: test-project
+    leaderElection:
+      leaderElect: true
+`
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: v1
+data:
+`+block+`kind: ConfigMap
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+  name: test-project-manager-config
+  namespace: test-project-system
+`, configMap)
+
+			Expect(result).To(ContainSubstring(block))
+			Expect(strings.Count(result, "include \"test-project.labels\"")).To(Equal(1))
+		})
+
+		It("should keep multiline annotations and descriptions out of the label rewrites", func() {
+			service := &unstructured.Unstructured{}
+			service.SetAPIVersion("v1")
+			service.SetKind("Service")
+			service.SetName("test-project-extra-service")
+			annotation := `    example.com/runbook: |-
+      Scaled by the platform team.
+      labels:
+        app.kubernetes.io/managed-by: kustomize
+        app.kubernetes.io/name: test-project
+`
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+`+annotation+`  labels:
+    app.kubernetes.io/managed-by: kustomize
+    app.kubernetes.io/name: test-project
+  name: test-project-extra-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 80
+`, service)
+
+			Expect(result).To(ContainSubstring(annotation))
+			Expect(result).To(ContainSubstring("    app.kubernetes.io/name: {{ include \"test-project.name\" . }}\n"))
+			Expect(strings.Count(result, "include \"test-project.labels\"")).To(Equal(1))
+			Expect(templater.Validate(result)).To(Succeed())
+		})
+	})
+
+	Context("CRLF line endings", func() {
+		It("should produce the same LF output for CRLF input", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+			deploymentResource.SetLabels(map[string]string{"control-plane": "controller-manager"})
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - args:
+        - --leader-elect
+        - --health-probe-bind-address=:8081
+        - --webhook-cert-path=/tmp/k8s-webhook-server/serving-certs
+        command:
+        - /manager
+        image: controller:latest
+        name: manager
+        resources:
+          limits:
+            cpu: 500m
+        volumeMounts:
+        - mountPath: /tmp/k8s-webhook-server/serving-certs
+          name: webhook-certs
+          readOnly: true
+      serviceAccountName: test-project-controller-manager
+      volumes:
+      - name: webhook-certs
+        secret:
+          secretName: webhook-server-cert
+`
+			crlfContent := strings.ReplaceAll(content, "\n", "\r\n")
+
+			result := templater.ApplyHelmSubstitutions(crlfContent, deploymentResource)
+
+			Expect(result).NotTo(ContainSubstring("\r"))
+			Expect(result).To(Equal(templater.ApplyHelmSubstitutions(content, deploymentResource)))
+			Expect(result).To(ContainSubstring("replicas: {{ .Values.manager.replicas }}\n"))
+			Expect(result).To(ContainSubstring(expectedManagerImageLine + "\n"))
+		})
+	})
+
+	Context("metrics cert secret name", func() {
+		var deploymentResource *unstructured.Unstructured
+
+		BeforeEach(func() {
+			deploymentResource = &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+		})
+
+		deploymentWithSecret := func(secretName string) string {
+			return `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        volumeMounts:
+        - mountPath: /tmp/k8s-metrics-server/metrics-certs
+          name: metrics-certs
+          readOnly: true
+      volumes:
+      - name: metrics-certs
+        secret:
+          items:
+          - key: ca.crt
+            path: ca.crt
+          optional: false
+          secretName: ` + secretName + `
+      - name: webhook-certs
+        secret:
+          secretName: webhook-server-cert
+`
+		}
+
+		It("should wrap and template the default metrics cert volume", func() {
+			result := templater.ApplyHelmSubstitutions(deploymentWithSecret("metrics-server-cert"), deploymentResource)
+
+			Expect(result).To(MatchRegexp(
+				`\{\{- if and \.Values\.certManager\.enabled \.Values\.metrics\.enabled \.Values\.metrics\.secure }}\n\s+- name: metrics-certs\n`))
+			Expect(result).To(ContainSubstring(
+				`secretName: {{ .Values.metrics.certSecretName | default "metrics-server-cert" }}`))
+		})
+
+		It("should still wrap the metrics cert volume when the secret is renamed", func() {
+			result := templater.ApplyHelmSubstitutions(deploymentWithSecret("my-metrics-tls"), deploymentResource)
+
+			Expect(result).To(MatchRegexp(
+				`\{\{- if and \.Values\.certManager\.enabled \.Values\.metrics\.enabled \.Values\.metrics\.secure }}\n\s+- name: metrics-certs\n`))
+			Expect(result).To(ContainSubstring(
+				`secretName: {{ .Values.metrics.certSecretName | default "my-metrics-tls" }}`))
+			Expect(result).NotTo(ContainSubstring("secretName: my-metrics-tls"))
+			Expect(result).NotTo(ContainSubstring(`.Values.metrics.certSecretName | default "webhook-server-cert"`))
+
+			twice := templater.ApplyHelmSubstitutions(result, deploymentResource)
+			Expect(twice).To(Equal(result))
+		})
+
+		It("should render the overridden secret name", func() {
+			block := `secretName: {{ .Values.metrics.certSecretName | default "metrics-server-cert" }}`
+
+			rendered, err := renderTemplate(block, map[string]any{"metrics": map[string]any{}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal("secretName: metrics-server-cert"))
+
+			rendered, err = renderTemplate(block, map[string]any{
+				"metrics": map[string]any{"certSecretName": "byo-metrics-tls"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal("secretName: byo-metrics-tls"))
+		})
+
+		It("should template the secretName of a renamed metrics Certificate", func() {
+			certResource := &unstructured.Unstructured{}
+			certResource.SetAPIVersion("cert-manager.io/v1")
+			certResource.SetKind("Certificate")
+			certResource.SetName("test-project-metrics-certs")
+
+			content := `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-metrics-certs
+  namespace: test-project-system
+spec:
+  dnsNames:
+  - test-project-controller-manager-metrics-service.test-project-system.svc
+  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer
+  secretName: my-metrics-tls
+`
+			result := templater.ApplyHelmSubstitutions(content, certResource)
+
+			Expect(result).To(ContainSubstring(
+				`secretName: {{ .Values.metrics.certSecretName | default "my-metrics-tls" }}`))
+		})
+	})
+
+	Context("webhook cert secret name", func() {
+		var deploymentResource *unstructured.Unstructured
+
+		BeforeEach(func() {
+			deploymentResource = &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+		})
+
+		deploymentWithSecret := func(secretName string) string {
+			return `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+      volumes:
+      - name: webhook-certs
+        secret:
+          secretName: ` + secretName + `
+`
+		}
+
+		DescribeTable("should wrap the webhook cert volume and template its secret name",
+			func(secretName string) {
+				result := templater.ApplyHelmSubstitutions(deploymentWithSecret(secretName), deploymentResource)
+
+				Expect(result).To(MatchRegexp(
+					`\{\{- if or \.Values\.certManager\.enabled \(\.Values\.webhook \| default dict\)\.caBundle }}` +
+						`\n\s+- name: webhook-certs\n`))
+				Expect(result).To(ContainSubstring(
+					fmt.Sprintf(`secretName: {{ .Values.webhook.certSecretName | default %q }}`, secretName)))
+				Expect(result).NotTo(ContainSubstring("secretName: " + secretName))
+				Expect(templater.ApplyHelmSubstitutions(result, deploymentResource)).To(Equal(result))
+			},
+			Entry("default secret", "webhook-server-cert"),
+			Entry("renamed secret", "my-webhook-tls"),
+		)
+
+		It("should render the default and overridden secret names", func() {
+			block := `secretName: {{ .Values.webhook.certSecretName | default "webhook-server-cert" }}`
+
+			rendered, err := renderTemplate(block, map[string]any{"webhook": map[string]any{}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal("secretName: webhook-server-cert"))
+
+			rendered, err = renderTemplate(block, map[string]any{
+				"webhook": map[string]any{"certSecretName": "byo-webhook-tls"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal("secretName: byo-webhook-tls"))
+		})
+
+		It("should template the secretName of the serving Certificate", func() {
+			certResource := &unstructured.Unstructured{}
+			certResource.SetAPIVersion("cert-manager.io/v1")
+			certResource.SetKind("Certificate")
+			certResource.SetName("test-project-serving-cert")
+
+			content := `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-serving-cert
+  namespace: test-project-system
+spec:
+  dnsNames:
+  - test-project-webhook-service.test-project-system.svc
+  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer
+  secretName: webhook-server-cert
+`
+			result := templater.ApplyHelmSubstitutions(content, certResource)
+
+			Expect(result).To(ContainSubstring(
+				`secretName: {{ .Values.webhook.certSecretName | default "webhook-server-cert" }}`))
+		})
+
+		It("should append webhook.extraDNSNames to the dnsNames of the serving Certificate", func() {
+			certResource := &unstructured.Unstructured{}
+			certResource.SetAPIVersion("cert-manager.io/v1")
+			certResource.SetKind("Certificate")
+			certResource.SetName("test-project-serving-cert")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-serving-cert
+  namespace: test-project-system
+spec:
+  dnsNames:
+  - test-project-webhook-service.test-project-system.svc
+  - test-project-webhook-service.test-project-system.svc.cluster.local
+  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer
+  secretName: webhook-server-cert
+`, certResource)
+
+			Expect(result).To(ContainSubstring(".svc.cluster.local\n" +
+				"  {{- range .Values.webhook.extraDNSNames }}\n" +
+				"  - {{ . | quote }}\n" +
+				"  {{- end }}\n" +
+				"  issuerRef:\n"))
+			Expect(templater.ApplyHelmSubstitutions(result, certResource)).To(Equal(result))
+			Expect(templater.Validate(result)).To(Succeed())
+
+			dnsNames := func(webhook map[string]any) []any {
+				GinkgoHelper()
+				rendered, err := renderTemplate(result, map[string]any{
+					"certManager": map[string]any{"enabled": true},
+					"webhook":     webhook,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				object := map[string]any{}
+				Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+				found, _, err := unstructured.NestedSlice(object, "spec", "dnsNames")
+				Expect(err).NotTo(HaveOccurred())
+				return found
+			}
+			serviceNames := []any{
+				"my-release-test-project-webhook-service.my-namespace.svc",
+				"my-release-test-project-webhook-service.my-namespace.svc.cluster.local",
+			}
+
+			Expect(dnsNames(map[string]any{})).To(Equal(serviceNames))
+			Expect(dnsNames(map[string]any{
+				"extraDNSNames": []any{"webhook.example.com", "*.webhooks.example.com"},
+			})).To(Equal(append(serviceNames, "webhook.example.com", "*.webhooks.example.com")))
+		})
+
+		It("should not add webhook.extraDNSNames to the metrics Certificate", func() {
+			certResource := &unstructured.Unstructured{}
+			certResource.SetAPIVersion("cert-manager.io/v1")
+			certResource.SetKind("Certificate")
+			certResource.SetName("test-project-metrics-certs")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-metrics-certs
+  namespace: test-project-system
+spec:
+  dnsNames:
+  - test-project-controller-manager-metrics-service.test-project-system.svc
+  secretName: metrics-server-cert
+`, certResource)
+
+			Expect(result).NotTo(ContainSubstring("extraDNSNames"))
+		})
+	})
+
+	Context("Secret resources", func() {
+		It("should keep multiline Secret data intact while templating its metadata", func() {
+			secretResource := &unstructured.Unstructured{}
+			secretResource.SetAPIVersion("v1")
+			secretResource.SetKind("Secret")
+			secretResource.SetName("test-project-bootstrap")
+
+			data := `data:
+  ca.crt: |
+    LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMvekNDQWVlZ0F3SUJBZ0lCQURBTkJna3Fo
+    a2lHOXcwQkFRc0ZBREFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1CNFhEVEl5TURVd01U
+    SXpOREF3TVZvWERUTXlNRFF5T0RJek5EQXdNVm93RlRFVE1CRUdBMVVFCg==
+  token: dGVzdC1wcm9qZWN0LXN5c3RlbQ==
+`
+			stringData := `stringData:
+  config.yaml: |
+    metadata:
+      labels:
+        app.kubernetes.io/name: test-project
+      name: test-project-controller-manager
+    url: https://test-project-webhook-service.test-project-system.svc:443
+`
+			content := `apiVersion: v1
+` + data + `kind: Secret
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+  name: test-project-bootstrap
+  namespace: test-project-system
+` + stringData + `type: Opaque
+`
+			result := templater.ApplyHelmSubstitutions(content, secretResource)
+
+			Expect(result).To(ContainSubstring(data))
+			Expect(result).To(ContainSubstring(strings.ReplaceAll(
+				stringData, ".test-project-system.", ".{{ .Release.Namespace }}.")))
+			Expect(result).To(ContainSubstring("  namespace: {{ .Release.Namespace }}\n"))
+			Expect(result).To(ContainSubstring("    {{- include \"test-project.labels\" . | nindent 4 }}\n"))
+			Expect(result).NotTo(ContainSubstring("__kubebuilder_data_payload"))
+			Expect(templater.Validate(result)).To(Succeed())
+		})
+	})
+
+	Context("ConfigMap resources", func() {
+		var configMapResource *unstructured.Unstructured
+
+		const configMap = `apiVersion: v1
+data:
+  controller.yaml: |
+    leaderElection: true
+    namespace: test-project-system
+  log-level: info
+kind: ConfigMap
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+  name: test-project-manager-config
+  namespace: test-project-system
+`
+
+		BeforeEach(func() {
+			configMapResource = &unstructured.Unstructured{}
+			configMapResource.SetAPIVersion("v1")
+			configMapResource.SetKind("ConfigMap")
+			configMapResource.SetName("test-project-manager-config")
+		})
+
+		dataBlock := func(result string) string {
+			start := strings.Index(result, "data:\n")
+			end := strings.Index(result, "\nkind: ConfigMap")
+			Expect(start).To(BeNumerically(">=", 0))
+			Expect(end).To(BeNumerically(">", start))
+			return result[start:end]
+		}
+
+		It("should template the name, namespace and data of a ConfigMap", func() {
+			result := templater.ApplyHelmSubstitutions(configMap, configMapResource)
+
+			Expect(result).To(ContainSubstring(
+				`name: {{ include "test-project.resourceName" (dict "suffix" "manager-config" "context" $) }}`))
+			Expect(result).To(ContainSubstring("  namespace: {{ .Release.Namespace }}\n"))
+			Expect(result).To(ContainSubstring(`data:
+  {{- with .Values.config }}
+  {{- toYaml . | nindent 2 }}
+  {{- end }}
+  {{- if not (hasKey (.Values.config | default dict) "controller.yaml") }}
+  controller.yaml: |
+    leaderElection: true
+    namespace: {{ .Release.Namespace }}
+  {{- end }}
+  {{- if not (hasKey (.Values.config | default dict) "log-level") }}
+  log-level: info
+  {{- end }}
+`))
+			Expect(templater.ApplyHelmSubstitutions(result, configMapResource)).To(Equal(result))
+			Expect(templater.Validate(result)).To(Succeed())
+		})
+
+		It("should render the default data when config is not set", func() {
+			result := templater.ApplyHelmSubstitutions(configMap, configMapResource)
+
+			rendered, err := renderTemplate(dataBlock(result), map[string]any{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal(`data:
+  controller.yaml: |
+    leaderElection: true
+    namespace: my-namespace
+  log-level: info`))
+		})
+
+		It("should merge config values over the default data", func() {
+			result := templater.ApplyHelmSubstitutions(configMap, configMapResource)
+
+			rendered, err := renderTemplate(dataBlock(result), map[string]any{
+				"config": map[string]any{"log-level": "debug", "feature-gates": "Alpha=true"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal(`data:
+  feature-gates: Alpha=true
+  log-level: debug
+  controller.yaml: |
+    leaderElection: true
+    namespace: my-namespace`))
+		})
+	})
+
+	Context("config checksum", func() {
+		var deployment *unstructured.Unstructured
+
+		const managerDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  template:
+    metadata:
+      annotations:
+        kubectl.kubernetes.io/default-container: manager
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers:
+      - command:
+        - /manager
+        image: controller:latest
+        name: manager
+`
+
+		const checksumLine = `checksum/config: {{ include (print $.Template.BasePath "/extras/manager-config.yaml") . | sha256sum }}`
+
+		BeforeEach(func() {
+			deployment = &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+			deployment.SetLabels(map[string]string{"control-plane": "controller-manager"})
+		})
+
+		It("should add the checksum to the pod template annotations of the manager Deployment", func() {
+			templater.SetConfigMapTemplates([]string{"extras/manager-config.yaml"})
+
+			result := templater.ApplyHelmSubstitutions(managerDeployment, deployment)
+
+			Expect(result).To(ContainSubstring("    metadata:\n      annotations:\n        " + checksumLine + "\n" +
+				"        kubectl.kubernetes.io/default-container: manager\n"))
+			Expect(strings.Count(result, "checksum/config")).To(Equal(2), "annotation and pod annotations omit list")
+			Expect(result[:strings.Index(result, "\nspec:")]).NotTo(ContainSubstring("checksum/config"))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+			Expect(templater.Validate(result)).To(Succeed())
+		})
+
+		It("should create the pod template annotations when there are none", func() {
+			templater.SetConfigMapTemplates([]string{"extras/manager-config.yaml", "extras/feature-flags.yaml"})
+			content := strings.Replace(managerDeployment,
+				"      annotations:\n        kubectl.kubernetes.io/default-container: manager\n", "", 1)
+
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+
+			Expect(result).To(ContainSubstring("    metadata:\n      annotations:\n        checksum/config: " +
+				`{{ include (print $.Template.BasePath "/extras/manager-config.yaml") . | ` +
+				`cat (include (print $.Template.BasePath "/extras/feature-flags.yaml") .) | sha256sum }}` + "\n"))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+		})
+
+		It("should change the checksum when the config values change", func() {
+			templater.SetConfigMapTemplates([]string{"extras/manager-config.yaml"})
+			configMapResource := &unstructured.Unstructured{}
+			configMapResource.SetAPIVersion("v1")
+			configMapResource.SetKind("ConfigMap")
+			configMapResource.SetName("test-project-manager-config")
+			configMap := templater.ApplyHelmSubstitutions(`apiVersion: v1
+data:
+  log-level: info
+kind: ConfigMap
+metadata:
+  name: test-project-manager-config
+  namespace: test-project-system
+`, configMapResource)
+
+			checksum := func(values map[string]any) string {
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl":               templater.GenerateHelpers(),
+					"templates/extras/manager-config.yaml": configMap,
+					"templates/checksum.yaml":              checksumLine,
+				}, values)
+				Expect(err).NotTo(HaveOccurred())
+				return rendered["templates/checksum.yaml"]
+			}
+
+			defaults := checksum(map[string]any{})
+			Expect(defaults).To(MatchRegexp(`^checksum/config: [0-9a-f]{64}$`))
+			Expect(checksum(map[string]any{})).To(Equal(defaults))
+			Expect(checksum(map[string]any{"config": map[string]any{"log-level": "debug"}})).NotTo(Equal(defaults))
+		})
+
+		It("should not add the checksum when the chart has no ConfigMaps", func() {
+			result := templater.ApplyHelmSubstitutions(managerDeployment, deployment)
+
+			Expect(result).NotTo(ContainSubstring("checksum/config"))
+		})
+	})
+
+	Context("force restart", func() {
+		var deployment *unstructured.Unstructured
+
+		const managerDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  template:
+    metadata:
+      annotations:
+        kubectl.kubernetes.io/default-container: manager
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers:
+      - command:
+        - /manager
+        image: controller:latest
+        name: manager
+`
+
+		BeforeEach(func() {
+			deployment = &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+			deployment.SetLabels(map[string]string{"control-plane": "controller-manager"})
+		})
+
+		podAnnotations := func(templated string, manager map[string]any) map[string]any {
+			GinkgoHelper()
+			manager["image"] = map[string]any{"repository": "controller"}
+			rendered, err := renderTemplate(templated, map[string]any{"manager": manager, "rbac": map[string]any{}})
+			Expect(err).NotTo(HaveOccurred())
+			object := map[string]any{}
+			Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+			found, _, err := unstructured.NestedMap(object, "spec", "template", "metadata", "annotations")
+			Expect(err).NotTo(HaveOccurred())
+			return found
+		}
+
+		It("should add the restartedAt annotation to the pod template only when forceRestart is true", func() {
+			result := templater.ApplyHelmSubstitutions(managerDeployment, deployment)
+
+			Expect(result).To(ContainSubstring("      annotations:\n" +
+				"        kubectl.kubernetes.io/default-container: manager\n" +
+				"        {{- if .Values.manager.forceRestart }}\n" +
+				`        {{- toYaml (dict "kubectl.kubernetes.io/restartedAt" ` +
+				`(now | date "2006-01-02T15:04:05Z07:00")) | nindent 8 }}` + "\n" +
+				"        {{- end }}\n"))
+			Expect(result[:strings.Index(result, "\nspec:")]).NotTo(ContainSubstring("restartedAt"))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+			Expect(templater.Validate(result)).To(Succeed())
+
+			Expect(podAnnotations(result, map[string]any{"forceRestart": false})).To(Equal(map[string]any{
+				"kubectl.kubernetes.io/default-container": "manager",
+			}))
+			restarted := podAnnotations(result, map[string]any{"forceRestart": true})
+			Expect(restarted).To(HaveKeyWithValue("kubectl.kubernetes.io/default-container", "manager"))
+			Expect(restarted["kubectl.kubernetes.io/restartedAt"]).To(
+				MatchRegexp(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2})$`))
+		})
+
+		It("should keep a restartedAt annotation set in the pod annotations from values", func() {
+			result := templater.ApplyHelmSubstitutions(managerDeployment, deployment)
+
+			Expect(podAnnotations(result, map[string]any{
+				"pod": map[string]any{"annotations": map[string]any{"kubectl.kubernetes.io/restartedAt": "manual"}},
+			})).To(HaveKeyWithValue("kubectl.kubernetes.io/restartedAt", "manual"))
+		})
+
+		It("should create the pod template annotations when there are none", func() {
+			content := strings.Replace(managerDeployment,
+				"      annotations:\n        kubectl.kubernetes.io/default-container: manager\n", "", 1)
+
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+
+			Expect(result).To(ContainSubstring("    metadata:\n      annotations:\n" +
+				"        {{- if .Values.manager.forceRestart }}\n"))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+			Expect(podAnnotations(result, map[string]any{"forceRestart": true})).To(
+				HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+	})
+
+	Context("webhook Service selector", func() {
+		const managerDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+    control-plane: controller-manager
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: test-project
+      control-plane: controller-manager
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: test-project
+        control-plane: controller-manager
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager
+`
+		const webhookService = `apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+  name: test-project-webhook-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 443
+    protocol: TCP
+    targetPort: 9443
+  selector:
+    app.kubernetes.io/name: test-project
+    control-plane: controller-manager
+`
+
+		// renderLabels renders the map nested under the header line of the templated resource.
+		renderLabels := func(templated, header string, values map[string]any) map[string]string {
+			lines := strings.Split(templated, "\n")
+			start := slices.Index(lines, header)
+			Expect(start).To(BeNumerically(">=", 0), "missing %q", header)
+			indent := len(header) - len(strings.TrimLeft(header, " "))
+			end := start + 1
+			for end < len(lines) && len(lines[end])-len(strings.TrimLeft(lines[end], " ")) > indent {
+				end++
+			}
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl": templater.GenerateHelpers(),
+				"templates/labels.yaml":  strings.Join(lines[start:end], "\n"),
+			}, values)
+			Expect(err).NotTo(HaveOccurred())
+
+			var block map[string]map[string]string
+			dedented := regexp.MustCompile(`(?m)^ {`+fmt.Sprint(indent)+`}`).
+				ReplaceAllString(rendered["templates/labels.yaml"], "")
+			Expect(yaml.Unmarshal([]byte(dedented), &block)).To(Succeed())
+			return block[strings.TrimSuffix(strings.TrimSpace(header), ":")]
+		}
+
+		It("should select the manager pods with the labels of the Deployment selector", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+			deploymentResource.SetLabels(map[string]string{"control-plane": "controller-manager"})
+			serviceResource := &unstructured.Unstructured{}
+			serviceResource.SetAPIVersion("v1")
+			serviceResource.SetKind("Service")
+			serviceResource.SetName("test-project-webhook-service")
+
+			deployment := templater.ApplyHelmSubstitutions(managerDeployment, deploymentResource)
+			service := templater.ApplyHelmSubstitutions(webhookService, serviceResource)
+
+			Expect(service).To(ContainSubstring(`  selector:
+    app.kubernetes.io/name: {{ include "test-project.name" . }}
+    control-plane: controller-manager
+`))
+			for _, values := range []map[string]any{
+				{"manager": map[string]any{}},
+				{
+					"manager":      map[string]any{"pod": map[string]any{"labels": map[string]any{"tier": "control"}}},
+					"nameOverride": "renamed",
+					"commonLabels": map[string]any{"team": "platform"},
+				},
+			} {
+				selector := renderLabels(service, "  selector:", values)
+				podLabels := renderLabels(deployment, "      labels:", values)
+
+				Expect(selector).To(Equal(renderLabels(deployment, "    matchLabels:", values)))
+				for key, value := range selector {
+					Expect(podLabels).To(HaveKeyWithValue(key, value))
+				}
+			}
+			Expect(renderLabels(service, "  selector:", map[string]any{"nameOverride": "renamed"})).To(
+				HaveKeyWithValue("app.kubernetes.io/name", "renamed"))
+		})
+	})
+
+	Context("Service annotations", func() {
+		serviceResource := func(name string) *unstructured.Unstructured {
+			service := &unstructured.Unstructured{}
+			service.SetAPIVersion("v1")
+			service.SetKind("Service")
+			service.SetName(name)
+			return service
+		}
+
+		It("should merge metrics.service.annotations into existing metrics Service annotations", func() {
+			content := `apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    prometheus.io/scrape: "true"
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager-metrics-service
+  namespace: test-project-system
+spec:
+  ports:
+  - name: https
+    port: 8443
+    targetPort: 8443
+`
+			resource := serviceResource("test-project-controller-manager-metrics-service")
+
+			result := templater.ApplyHelmSubstitutions(content, resource)
+
+			Expect(result).To(ContainSubstring(`  annotations:
+    prometheus.io/scrape: "true"
+    {{- with (.Values.metrics.service | default dict).annotations }}
+    {{- with omit . "prometheus.io/scrape" }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- end }}
+    {{- with .Values.commonAnnotations }}
+    {{- with omit . "prometheus.io/scrape" }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- end }}
+  labels:
+`))
+			Expect(result).NotTo(ContainSubstring(".Values.webhook.service"))
+			Expect(templater.ApplyHelmSubstitutions(result, resource)).To(Equal(result))
+
+			block := result[strings.Index(result, "  annotations:"):strings.Index(result, "  labels:")]
+			rendered, err := renderTemplate(block, map[string]any{
+				"metrics": map[string]any{"service": map[string]any{"annotations": map[string]any{
+					"prometheus.io/scrape":                              "false",
+					"service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+				}}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal(`  annotations:
+    prometheus.io/scrape: "true"
+    service.beta.kubernetes.io/aws-load-balancer-type: nlb
+`))
+		})
+
+		It("should add webhook.service.annotations to a webhook Service without annotations", func() {
+			content := `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-webhook-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 443
+    targetPort: 9443
+`
+			resource := serviceResource("test-project-webhook-service")
+
+			result := templater.ApplyHelmSubstitutions(content, resource)
+
+			Expect(result).To(ContainSubstring(`  namespace: {{ .Release.Namespace }}
+  {{- if or ((.Values.webhook.service | default dict).annotations) .Values.commonAnnotations }}
+  annotations:
+    {{- with (.Values.webhook.service | default dict).annotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- with .Values.commonAnnotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- end }}
+spec:
+`))
+			Expect(result).NotTo(ContainSubstring(".Values.metrics.service"))
+
+			block := result[strings.Index(result, "  {{- if or ((.Values.webhook"):strings.Index(result, "spec:")]
+			rendered, err := renderTemplate(block, map[string]any{"webhook": map[string]any{}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.TrimSpace(rendered)).To(BeEmpty())
+
+			rendered, err = renderTemplate(block, map[string]any{
+				"webhook": map[string]any{"service": map[string]any{"annotations": map[string]any{"team": "platform"}}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal("\n  annotations:\n    team: platform\n"))
+		})
+
+		It("should leave other Services untouched", func() {
+			content := `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-extra-service
+spec:
+  ports:
+  - port: 80
+`
+			result := templater.ApplyHelmSubstitutions(content, serviceResource("test-project-extra-service"))
+
+			Expect(result).NotTo(ContainSubstring(".service | default dict"))
+		})
+	})
+
+	Context("metrics Service exposure", func() {
+		const metricsServiceName = "test-project-controller-manager-metrics-service"
+		content := `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-controller-manager-metrics-service
+  namespace: test-project-system
+spec:
+  ports:
+  - name: https
+    port: 8443
+    targetPort: 8443
+`
+		var (
+			result   string
+			exposure map[string]any
+		)
+
+		BeforeEach(func() {
+			exposure = map[string]any{
+				"externalTrafficPolicy":    "Local",
+				"loadBalancerSourceRanges": []any{"10.0.0.0/8"},
+			}
+
+			resource := &unstructured.Unstructured{}
+			resource.SetAPIVersion("v1")
+			resource.SetKind("Service")
+			resource.SetName(metricsServiceName)
+
+			result = templater.ApplyHelmSubstitutions(content, resource)
+			Expect(templater.ApplyHelmSubstitutions(result, resource)).To(Equal(result))
+		})
+
+		specBlock := func() string {
+			return result[strings.Index(result, "spec:"):strings.Index(result, "  ports:")]
+		}
+
+		It("should omit externalTrafficPolicy and loadBalancerSourceRanges for ClusterIP", func() {
+			Expect(result).To(ContainSubstring(
+				`  type: {{ (.Values.metrics.service | default dict).type | default "ClusterIP" }}`))
+
+			rendered, err := renderTemplate(specBlock(), map[string]any{"metrics": map[string]any{"service": exposure}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal("spec:\n  type: ClusterIP\n"))
+		})
+
+		It("should render externalTrafficPolicy and loadBalancerSourceRanges for LoadBalancer", func() {
+			exposure["type"] = "LoadBalancer"
+
+			rendered, err := renderTemplate(specBlock(), map[string]any{"metrics": map[string]any{"service": exposure}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal(`spec:
+  type: LoadBalancer
+  externalTrafficPolicy: Local
+  loadBalancerSourceRanges:
+    - 10.0.0.0/8
+`))
+		})
+
+		Context("port name and appProtocol", func() {
+			portsBlock := func() string {
+				return result[strings.Index(result, "  ports:"):strings.LastIndex(result, "{{- end }}")]
+			}
+			render := func(metrics map[string]any) string {
+				GinkgoHelper()
+				metrics["port"] = 8443
+				rendered, err := renderTemplate(portsBlock(), map[string]any{"metrics": metrics})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
+
+			It("should name the port https and set appProtocol for secure metrics", func() {
+				Expect(render(map[string]any{"secure": true, "appProtocol": "https"})).To(Equal(`  ports:
+  - name: https
+    appProtocol: https
+    port: 8443
+    targetPort: 8443
+`))
+			})
+
+			It("should name the port http and set appProtocol for insecure metrics", func() {
+				Expect(render(map[string]any{"secure": false, "appProtocol": "http"})).To(Equal(`  ports:
+  - name: http
+    appProtocol: http
+    port: 8443
+    targetPort: 8443
+`))
+			})
+
+			It("should omit appProtocol unless set", func() {
+				Expect(render(map[string]any{"secure": true})).To(Equal(`  ports:
+  - name: https
+    port: 8443
+    targetPort: 8443
+`))
+			})
+		})
+
+		It("should name the port and keep a manifest appProtocol as the default", func() {
+			resource := &unstructured.Unstructured{}
+			resource.SetAPIVersion("v1")
+			resource.SetKind("Service")
+			resource.SetName(metricsServiceName)
+
+			templated := templater.ApplyHelmSubstitutions(`apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-controller-manager-metrics-service
+spec:
+  ports:
+  - appProtocol: https
+    port: 8443
+    protocol: TCP
+    targetPort: 8443
+`, resource)
+
+			Expect(templated).To(ContainSubstring(`  ports:
+  - name: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}
+    {{- with .Values.metrics.appProtocol | default "https" }}
+    appProtocol: {{ . }}
+    {{- end }}
+    port: {{ .Values.metrics.port }}
+    protocol: TCP
+    targetPort: {{ .Values.metrics.port }}
+`))
+			Expect(templater.ApplyHelmSubstitutions(templated, resource)).To(Equal(templated))
+		})
+	})
+
+	Context("governance resources", func() {
+		It("should wrap a ResourceQuota in governance.enabled and move it to the release namespace", func() {
+			quota := &unstructured.Unstructured{}
+			quota.SetAPIVersion("v1")
+			quota.SetKind("ResourceQuota")
+			quota.SetName("test-project-quota")
+
+			content := `apiVersion: v1
+kind: ResourceQuota
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+    app.kubernetes.io/name: test-project
+  name: test-project-quota
+  namespace: test-project-system
+spec:
+  hard:
+    limits.cpu: "4"
+    pods: "10"
+`
+			result := templater.ApplyHelmSubstitutions(content, quota)
+
+			Expect(result).To(HavePrefix("{{- if .Values.governance.enabled }}\n"))
+			Expect(strings.TrimSpace(result)).To(HaveSuffix("{{- end }}"))
+			Expect(result).To(ContainSubstring("  namespace: {{ .Release.Namespace }}\n"))
+			Expect(result).NotTo(ContainSubstring(testProjectSystemNamespace))
+			Expect(result).To(ContainSubstring(`    {{- include "test-project.labels" . | nindent 4 }}`))
+			Expect(result).To(ContainSubstring(
+				`  name: {{ include "test-project.resourceName" (dict "suffix" "quota" "context" $) }}`))
+			Expect(templater.ApplyHelmSubstitutions(result, quota)).To(Equal(result))
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl": templater.GenerateHelpers(),
+				"templates/quota.yaml":   result,
+			}, map[string]any{"governance": map[string]any{"enabled": true}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/quota.yaml"]).To(ContainSubstring("  namespace: my-namespace\n"))
+			Expect(rendered["templates/quota.yaml"]).To(ContainSubstring("    limits.cpu: \"4\"\n"))
+
+			rendered, err = renderChart(map[string]string{
+				"templates/_helpers.tpl": templater.GenerateHelpers(),
+				"templates/quota.yaml":   result,
+			}, map[string]any{"governance": map[string]any{"enabled": false}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.TrimSpace(rendered["templates/quota.yaml"])).To(BeEmpty())
+		})
+
+		It("should wrap a LimitRange in governance.enabled", func() {
+			limitRange := &unstructured.Unstructured{}
+			limitRange.SetAPIVersion("v1")
+			limitRange.SetKind("LimitRange")
+			limitRange.SetName("test-project-limits")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: v1
+kind: LimitRange
+metadata:
+  name: test-project-limits
+  namespace: test-project-system
+spec:
+  limits:
+  - default:
+      cpu: 500m
+    type: Container
+`, limitRange)
+
+			Expect(result).To(HavePrefix("{{- if .Values.governance.enabled }}\n"))
+			Expect(result).To(ContainSubstring("  namespace: {{ .Release.Namespace }}\n"))
+		})
+	})
+
+	Context("PriorityClass", func() {
+		It("should create the PriorityClass from values and point the manager pods at it", func() {
+			priorityClass := &unstructured.Unstructured{}
+			priorityClass.SetAPIVersion("scheduling.k8s.io/v1")
+			priorityClass.SetKind("PriorityClass")
+			priorityClass.SetName("test-project-high-priority")
+
+			class := templater.ApplyHelmSubstitutions(`apiVersion: scheduling.k8s.io/v1
+description: Priority of the controller manager
+kind: PriorityClass
+metadata:
+  name: test-project-high-priority
+value: 1000000
+`, priorityClass)
+
+			Expect(class).To(HavePrefix("{{- if .Values.priorityClass.create }}\n"))
+			Expect(class).To(ContainSubstring("\nvalue: {{ .Values.priorityClass.value }}\n"))
+			Expect(templater.ApplyHelmSubstitutions(class, priorityClass)).To(Equal(class))
+
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			manager := templater.ApplyHelmSubstitutions(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  template:
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager
+      priorityClassName: test-project-high-priority
+`, deployment)
+
+			Expect(manager).To(ContainSubstring(`      {{- if (.Values.priorityClass | default dict).create }}
+      priorityClassName: {{ include "test-project.resourceName" (dict "suffix" "high-priority" "context" $) }}
+      {{- else }}
+      {{- with .Values.manager.priorityClassName }}
+      priorityClassName: {{ . | quote }}
+      {{- end }}
+      {{- end }}
+`))
+			Expect(templater.ApplyHelmSubstitutions(manager, deployment)).To(Equal(manager))
+
+			render := func(values map[string]any) map[string]string {
+				GinkgoHelper()
+				values["rbac"] = map[string]any{}
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl":    templater.GenerateHelpers(),
+					"templates/priority.yaml":   class,
+					"templates/deployment.yaml": manager,
+				}, values)
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
+
+			By("creating the class and referencing it")
+			rendered := render(map[string]any{
+				"priorityClass": map[string]any{"create": true, "value": 2000},
+				"manager": map[string]any{
+					"image":             map[string]any{"repository": "controller"},
+					"priorityClassName": "test-project-high-priority",
+				},
+			})
+			Expect(rendered["templates/priority.yaml"]).To(ContainSubstring(
+				"  name: my-release-test-project-high-priority\n"))
+			Expect(rendered["templates/priority.yaml"]).To(HaveSuffix("\nvalue: 2000"))
+			Expect(rendered["templates/deployment.yaml"]).To(ContainSubstring(
+				"      priorityClassName: my-release-test-project-high-priority\n"))
+
+			By("falling back to manager.priorityClassName without creating the class")
+			rendered = render(map[string]any{
+				"priorityClass": map[string]any{"create": false, "value": 2000},
+				"manager": map[string]any{
+					"image":             map[string]any{"repository": "controller"},
+					"priorityClassName": "system-cluster-critical",
+				},
+			})
+			Expect(strings.TrimSpace(rendered["templates/priority.yaml"])).To(BeEmpty())
+			Expect(rendered["templates/deployment.yaml"]).To(ContainSubstring(
+				"      priorityClassName: \"system-cluster-critical\"\n"))
+		})
+	})
+
+	Context("admission policies", func() {
+		It("should wrap a ValidatingAdmissionPolicy in admissionPolicy.enabled", func() {
+			policy := &unstructured.Unstructured{}
+			policy.SetAPIVersion("admissionregistration.k8s.io/v1")
+			policy.SetKind("ValidatingAdmissionPolicy")
+			policy.SetName("test-project-require-labels")
+
+			content := `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: test-project-require-labels
+spec:
+  failurePolicy: Fail
+  matchConstraints:
+    resourceRules:
+    - apiGroups:
+      - apps
+      apiVersions:
+      - v1
+      operations:
+      - CREATE
+      resources:
+      - deployments
+  validations:
+  - expression: has(object.metadata.labels)
+`
+			result := templater.ApplyHelmSubstitutions(content, policy)
+
+			Expect(result).To(HavePrefix("{{- if .Values.admissionPolicy.enabled }}\n"))
+			Expect(strings.TrimSpace(result)).To(HaveSuffix("{{- end }}"))
+			Expect(result).To(ContainSubstring(
+				`  name: {{ include "test-project.resourceName" (dict "suffix" "require-labels" "context" $) }}`))
+			Expect(templater.ApplyHelmSubstitutions(result, policy)).To(Equal(result))
+		})
+
+		It("should wrap a ValidatingAdmissionPolicyBinding and template its paramRef namespace", func() {
+			binding := &unstructured.Unstructured{}
+			binding.SetAPIVersion("admissionregistration.k8s.io/v1")
+			binding.SetKind("ValidatingAdmissionPolicyBinding")
+			binding.SetName("test-project-require-labels-binding")
+
+			content := `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: test-project-require-labels-binding
+spec:
+  paramRef:
+    name: test-project-require-labels-params
+    namespace: test-project-system
+    parameterNotFoundAction: Deny
+  policyName: test-project-require-labels
+  validationActions:
+  - Deny
+`
+			result := templater.ApplyHelmSubstitutions(content, binding)
+
+			Expect(result).To(HavePrefix("{{- if .Values.admissionPolicy.enabled }}\n"))
+			Expect(result).To(ContainSubstring("    namespace: {{ .Release.Namespace }}\n"))
+			Expect(result).NotTo(ContainSubstring(testProjectSystemNamespace))
+			Expect(result).To(ContainSubstring(
+				`  policyName: {{ include "test-project.resourceName" (dict "suffix" "require-labels" "context" $) }}`))
+			Expect(templater.ApplyHelmSubstitutions(result, binding)).To(Equal(result))
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl": templater.GenerateHelpers(),
+				"templates/binding.yaml": result,
+			}, map[string]any{"admissionPolicy": map[string]any{"enabled": true}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/binding.yaml"]).To(ContainSubstring("    namespace: my-namespace\n"))
+		})
+	})
+
+	Context("Gateway API", func() {
+		const metricsServiceName = `{{ include "test-project.resourceName" ` +
+			`(dict "suffix" "controller-manager-metrics-service" "context" $) }}`
+
+		It("should wrap an HTTPRoute in gateway.enabled and point it at the metrics service", func() {
+			route := &unstructured.Unstructured{}
+			route.SetAPIVersion("gateway.networking.k8s.io/v1")
+			route.SetKind("HTTPRoute")
+			route.SetName("test-project-metrics-route")
+
+			content := `apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: test-project-metrics-route
+  namespace: test-project-system
+spec:
+  parentRefs:
+  - name: test-project-gateway
+    namespace: test-project-system
+  rules:
+  - backendRefs:
+    - name: test-project-controller-manager-metrics-service
+      namespace: test-project-system
+      port: 8443
+    matches:
+    - path:
+        type: PathPrefix
+        value: /metrics
+`
+			result := templater.ApplyHelmSubstitutions(content, route)
+
+			Expect(result).To(HavePrefix("{{- if .Values.gateway.enabled }}\n"))
+			Expect(strings.TrimSpace(result)).To(HaveSuffix("{{- end }}"))
+			Expect(result).To(ContainSubstring("    - name: " + metricsServiceName + "\n" +
+				"      namespace: {{ .Release.Namespace }}\n"))
+			Expect(result).To(ContainSubstring(
+				`  - name: {{ include "test-project.resourceName" (dict "suffix" "gateway" "context" $) }}` + "\n" +
+					"    namespace: {{ .Release.Namespace }}\n"))
+			Expect(result).NotTo(ContainSubstring(testProjectSystemNamespace))
+			Expect(templater.ApplyHelmSubstitutions(result, route)).To(Equal(result))
+
+			service := &unstructured.Unstructured{}
+			service.SetAPIVersion("v1")
+			service.SetKind("Service")
+			service.SetName("test-project-controller-manager-metrics-service")
+			Expect(templater.ApplyHelmSubstitutions(`apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-controller-manager-metrics-service
+  namespace: test-project-system
+`, service)).To(ContainSubstring("  name: " + metricsServiceName + "\n"))
+		})
+
+		It("should wrap a Gateway in gateway.enabled", func() {
+			gateway := &unstructured.Unstructured{}
+			gateway.SetAPIVersion("gateway.networking.k8s.io/v1")
+			gateway.SetKind("Gateway")
+			gateway.SetName("test-project-gateway")
+
+			content := `apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: test-project-gateway
+  namespace: test-project-system
+spec:
+  gatewayClassName: example
+  listeners:
+  - name: http
+    port: 80
+    protocol: HTTP
+`
+			result := templater.ApplyHelmSubstitutions(content, gateway)
+
+			Expect(result).To(HavePrefix("{{- if .Values.gateway.enabled }}\n"))
+			Expect(result).To(ContainSubstring("  namespace: {{ .Release.Namespace }}\n"))
+		})
+
+		It("should leave a Gateway from another API group unwrapped", func() {
+			gateway := &unstructured.Unstructured{}
+			gateway.SetAPIVersion("networking.istio.io/v1")
+			gateway.SetKind("Gateway")
+			gateway.SetName("test-project-gateway")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: networking.istio.io/v1
+kind: Gateway
+metadata:
+  name: test-project-gateway
+`, gateway)
+
+			Expect(result).NotTo(ContainSubstring(".Values.gateway.enabled"))
+		})
+	})
+
+	Context("workloads running the manager image", func() {
+		const managerImage = `image: "{{ with (.Values.global | default dict).imageRegistry }}{{ . }}/{{ end }}` +
+			`{{ .Values.manager.image.repository | default "controller" }}`
+
+		It("should wrap a Job in jobs.enabled and template the manager image", func() {
+			job := &unstructured.Unstructured{}
+			job.SetAPIVersion("batch/v1")
+			job.SetKind("Job")
+			job.SetName("test-project-migrate")
+
+			content := `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test-project-migrate
+  namespace: test-project-system
+spec:
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        - migrate
+        image: controller:latest
+        name: manager
+      restartPolicy: Never
+`
+			result := templater.ApplyHelmSubstitutions(content, job)
+
+			Expect(result).To(HavePrefix("{{- if .Values.jobs.enabled }}\n"))
+			Expect(strings.TrimSpace(result)).To(HaveSuffix("{{- end }}"))
+			Expect(result).To(ContainSubstring("        " + managerImage))
+			Expect(result).NotTo(ContainSubstring("controller:latest"))
+			Expect(templater.ApplyHelmSubstitutions(result, job)).To(Equal(result))
+		})
+
+		It("should wrap a CronJob in jobs.enabled and template the manager image", func() {
+			cronJob := &unstructured.Unstructured{}
+			cronJob.SetAPIVersion("batch/v1")
+			cronJob.SetKind("CronJob")
+			cronJob.SetName("test-project-cleanup")
+
+			content := `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: test-project-cleanup
+  namespace: test-project-system
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: sidecar
+            image: busybox:1.36
+          - command:
+            - /manager
+            - cleanup
+            image: controller:latest
+            name: manager
+          restartPolicy: OnFailure
+`
+			result := templater.ApplyHelmSubstitutions(content, cronJob)
+
+			Expect(result).To(HavePrefix("{{- if .Values.jobs.enabled }}\n"))
+			Expect(result).To(ContainSubstring("            " + managerImage))
+			Expect(result).To(ContainSubstring("image: busybox:1.36"))
+			Expect(result).NotTo(ContainSubstring("controller:latest"))
+		})
+
+		It("should template only the manager image of a Job, not its env", func() {
+			job := &unstructured.Unstructured{}
+			job.SetAPIVersion("batch/v1")
+			job.SetKind("Job")
+			job.SetName("test-project-migrate")
+
+			content := `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test-project-migrate
+spec:
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        env:
+        - name: MODE
+          value: migrate
+        image: controller:latest
+        name: manager
+`
+			result := templater.ApplyHelmSubstitutions(content, job)
+
+			Expect(result).To(ContainSubstring("        " + managerImage))
+			Expect(result).To(ContainSubstring("          value: migrate"))
+			Expect(result).NotTo(ContainSubstring(".Values.manager.env"))
+			Expect(result).NotTo(ContainSubstring(".Values.manager.resources"))
+		})
+
+		It("should template the manager image of a DaemonSet without wrapping it", func() {
+			daemonSet := &unstructured.Unstructured{}
+			daemonSet.SetAPIVersion("apps/v1")
+			daemonSet.SetKind("DaemonSet")
+			daemonSet.SetName("test-project-node-agent")
+
+			content := `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: test-project-node-agent
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --node-agent
+        image: controller:latest
+        imagePullPolicy: Always
+        name: manager
+`
+			result := templater.ApplyHelmSubstitutions(content, daemonSet)
+
+			Expect(result).To(HavePrefix("apiVersion: apps/v1"))
+			Expect(result).To(ContainSubstring("        " + managerImage))
+			Expect(result).To(ContainSubstring(
+				`        imagePullPolicy: {{ .Values.manager.image.pullPolicy | default "IfNotPresent" }}`))
+			Expect(result).To(ContainSubstring("        - --node-agent"))
+			Expect(result).NotTo(ContainSubstring(".Values.manager.args"))
+			Expect(templater.ApplyHelmSubstitutions(result, daemonSet)).To(Equal(result))
+		})
+
+		It("should template the image, env and resources of a manager DaemonSet but not replicas", func() {
+			daemonSet := &unstructured.Unstructured{}
+			daemonSet.SetAPIVersion("apps/v1")
+			daemonSet.SetKind("DaemonSet")
+			daemonSet.SetName("test-project-node-manager")
+
+			content := `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: test-project-node-manager
+spec:
+  selector:
+    matchLabels:
+      control-plane: node-manager
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        env:
+        - name: NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        image: controller:latest
+        name: manager
+        resources:
+          limits:
+            cpu: 500m
+`
+			result := templater.ApplyHelmSubstitutions(content, daemonSet)
+
+			Expect(result).To(ContainSubstring("        " + managerImage))
+			Expect(result).To(ContainSubstring(".Values.manager.env"))
+			Expect(result).To(ContainSubstring(".Values.manager.resources"))
+			Expect(result).To(ContainSubstring("  {{- with .Values.manager.updateStrategy }}"))
+			Expect(result).NotTo(ContainSubstring("replicas"))
+			Expect(result).NotTo(ContainSubstring(".Values.manager.strategy"))
+			Expect(templater.ApplyHelmSubstitutions(result, daemonSet)).To(Equal(result))
+		})
+
+		It("should template the serviceName and persistence of a manager StatefulSet", func() {
+			statefulSet := &unstructured.Unstructured{}
+			statefulSet.SetAPIVersion("apps/v1")
+			statefulSet.SetKind("StatefulSet")
+			statefulSet.SetName("test-project-state")
+
+			content := `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: test-project-state
+  namespace: test-project-system
+spec:
+  serviceName: test-project-state-headless
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        image: controller:latest
+        name: manager
+        volumeMounts:
+        - mountPath: /data
+          name: data
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      accessModes:
+      - ReadWriteOnce
+      resources:
+        requests:
+          storage: 1Gi
+  - metadata:
+      name: cache
+    spec:
+      resources:
+        requests:
+          storage: 512Mi
+      storageClassName: fast
+`
+			result := templater.ApplyHelmSubstitutions(content, statefulSet)
+
+			Expect(result).To(ContainSubstring(
+				`  serviceName: {{ include "test-project.resourceName" (dict "suffix" "state-headless" "context" $) }}`))
+			Expect(result).To(ContainSubstring("        " + managerImage))
+			Expect(result).To(ContainSubstring(
+				`          storage: {{ (.Values.manager.persistence | default dict).size | default "1Gi" }}`))
+			Expect(result).To(ContainSubstring(
+				`      storageClassName: {{ (.Values.manager.persistence | default dict).storageClassName | default "fast" }}`))
+			Expect(templater.ApplyHelmSubstitutions(result, statefulSet)).To(Equal(result))
+
+			claims := result[strings.Index(result, "  volumeClaimTemplates:"):]
+			rendered, err := renderTemplate(claims, map[string]any{
+				"manager": map[string]any{"persistence": map[string]any{"size": "10Gi", "storageClassName": "standard"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal(`  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      storageClassName: standard
+      accessModes:
+      - ReadWriteOnce
+      resources:
+        requests:
+          storage: 10Gi
+  - metadata:
+      name: cache
+    spec:
+      resources:
+        requests:
+          storage: 10Gi
+      storageClassName: standard
+`))
+
+			rendered, err = renderTemplate(claims, map[string]any{"manager": map[string]any{}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(ContainSubstring("storage: 1Gi"))
+			Expect(rendered).To(ContainSubstring("storage: 512Mi"))
+			Expect(rendered).To(ContainSubstring("storageClassName: fast"))
+			Expect(strings.Count(rendered, "storageClassName")).To(Equal(1))
+		})
+
+		It("should leave the image of a Job without a manager container untouched", func() {
+			job := &unstructured.Unstructured{}
+			job.SetAPIVersion("batch/v1")
+			job.SetKind("Job")
+			job.SetName("test-project-hook")
+
+			content := `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test-project-hook
+spec:
+  template:
+    spec:
+      containers:
+      - image: busybox:1.36
+        name: manager-hook
+`
+			result := templater.ApplyHelmSubstitutions(content, job)
+
+			Expect(result).To(ContainSubstring("image: busybox:1.36"))
+			Expect(result).NotTo(ContainSubstring(".Values.manager.image"))
+		})
+	})
+
+	Context("conditional wrapping", func() {
+		It("should add metrics conditional for ServiceMonitor resources", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// Should be wrapped with prometheus enabled conditional
+			Expect(result).To(ContainSubstring("{{- if .Values.prometheus.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should add networkPolicy conditional for NetworkPolicy resources", func() {
+			networkPolicyResource := &unstructured.Unstructured{}
+			networkPolicyResource.SetAPIVersion("networking.k8s.io/v1")
+			networkPolicyResource.SetKind("NetworkPolicy")
+			networkPolicyResource.SetName("allow-metrics-traffic")
+
+			content := `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-metrics-traffic
+  namespace: test-system
+spec:
+  podSelector:
+    matchLabels:
+      control-plane: controller-manager`
+
+			result := templater.ApplyHelmSubstitutions(content, networkPolicyResource)
+
+			Expect(result).To(ContainSubstring("{{- if .Values.networkPolicy.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should add webhook conditional for webhook NetworkPolicy resources", func() {
+			networkPolicyResource := &unstructured.Unstructured{}
+			networkPolicyResource.SetAPIVersion("networking.k8s.io/v1")
+			networkPolicyResource.SetKind("NetworkPolicy")
+			networkPolicyResource.SetName("allow-webhook-traffic")
+
+			content := `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-webhook-traffic
+  namespace: test-system
+spec:
+  podSelector:
+    matchLabels:
+      control-plane: controller-manager`
+
+			result := templater.ApplyHelmSubstitutions(content, networkPolicyResource)
+
+			Expect(result).To(ContainSubstring("{{- if and .Values.networkPolicy.enabled .Values.webhook.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should not wrap NetworkPolicy with wrong apiVersion", func() {
+			networkPolicyResource := &unstructured.Unstructured{}
+			networkPolicyResource.SetAPIVersion("acme.io/v1")
+			networkPolicyResource.SetKind("NetworkPolicy")
+			networkPolicyResource.SetName("custom-policy")
+
+			content := `apiVersion: acme.io/v1
+kind: NetworkPolicy
+metadata:
+  name: custom-policy`
+
+			result := templater.ApplyHelmSubstitutions(content, networkPolicyResource)
+
+			Expect(result).NotTo(ContainSubstring("{{- if .Values.networkPolicy.enabled }}"))
+		})
+
+		It("should template ServiceMonitor port and scheme based on metrics.secure", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - port: https
+    scheme: https`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// Port should be templated to conditional
+			Expect(result).To(ContainSubstring("port: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"))
+			Expect(result).NotTo(ContainSubstring("port: https"))
+
+			// Scheme should be templated to conditional
+			Expect(result).To(ContainSubstring("scheme: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"))
+			Expect(result).NotTo(ContainSubstring("scheme: https"))
+		})
+
+		It("should wrap ServiceMonitor bearerTokenFile with metrics.secure conditional", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+    port: https`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// BearerTokenFile should be wrapped with conditional, and left out when authorization is set
+			Expect(result).To(ContainSubstring(
+				"- {{- if and .Values.metrics.secure (not .Values.prometheus.authorization) }}"))
+			Expect(result).To(ContainSubstring("bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should wrap ServiceMonitor tlsConfig with metrics.secure conditional", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - port: https
+    scheme: https
+    tlsConfig:
+      insecureSkipVerify: true`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// TLS config should be wrapped with conditional
+			Expect(result).To(ContainSubstring("{{- if .Values.metrics.secure }}"))
+			Expect(result).To(ContainSubstring("tlsConfig:"))
+			Expect(result).To(ContainSubstring("insecureSkipVerify: true"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+
+			// Should have port and scheme templated too
+			Expect(result).To(ContainSubstring("port: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"))
+			Expect(result).To(ContainSubstring("scheme: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}"))
+		})
+
+		It("should wrap ServiceMonitor with certManager.enabled conditional when using default cert-manager secret", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - port: https
+    scheme: https
+    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+    tlsConfig:
+      serverName: service.namespace.svc
+      insecureSkipVerify: false
+      ca:
+        secret:
+          name: metrics-server-cert
+          key: ca.crt
+      cert:
+        secret:
+          name: metrics-server-cert
+          key: tls.crt
+      keySecret:
+        name: metrics-server-cert
+        key: tls.key`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// Should have cert-manager conditional (using default cert-manager secret)
+			Expect(result).To(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+
+			// Should template secret names, defaulting to the cert-manager secret
+			Expect(strings.Count(result,
+				`name: {{ .Values.metrics.certSecretName | default "metrics-server-cert" }}`)).To(Equal(3))
+
+			// Should have else branch with insecureSkipVerify
+			Expect(result).To(ContainSubstring("{{- else }}"))
+			Expect(result).To(ContainSubstring("insecureSkipVerify: true"))
+		})
+
+		It("should preserve custom cert secrets without cert-manager conditional", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			// Custom certs from Vault/manual secrets - NOT cert-manager
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - port: https
+    scheme: https
+    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+    tlsConfig:
+      serverName: custom-service.namespace.svc
+      insecureSkipVerify: false
+      ca:
+        secret:
+          name: vault-ca-secret
+          key: ca.crt
+      cert:
+        secret:
+          name: vault-client-cert
+          key: tls.crt
+      keySecret:
+        name: vault-client-key
+        key: tls.key`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// Should NOT have cert-manager conditional (custom secrets)
+			Expect(result).NotTo(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+			Expect(result).NotTo(ContainSubstring("{{- else }}"))
+
+			// Custom secret names should be preserved as-is
+			Expect(result).To(ContainSubstring("name: vault-ca-secret"))
+			Expect(result).To(ContainSubstring("name: vault-client-cert"))
+			Expect(result).To(ContainSubstring("name: vault-client-key"))
+			Expect(result).To(ContainSubstring("insecureSkipVerify: false"))
+
+			// Should still have metrics.secure wrapper
+			Expect(result).To(ContainSubstring("{{- if .Values.metrics.secure }}"))
+		})
+
+		It("should NOT add cert-manager conditional when ServiceMonitor only has insecureSkipVerify", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - port: https
+    scheme: https
+    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+    tlsConfig:
+      insecureSkipVerify: true`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// Should NOT have cert-manager conditional (no cert-manager fields detected)
+			Expect(result).NotTo(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+
+			// Should preserve insecureSkipVerify: true as-is
+			Expect(result).To(ContainSubstring("insecureSkipVerify: true"))
+
+			// Should still have metrics.secure wrapper
+			Expect(result).To(ContainSubstring("{{- if .Values.metrics.secure }}"))
+		})
+
+		It("should convert insecureSkipVerify: false to true when no cert-manager fields present", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			// Invalid config: insecureSkipVerify: false without certs
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - port: https
+    scheme: https
+    tlsConfig:
+      insecureSkipVerify: false`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// Should convert to insecureSkipVerify: true (can't have false without certs)
+			Expect(result).To(ContainSubstring("insecureSkipVerify: true"))
+			Expect(result).NotTo(ContainSubstring("insecureSkipVerify: false"))
+
+			// Should NOT have cert-manager conditional (no cert-manager fields)
+			Expect(result).NotTo(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+		})
+
+		It("should add prometheus.authorization to every ServiceMonitor endpoint", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+    path: /metrics
+    port: https
+    scheme: https
+    tlsConfig:
+      insecureSkipVerify: true
+  selector:
+    matchLabels:
+      control-plane: controller-manager`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			Expect(result).To(HavePrefix("{{- if .Values.prometheus.enabled }}"))
+			Expect(result).To(HaveSuffix("{{- end }}"))
+			Expect(result).To(ContainSubstring(`    tlsConfig:
+      insecureSkipVerify: true
+    {{- end }}
+    {{- with .Values.prometheus.authorization }}
+    authorization:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+`))
+
+			render := func(prometheus map[string]any) string {
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl":        templater.GenerateHelpers(),
+					"templates/servicemonitor.yaml": result,
+				}, map[string]any{
+					"prometheus": prometheus,
+					"metrics":    map[string]any{"secure": true},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered["templates/servicemonitor.yaml"]
+			}
+
+			withAuthorization := render(map[string]any{
+				"enabled": true,
+				"authorization": map[string]any{
+					"type":        "Bearer",
+					"credentials": map[string]any{"name": "metrics-reader-token", "key": "token"},
+				},
+			})
+			Expect(withAuthorization).To(ContainSubstring(`    tlsConfig:
+      insecureSkipVerify: true
+    authorization:
+      credentials:
+        key: token
+        name: metrics-reader-token
+      type: Bearer
+`))
+			Expect(withAuthorization).NotTo(ContainSubstring("bearerTokenFile"))
+
+			withoutAuthorization := render(map[string]any{"enabled": true})
+			Expect(withoutAuthorization).To(ContainSubstring("bearerTokenFile:"))
+			Expect(withoutAuthorization).To(ContainSubstring("insecureSkipVerify: true"))
+			Expect(withoutAuthorization).NotTo(ContainSubstring("authorization:"))
+
+			Expect(strings.TrimSpace(render(map[string]any{
+				"enabled":       false,
+				"authorization": map[string]any{"type": "Bearer"},
+			}))).To(BeEmpty())
+		})
+
+		It("should append prometheus relabelings to every ServiceMonitor endpoint", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - path: /metrics
+    port: https
+    relabelings:
+    - action: replace
+      replacement: operators
+      targetLabel: team
+  selector:
+    matchLabels:
+      control-plane: controller-manager`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl":        templater.GenerateHelpers(),
+				"templates/servicemonitor.yaml": result,
+			}, map[string]any{
+				"prometheus": map[string]any{
+					"enabled": true,
+					"metricRelabelings": []any{map[string]any{
+						"action":       "drop",
+						"regex":        "go_gc_.*",
+						"sourceLabels": []any{"__name__"},
+					}},
+					"relabelings": []any{map[string]any{
+						"action":       "replace",
+						"replacement":  "$1",
+						"sourceLabels": []any{"__meta_kubernetes_pod_node_name"},
+						"targetLabel":  "node",
+					}},
+				},
+				"metrics": map[string]any{"secure": true},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/servicemonitor.yaml"]).To(ContainSubstring(`  - path: /metrics
+    port: https
+    relabelings:
+    - action: replace
+      replacement: operators
+      targetLabel: team
+    - action: replace
+      replacement: $1
+      sourceLabels:
+      - __meta_kubernetes_pod_node_name
+      targetLabel: node
+    metricRelabelings:
+      - action: drop
+        regex: go_gc_.*
+        sourceLabels:
+        - __name__
+  namespaceSelector:
+    matchNames:
+    - my-namespace
+  selector:
+`))
+		})
+
+		It("should scope the ServiceMonitor namespaceSelector to the release namespace", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor
+  namespace: test-project-system
+spec:
+  endpoints:
+  - path: /metrics
+    port: https
+  selector:
+    matchLabels:
+      control-plane: controller-manager`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			Expect(result).To(ContainSubstring(`  namespaceSelector:
+    matchNames:
+    - {{ .Release.Namespace }}
+  selector:
+`))
+			Expect(result).To(HaveSuffix("{{- end }}"))
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl":        templater.GenerateHelpers(),
+				"templates/servicemonitor.yaml": result,
+			}, map[string]any{
+				"prometheus": map[string]any{"enabled": true},
+				"metrics":    map[string]any{"secure": true},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var monitor map[string]any
+			Expect(yaml.Unmarshal([]byte(rendered["templates/servicemonitor.yaml"]), &monitor)).To(Succeed())
+			matchNames, found, err := unstructured.NestedStringSlice(monitor, "spec", "namespaceSelector", "matchNames")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(matchNames).To(Equal([]string{"my-namespace"}))
+		})
+
+		It("should add metrics conditional for metrics services", func() {
+			serviceResource := &unstructured.Unstructured{}
+			serviceResource.SetAPIVersion("v1")
+			serviceResource.SetKind("Service")
+			serviceResource.SetName("test-project-controller-manager-metrics-service")
+
+			content := `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-controller-manager-metrics-service`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceResource)
+
+			// Should be wrapped with metrics enabled conditional
+			Expect(result).To(ContainSubstring("{{- if .Values.metrics.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should add cert-manager conditional for Certificate resources", func() {
+			certResource := &unstructured.Unstructured{}
+			certResource.SetAPIVersion("cert-manager.io/v1")
+			certResource.SetKind("Certificate")
+			certResource.SetName("test-project-serving-cert")
+
+			content := `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-serving-cert`
+
+			result := templater.ApplyHelmSubstitutions(content, certResource)
+
+			// Should be wrapped with certManager enabled conditional
+			Expect(result).To(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should add combined conditionals for metrics certificates", func() {
+			certResource := &unstructured.Unstructured{}
+			certResource.SetAPIVersion("cert-manager.io/v1")
+			certResource.SetKind("Certificate")
+			certResource.SetName("test-project-metrics-certs")
+
+			content := `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-metrics-certs`
+
+			result := templater.ApplyHelmSubstitutions(content, certResource)
+
+			// Should be wrapped with certManager, metrics, AND metrics.secure conditionals
+			// Metrics certs only needed when using HTTPS (metrics.secure=true)
+			Expect(result).To(ContainSubstring(
+				"{{- if and .Values.certManager.enabled .Values.metrics.enabled .Values.metrics.secure }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should add kind conditionals to essential ClusterRole resources", func() {
+			// Test essential RBAC
+			clusterRoleResource := &unstructured.Unstructured{}
+			clusterRoleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			clusterRoleResource.SetKind("ClusterRole")
+			clusterRoleResource.SetName("test-project-manager-role")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-project-manager-role`
+
+			result := templater.ApplyHelmSubstitutions(content, clusterRoleResource)
+
+			// Should NOT have rbac.create conditional (always created)
+			Expect(result).NotTo(ContainSubstring("{{- if .Values.rbac.create }}"))
+			// Should have kind conditional for namespace-scoped support
+			Expect(result).To(ContainSubstring("{{- if .Values.rbac.namespaced }}"))
+		})
+
+		It("should add webhook conditional for webhook services", func() {
+			serviceResource := &unstructured.Unstructured{}
+			serviceResource.SetAPIVersion("v1")
+			serviceResource.SetKind("Service")
+			serviceResource.SetName("test-project-webhook-service")
+
+			webhookContent := `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-webhook-service`
+
+			webhookResult := templater.ApplyHelmSubstitutions(webhookContent, serviceResource)
+
+			// Should wrap webhook service with webhook.enabled conditional
+			Expect(webhookResult).To(ContainSubstring("{{- if .Values.webhook.enabled }}"))
+			Expect(webhookResult).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should add webhook conditional for webhook configurations", func() {
+			mutatingWebhookResource := &unstructured.Unstructured{}
+			mutatingWebhookResource.SetAPIVersion("admissionregistration.k8s.io/v1")
+			mutatingWebhookResource.SetKind("MutatingWebhookConfiguration")
+			mutatingWebhookResource.SetName("test-project-mutating-webhook-configuration")
+
+			content := `apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: test-project-mutating-webhook-configuration`
+
+			result := templater.ApplyHelmSubstitutions(content, mutatingWebhookResource)
+
+			// Webhook configurations should be conditional on webhook.enabled
+			Expect(result).To(ContainSubstring("{{- if .Values.webhook.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should add webhook conditional for validating webhook configurations", func() {
+			validatingWebhookResource := &unstructured.Unstructured{}
+			validatingWebhookResource.SetAPIVersion("admissionregistration.k8s.io/v1")
+			validatingWebhookResource.SetKind("ValidatingWebhookConfiguration")
+			validatingWebhookResource.SetName("test-project-validating-webhook-configuration")
+
+			content := `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  annotations:
+    cert-manager.io/inject-ca-from: test-project-system/test-project-serving-cert
+  name: test-project-validating-webhook-configuration`
+
+			result := templater.ApplyHelmSubstitutions(content, validatingWebhookResource)
+
+			// Webhook configurations should be wrapped with webhook.enabled
+			Expect(result).To(ContainSubstring("{{- if .Values.webhook.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+			// Cert-manager annotation should still be conditional on certManager.enabled
+			Expect(result).To(ContainSubstring("{{- if .Values.certManager.enabled }}"))
+		})
+
+		Context("webhook CA bundle", func() {
+			var webhookResource *unstructured.Unstructured
+
+			const webhookConfiguration = `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  annotations:
+    cert-manager.io/inject-ca-from: test-project-system/test-project-serving-cert
+  name: test-project-validating-webhook-configuration
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    service:
+      name: test-project-webhook-service
+      namespace: test-project-system
+      path: /validate
+  name: vguestbook.kb.io
+  sideEffects: None
+`
+
+			BeforeEach(func() {
+				webhookResource = &unstructured.Unstructured{}
+				webhookResource.SetAPIVersion("admissionregistration.k8s.io/v1")
+				webhookResource.SetKind("ValidatingWebhookConfiguration")
+				webhookResource.SetName("test-project-validating-webhook-configuration")
+			})
+
+			render := func(templated string, certManagerEnabled bool, caBundle string) string {
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl": templater.GenerateHelpers(),
+					"templates/webhook.yaml": templated,
+				}, map[string]any{
+					"certManager": map[string]any{"enabled": certManagerEnabled},
+					"webhook":     map[string]any{"enabled": true, "caBundle": caBundle},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered["templates/webhook.yaml"]
+			}
+
+			It("should template the clientConfig caBundle from webhook.caBundle", func() {
+				result := templater.ApplyHelmSubstitutions(webhookConfiguration, webhookResource)
+
+				Expect(result).To(ContainSubstring(`  clientConfig:
+    {{- if and (not .Values.certManager.enabled) .Values.webhook.caBundle }}
+    caBundle: {{ .Values.webhook.caBundle }}
+    {{- end }}
+    service:
+`))
+				Expect(templater.ApplyHelmSubstitutions(result, webhookResource)).To(Equal(result))
+				Expect(templater.Validate(result)).To(Succeed())
+			})
+
+			It("should render a user-supplied CA bundle only when cert-manager is disabled", func() {
+				result := templater.ApplyHelmSubstitutions(webhookConfiguration, webhookResource)
+
+				byoCA := render(result, false, "Y2EtYnVuZGxl")
+				Expect(byoCA).To(ContainSubstring("  clientConfig:\n    caBundle: Y2EtYnVuZGxl\n    service:\n"))
+				Expect(byoCA).NotTo(ContainSubstring("inject-ca-from"))
+
+				certManager := render(result, true, "Y2EtYnVuZGxl")
+				Expect(certManager).NotTo(ContainSubstring("caBundle"))
+				Expect(certManager).To(ContainSubstring("cert-manager.io/inject-ca-from: my-namespace/"))
+
+				Expect(render(result, false, "")).NotTo(ContainSubstring("caBundle"))
+			})
+
+			It("should keep a caBundle from the manifest when webhook.caBundle is not set", func() {
+				content := strings.Replace(webhookConfiguration,
+					"  clientConfig:\n", "  clientConfig:\n    caBundle: b3JpZ2luYWw=\n", 1)
+
+				result := templater.ApplyHelmSubstitutions(content, webhookResource)
+
+				Expect(result).To(ContainSubstring(`  clientConfig:
+    {{- if and (not .Values.certManager.enabled) .Values.webhook.caBundle }}
+    caBundle: {{ .Values.webhook.caBundle }}
+    {{- else }}
+    caBundle: b3JpZ2luYWw=
+    {{- end }}
+    service:
+`))
+				Expect(render(result, false, "")).To(ContainSubstring("    caBundle: b3JpZ2luYWw=\n"))
+				Expect(render(result, false, "Y2EtYnVuZGxl")).To(ContainSubstring("    caBundle: Y2EtYnVuZGxl\n"))
+			})
+
+			It("should mount the user's serving certificate along with a user-supplied CA bundle", func() {
+				deploymentResource := &unstructured.Unstructured{}
+				deploymentResource.SetAPIVersion("apps/v1")
+				deploymentResource.SetKind("Deployment")
+				deploymentResource.SetName("test-project-controller-manager")
+
+				deployment := templater.ApplyHelmSubstitutions(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --webhook-cert-path=/tmp/k8s-webhook-server/serving-certs
+        image: controller:latest
+        name: manager
+        volumeMounts:
+        - mountPath: /tmp/k8s-webhook-server/serving-certs
+          name: webhook-certs
+          readOnly: true
+      volumes:
+      - name: webhook-certs
+        secret:
+          secretName: webhook-server-cert
+`, deploymentResource)
+				webhook := templater.ApplyHelmSubstitutions(webhookConfiguration, webhookResource)
+
+				render := func(caBundle string) (string, string) {
+					GinkgoHelper()
+					rendered, err := renderChart(map[string]string{
+						"templates/_helpers.tpl": templater.GenerateHelpers(),
+						"templates/manager.yaml": deployment,
+						"templates/webhook.yaml": webhook,
+					}, map[string]any{
+						"manager":     map[string]any{"image": map[string]any{"repository": "controller"}},
+						"certManager": map[string]any{"enabled": false},
+						"webhook": map[string]any{
+							"enabled": true, "caBundle": caBundle, "certSecretName": "my-webhook-tls",
+						},
+						"rbac": map[string]any{},
+					})
+					Expect(err).NotTo(HaveOccurred())
+					return rendered["templates/manager.yaml"], rendered["templates/webhook.yaml"]
+				}
+
+				manager, webhookRendered := render("Y2EtYnVuZGxl")
+				Expect(webhookRendered).To(ContainSubstring("    caBundle: Y2EtYnVuZGxl\n"))
+				Expect(manager).To(ContainSubstring("- --webhook-cert-path=/tmp/k8s-webhook-server/serving-certs\n"))
+				Expect(manager).To(ContainSubstring("- mountPath: /tmp/k8s-webhook-server/serving-certs\n"))
+				Expect(manager).To(ContainSubstring("- name: webhook-certs\n"))
+				Expect(manager).To(ContainSubstring("secretName: my-webhook-tls\n"))
+
+				manager, _ = render("")
+				Expect(manager).NotTo(ContainSubstring("--webhook-cert-path"))
+				Expect(manager).NotTo(ContainSubstring("webhook-certs"))
+			})
+		})
+
+		Context("webhook match policy and side effects", func() {
+			const webhookConfiguration = `apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: test-project-mutating-webhook-configuration
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    service:
+      name: test-project-webhook-service
+      namespace: test-project-system
+      path: /mutate-guestbook
+  failurePolicy: Fail
+  name: mguestbook.kb.io
+  sideEffects: None
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    service:
+      name: test-project-webhook-service
+      namespace: test-project-system
+      path: /mutate-cronjob
+  failurePolicy: Fail
+  matchPolicy: Exact
+  name: mcronjob.kb.io
+  sideEffects: NoneOnDryRun
+`
+
+			webhookResource := &unstructured.Unstructured{}
+			webhookResource.SetAPIVersion("admissionregistration.k8s.io/v1")
+			webhookResource.SetKind("MutatingWebhookConfiguration")
+			webhookResource.SetName("test-project-mutating-webhook-configuration")
+
+			It("should template matchPolicy and sideEffects of every webhook entry", func() {
+				result := templater.ApplyHelmSubstitutions(webhookConfiguration, webhookResource)
+
+				Expect(result).To(ContainSubstring(`  failurePolicy: Fail
+  matchPolicy: {{ .Values.webhook.matchPolicy | default "Equivalent" }}
+  name: mguestbook.kb.io
+  sideEffects: {{ .Values.webhook.sideEffects | default "None" }}
+`))
+				Expect(result).To(ContainSubstring(`  failurePolicy: Fail
+  matchPolicy: {{ .Values.webhook.matchPolicy | default "Exact" }}
+  name: mcronjob.kb.io
+  sideEffects: {{ .Values.webhook.sideEffects | default "NoneOnDryRun" }}
+`))
+				Expect(strings.Count(result, "matchPolicy:")).To(Equal(2))
+				Expect(templater.ApplyHelmSubstitutions(result, webhookResource)).To(Equal(result))
+				Expect(templater.Validate(result)).To(Succeed())
+			})
+
+			It("should render the values for every entry when set", func() {
+				result := templater.ApplyHelmSubstitutions(webhookConfiguration, webhookResource)
+
+				render := func(webhook map[string]any) []any {
+					GinkgoHelper()
+					webhook["enabled"] = true
+					rendered, err := renderChart(map[string]string{
+						"templates/_helpers.tpl": templater.GenerateHelpers(),
+						"templates/webhook.yaml": result,
+					}, map[string]any{"certManager": map[string]any{"enabled": true}, "webhook": webhook})
+					Expect(err).NotTo(HaveOccurred())
+					object := map[string]any{}
+					Expect(yaml.Unmarshal([]byte(rendered["templates/webhook.yaml"]), &object)).To(Succeed())
+					webhooks, _, _ := unstructured.NestedSlice(object, "webhooks")
+					Expect(webhooks).To(HaveLen(2))
+					return webhooks
+				}
+
+				defaults := render(map[string]any{})
+				Expect(defaults[0]).To(And(
+					HaveKeyWithValue("matchPolicy", "Equivalent"), HaveKeyWithValue("sideEffects", "None")))
+				Expect(defaults[1]).To(And(
+					HaveKeyWithValue("matchPolicy", "Exact"), HaveKeyWithValue("sideEffects", "NoneOnDryRun")))
+
+				for _, webhook := range render(map[string]any{"matchPolicy": "Exact", "sideEffects": "None"}) {
+					Expect(webhook).To(And(HaveKeyWithValue("matchPolicy", "Exact"), HaveKeyWithValue("sideEffects", "None")))
+				}
+			})
+		})
+
+		It("should add crd.enabled conditional and resource-policy annotation for CRDs", func() {
+			crdResource := &unstructured.Unstructured{}
+			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
+			crdResource.SetKind("CustomResourceDefinition")
+			crdResource.SetName("guestbooks.example.com")
+
+			content := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: guestbooks.example.com
+spec:
+  group: example.com`
+
+			result := templater.ApplyHelmSubstitutions(content, crdResource)
+
+			// Should be wrapped with crd.enabled conditional
+			Expect(result).To(ContainSubstring("{{- if .Values.crd.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+			// Should have resource-policy annotation for helm uninstall protection
+			Expect(result).To(ContainSubstring("{{- if .Values.crd.keep }}"))
+			Expect(result).To(ContainSubstring(`"helm.sh/resource-policy": keep`))
+			// Injected annotations should use 2-space indentation matching sigs.k8s.io/yaml output.
+			// annotations: at 2-space indent, values at 4-space indent.
+			expectedAnnotations := "  annotations:\n" +
+				"    {{- if .Values.crd.keep }}\n" +
+				"    \"helm.sh/resource-policy\": keep\n" +
+				"    {{- end }}"
+			Expect(result).To(ContainSubstring(expectedAnnotations))
+		})
+
+		It("should add resource-policy annotation to CRDs that already have annotations", func() {
+			crdResource := &unstructured.Unstructured{}
+			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
+			crdResource.SetKind("CustomResourceDefinition")
+			crdResource.SetName("configs.example.com")
+
+			content := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v0.17.0
+  name: configs.example.com
+spec:
+  group: example.com`
+
+			result := templater.ApplyHelmSubstitutions(content, crdResource)
+
+			// Should be wrapped with crd.enabled conditional
+			Expect(result).To(ContainSubstring("{{- if .Values.crd.enabled }}"))
+			// Should have resource-policy annotation
+			Expect(result).To(ContainSubstring("{{- if .Values.crd.keep }}"))
+			Expect(result).To(ContainSubstring(`"helm.sh/resource-policy": keep`))
+			// Should preserve existing annotation
+			Expect(result).To(ContainSubstring("controller-gen.kubebuilder.io/version"))
+			// Injected annotation should be at same indent as existing annotations (4 spaces)
+			expectedAnnotations := "  annotations:\n" +
+				"    {{- if .Values.crd.keep }}\n" +
+				"    \"helm.sh/resource-policy\": keep\n" +
+				"    {{- end }}\n" +
+				"    controller-gen.kubebuilder.io/version"
+			Expect(result).To(ContainSubstring(expectedAnnotations))
+		})
+
+		It("should add manager.enabled conditional for manager Deployments", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  replicas: 1`
+
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
+
+			// Should be wrapped with manager.enabled conditional that defaults to true when key is absent
+			expectedConditional := `{{- if or (not (hasKey .Values.manager "enabled")) (.Values.manager.enabled) }}`
+			Expect(result).To(ContainSubstring(expectedConditional))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should guard the whole manager Deployment with manager.enabled", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  replicas: 1`
+
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
+
+			Expect(result).To(HavePrefix(
+				"{{- if or (not (hasKey .Values.manager \"enabled\")) (.Values.manager.enabled) }}\napiVersion: apps/v1\n"))
+			Expect(strings.TrimSpace(result)).To(HaveSuffix("  replicas: {{ .Values.manager.replicas }}\n{{- end }}"))
+
+			for enabled, rendersDeployment := range map[string]bool{"absent": true, "true": true, "false": false} {
+				manager := map[string]any{}
+				if enabled != "absent" {
+					manager["enabled"] = enabled == "true"
+				}
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl": templater.GenerateHelpers(),
+					"templates/manager.yaml": result,
+				}, map[string]any{"manager": manager})
+				Expect(err).NotTo(HaveOccurred())
+				if rendersDeployment {
+					Expect(rendered["templates/manager.yaml"]).To(ContainSubstring("kind: Deployment"), enabled)
+				} else {
+					Expect(strings.TrimSpace(rendered["templates/manager.yaml"])).To(BeEmpty(), enabled)
+				}
+			}
+		})
+
+		It("should not add manager.enabled conditional for non-manager Deployments", func() {
+			deploymentResource := &unstructured.Unstructured{}
+			deploymentResource.SetAPIVersion("apps/v1")
+			deploymentResource.SetKind("Deployment")
+			deploymentResource.SetName("other-deployment")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: other-deployment
+  namespace: test-project-system
+spec:
+  replicas: 1`
+
+			result := templater.ApplyHelmSubstitutions(content, deploymentResource)
+
+			// Should NOT be wrapped with manager.enabled conditional
+			expectedConditional := `{{- if or (not (hasKey .Values.manager "enabled")) (.Values.manager.enabled) }}`
+			Expect(result).NotTo(ContainSubstring(expectedConditional))
+			Expect(result).NotTo(ContainSubstring(".Values.manager.enabled"))
+		})
+	})
+
+	Context("helper RBAC wrapping", func() {
+		It("should add rbac.helpers conditional for helper RBAC roles", func() {
+			clusterRoleResource := &unstructured.Unstructured{}
+			clusterRoleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			clusterRoleResource.SetKind("ClusterRole")
+			clusterRoleResource.SetName("test-project-memcached-editor-role")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-project-memcached-editor-role`
+
+			result := templater.ApplyHelmSubstitutions(content, clusterRoleResource)
+
+			// Should be wrapped with rbac.helpers conditional
+			Expect(result).To(ContainSubstring("{{- if .Values.rbac.helpers.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+
+		It("should add rbac.helpers conditional for helper ClusterRoleBindings", func() {
+			bindingResource := &unstructured.Unstructured{}
+			bindingResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			bindingResource.SetKind("ClusterRoleBinding")
+			bindingResource.SetName("test-project-memcached-viewer-rolebinding")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: test-project-memcached-viewer-rolebinding`
+
+			result := templater.ApplyHelmSubstitutions(content, bindingResource)
+
+			// Should be wrapped with rbac.helpers conditional
+			Expect(result).To(ContainSubstring("{{- if .Values.rbac.helpers.enabled }}"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
+		})
+	})
+
+	Context("chart.fullname templating", func() {
+		It("should template resource names with test-project.resourceName for proper truncation", func() {
+			serviceAccountResource := &unstructured.Unstructured{}
+			serviceAccountResource.SetAPIVersion("v1")
+			serviceAccountResource.SetKind("ServiceAccount")
+			serviceAccountResource.SetName("test-project-controller-manager")
+
+			content := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceAccountResource)
+
+			// Should template with test-project.serviceAccountName helper
+			expected := `name: {{ include "test-project.serviceAccountName" . }}`
+			Expect(result).To(ContainSubstring(expected))
+			Expect(result).NotTo(ContainSubstring("name: test-project-controller-manager"))
+		})
+		It("should template ServiceMonitor name with test-project.resourceName for proper truncation", func() {
+			serviceMonitorResource := &unstructured.Unstructured{}
+			serviceMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			serviceMonitorResource.SetKind("ServiceMonitor")
+			serviceMonitorResource.SetName("test-project-controller-manager-metrics-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: test-project-controller-manager-metrics-monitor`
+
+			result := templater.ApplyHelmSubstitutions(content, serviceMonitorResource)
+
+			// Should template with test-project.resourceName which handles 63-char truncation
+			expected := `name: {{ include "test-project.resourceName" ` +
+				`(dict "suffix" "controller-manager-metrics-monitor" "context" $) }}`
+			Expect(result).To(ContainSubstring(expected))
+			Expect(result).NotTo(ContainSubstring("name: test-project-controller-manager-metrics-monitor"))
+		})
+
+		It("should template secretRef name inside envFrom", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        envFrom:
+        - secretRef:
+            name: test-project-manager-secrets`
+
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+
+			// secretRef.name inside envFrom must be templated, not left as a hardcoded string
+			expectedSecretRef := `name: {{ include "test-project.resourceName" ` +
+				`(dict "suffix" "manager-secrets" "context" $) }}`
+			Expect(result).To(ContainSubstring(expectedSecretRef))
+			Expect(result).NotTo(ContainSubstring("name: test-project-manager-secrets"))
+		})
+
+		It("should template configMapRef name inside envFrom", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        envFrom:
+        - configMapRef:
+            name: test-project-manager-config`
+
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+
+			// configMapRef.name inside envFrom must be templated
+			expectedConfigMapRef := `name: {{ include "test-project.resourceName" ` +
+				`(dict "suffix" "manager-config" "context" $) }}`
+			Expect(result).To(ContainSubstring(expectedConfigMapRef))
+			Expect(result).NotTo(ContainSubstring("name: test-project-manager-config"))
+		})
+	})
+
+	Context("app.kubernetes.io/name label templating", func() {
+		It("should template app.kubernetes.io/name for Deployment", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+    control-plane: controller-manager`
+
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template app.kubernetes.io/name for Service", func() {
+			service := &unstructured.Unstructured{}
+			service.SetAPIVersion("v1")
+			service.SetKind("Service")
+			service.SetName("test-project-webhook-service")
+
+			content := `apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, service)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template app.kubernetes.io/name for ServiceAccount", func() {
+			sa := &unstructured.Unstructured{}
+			sa.SetAPIVersion("v1")
+			sa.SetKind("ServiceAccount")
+			sa.SetName("test-project-controller-manager")
+
+			content := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, sa)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template app.kubernetes.io/name for ClusterRole", func() {
+			clusterRole := &unstructured.Unstructured{}
+			clusterRole.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			clusterRole.SetKind("ClusterRole")
+			clusterRole.SetName("test-project-manager-role")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, clusterRole)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template app.kubernetes.io/name for Role", func() {
+			role := &unstructured.Unstructured{}
+			role.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			role.SetKind("Role")
+			role.SetName("test-project-leader-election-role")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, role)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template app.kubernetes.io/name for RoleBinding", func() {
+			rb := &unstructured.Unstructured{}
+			rb.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			rb.SetKind("RoleBinding")
+			rb.SetName("test-project-leader-election-rolebinding")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, rb)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template app.kubernetes.io/name for ClusterRoleBinding", func() {
+			crb := &unstructured.Unstructured{}
+			crb.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			crb.SetKind("ClusterRoleBinding")
+			crb.SetName("test-project-manager-rolebinding")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, crb)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template app.kubernetes.io/name for Certificate", func() {
+			cert := &unstructured.Unstructured{}
+			cert.SetAPIVersion("cert-manager.io/v1")
+			cert.SetKind("Certificate")
+			cert.SetName("test-project-serving-cert")
+
+			content := `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, cert)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template app.kubernetes.io/name for Issuer", func() {
+			issuer := &unstructured.Unstructured{}
+			issuer.SetAPIVersion("cert-manager.io/v1")
+			issuer.SetKind("Issuer")
+			issuer.SetName("test-project-selfsigned-issuer")
+
+			content := `apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, issuer)
+
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should handle label already templated without breaking", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/name: {{ include "test-project.name" . }}
+    control-plane: controller-manager`
+
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+
+			// Should keep the template as-is
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).ToNot(ContainSubstring("app.kubernetes.io/name: test-project"))
+		})
+
+		It("should template multiple occurrences in same resource", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+spec:
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: test-project
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: test-project`
+
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+
+			// All three should be templated
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: test-project"))
+			// Count occurrences - should be 3
+			count := strings.Count(result, "app.kubernetes.io/name: {{ include \"test-project.name\" . }}")
+			Expect(count).To(Equal(3))
+		})
+	})
+
+	Context("existing Go template syntax escaping", func() {
+		It("should escape existing Go template syntax in CRD samples", func() {
+			crdResource := &unstructured.Unstructured{}
+			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
+			crdResource.SetKind("CustomResourceDefinition")
+			crdResource.SetName("changetransferpolicies.promoter.argoproj.io")
+
+			content := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: changetransferpolicies.promoter.argoproj.io
+spec:
+  names:
+    kind: ChangeTransferPolicy
+  versions:
+  - name: v1alpha1
+    schema:
+      openAPIV3Schema:
+        properties:
+          spec:
+            properties:
+              pullRequest:
+                properties:
+                  template:
+                    properties:
+                      description:
+                        default: "Promoting {{ .ChangeTransferPolicy.Spec.ActiveBranch }}"
+                        type: string
+                      title:
+                        default: "Promote {{ trunc 5 .ChangeTransferPolicy.Status.Proposed.Dry.Sha }}"
+                        type: string`
+
+			result := templater.ApplyHelmSubstitutions(content, crdResource)
+
+			// Existing {{ }} should be escaped to {{ "{{ ... }}" }}
+			Expect(result).To(ContainSubstring(`{{ "{{ .ChangeTransferPolicy.Spec.ActiveBranch }}" }}`),
+				"existing template syntax should be escaped")
+			Expect(result).To(ContainSubstring(`{{ "{{ trunc 5 .ChangeTransferPolicy.Status.Proposed.Dry.Sha }}" }}`),
+				"function calls in templates should be escaped")
+
+			// Should NOT have unescaped Go template syntax (which would break Helm)
+			// We check that all ChangeTransferPolicy references are properly wrapped
+			// Pattern checks for: default: "...<text>{{ .ChangeTransferPolicy" (not escaped)
+			// The properly escaped version is: default: "...{{ "{{ .ChangeTransferPolicy..." }}"
+			Expect(result).NotTo(MatchRegexp(`default:\s+"[^{]*\{\{\s*\.ChangeTransferPolicy`),
+				"unescaped Go templates should not exist in default values")
+		})
+
+		It("should escape multiple template expressions on the same line", func() {
+			crdResource := &unstructured.Unstructured{}
+			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
+			crdResource.SetKind("CustomResourceDefinition")
+			crdResource.SetName("policies.example.com")
+
+			content := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  versions:
+  - schema:
+      openAPIV3Schema:
+        properties:
+          spec:
+            properties:
+              message:
+                default: "From {{ .Source.Branch }} to {{ .Target.Branch }}"`
+
+			result := templater.ApplyHelmSubstitutions(content, crdResource)
+
+			// Both templates should be escaped (applies to all resources)
+			Expect(result).To(ContainSubstring(`{{ "{{ .Source.Branch }}" }}`))
+			Expect(result).To(ContainSubstring(`{{ "{{ .Target.Branch }}" }}`))
+		})
+
+		It("should escape templates with special characters", func() {
+			crdResource := &unstructured.Unstructured{}
+			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
+			crdResource.SetKind("CustomResourceDefinition")
+			crdResource.SetName("configs.example.com")
+
+			content := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  versions:
+  - schema:
+      openAPIV3Schema:
+        properties:
+          spec:
+            properties:
+              value:
+                default: "Value: {{ .Config.Key-With-Dashes }}"`
+
+			result := templater.ApplyHelmSubstitutions(content, crdResource)
+
+			Expect(result).To(ContainSubstring(`{{ "{{ .Config.Key-With-Dashes }}" }}`))
+		})
+
+		It("should handle template syntax with quotes correctly", func() {
+			crdResource := &unstructured.Unstructured{}
+			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
+			crdResource.SetKind("CustomResourceDefinition")
+			crdResource.SetName("messages.example.com")
+
+			content := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  versions:
+  - schema:
+      openAPIV3Schema:
+        properties:
+          spec:
+            properties:
+              template:
+                default: '{{ .Config.Message "default" }}'`
+
+			result := templater.ApplyHelmSubstitutions(content, crdResource)
+
+			// Quotes inside templates should be escaped
+			Expect(result).To(ContainSubstring(`{{ "{{ .Config.Message \"default\" }}" }}`))
+		})
+
+		It("should not double-escape quotes already escaped by yaml.Marshal in double-quoted YAML scalars", func() {
+			// Regression test: yaml.Marshal represents literal " inside a {{ }} expression as \"
+			// in a double-quoted YAML scalar, so a second pass escaping " to \" produced \\" which
+			// broke Helm's template parser by closing the string literal early (U+002D '-' error).
+			crdResource := &unstructured.Unstructured{}
+			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
+			crdResource.SetKind("CustomResourceDefinition")
+			crdResource.SetName("webrequestcommitstatuses.promoter.argoproj.io")
+
+			// Simulate the raw YAML text that yaml.Marshal produces for a double-quoted scalar
+			// containing a " character where the inner " appears as \" in the YAML text.
+			content := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  versions:
+  - schema:
+      openAPIV3Schema:
+        properties:
+          spec:
+            description: "example: {{ index .NamespaceMetadata.Labels \"asset-id\" }}"`
+
+			result := templater.ApplyHelmSubstitutions(content, crdResource)
+
+			// The escaped form must be valid Go template syntax: \" (single backslash+quote),
+			// NOT \\" (double backslash+quote) which would terminate the string literal early.
+			Expect(result).To(ContainSubstring(`{{ "{{ index .NamespaceMetadata.Labels \"asset-id\" }}" }}`),
+				"pre-escaped YAML quotes must not be double-escaped to \\\\ which breaks Helm template parsing")
+			Expect(result).NotTo(ContainSubstring(`\\"asset-id\\"`),
+				"double-escaped quotes (\\\\\") must not appear in the output")
+		})
+
+		It("should escape templates in ConfigMaps and other non-CRD resources", func() {
+			configMapResource := &unstructured.Unstructured{}
+			configMapResource.SetAPIVersion("v1")
+			configMapResource.SetKind("ConfigMap")
+			configMapResource.SetName("template-config")
+			configMapResource.SetNamespace(testProjectSystemNamespace)
+
+			// ANY resource can have Go template syntax that needs escaping
+			// Examples: ConfigMaps with notification templates, Secrets with webhook URLs,
+			// Deployment annotations with CI/CD metadata, etc.
+			content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: template-config
+  namespace: test-project-system
+  labels:
+    app.kubernetes.io/name: test-project
+data:
+  notification: "Deployed from {{ .Source.Branch }} to {{ .Target.Branch }}"`
+
+			result := templater.ApplyHelmSubstitutions(content, configMapResource)
+
+			// Existing templates should be escaped (applies to ALL resources, not just CRDs)
+			Expect(result).To(ContainSubstring(`{{ "{{ .Source.Branch }}" }}`))
+			Expect(result).To(ContainSubstring(`{{ "{{ .Target.Branch }}" }}`))
+
+			// Helm templates should still be added normally
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+			Expect(result).To(ContainSubstring(`app.kubernetes.io/name: {{ include "test-project.name" . }}`))
+		})
+
+		It("should handle content without any templates", func() {
+			configMapResource := &unstructured.Unstructured{}
+			configMapResource.SetAPIVersion("v1")
+			configMapResource.SetKind("ConfigMap")
+			configMapResource.SetName("no-template")
+
+			content := `apiVersion: v1
+kind: ConfigMap
+data:
+  message: "No templates here"`
+
+			result := templater.ApplyHelmSubstitutions(content, configMapResource)
+
+			// Should not add any escaping
+			Expect(result).To(ContainSubstring(`message: "No templates here"`))
+			Expect(result).NotTo(ContainSubstring(`{{ "{{`))
+		})
+	})
+
+	Context("edge cases", func() {
+		It("should handle empty content", func() {
+			testResource := &unstructured.Unstructured{}
+			testResource.SetKind("ConfigMap")
+
+			result := templater.ApplyHelmSubstitutions("", testResource)
+			Expect(result).To(BeEmpty())
+		})
+
+		It("should add conditional namespace for ClusterRole when rendering as Role", func() {
+			clusterRoleResource := &unstructured.Unstructured{}
+			clusterRoleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			clusterRoleResource.SetKind("ClusterRole")
+			clusterRoleResource.SetName("test-project-manager-role")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-project-manager-role`
+
+			result := templater.ApplyHelmSubstitutions(content, clusterRoleResource)
+
+			// Should add conditional namespace for Role variant
+			Expect(result).To(ContainSubstring("namespace: {{ .Values.rbac.watchNamespace | default .Release.Namespace }}"))
+			// Namespace should be conditional (only when rbac.namespaced is true)
+			Expect(result).To(ContainSubstring("{{- if .Values.rbac.namespaced }}"))
+		})
+
+		It("should handle malformed YAML gracefully", func() {
+			testResource := &unstructured.Unstructured{}
+			testResource.SetKind("ConfigMap")
+
+			malformedContent := "not: valid: yaml: content:"
+			result := templater.ApplyHelmSubstitutions(malformedContent, testResource)
+
+			// Should return content as-is for malformed YAML
+			Expect(result).To(Equal(malformedContent))
+		})
+	})
+
+	Context("namespace-scoped RBAC resources", func() {
+		It("should preserve explicit namespace in Role for cross-namespace permissions", func() {
+			roleResource := &unstructured.Unstructured{}
+			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			roleResource.SetKind("Role")
+			roleResource.SetName("test-project-manager-role")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: test-project-manager-role
+  namespace: infrastructure
+  labels:
+    app.kubernetes.io/name: test-project
+rules:
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - get
+  - list
+  - patch
+  - update
+  - watch`
+
+			result := templater.ApplyHelmSubstitutions(content, roleResource)
+
+			// Namespace should be preserved (not templated) for cross-namespace permissions
+			Expect(result).To(ContainSubstring("namespace: infrastructure"),
+				"explicit namespace should be preserved for cross-namespace Role")
+			Expect(result).NotTo(ContainSubstring("namespace: {{ .Release.Namespace }}"),
+				"explicit namespace should NOT be templated to Release.Namespace")
+
+			// Labels should still be templated
+			Expect(result).To(ContainSubstring(`app.kubernetes.io/name: {{ include "test-project.name" . }}`))
+
+			// Name should be templated
+			Expect(result).To(ContainSubstring(`name: {{ include "test-project.resourceName"`))
+
+			// Rules should be preserved
+			Expect(result).To(ContainSubstring("- apps"))
+			Expect(result).To(ContainSubstring("- deployments"))
+		})
+
+		It("should preserve explicit namespace in Role for leader election", func() {
+			roleResource := &unstructured.Unstructured{}
+			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			roleResource.SetKind("Role")
+			roleResource.SetName("test-project-leader-election-role")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: test-project-leader-election-role
+  namespace: production
+  labels:
+    app.kubernetes.io/name: test-project
+rules:
+- apiGroups:
+  - coordination.k8s.io
+  resources:
+  - leases
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - events
+  verbs:
+  - create
+  - patch
+  - update`
+
+			result := templater.ApplyHelmSubstitutions(content, roleResource)
+
+			// Namespace should be preserved for cross-namespace leader election
+			Expect(result).To(ContainSubstring("namespace: production"),
+				"explicit namespace should be preserved for cross-namespace leader election Role")
+
+			// Verify leader election permissions
+			Expect(result).To(ContainSubstring("- coordination.k8s.io"))
+			Expect(result).To(ContainSubstring("- leases"))
+			Expect(result).To(ContainSubstring("- events"))
+		})
+
+		It("should preserve explicit namespace in RoleBinding metadata", func() {
+			roleBindingResource := &unstructured.Unstructured{}
+			roleBindingResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			roleBindingResource.SetKind("RoleBinding")
+			roleBindingResource.SetName("test-project-manager-rolebinding")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: test-project-manager-rolebinding
+  namespace: infrastructure
+  labels:
+    app.kubernetes.io/name: test-project
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: test-project-manager-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager
+  namespace: test-project-system`
+
+			result := templater.ApplyHelmSubstitutions(content, roleBindingResource)
+
+			// RoleBinding metadata namespace should be preserved
+			Expect(result).To(ContainSubstring("metadata:\n  name:"))
+			Expect(result).To(ContainSubstring("namespace: infrastructure"),
+				"RoleBinding metadata namespace should be preserved")
+
+			// Subject namespace should be templated (references the controller namespace)
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"),
+				"subject namespace should be templated to Release.Namespace")
+
+			// Name references should be templated
+			Expect(result).To(ContainSubstring(`name: {{ include "test-project.resourceName"`))
+		})
+
+		It("should template Role namespace when it matches project namespace", func() {
+			roleResource := &unstructured.Unstructured{}
+			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			roleResource.SetKind("Role")
+			roleResource.SetName("test-project-leader-election-role")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: test-project-leader-election-role
+  namespace: test-project-system
+  labels:
+    app.kubernetes.io/name: test-project
+rules:
+- apiGroups:
+  - coordination.k8s.io
+  resources:
+  - leases
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete`
+
+			result := templater.ApplyHelmSubstitutions(content, roleResource)
+
+			// When namespace matches project namespace, it should be templated
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"),
+				"Role namespace should be templated when it matches project namespace")
+			Expect(result).NotTo(ContainSubstring("namespace: test-project-system"),
+				"project namespace should be templated, not preserved")
+		})
+
+		It("should handle RoleBinding with multiple subjects correctly", func() {
+			roleBindingResource := &unstructured.Unstructured{}
+			roleBindingResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			roleBindingResource.SetKind("RoleBinding")
+			roleBindingResource.SetName("test-project-manager-rolebinding")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: test-project-manager-rolebinding
+  namespace: infrastructure
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: test-project-manager-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager
+  namespace: test-project-system
+- kind: ServiceAccount
+  name: test-project-webhook
+  namespace: test-project-system`
+
+			result := templater.ApplyHelmSubstitutions(content, roleBindingResource)
+
+			// Both subject namespaces should be templated
+			subjectNamespaceCount := strings.Count(result, "namespace: {{ .Release.Namespace }}")
+			Expect(subjectNamespaceCount).To(BeNumerically(">=", 2),
+				"both subject namespaces should be templated")
+
+			// RoleBinding metadata namespace should be preserved
+			Expect(result).To(ContainSubstring("namespace: infrastructure"))
+		})
+
+		It("should preserve resource names when namespace appears as substring", func() {
+			// Critical: namespace "user" must NOT break resource name "users"
+			// This validates field-aware replacement prevents substring corruption
+			roleResource := &unstructured.Unstructured{}
+			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			roleResource.SetKind("ClusterRole")
+			roleResource.SetName(testManagerRoleName)
+
+			// Scenario: manager namespace is "user", CRD resource is testRoleNamespaceUsers
+			customTemplater := &Templater{
+				detectedPrefix:   testProjectName,
+				chartName:        testProjectName,
+				managerNamespace: "user", // Short namespace that appears as substring
+				roleNamespaces:   nil,
+			}
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-project-manager-role
+rules:
+- apiGroups:
+  - identity.example.com
+  resources:
+  - users
+  - users/finalizers
+  - users/status
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete`
+
+			result := customTemplater.ApplyHelmSubstitutions(content, roleResource)
+
+			// Critical: resource name "users" must NOT be replaced with "{{ .Release.Namespace }}s"
+			Expect(result).To(ContainSubstring("- users"),
+				"resource name 'users' should remain unchanged")
+			Expect(result).To(ContainSubstring("- users/finalizers"),
+				"resource name 'users/finalizers' should remain unchanged")
+			Expect(result).To(ContainSubstring("- users/status"),
+				"resource name 'users/status' should remain unchanged")
+
+			// Ensure we didn't create templated resource names
+			Expect(result).NotTo(ContainSubstring("- {{ .Release.Namespace }}s"),
+				"must NOT replace 'user' substring in resource names")
+			Expect(result).NotTo(MatchRegexp(`resources:\s*-\s*\{\{.*\}\}`),
+				"resource names must never be templated")
+		})
+
+		It("should handle edge case where namespace is substring of multiple fields", func() {
+			// Test more edge cases: namespace "app" appears in "applications", "apps", etc.
+			customTemplater := &Templater{
+				detectedPrefix:   testProjectName,
+				chartName:        testProjectName,
+				managerNamespace: "app",
+				roleNamespaces:   nil,
+			}
+
+			roleBindingResource := &unstructured.Unstructured{}
+			roleBindingResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			roleBindingResource.SetKind("RoleBinding")
+			roleBindingResource.SetName("manager-rolebinding")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: test-project-manager-rolebinding
+  namespace: app
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: test-project-manager-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager
+  namespace: app`
+
+			result := customTemplater.ApplyHelmSubstitutions(content, roleBindingResource)
+
+			// Namespace fields should be templated: the metadata one follows rbac.watchNamespace,
+			// the subject one stays with the ServiceAccount
+			Expect(result).To(ContainSubstring("  namespace: {{ .Values.rbac.watchNamespace | default .Release.Namespace }}"),
+				"metadata namespace should be templated")
+			Expect(result).To(HaveSuffix("  namespace: {{ .Release.Namespace }}"),
+				"subject namespace should be templated")
+			Expect(strings.Count(result, "namespace: {{")).To(Equal(2),
+				"should have exactly 2 namespace field replacements")
+
+			// Verify apiGroup field is NOT affected (contains "app" in "rbac.authorization.k8s.io")
+			Expect(result).To(ContainSubstring("apiGroup: rbac.authorization.k8s.io"),
+				"apiGroup should not be affected by namespace replacement")
+		})
+
+		It("should handle ALL Kubernetes DNS patterns generically", func() {
+			// This test validates DNS replacement works for ANY K8s DNS pattern
+			configMapResource := &unstructured.Unstructured{}
+			configMapResource.SetAPIVersion("v1")
+			configMapResource.SetKind("ConfigMap")
+			configMapResource.SetName("dns-config")
+
+			content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: dns-config
+  namespace: test-project-system
+data:
+  # Standard service DNS
+  service-short: api.test-project-system.svc
+  service-full: api.test-project-system.svc.cluster.local
+  service-port: api.test-project-system.svc:8080
+  service-path: https://api.test-project-system.svc.cluster.local:443/v1
+  
+  # Pod DNS
+  pod-dns: my-pod.test-project-system.pod.cluster.local
+  
+  # Endpoints DNS  
+  endpoints-dns: my-service.test-project-system.endpoints.cluster.local
+  
+  # Headless service (StatefulSet)
+  stateful-0: app-0.app-headless.test-project-system.svc.cluster.local
+  stateful-1: app-1.app-headless.test-project-system.svc.cluster.local
+  
+  # External namespace should be preserved
+  external-svc: monitoring.monitoring-system.svc.cluster.local`
+
+			result := templater.ApplyHelmSubstitutions(content, configMapResource)
+
+			// Verify ALL manager namespace DNS patterns are templated
+			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc"))
+			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc.cluster.local"))
+			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc:8080"))
+			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc.cluster.local:443"))
+			Expect(result).To(ContainSubstring("my-pod.{{ .Release.Namespace }}.pod.cluster.local"))
+			Expect(result).To(ContainSubstring("my-service.{{ .Release.Namespace }}.endpoints.cluster.local"))
+			Expect(result).To(ContainSubstring("app-0.app-headless.{{ .Release.Namespace }}.svc.cluster.local"))
+			Expect(result).To(ContainSubstring("app-1.app-headless.{{ .Release.Namespace }}.svc.cluster.local"))
+
+			// Verify NO hardcoded manager namespace remains
+			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+			Expect(result).NotTo(ContainSubstring(".test-project-system.pod"))
+			Expect(result).NotTo(ContainSubstring(".test-project-system.endpoints"))
+
+			// Verify external namespace is preserved
+			Expect(result).To(ContainSubstring("monitoring.monitoring-system.svc.cluster.local"))
+		})
+
+		It("should NOT replace namespace-like strings in non-DNS contexts", func() {
+			// Edge case: ensure we don't break strings that happen to contain the namespace
+			configMapResource := &unstructured.Unstructured{}
+			configMapResource.SetAPIVersion("v1")
+			configMapResource.SetKind("ConfigMap")
+			configMapResource.SetName("edge-cases")
+
+			// Using "app" as namespace to test substring issues
+			customTemplater := &Templater{
+				detectedPrefix:   "test",
+				chartName:        "test",
+				managerNamespace: "app",
+				roleNamespaces:   nil,
+			}
+
+			content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: edge-cases
+  namespace: app
+data:
+  # DNS patterns - should be templated
+  service-url: http://api.app.svc:8080
+  
+  # NOT DNS patterns - should be preserved
+  app-name: "my-application"
+  app-version: "v1.2.3"
+  mapping: "application-mapping"
+  labels: "app=frontend,app.kubernetes.io/name=myapp"
+  
+  # Tricky: "app" in various contexts
+  erapplication: "some-value"
+  wrapperapp: "another-value"`
+
+			result := customTemplater.ApplyHelmSubstitutions(content, configMapResource)
+
+			// DNS pattern should be templated
+			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc:8080"))
+
+			// Non-DNS occurrences should be preserved
+			Expect(result).To(ContainSubstring(`app-name: "my-application"`))
+			Expect(result).To(ContainSubstring("app-version"))
+			Expect(result).To(ContainSubstring("mapping: \"application-mapping\""))
+			Expect(result).To(ContainSubstring("app=frontend"))
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name=myapp"))
+			Expect(result).To(ContainSubstring("erapplication"))
+			Expect(result).To(ContainSubstring("wrapperapp"))
+
+			// Namespace field should be templated
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+		})
+
+		It("should handle ANY resource type with namespace references (generic test)", func() {
+			// This test validates that namespace replacement is GENERIC and works
+			// for any resource type, including custom resources in extras/ directory
+
+			// Test with a custom ConfigMap (common in extras/)
+			configMapResource := &unstructured.Unstructured{}
+			configMapResource.SetAPIVersion("v1")
+			configMapResource.SetKind("ConfigMap")
+			configMapResource.SetName("custom-config")
+
+			content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: custom-config
+  namespace: test-project-system
+data:
+  service-url: http://api-service.test-project-system.svc.cluster.local:8080
+  webhook-endpoint: https://webhook.test-project-system.svc:9443/validate
+  annotation-ref: "test-project-system/my-resource"`
+
+			result := templater.ApplyHelmSubstitutions(content, configMapResource)
+
+			// 1. Namespace field should be templated
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+			Expect(result).NotTo(ContainSubstring("namespace: test-project-system"))
+
+			// 2. DNS names in data values should be templated
+			Expect(result).To(ContainSubstring("http://api-service.{{ .Release.Namespace }}.svc.cluster.local:8080"))
+			Expect(result).NotTo(ContainSubstring(".test-project-system.svc.cluster.local"))
+
+			// 3. DNS names with ports should be templated
+			Expect(result).To(ContainSubstring("https://webhook.{{ .Release.Namespace }}.svc:9443"))
+			Expect(result).NotTo(ContainSubstring(".test-project-system.svc:9443"))
+
+			// 4. Annotation-style references should be templated
+			Expect(result).To(ContainSubstring("{{ .Release.Namespace }}/my-resource"))
+			Expect(result).NotTo(ContainSubstring("test-project-system/my-resource"))
+		})
+
+		It("should handle Secret with namespace references", func() {
+			secretResource := &unstructured.Unstructured{}
+			secretResource.SetAPIVersion("v1")
+			secretResource.SetKind("Secret")
+			secretResource.SetName("app-secret")
+
+			content := `apiVersion: v1
+kind: Secret
+metadata:
+  name: app-secret
+  namespace: test-project-system
+  annotations:
+    source: test-project-system/config
+stringData:
+  database-url: postgresql://db.test-project-system.svc:5432/mydb
+  redis-url: redis://cache.test-project-system.svc.cluster.local:6379`
+
+			result := templater.ApplyHelmSubstitutions(content, secretResource)
+
+			// Namespace field templated
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+
+			// Annotation value templated
+			Expect(result).To(ContainSubstring("source: {{ .Release.Namespace }}/config"))
+
+			// DNS names in data templated
+			Expect(result).To(ContainSubstring("postgresql://db.{{ .Release.Namespace }}.svc:5432"))
+			Expect(result).To(ContainSubstring("redis://cache.{{ .Release.Namespace }}.svc.cluster.local:6379"))
+
+			// No hardcoded namespace remains
+			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+		})
+
+		It("should handle Ingress with namespace references", func() {
+			ingressResource := &unstructured.Unstructured{}
+			ingressResource.SetAPIVersion("networking.k8s.io/v1")
+			ingressResource.SetKind("Ingress")
+			ingressResource.SetName("app-ingress")
+
+			content := `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: app-ingress
+  namespace: test-project-system
+  annotations:
+    nginx.ingress.kubernetes.io/auth-url: http://auth.test-project-system.svc.cluster.local/verify
+    cert-manager.io/issuer: test-project-system/letsencrypt
+spec:
+  rules:
+  - host: example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: app-service
+            port:
+              number: 80`
+
+			result := templater.ApplyHelmSubstitutions(content, ingressResource)
+
+			// Namespace field templated
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+
+			// Annotation DNS templated
+			Expect(result).To(ContainSubstring("http://auth.{{ .Release.Namespace }}.svc.cluster.local/verify"))
+
+			// Annotation reference templated
+			Expect(result).To(ContainSubstring("cert-manager.io/issuer: {{ .Release.Namespace }}/letsencrypt"))
+
+			// No hardcoded namespace
+			Expect(result).NotTo(ContainSubstring("test-project-system/"))
+			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+		})
+
+		It("should handle PodMonitor with namespace references", func() {
+			podMonitorResource := &unstructured.Unstructured{}
+			podMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
+			podMonitorResource.SetKind("PodMonitor")
+			podMonitorResource.SetName("app-monitor")
+
+			content := `apiVersion: monitoring.coreos.com/v1
+kind: PodMonitor
+metadata:
+  name: app-monitor
+  namespace: test-project-system
+spec:
+  selector:
+    matchLabels:
+      app: myapp
+  podMetricsEndpoints:
+  - port: metrics
+    scheme: https
+    tlsConfig:
+      serverName: metrics.test-project-system.svc
+      ca:
+        configMap:
+          name: prometheus-ca
+          namespace: test-project-system`
+
+			result := templater.ApplyHelmSubstitutions(content, podMonitorResource)
+
+			// Namespace field templated
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+
+			// ServerName DNS templated
+			Expect(result).To(ContainSubstring("serverName: metrics.{{ .Release.Namespace }}.svc"))
+
+			// ConfigMap namespace reference templated
+			namespaceCount := strings.Count(result, "namespace: {{ .Release.Namespace }}")
+			Expect(namespaceCount).To(Equal(2), "both metadata and configMap namespace should be templated")
+
+			// No hardcoded namespace in DNS
+			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+		})
+
+		It("should handle custom CRD with multiple namespace contexts", func() {
+			customResource := &unstructured.Unstructured{}
+			customResource.SetAPIVersion("example.com/v1")
+			customResource.SetKind("Application")
+			customResource.SetName("my-app")
+
+			content := `apiVersion: example.com/v1
+kind: Application
+metadata:
+  name: my-app
+  namespace: test-project-system
+  annotations:
+    backup.velero.io/backup-volumes: test-project-system/pvc
+spec:
+  database:
+    host: postgres.test-project-system.svc.cluster.local
+    port: 5432
+  messaging:
+    brokerURL: amqp://rabbitmq.test-project-system.svc:5672
+  externalServices:
+    - name: external-api
+      url: https://api.external-namespace.svc/v1
+  references:
+    configMapRef: test-project-system/app-config
+    secretRef: test-project-system/app-secret`
 
-			result := templater.ApplyHelmSubstitutions(content, crdResource)
+			result := templater.ApplyHelmSubstitutions(content, customResource)
 
-			// Both templates should be escaped (applies to all resources)
-			Expect(result).To(ContainSubstring(`{{ "{{ .Source.Branch }}" }}`))
-			Expect(result).To(ContainSubstring(`{{ "{{ .Target.Branch }}" }}`))
-		})
+			// Namespace field templated
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
 
-		It("should escape templates with special characters", func() {
-			crdResource := &unstructured.Unstructured{}
-			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
-			crdResource.SetKind("CustomResourceDefinition")
-			crdResource.SetName("configs.example.com")
+			// Annotation reference templated
+			Expect(result).To(ContainSubstring("{{ .Release.Namespace }}/pvc"))
 
-			content := `apiVersion: apiextensions.k8s.io/v1
-kind: CustomResourceDefinition
-spec:
-  versions:
-  - schema:
-      openAPIV3Schema:
-        properties:
-          spec:
-            properties:
-              value:
-                default: "Value: {{ .Config.Key-With-Dashes }}"`
+			// DNS names templated
+			Expect(result).To(ContainSubstring("postgres.{{ .Release.Namespace }}.svc.cluster.local"))
+			Expect(result).To(ContainSubstring("rabbitmq.{{ .Release.Namespace }}.svc:5672"))
 
-			result := templater.ApplyHelmSubstitutions(content, crdResource)
+			// External namespace preserved (not manager namespace)
+			Expect(result).To(ContainSubstring("https://api.external-namespace.svc/v1"))
 
-			Expect(result).To(ContainSubstring(`{{ "{{ .Config.Key-With-Dashes }}" }}`))
+			// ConfigMap/Secret refs templated
+			Expect(result).To(ContainSubstring("configMapRef: {{ .Release.Namespace }}/app-config"))
+			Expect(result).To(ContainSubstring("secretRef: {{ .Release.Namespace }}/app-secret"))
+
+			// No manager namespace remains
+			Expect(result).NotTo(ContainSubstring("test-project-system/"))
+			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
 		})
 
-		It("should handle template syntax with quotes correctly", func() {
-			crdResource := &unstructured.Unstructured{}
-			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
-			crdResource.SetKind("CustomResourceDefinition")
-			crdResource.SetName("messages.example.com")
+		It("should NOT replace namespace in non-manager context", func() {
+			// Critical: cross-namespace references must be preserved
+			customResource := &unstructured.Unstructured{}
+			customResource.SetAPIVersion("v1")
+			customResource.SetKind("ConfigMap")
+			customResource.SetName("federation-config")
 
-			content := `apiVersion: apiextensions.k8s.io/v1
-kind: CustomResourceDefinition
-spec:
-  versions:
-  - schema:
-      openAPIV3Schema:
-        properties:
-          spec:
-            properties:
-              template:
-                default: '{{ .Config.Message "default" }}'`
+			content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: federation-config
+  namespace: test-project-system
+data:
+  clusters: |
+    - name: cluster-a
+      apiserver: https://api.cluster-a-system.svc:6443
+    - name: cluster-b
+      apiserver: https://api.cluster-b-system.svc:6443
+  external-service: https://monitoring.monitoring-system.svc.cluster.local:9090
+  internal-service: https://internal.test-project-system.svc:8080`
 
-			result := templater.ApplyHelmSubstitutions(content, crdResource)
+			result := templater.ApplyHelmSubstitutions(content, customResource)
 
-			// Quotes inside templates should be escaped
-			Expect(result).To(ContainSubstring(`{{ "{{ .Config.Message \"default\" }}" }}`))
+			// Manager namespace field templated
+			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+			Expect(result).NotTo(ContainSubstring("namespace: test-project-system"))
+
+			// Manager namespace in DNS templated (appears once in internal-service)
+			Expect(result).To(ContainSubstring("internal.{{ .Release.Namespace }}.svc:8080"))
+			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+
+			// External namespaces preserved (these don't match manager namespace)
+			Expect(result).To(ContainSubstring("cluster-a-system.svc"))
+			Expect(result).To(ContainSubstring("cluster-b-system.svc"))
+			Expect(result).To(ContainSubstring("monitoring-system.svc"))
 		})
+	})
 
-		It("should not double-escape quotes already escaped by yaml.Marshal in double-quoted YAML scalars", func() {
-			// Regression test: yaml.Marshal represents literal " inside a {{ }} expression as \"
-			// in a double-quoted YAML scalar, so a second pass escaping " to \" produced \\" which
-			// broke Helm's template parser by closing the string literal early (U+002D '-' error).
-			crdResource := &unstructured.Unstructured{}
-			crdResource.SetAPIVersion("apiextensions.k8s.io/v1")
-			crdResource.SetKind("CustomResourceDefinition")
-			crdResource.SetName("webrequestcommitstatuses.promoter.argoproj.io")
+	Context("templatePorts", func() {
+		It("should template webhook service ports", func() {
+			webhookService := &unstructured.Unstructured{}
+			webhookService.SetAPIVersion("v1")
+			webhookService.SetKind("Service")
+			webhookService.SetName("test-project-webhook-service")
 
-			// Simulate the raw YAML text that yaml.Marshal produces for a double-quoted scalar
-			// containing a " character where the inner " appears as \" in the YAML text.
-			content := `apiVersion: apiextensions.k8s.io/v1
-kind: CustomResourceDefinition
+			content := `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-webhook-service
+  namespace: test-project-system
 spec:
-  versions:
-  - schema:
-      openAPIV3Schema:
-        properties:
-          spec:
-            description: "example: {{ index .NamespaceMetadata.Labels \"asset-id\" }}"`
+  ports:
+  - port: 443
+    targetPort: 9443
+    protocol: TCP
+  selector:
+    control-plane: controller-manager`
 
-			result := templater.ApplyHelmSubstitutions(content, crdResource)
+			result := templater.templatePorts(content, webhookService)
 
-			// The escaped form must be valid Go template syntax: \" (single backslash+quote),
-			// NOT \\" (double backslash+quote) which would terminate the string literal early.
-			Expect(result).To(ContainSubstring(`{{ "{{ index .NamespaceMetadata.Labels \"asset-id\" }}" }}`),
-				"pre-escaped YAML quotes must not be double-escaped to \\\\ which breaks Helm template parsing")
-			Expect(result).NotTo(ContainSubstring(`\\"asset-id\\"`),
-				"double-escaped quotes (\\\\\") must not appear in the output")
+			// Should template webhook port
+			Expect(result).To(ContainSubstring("targetPort: {{ .Values.webhook.port }}"))
+			Expect(result).NotTo(ContainSubstring("targetPort: 9443"))
 		})
 
-		It("should escape templates in ConfigMaps and other non-CRD resources", func() {
-			configMapResource := &unstructured.Unstructured{}
-			configMapResource.SetAPIVersion("v1")
-			configMapResource.SetKind("ConfigMap")
-			configMapResource.SetName("template-config")
-			configMapResource.SetNamespace(testProjectSystemNamespace)
+		It("should template the webhook Service port and keep both ends on the values", func() {
+			webhookService := &unstructured.Unstructured{}
+			webhookService.SetAPIVersion("v1")
+			webhookService.SetKind("Service")
+			webhookService.SetName("test-project-webhook-service")
 
-			// ANY resource can have Go template syntax that needs escaping
-			// Examples: ConfigMaps with notification templates, Secrets with webhook URLs,
-			// Deployment annotations with CI/CD metadata, etc.
 			content := `apiVersion: v1
-kind: ConfigMap
+kind: Service
 metadata:
-  name: template-config
+  name: test-project-webhook-service
   namespace: test-project-system
-  labels:
-    app.kubernetes.io/name: test-project
-data:
-  notification: "Deployed from {{ .Source.Branch }} to {{ .Target.Branch }}"`
+spec:
+  ports:
+  - port: 443
+    protocol: TCP
+    targetPort: 9443
+  selector:
+    control-plane: controller-manager`
 
-			result := templater.ApplyHelmSubstitutions(content, configMapResource)
+			result := templater.templatePorts(content, webhookService)
 
-			// Existing templates should be escaped (applies to ALL resources, not just CRDs)
-			Expect(result).To(ContainSubstring(`{{ "{{ .Source.Branch }}" }}`))
-			Expect(result).To(ContainSubstring(`{{ "{{ .Target.Branch }}" }}`))
+			Expect(result).To(ContainSubstring(
+				"  - port: {{ (.Values.webhook.service | default dict).port | default 443 }}\n"))
+			Expect(result).To(ContainSubstring("    targetPort: {{ .Values.webhook.port }}\n"))
+			Expect(templater.templatePorts(result, webhookService)).To(Equal(result))
 
-			// Helm templates should still be added normally
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
-			Expect(result).To(ContainSubstring(`app.kubernetes.io/name: {{ include "test-project.name" . }}`))
+			rendered, err := renderTemplate(result, map[string]any{
+				"webhook": map[string]any{"port": 9444, "service": map[string]any{"port": 8443}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(ContainSubstring("  - port: 8443\n"))
+			Expect(rendered).To(ContainSubstring("    targetPort: 9444\n"))
+
+			rendered, err = renderTemplate(result, map[string]any{"webhook": map[string]any{"port": 9443}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(ContainSubstring("  - port: 443\n"))
 		})
 
-		It("should handle content without any templates", func() {
-			configMapResource := &unstructured.Unstructured{}
-			configMapResource.SetAPIVersion("v1")
-			configMapResource.SetKind("ConfigMap")
-			configMapResource.SetName("no-template")
+		It("should point webhook configurations at the templated webhook Service port", func() {
+			webhookConfig := &unstructured.Unstructured{}
+			webhookConfig.SetAPIVersion("admissionregistration.k8s.io/v1")
+			webhookConfig.SetKind("ValidatingWebhookConfiguration")
+			webhookConfig.SetName("test-project-validating-webhook-configuration")
 
-			content := `apiVersion: v1
-kind: ConfigMap
-data:
-  message: "No templates here"`
+			content := `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: test-project-validating-webhook-configuration
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    service:
+      name: test-project-webhook-service
+      namespace: test-project-system
+      path: /validate
+      port: 8443
+  name: vmemcached.kb.io
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    service:
+      name: test-project-webhook-service
+      namespace: test-project-system
+      path: /validate-other
+  name: vother.kb.io`
 
-			result := templater.ApplyHelmSubstitutions(content, configMapResource)
+			result := templater.templatePorts(content, webhookConfig)
 
-			// Should not add any escaping
-			Expect(result).To(ContainSubstring(`message: "No templates here"`))
-			Expect(result).NotTo(ContainSubstring(`{{ "{{`))
+			Expect(result).To(ContainSubstring("      path: /validate\n" +
+				"      port: {{ (.Values.webhook.service | default dict).port | default 8443 }}\n"))
+			Expect(result).To(ContainSubstring("      path: /validate-other\n" +
+				"      port: {{ (.Values.webhook.service | default dict).port | default 443 }}\n"))
+			Expect(result).NotTo(ContainSubstring("port: 8443\n"))
+			Expect(templater.templatePorts(result, webhookConfig)).To(Equal(result))
 		})
-	})
 
-	Context("edge cases", func() {
-		It("should handle empty content", func() {
-			testResource := &unstructured.Unstructured{}
-			testResource.SetKind("ConfigMap")
+		It("should keep the manager webhook containerPort on webhook.port", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			result := templater.ApplyHelmSubstitutions("", testResource)
-			Expect(result).To(BeEmpty())
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --webhook-cert-path=/tmp/k8s-webhook-server/serving-certs
+        name: manager
+        ports:
+        - containerPort: 9443
+          name: webhook-server
+          protocol: TCP`
+
+			result := templater.templatePorts(content, deployment)
+
+			Expect(result).To(ContainSubstring("- containerPort: {{ .Values.webhook.port }}"))
+			Expect(result).NotTo(ContainSubstring("containerPort: 9443"))
 		})
 
-		It("should add conditional namespace for ClusterRole when rendering as Role", func() {
-			clusterRoleResource := &unstructured.Unstructured{}
-			clusterRoleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			clusterRoleResource.SetKind("ClusterRole")
-			clusterRoleResource.SetName("test-project-manager-role")
+		It("should render the manager metrics containerPort from metrics.port only with metrics.enabled", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-manager-role`
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --metrics-bind-address=:8443
+        - --health-probe-bind-address=:8081
+        name: manager
+        ports:
+        - containerPort: 8443
+          name: https
+          protocol: TCP
+        - containerPort: 8081
+          name: health
+          protocol: TCP
+      - name: sidecar
+        ports:
+        - containerPort: 8443
+          name: https`
 
-			result := templater.ApplyHelmSubstitutions(content, clusterRoleResource)
+			result := templater.templatePorts(content, deployment)
 
-			// Should add conditional namespace for Role variant
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
-			// Namespace should be conditional (only when rbac.namespaced is true)
-			Expect(result).To(ContainSubstring("{{- if .Values.rbac.namespaced }}"))
+			Expect(result).To(ContainSubstring(`
+        ports:
+        {{- if .Values.metrics.enabled }}
+        - containerPort: {{ .Values.metrics.port }}
+          name: https
+          protocol: TCP
+        {{- end }}
+        - containerPort: {{ .Values.manager.healthProbe.port }}
+          name: health
+`))
+			Expect(result).To(HaveSuffix("      - name: sidecar\n        ports:\n        - containerPort: 8443\n          name: https"))
+			Expect(templater.templatePorts(result, deployment)).To(Equal(result))
+
+			render := func(metrics map[string]any) string {
+				GinkgoHelper()
+				rendered, err := renderTemplate(result, map[string]any{
+					"metrics": metrics,
+					"manager": map[string]any{"healthProbe": map[string]any{"port": 8081}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
+			Expect(render(map[string]any{"enabled": true, "port": 9443})).To(ContainSubstring("- containerPort: 9443\n"))
+			Expect(render(map[string]any{"enabled": false, "port": 8443})).To(ContainSubstring(
+				"        ports:\n        - containerPort: 8081\n"))
 		})
 
-		It("should handle malformed YAML gracefully", func() {
-			testResource := &unstructured.Unstructured{}
-			testResource.SetKind("ConfigMap")
+		It("should template metrics service ports", func() {
+			metricsService := &unstructured.Unstructured{}
+			metricsService.SetAPIVersion("v1")
+			metricsService.SetKind("Service")
+			metricsService.SetName("test-project-controller-manager-metrics-service")
 
-			malformedContent := "not: valid: yaml: content:"
-			result := templater.ApplyHelmSubstitutions(malformedContent, testResource)
+			content := `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-controller-manager-metrics-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 8443
+    targetPort: 8443
+    protocol: TCP
+    name: https
+  selector:
+    control-plane: controller-manager`
 
-			// Should return content as-is for malformed YAML
-			Expect(result).To(Equal(malformedContent))
+			result := templater.templatePorts(content, metricsService)
+
+			// Should template metrics port
+			Expect(result).To(ContainSubstring("port: {{ .Values.metrics.port }}"))
+			Expect(result).To(ContainSubstring("targetPort: {{ .Values.metrics.port }}"))
+			Expect(result).NotTo(ContainSubstring("port: 8443"))
+			Expect(result).NotTo(ContainSubstring("targetPort: 8443"))
 		})
-	})
 
-	Context("namespace-scoped RBAC resources", func() {
-		It("should preserve explicit namespace in Role for cross-namespace permissions", func() {
-			roleResource := &unstructured.Unstructured{}
-			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			roleResource.SetKind("Role")
-			roleResource.SetName("test-project-manager-role")
+		It("should template webhook container ports in Deployment", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: Role
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-manager-role
-  namespace: infrastructure
-  labels:
-    app.kubernetes.io/name: test-project
-rules:
-- apiGroups:
-  - apps
-  resources:
-  - deployments
-  verbs:
-  - get
-  - list
-  - patch
-  - update
-  - watch`
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        ports:
+        - containerPort: 9443
+          name: webhook-server
+          protocol: TCP`
 
-			result := templater.ApplyHelmSubstitutions(content, roleResource)
+			result := templater.templatePorts(content, deployment)
 
-			// Namespace should be preserved (not templated) for cross-namespace permissions
-			Expect(result).To(ContainSubstring("namespace: infrastructure"),
-				"explicit namespace should be preserved for cross-namespace Role")
-			Expect(result).NotTo(ContainSubstring("namespace: {{ .Release.Namespace }}"),
-				"explicit namespace should NOT be templated to Release.Namespace")
+			// Should template webhook containerPort
+			Expect(result).To(ContainSubstring("containerPort: {{ .Values.webhook.port }}"))
+			Expect(result).NotTo(ContainSubstring("containerPort: 9443"))
+		})
 
-			// Labels should still be templated
-			Expect(result).To(ContainSubstring(`app.kubernetes.io/name: {{ include "test-project.name" . }}`))
+		It("should template health probe ports in Deployment", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			// Name should be templated
-			Expect(result).To(ContainSubstring(`name: {{ include "test-project.resourceName"`))
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 8081
+        readinessProbe:
+          httpGet:
+            path: /readyz
+            port: 8081`
 
-			// Rules should be preserved
-			Expect(result).To(ContainSubstring("- apps"))
-			Expect(result).To(ContainSubstring("- deployments"))
+			result := templater.templatePorts(content, deployment)
+
+			Expect(result).To(ContainSubstring("port: {{ .Values.manager.healthProbe.port }}"))
+			Expect(result).NotTo(ContainSubstring("port: 8081"))
 		})
 
-		It("should preserve explicit namespace in Role for leader election", func() {
-			roleResource := &unstructured.Unstructured{}
-			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			roleResource.SetKind("Role")
-			roleResource.SetName("test-project-leader-election-role")
+		// Regression test for #5865: the health probe port must be templated in all
+		// four places it appears in the manager Deployment (bind-address arg, the
+		// "health" containerPort, and the liveness and readiness httpGet ports), so it
+		// can be configured from values.yaml like the metrics and webhook ports.
+		It("should template every health probe port reference in the manager Deployment", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: Role
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-leader-election-role
-  namespace: production
-  labels:
-    app.kubernetes.io/name: test-project
-rules:
-- apiGroups:
-  - coordination.k8s.io
-  resources:
-  - leases
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - ""
-  resources:
-  - events
-  verbs:
-  - create
-  - patch
-  - update`
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        args:
+        - --health-probe-bind-address=:8081
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 8081
+        ports:
+        - containerPort: 8081
+          name: health
+          protocol: TCP
+        readinessProbe:
+          httpGet:
+            path: /readyz
+            port: 8081`
 
-			result := templater.ApplyHelmSubstitutions(content, roleResource)
+			result := templater.templatePorts(content, deployment)
 
-			// Namespace should be preserved for cross-namespace leader election
-			Expect(result).To(ContainSubstring("namespace: production"),
-				"explicit namespace should be preserved for cross-namespace leader election Role")
+			Expect(result).To(ContainSubstring("--health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}"))
+			Expect(result).To(ContainSubstring("containerPort: {{ .Values.manager.healthProbe.port }}"))
+			Expect(result).To(ContainSubstring(`            path: /healthz
+            port: {{ .Values.manager.healthProbe.port }}`))
+			Expect(result).To(ContainSubstring(`            path: /readyz
+            port: {{ .Values.manager.healthProbe.port }}`))
+			Expect(result).NotTo(ContainSubstring("8081"))
 
-			// Verify leader election permissions
-			Expect(result).To(ContainSubstring("- coordination.k8s.io"))
-			Expect(result).To(ContainSubstring("- leases"))
-			Expect(result).To(ContainSubstring("- events"))
+			rendered, err := renderTemplate(result, map[string]any{
+				"manager": map[string]any{"healthProbe": map[string]any{"port": 9091}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(ContainSubstring("- --health-probe-bind-address=:9091\n"))
+			Expect(rendered).To(ContainSubstring("- containerPort: 9091\n"))
+			Expect(strings.Count(rendered, "            port: 9091")).To(Equal(2))
+			Expect(rendered).NotTo(ContainSubstring("8081"))
 		})
 
-		It("should preserve explicit namespace in RoleBinding metadata", func() {
-			roleBindingResource := &unstructured.Unstructured{}
-			roleBindingResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			roleBindingResource.SetKind("RoleBinding")
-			roleBindingResource.SetName("test-project-manager-rolebinding")
+		It("should template the scheme of both health probes", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: RoleBinding
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-manager-rolebinding
-  namespace: infrastructure
-  labels:
-    app.kubernetes.io/name: test-project
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: Role
-  name: test-project-manager-role
-subjects:
-- kind: ServiceAccount
   name: test-project-controller-manager
-  namespace: test-project-system`
-
-			result := templater.ApplyHelmSubstitutions(content, roleBindingResource)
-
-			// RoleBinding metadata namespace should be preserved
-			Expect(result).To(ContainSubstring("metadata:\n  name:"))
-			Expect(result).To(ContainSubstring("namespace: infrastructure"),
-				"RoleBinding metadata namespace should be preserved")
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 8081
+        readinessProbe:
+          httpGet:
+            path: /readyz
+            port: 8081
+            scheme: HTTPS`
 
-			// Subject namespace should be templated (references the controller namespace)
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"),
-				"subject namespace should be templated to Release.Namespace")
+			result := templater.templatePorts(content, deployment)
 
-			// Name references should be templated
-			Expect(result).To(ContainSubstring(`name: {{ include "test-project.resourceName"`))
+			Expect(result).To(ContainSubstring(`            path: /healthz
+            port: {{ .Values.manager.healthProbe.port }}
+            scheme: {{ .Values.manager.healthProbe.scheme | default "HTTP" }}`))
+			Expect(result).To(ContainSubstring(`            path: /readyz
+            port: {{ .Values.manager.healthProbe.port }}
+            scheme: {{ .Values.manager.healthProbe.scheme | default "HTTPS" }}`))
+			Expect(strings.Count(result, "scheme:")).To(Equal(2))
+			Expect(templater.templatePorts(result, deployment)).To(Equal(result))
+
+			schemes := func(healthProbe map[string]any) []string {
+				GinkgoHelper()
+				healthProbe["port"] = 8081
+				rendered, err := renderTemplate(result, map[string]any{
+					"manager": map[string]any{"healthProbe": healthProbe},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				var parsed map[string]any
+				Expect(yaml.Unmarshal([]byte(rendered), &parsed)).To(Succeed())
+				containers, _, _ := unstructured.NestedSlice(parsed, "spec", "template", "spec", "containers")
+				manager := containers[0].(map[string]any)
+				var found []string
+				for _, probe := range []string{"livenessProbe", "readinessProbe"} {
+					scheme, _, _ := unstructured.NestedString(manager, probe, "httpGet", "scheme")
+					found = append(found, scheme)
+				}
+				return found
+			}
+			Expect(schemes(map[string]any{})).To(Equal([]string{"HTTP", "HTTPS"}))
+			Expect(schemes(map[string]any{"scheme": "HTTPS"})).To(Equal([]string{"HTTPS", "HTTPS"}))
 		})
 
-		It("should template Role namespace when it matches project namespace", func() {
-			roleResource := &unstructured.Unstructured{}
-			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			roleResource.SetKind("Role")
-			roleResource.SetName("test-project-leader-election-role")
+		It("should leave probes using the named health port untouched", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: Role
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-leader-election-role
-  namespace: test-project-system
-  labels:
-    app.kubernetes.io/name: test-project
-rules:
-- apiGroups:
-  - coordination.k8s.io
-  resources:
-  - leases
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete`
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        args:
+        - --health-probe-bind-address=:8081
+        ports:
+        - containerPort: 8081
+          name: health
+          protocol: TCP
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: health
+        readinessProbe:
+          httpGet:
+            path: /readyz
+            port: health`
 
-			result := templater.ApplyHelmSubstitutions(content, roleResource)
+			result := templater.templatePorts(content, deployment)
 
-			// When namespace matches project namespace, it should be templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"),
-				"Role namespace should be templated when it matches project namespace")
-			Expect(result).NotTo(ContainSubstring("namespace: test-project-system"),
-				"project namespace should be templated, not preserved")
+			Expect(result).To(ContainSubstring("--health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}"))
+			Expect(result).To(ContainSubstring("containerPort: {{ .Values.manager.healthProbe.port }}"))
+			Expect(result).To(ContainSubstring(`            path: /healthz
+            port: health`))
+			Expect(result).To(ContainSubstring(`            path: /readyz
+            port: health`))
 		})
 
-		It("should handle RoleBinding with multiple subjects correctly", func() {
-			roleBindingResource := &unstructured.Unstructured{}
-			roleBindingResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			roleBindingResource.SetKind("RoleBinding")
-			roleBindingResource.SetName("test-project-manager-rolebinding")
+		It("should preserve the host when templating bind-address args in HOST:PORT form", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: RoleBinding
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-manager-rolebinding
-  namespace: infrastructure
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: Role
-  name: test-project-manager-role
-subjects:
-- kind: ServiceAccount
   name: test-project-controller-manager
-  namespace: test-project-system
-- kind: ServiceAccount
-  name: test-project-webhook
-  namespace: test-project-system`
-
-			result := templater.ApplyHelmSubstitutions(content, roleBindingResource)
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        args:
+        - --metrics-bind-address=localhost:8443
+        - --health-probe-bind-address=localhost:8081`
 
-			// Both subject namespaces should be templated
-			subjectNamespaceCount := strings.Count(result, "namespace: {{ .Release.Namespace }}")
-			Expect(subjectNamespaceCount).To(BeNumerically(">=", 2),
-				"both subject namespaces should be templated")
+			result := templater.templatePorts(content, deployment)
 
-			// RoleBinding metadata namespace should be preserved
-			Expect(result).To(ContainSubstring("namespace: infrastructure"))
+			Expect(result).To(ContainSubstring("--metrics-bind-address=localhost:{{ .Values.metrics.port }}"))
+			Expect(result).To(ContainSubstring("--health-probe-bind-address=localhost:{{ .Values.manager.healthProbe.port }}"))
 		})
 
-		It("should preserve resource names when namespace appears as substring", func() {
-			// Critical: namespace "user" must NOT break resource name "users"
-			// This validates field-aware replacement prevents substring corruption
-			roleResource := &unstructured.Unstructured{}
-			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			roleResource.SetKind("ClusterRole")
-			roleResource.SetName(testManagerRoleName)
-
-			// Scenario: manager namespace is "user", CRD resource is testRoleNamespaceUsers
-			customTemplater := &Templater{
-				detectedPrefix:   testProjectName,
-				chartName:        testProjectName,
-				managerNamespace: "user", // Short namespace that appears as substring
-				roleNamespaces:   nil,
-			}
+		It("should preserve the host when templating bind-address args in IPv6 form", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-manager-role
-rules:
-- apiGroups:
-  - identity.example.com
-  resources:
-  - users
-  - users/finalizers
-  - users/status
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete`
-
-			result := customTemplater.ApplyHelmSubstitutions(content, roleResource)
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        args:
+        - --metrics-bind-address=[::1]:8443
+        - --health-probe-bind-address=[::1]:8081`
 
-			// Critical: resource name "users" must NOT be replaced with "{{ .Release.Namespace }}s"
-			Expect(result).To(ContainSubstring("- users"),
-				"resource name 'users' should remain unchanged")
-			Expect(result).To(ContainSubstring("- users/finalizers"),
-				"resource name 'users/finalizers' should remain unchanged")
-			Expect(result).To(ContainSubstring("- users/status"),
-				"resource name 'users/status' should remain unchanged")
+			result := templater.templatePorts(content, deployment)
 
-			// Ensure we didn't create templated resource names
-			Expect(result).NotTo(ContainSubstring("- {{ .Release.Namespace }}s"),
-				"must NOT replace 'user' substring in resource names")
-			Expect(result).NotTo(MatchRegexp(`resources:\s*-\s*\{\{.*\}\}`),
-				"resource names must never be templated")
+			Expect(result).To(ContainSubstring("--metrics-bind-address=[::1]:{{ .Values.metrics.port }}"))
+			Expect(result).To(ContainSubstring("--health-probe-bind-address=[::1]:{{ .Values.manager.healthProbe.port }}"))
 		})
 
-		It("should handle edge case where namespace is substring of multiple fields", func() {
-			// Test more edge cases: namespace "app" appears in "applications", "apps", etc.
-			customTemplater := &Templater{
-				detectedPrefix:   testProjectName,
-				chartName:        testProjectName,
-				managerNamespace: "app",
-				roleNamespaces:   nil,
-			}
-
-			roleBindingResource := &unstructured.Unstructured{}
-			roleBindingResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			roleBindingResource.SetKind("RoleBinding")
-			roleBindingResource.SetName("manager-rolebinding")
+		It("should template port-related args in Deployment", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: RoleBinding
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: test-project-manager-rolebinding
-  namespace: app
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: test-project-manager-role
-subjects:
-- kind: ServiceAccount
   name: test-project-controller-manager
-  namespace: app`
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        args:
+        - --metrics-bind-address=:8443
+        - --health-probe-bind-address=:8081
+        - --leader-elect`
 
-			result := customTemplater.ApplyHelmSubstitutions(content, roleBindingResource)
+			result := templater.templatePorts(content, deployment)
 
-			// Namespace fields should be templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"),
-				"namespace fields should be templated")
+			Expect(result).To(ContainSubstring("--metrics-bind-address=:{{ .Values.metrics.port }}"))
+			Expect(result).NotTo(ContainSubstring("--metrics-bind-address=:8443"))
+			Expect(result).To(ContainSubstring("--health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}"))
+			Expect(result).To(ContainSubstring("--leader-elect"))
+		})
+
+		It("should template custom port values", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        args:
+        - --metrics-bind-address=:9090
+        - --health-probe-bind-address=:9091
+        - --webhook-port=9444
+        ports:
+        - containerPort: 9444
+          name: webhook-server
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 9091`
 
-			// Verify we have exactly 2 namespace template substitutions (metadata + subject)
-			namespaceTemplateCount := strings.Count(result, "namespace: {{ .Release.Namespace }}")
-			Expect(namespaceTemplateCount).To(Equal(2),
-				"should have exactly 2 namespace field replacements")
+			result := templater.templatePorts(content, deployment)
 
-			// Verify apiGroup field is NOT affected (contains "app" in "rbac.authorization.k8s.io")
-			Expect(result).To(ContainSubstring("apiGroup: rbac.authorization.k8s.io"),
-				"apiGroup should not be affected by namespace replacement")
+			Expect(result).To(ContainSubstring("--metrics-bind-address=:{{ .Values.metrics.port }}"))
+			Expect(result).To(ContainSubstring("--webhook-port={{ .Values.webhook.port }}"))
+			Expect(result).To(ContainSubstring("containerPort: {{ .Values.webhook.port }}"))
+			Expect(result).To(ContainSubstring("--health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}"))
+			Expect(result).To(ContainSubstring("port: {{ .Values.manager.healthProbe.port }}"))
+			Expect(result).NotTo(ContainSubstring(":9091"))
 		})
 
-		It("should handle ALL Kubernetes DNS patterns generically", func() {
-			// This test validates DNS replacement works for ANY K8s DNS pattern
-			configMapResource := &unstructured.Unstructured{}
-			configMapResource.SetAPIVersion("v1")
-			configMapResource.SetKind("ConfigMap")
-			configMapResource.SetName("dns-config")
+		It("should not template non-webhook/metrics resources", func() {
+			regularService := &unstructured.Unstructured{}
+			regularService.SetAPIVersion("v1")
+			regularService.SetKind("Service")
+			regularService.SetName("test-project-some-other-service")
 
 			content := `apiVersion: v1
-kind: ConfigMap
+kind: Service
 metadata:
-  name: dns-config
-  namespace: test-project-system
-data:
-  # Standard service DNS
-  service-short: api.test-project-system.svc
-  service-full: api.test-project-system.svc.cluster.local
-  service-port: api.test-project-system.svc:8080
-  service-path: https://api.test-project-system.svc.cluster.local:443/v1
-  
-  # Pod DNS
-  pod-dns: my-pod.test-project-system.pod.cluster.local
-  
-  # Endpoints DNS  
-  endpoints-dns: my-service.test-project-system.endpoints.cluster.local
-  
-  # Headless service (StatefulSet)
-  stateful-0: app-0.app-headless.test-project-system.svc.cluster.local
-  stateful-1: app-1.app-headless.test-project-system.svc.cluster.local
-  
-  # External namespace should be preserved
-  external-svc: monitoring.monitoring-system.svc.cluster.local`
+  name: test-project-some-other-service
+spec:
+  ports:
+  - port: 8080
+    targetPort: 8080`
 
-			result := templater.ApplyHelmSubstitutions(content, configMapResource)
+			result := templater.templatePorts(content, regularService)
 
-			// Verify ALL manager namespace DNS patterns are templated
-			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc"))
-			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc.cluster.local"))
-			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc:8080"))
-			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc.cluster.local:443"))
-			Expect(result).To(ContainSubstring("my-pod.{{ .Release.Namespace }}.pod.cluster.local"))
-			Expect(result).To(ContainSubstring("my-service.{{ .Release.Namespace }}.endpoints.cluster.local"))
-			Expect(result).To(ContainSubstring("app-0.app-headless.{{ .Release.Namespace }}.svc.cluster.local"))
-			Expect(result).To(ContainSubstring("app-1.app-headless.{{ .Release.Namespace }}.svc.cluster.local"))
+			// Should not template regular service ports
+			Expect(result).To(ContainSubstring("port: 8080"))
+			Expect(result).To(ContainSubstring("targetPort: 8080"))
+			Expect(result).NotTo(ContainSubstring("{{ .Values"))
+		})
 
-			// Verify NO hardcoded manager namespace remains
-			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
-			Expect(result).NotTo(ContainSubstring(".test-project-system.pod"))
-			Expect(result).NotTo(ContainSubstring(".test-project-system.endpoints"))
+		It("should template metrics NetworkPolicy ports", func() {
+			networkPolicy := &unstructured.Unstructured{}
+			networkPolicy.SetAPIVersion("networking.k8s.io/v1")
+			networkPolicy.SetKind("NetworkPolicy")
+			networkPolicy.SetName("test-project-allow-metrics-traffic")
 
-			// Verify external namespace is preserved
-			Expect(result).To(ContainSubstring("monitoring.monitoring-system.svc.cluster.local"))
-		})
+			content := `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: test-project-allow-metrics-traffic
+spec:
+  ingress:
+  - ports:
+    - port: 8443
+      protocol: TCP`
 
-		It("should NOT replace namespace-like strings in non-DNS contexts", func() {
-			// Edge case: ensure we don't break strings that happen to contain the namespace
-			configMapResource := &unstructured.Unstructured{}
-			configMapResource.SetAPIVersion("v1")
-			configMapResource.SetKind("ConfigMap")
-			configMapResource.SetName("edge-cases")
+			result := templater.templatePorts(content, networkPolicy)
 
-			// Using "app" as namespace to test substring issues
-			customTemplater := &Templater{
-				detectedPrefix:   "test",
-				chartName:        "test",
-				managerNamespace: "app",
-				roleNamespaces:   nil,
-			}
+			Expect(result).To(ContainSubstring("port: {{ .Values.metrics.port }}"))
+			Expect(result).NotTo(ContainSubstring("port: 8443"))
+		})
 
-			content := `apiVersion: v1
-kind: ConfigMap
+		It("should template webhook NetworkPolicy ports", func() {
+			networkPolicy := &unstructured.Unstructured{}
+			networkPolicy.SetAPIVersion("networking.k8s.io/v1")
+			networkPolicy.SetKind("NetworkPolicy")
+			networkPolicy.SetName("test-project-allow-webhook-traffic")
+
+			content := `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
 metadata:
-  name: edge-cases
-  namespace: app
-data:
-  # DNS patterns - should be templated
-  service-url: http://api.app.svc:8080
-  
-  # NOT DNS patterns - should be preserved
-  app-name: "my-application"
-  app-version: "v1.2.3"
-  mapping: "application-mapping"
-  labels: "app=frontend,app.kubernetes.io/name=myapp"
-  
-  # Tricky: "app" in various contexts
-  erapplication: "some-value"
-  wrapperapp: "another-value"`
+  name: test-project-allow-webhook-traffic
+spec:
+  ingress:
+  - ports:
+    - port: 443
+      protocol: TCP`
 
-			result := customTemplater.ApplyHelmSubstitutions(content, configMapResource)
+			result := templater.templatePorts(content, networkPolicy)
 
-			// DNS pattern should be templated
-			Expect(result).To(ContainSubstring("api.{{ .Release.Namespace }}.svc:8080"))
+			Expect(result).To(ContainSubstring("port: {{ .Values.webhook.port }}"))
+			Expect(result).NotTo(ContainSubstring("port: 443"))
+		})
 
-			// Non-DNS occurrences should be preserved
-			Expect(result).To(ContainSubstring(`app-name: "my-application"`))
-			Expect(result).To(ContainSubstring("app-version"))
-			Expect(result).To(ContainSubstring("mapping: \"application-mapping\""))
-			Expect(result).To(ContainSubstring("app=frontend"))
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name=myapp"))
-			Expect(result).To(ContainSubstring("erapplication"))
-			Expect(result).To(ContainSubstring("wrapperapp"))
+		It("should not template custom NetworkPolicy ports", func() {
+			networkPolicy := &unstructured.Unstructured{}
+			networkPolicy.SetAPIVersion("networking.k8s.io/v1")
+			networkPolicy.SetKind("NetworkPolicy")
+			networkPolicy.SetName("test-project-custom-policy")
 
-			// Namespace field should be templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
-		})
+			content := `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: test-project-custom-policy
+spec:
+  ingress:
+  - ports:
+    - port: 8080
+      protocol: TCP`
 
-		It("should handle ANY resource type with namespace references (generic test)", func() {
-			// This test validates that namespace replacement is GENERIC and works
-			// for any resource type, including custom resources in extras/ directory
+			result := templater.templatePorts(content, networkPolicy)
 
-			// Test with a custom ConfigMap (common in extras/)
-			configMapResource := &unstructured.Unstructured{}
-			configMapResource.SetAPIVersion("v1")
-			configMapResource.SetKind("ConfigMap")
-			configMapResource.SetName("custom-config")
+			Expect(result).To(ContainSubstring("port: 8080"))
+			Expect(result).NotTo(ContainSubstring("{{ .Values"))
+		})
+	})
 
-			content := `apiVersion: v1
-kind: ConfigMap
+	Context("cert-manager resource name templating", func() {
+		It("should template Certificate resource name with chart.fullname", func() {
+			cert := &unstructured.Unstructured{}
+			cert.SetAPIVersion("cert-manager.io/v1")
+			cert.SetKind("Certificate")
+			cert.SetName("test-project-serving-cert")
+
+			content := `apiVersion: cert-manager.io/v1
+kind: Certificate
 metadata:
-  name: custom-config
-  namespace: test-project-system
-data:
-  service-url: http://api-service.test-project-system.svc.cluster.local:8080
-  webhook-endpoint: https://webhook.test-project-system.svc:9443/validate
-  annotation-ref: "test-project-system/my-resource"`
+  name: test-project-serving-cert
+  namespace: test-project-system`
 
-			result := templater.ApplyHelmSubstitutions(content, configMapResource)
+			result := templater.ApplyHelmSubstitutions(content, cert)
 
-			// 1. Namespace field should be templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
-			Expect(result).NotTo(ContainSubstring("namespace: test-project-system"))
+			expectedCert := `name: {{ include "test-project.resourceName" (dict "suffix" "serving-cert" "context" $) }}`
+			Expect(result).To(ContainSubstring(expectedCert))
+			Expect(result).NotTo(ContainSubstring("name: test-project-serving-cert"))
+		})
 
-			// 2. DNS names in data values should be templated
-			Expect(result).To(ContainSubstring("http://api-service.{{ .Release.Namespace }}.svc.cluster.local:8080"))
-			Expect(result).NotTo(ContainSubstring(".test-project-system.svc.cluster.local"))
+		It("should point each Certificate's dnsNames at the Service it names", func() {
+			certificate := func(name, dnsNames string) string {
+				cert := &unstructured.Unstructured{}
+				cert.SetAPIVersion("cert-manager.io/v1")
+				cert.SetKind("Certificate")
+				cert.SetName(name)
+				return templater.ApplyHelmSubstitutions(`apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: `+name+`
+  namespace: test-project-system
+spec:
+  dnsNames:
+`+dnsNames+`  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer
+  secretName: `+strings.TrimPrefix(name, "test-project-")+`
+`, cert)
+			}
 
-			// 3. DNS names with ports should be templated
-			Expect(result).To(ContainSubstring("https://webhook.{{ .Release.Namespace }}.svc:9443"))
-			Expect(result).NotTo(ContainSubstring(".test-project-system.svc:9443"))
+			webhook := certificate("test-project-serving-cert",
+				"  - test-project-webhook-service.test-project-system.svc\n"+
+					"  - test-project-webhook-service.test-project-system.svc.cluster.local\n")
+			metrics := certificate("test-project-metrics-certs",
+				"  - test-project-controller-manager-metrics-service.test-project-system.svc\n")
+			api := certificate("test-project-api-cert",
+				"  - test-project-api-service.test-project-system.svc\n"+
+					"  - test-project-api-service\n"+
+					"  - api.example.com\n")
+
+			Expect(webhook).To(ContainSubstring(`  dnsNames:
+  - {{ include "test-project.resourceName" (dict "suffix" "webhook-service" "context" $) }}.{{ .Release.Namespace }}.svc
+  - {{ include "test-project.resourceName" (dict "suffix" "webhook-service" "context" $) }}.{{ .Release.Namespace }}.svc.cluster.local
+`))
+			Expect(metrics).To(ContainSubstring(`  dnsNames:
+  - {{ include "test-project.resourceName" (dict "suffix" "controller-manager-metrics-service" "context" $) }}.{{ .Release.Namespace }}.svc
+`))
+			Expect(api).To(ContainSubstring(`  dnsNames:
+  - {{ include "test-project.resourceName" (dict "suffix" "api-service" "context" $) }}.{{ .Release.Namespace }}.svc
+  - {{ include "test-project.resourceName" (dict "suffix" "api-service" "context" $) }}
+  - api.example.com
+`))
+			for _, result := range []string{webhook, metrics, api} {
+				Expect(result).NotTo(ContainSubstring("- test-project-"))
+				Expect(templater.Validate(result)).To(Succeed())
+			}
 
-			// 4. Annotation-style references should be templated
-			Expect(result).To(ContainSubstring("{{ .Release.Namespace }}/my-resource"))
-			Expect(result).NotTo(ContainSubstring("test-project-system/my-resource"))
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl":  templater.GenerateHelpers(),
+				"templates/api-cert.yaml": api,
+			}, map[string]any{"certManager": map[string]any{"enabled": true}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/api-cert.yaml"]).To(ContainSubstring(
+				"  - my-release-test-project-api-service.my-namespace.svc\n  - my-release-test-project-api-service\n"))
 		})
 
-		It("should handle Secret with namespace references", func() {
-			secretResource := &unstructured.Unstructured{}
-			secretResource.SetAPIVersion("v1")
-			secretResource.SetKind("Secret")
-			secretResource.SetName("app-secret")
+		It("should set Certificate ipAddresses and subject from certManager values", func() {
+			cert := &unstructured.Unstructured{}
+			cert.SetAPIVersion("cert-manager.io/v1")
+			cert.SetKind("Certificate")
+			cert.SetName("test-project-serving-cert")
 
-			content := `apiVersion: v1
-kind: Secret
+			result := templater.ApplyHelmSubstitutions(`apiVersion: cert-manager.io/v1
+kind: Certificate
 metadata:
-  name: app-secret
+  name: test-project-serving-cert
   namespace: test-project-system
-  annotations:
-    source: test-project-system/config
-stringData:
-  database-url: postgresql://db.test-project-system.svc:5432/mydb
-  redis-url: redis://cache.test-project-system.svc.cluster.local:6379`
+spec:
+  dnsNames:
+  - test-project-webhook-service.test-project-system.svc
+  secretName: webhook-server-cert
+`, cert)
+
+			Expect(result).To(ContainSubstring(`spec:
+  {{- with .Values.certManager.ipAddresses }}
+  ipAddresses:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .Values.certManager.subject }}
+  subject:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  dnsNames:
+`))
+			Expect(result).To(HavePrefix("{{- if .Values.certManager.enabled }}\n"))
+			Expect(templater.ApplyHelmSubstitutions(result, cert)).To(Equal(result))
+
+			render := func(certManager map[string]any) string {
+				certManager["enabled"] = true
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl":      templater.GenerateHelpers(),
+					"templates/serving-cert.yaml": result,
+				}, map[string]any{"certManager": certManager, "webhook": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered["templates/serving-cert.yaml"]
+			}
 
-			result := templater.ApplyHelmSubstitutions(content, secretResource)
+			Expect(render(map[string]any{})).To(ContainSubstring("spec:\n  dnsNames:\n"))
+			Expect(render(map[string]any{
+				"ipAddresses": []any{"10.0.0.10"},
+				"subject":     map[string]any{"organizations": []any{"acme"}},
+			})).To(ContainSubstring(`spec:
+  ipAddresses:
+    - 10.0.0.10
+  subject:
+    organizations:
+    - acme
+  dnsNames:
+`))
+		})
+
+		It("should keep Certificate ipAddresses and subject from the manifest as defaults", func() {
+			cert := &unstructured.Unstructured{}
+			cert.SetAPIVersion("cert-manager.io/v1")
+			cert.SetKind("Certificate")
+			cert.SetName("test-project-api-cert")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-api-cert
+  namespace: test-project-system
+spec:
+  ipAddresses:
+  - 127.0.0.1
+  secretName: api-cert
+  subject:
+    organizations:
+    - example
+`, cert)
+
+			Expect(result).To(ContainSubstring(`spec:
+  {{- with .Values.certManager.ipAddresses }}
+  ipAddresses:
+    {{- toYaml . | nindent 4 }}
+  {{- else }}
+  ipAddresses:
+  - 127.0.0.1
+  {{- end }}
+  secretName: api-cert
+  {{- with .Values.certManager.subject }}
+  subject:
+    {{- toYaml . | nindent 4 }}
+  {{- else }}
+  subject:
+    organizations:
+    - example
+  {{- end }}
+`))
+			Expect(templater.ApplyHelmSubstitutions(result, cert)).To(Equal(result))
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl":  templater.GenerateHelpers(),
+				"templates/api-cert.yaml": result,
+			}, map[string]any{"certManager": map[string]any{
+				"enabled": true, "subject": map[string]any{"organizations": []any{"acme"}},
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/api-cert.yaml"]).To(HaveSuffix(`spec:
+  ipAddresses:
+  - 127.0.0.1
+  secretName: api-cert
+  subject:
+    organizations:
+    - acme`))
+		})
 
-			// Namespace field templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+		It("should template Issuer resource name with chart.fullname", func() {
+			issuer := &unstructured.Unstructured{}
+			issuer.SetAPIVersion("cert-manager.io/v1")
+			issuer.SetKind("Issuer")
+			issuer.SetName("test-project-selfsigned-issuer")
 
-			// Annotation value templated
-			Expect(result).To(ContainSubstring("source: {{ .Release.Namespace }}/config"))
+			content := `apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: test-project-selfsigned-issuer
+  namespace: test-project-system
+spec:
+  selfSigned: {}`
 
-			// DNS names in data templated
-			Expect(result).To(ContainSubstring("postgresql://db.{{ .Release.Namespace }}.svc:5432"))
-			Expect(result).To(ContainSubstring("redis://cache.{{ .Release.Namespace }}.svc.cluster.local:6379"))
+			result := templater.ApplyHelmSubstitutions(content, issuer)
 
-			// No hardcoded namespace remains
-			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+			Expect(result).To(ContainSubstring(expectedIssuerName))
+			Expect(result).NotTo(ContainSubstring("name: test-project-selfsigned-issuer"))
 		})
 
-		It("should handle Ingress with namespace references", func() {
-			ingressResource := &unstructured.Unstructured{}
-			ingressResource.SetAPIVersion("networking.k8s.io/v1")
-			ingressResource.SetKind("Ingress")
-			ingressResource.SetName("app-ingress")
+		It("should render the Issuer spec from certManager.issuerSpec or keep the self-signed default", func() {
+			issuer := &unstructured.Unstructured{}
+			issuer.SetAPIVersion("cert-manager.io/v1")
+			issuer.SetKind("Issuer")
+			issuer.SetName("test-project-selfsigned-issuer")
 
-			content := `apiVersion: networking.k8s.io/v1
-kind: Ingress
+			content := `apiVersion: cert-manager.io/v1
+kind: Issuer
 metadata:
-  name: app-ingress
+  name: test-project-selfsigned-issuer
   namespace: test-project-system
-  annotations:
-    nginx.ingress.kubernetes.io/auth-url: http://auth.test-project-system.svc.cluster.local/verify
-    cert-manager.io/issuer: test-project-system/letsencrypt
 spec:
-  rules:
-  - host: example.com
-    http:
-      paths:
-      - path: /
-        pathType: Prefix
-        backend:
-          service:
-            name: app-service
-            port:
-              number: 80`
+  selfSigned: {}`
 
-			result := templater.ApplyHelmSubstitutions(content, ingressResource)
+			result := templater.ApplyHelmSubstitutions(content, issuer)
 
-			// Namespace field templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+			Expect(result).To(HavePrefix("{{- if .Values.certManager.enabled }}\n"))
+			Expect(result).To(ContainSubstring(`spec:
+  {{- with .Values.certManager.issuerSpec }}
+  {{- toYaml . | nindent 2 }}
+  {{- else }}
+  selfSigned: {}
+  {{- end }}
+`))
+
+			render := func(certManager map[string]any) string {
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl": templater.GenerateHelpers(),
+					"templates/issuer.yaml":  result,
+				}, map[string]any{"certManager": certManager})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered["templates/issuer.yaml"]
+			}
 
-			// Annotation DNS templated
-			Expect(result).To(ContainSubstring("http://auth.{{ .Release.Namespace }}.svc.cluster.local/verify"))
+			By("defaulting to the self-signed issuer")
+			Expect(render(map[string]any{"enabled": true})).To(HaveSuffix("spec:\n  selfSigned: {}"))
 
-			// Annotation reference templated
-			Expect(result).To(ContainSubstring("cert-manager.io/issuer: {{ .Release.Namespace }}/letsencrypt"))
+			By("rendering a CA issuer from values")
+			Expect(render(map[string]any{
+				"enabled":    true,
+				"issuerSpec": map[string]any{"ca": map[string]any{"secretName": "my-ca-key-pair"}},
+			})).To(HaveSuffix("spec:\n  ca:\n    secretName: my-ca-key-pair"))
 
-			// No hardcoded namespace
-			Expect(result).NotTo(ContainSubstring("test-project-system/"))
-			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+			By("rendering nothing while cert-manager is disabled")
+			Expect(strings.TrimSpace(render(map[string]any{"enabled": false}))).To(BeEmpty())
 		})
 
-		It("should handle PodMonitor with namespace references", func() {
-			podMonitorResource := &unstructured.Unstructured{}
-			podMonitorResource.SetAPIVersion("monitoring.coreos.com/v1")
-			podMonitorResource.SetKind("PodMonitor")
-			podMonitorResource.SetName("app-monitor")
+		It("should template issuer reference in certificates with chart.fullname", func() {
+			cert := &unstructured.Unstructured{}
+			cert.SetAPIVersion("cert-manager.io/v1")
+			cert.SetKind("Certificate")
+			cert.SetName("test-project-serving-cert")
 
-			content := `apiVersion: monitoring.coreos.com/v1
-kind: PodMonitor
+			content := `apiVersion: cert-manager.io/v1
+kind: Certificate
 metadata:
-  name: app-monitor
-  namespace: test-project-system
+  name: test-project-serving-cert
 spec:
-  selector:
-    matchLabels:
-      app: myapp
-  podMetricsEndpoints:
-  - port: metrics
-    scheme: https
-    tlsConfig:
-      serverName: metrics.test-project-system.svc
-      ca:
-        configMap:
-          name: prometheus-ca
-          namespace: test-project-system`
-
-			result := templater.ApplyHelmSubstitutions(content, podMonitorResource)
-
-			// Namespace field templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
-
-			// ServerName DNS templated
-			Expect(result).To(ContainSubstring("serverName: metrics.{{ .Release.Namespace }}.svc"))
+  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer`
 
-			// ConfigMap namespace reference templated
-			namespaceCount := strings.Count(result, "namespace: {{ .Release.Namespace }}")
-			Expect(namespaceCount).To(Equal(2), "both metadata and configMap namespace should be templated")
+			result := templater.ApplyHelmSubstitutions(content, cert)
 
-			// No hardcoded namespace in DNS
-			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+			Expect(result).To(ContainSubstring(expectedIssuerName))
+			Expect(result).NotTo(ContainSubstring("name: test-project-selfsigned-issuer"))
 		})
 
-		It("should handle custom CRD with multiple namespace contexts", func() {
-			customResource := &unstructured.Unstructured{}
-			customResource.SetAPIVersion("example.com/v1")
-			customResource.SetKind("Application")
-			customResource.SetName("my-app")
+		It("should template all resource types generically", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: example.com/v1
-kind: Application
+			content := `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: my-app
+  name: test-project-controller-manager
   namespace: test-project-system
-  annotations:
-    backup.velero.io/backup-volumes: test-project-system/pvc
 spec:
-  database:
-    host: postgres.test-project-system.svc.cluster.local
-    port: 5432
-  messaging:
-    brokerURL: amqp://rabbitmq.test-project-system.svc:5672
-  externalServices:
-    - name: external-api
-      url: https://api.external-namespace.svc/v1
-  references:
-    configMapRef: test-project-system/app-config
-    secretRef: test-project-system/app-secret`
+  template:
+    spec:
+      serviceAccountName: test-project-controller-manager`
 
-			result := templater.ApplyHelmSubstitutions(content, customResource)
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Namespace field templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+			// Deployment name uses test-project.resourceName
+			expectedName := `name: {{ include "test-project.resourceName" (dict "suffix" "controller-manager" "context" $) }}`
+			// ServiceAccount reference uses test-project.serviceAccountName
+			expectedSA := `serviceAccountName: {{ include "test-project.serviceAccountName" . }}`
+			Expect(result).To(ContainSubstring(expectedName))
+			Expect(result).To(ContainSubstring(expectedSA))
+			Expect(result).NotTo(ContainSubstring("name: test-project-controller-manager"))
+		})
 
-			// Annotation reference templated
-			Expect(result).To(ContainSubstring("{{ .Release.Namespace }}/pvc"))
+		It("should handle custom kustomize prefix", func() {
+			customPrefixTemplater := &Templater{
+				detectedPrefix:   "ln",            // Custom short prefix from kustomize
+				chartName:        testProjectName, // Chart/project name
+				managerNamespace: "ln-system",     // Manager namespace
+				roleNamespaces:   nil,
+			}
 
-			// DNS names templated
-			Expect(result).To(ContainSubstring("postgres.{{ .Release.Namespace }}.svc.cluster.local"))
-			Expect(result).To(ContainSubstring("rabbitmq.{{ .Release.Namespace }}.svc:5672"))
+			issuer := &unstructured.Unstructured{}
+			issuer.SetAPIVersion("cert-manager.io/v1")
+			issuer.SetKind("Issuer")
+			issuer.SetName("ln-selfsigned-issuer")
 
-			// External namespace preserved (not manager namespace)
-			Expect(result).To(ContainSubstring("https://api.external-namespace.svc/v1"))
+			content := `apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: ln-selfsigned-issuer
+  labels:
+    app.kubernetes.io/name: ln`
 
-			// ConfigMap/Secret refs templated
-			Expect(result).To(ContainSubstring("configMapRef: {{ .Release.Namespace }}/app-config"))
-			Expect(result).To(ContainSubstring("secretRef: {{ .Release.Namespace }}/app-secret"))
+			result := customPrefixTemplater.ApplyHelmSubstitutions(content, issuer)
 
-			// No manager namespace remains
-			Expect(result).NotTo(ContainSubstring("test-project-system/"))
-			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
+			// Resource name uses test-project.resourceName
+			Expect(result).To(ContainSubstring(expectedIssuerName))
+			Expect(result).NotTo(ContainSubstring("name: ln-selfsigned-issuer"))
+			// Label uses test-project.name
+			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
+			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: ln"))
 		})
 
-		It("should NOT replace namespace in non-manager context", func() {
-			// Critical: cross-namespace references must be preserved
-			customResource := &unstructured.Unstructured{}
-			customResource.SetAPIVersion("v1")
-			customResource.SetKind("ConfigMap")
-			customResource.SetName("federation-config")
+		It("should scope the leader-election Role name to the release", func() {
+			role := &unstructured.Unstructured{}
+			role.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			role.SetKind("Role")
+			role.SetName("test-project-leader-election-role")
 
-			content := `apiVersion: v1
-kind: ConfigMap
+			result := templater.ApplyHelmSubstitutions(`apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
 metadata:
-  name: federation-config
+  name: test-project-leader-election-role
   namespace: test-project-system
-data:
-  clusters: |
-    - name: cluster-a
-      apiserver: https://api.cluster-a-system.svc:6443
-    - name: cluster-b
-      apiserver: https://api.cluster-b-system.svc:6443
-  external-service: https://monitoring.monitoring-system.svc.cluster.local:9090
-  internal-service: https://internal.test-project-system.svc:8080`
-
-			result := templater.ApplyHelmSubstitutions(content, customResource)
-
-			// Manager namespace field templated
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
-			Expect(result).NotTo(ContainSubstring("namespace: test-project-system"))
-
-			// Manager namespace in DNS templated (appears once in internal-service)
-			Expect(result).To(ContainSubstring("internal.{{ .Release.Namespace }}.svc:8080"))
-			Expect(result).NotTo(ContainSubstring(".test-project-system.svc"))
-
-			// External namespaces preserved (these don't match manager namespace)
-			Expect(result).To(ContainSubstring("cluster-a-system.svc"))
-			Expect(result).To(ContainSubstring("cluster-b-system.svc"))
-			Expect(result).To(ContainSubstring("monitoring-system.svc"))
+rules:
+- apiGroups:
+  - coordination.k8s.io
+  resources:
+  - leases
+  verbs:
+  - get
+`, role)
+
+			Expect(result).To(ContainSubstring(`  name: {{ include "test-project.resourceName" ` +
+				`(dict "suffix" "leader-election-role" "context" $) }}`))
+			Expect(result).NotTo(ContainSubstring("name: test-project-leader-election-role"))
+
+			for _, release := range []string{"first", "second"} {
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl": templater.GenerateHelpers(),
+					"templates/role.yaml":    result,
+				}, map[string]any{"fullnameOverride": release})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rendered["templates/role.yaml"]).To(ContainSubstring("  name: " + release + "-leader-election-role\n"))
+			}
 		})
-	})
-
-	Context("templatePorts", func() {
-		It("should template webhook service ports", func() {
-			webhookService := &unstructured.Unstructured{}
-			webhookService.SetAPIVersion("v1")
-			webhookService.SetKind("Service")
-			webhookService.SetName("test-project-webhook-service")
 
-			content := `apiVersion: v1
-kind: Service
+		It("should template RoleBinding roleRef and subjects", func() {
+			rb := &unstructured.Unstructured{}
+			rb.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			rb.SetKind("RoleBinding")
+			rb.SetName("test-project-leader-election-rolebinding")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
 metadata:
-  name: test-project-webhook-service
-  namespace: test-project-system
-spec:
-  ports:
-  - port: 443
-    targetPort: 9443
-    protocol: TCP
-  selector:
-    control-plane: controller-manager`
+  name: test-project-leader-election-rolebinding
+roleRef:
+  name: test-project-leader-election-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager`
 
-			result := templater.templatePorts(content, webhookService)
+			result := templater.ApplyHelmSubstitutions(content, rb)
 
-			// Should template webhook port
-			Expect(result).To(ContainSubstring("targetPort: {{ .Values.webhook.port }}"))
-			Expect(result).NotTo(ContainSubstring("targetPort: 9443"))
+			// RoleBinding and Role use test-project.resourceName
+			expectedRB := `name: {{ include "test-project.resourceName" ` +
+				`(dict "suffix" "leader-election-rolebinding" "context" $) }}`
+			expectedRole := `name: {{ include "test-project.resourceName" ` +
+				`(dict "suffix" "leader-election-role" "context" $) }}`
+			// ServiceAccount subject uses test-project.serviceAccountName
+			expectedSA := `name: {{ include "test-project.serviceAccountName" . }}`
+			Expect(result).To(ContainSubstring(expectedRB))
+			Expect(result).To(ContainSubstring(expectedRole))
+			Expect(result).To(ContainSubstring(expectedSA))
 		})
 
-		It("should template metrics service ports", func() {
-			metricsService := &unstructured.Unstructured{}
-			metricsService.SetAPIVersion("v1")
-			metricsService.SetKind("Service")
-			metricsService.SetName("test-project-controller-manager-metrics-service")
+		It("should template ClusterRoleBinding subject namespaces and roleRef", func() {
+			crb := &unstructured.Unstructured{}
+			crb.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			crb.SetKind("ClusterRoleBinding")
+			crb.SetName("test-project-metrics-auth-rolebinding")
 
-			content := `apiVersion: v1
-kind: Service
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
 metadata:
-  name: test-project-controller-manager-metrics-service
+  name: test-project-metrics-auth-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: test-project-metrics-auth-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager
   namespace: test-project-system
-spec:
-  ports:
-  - port: 8443
-    targetPort: 8443
-    protocol: TCP
-    name: https
-  selector:
-    control-plane: controller-manager`
+- kind: ServiceAccount
+  name: prometheus
+  namespace: monitoring`
 
-			result := templater.templatePorts(content, metricsService)
+			result := templater.ApplyHelmSubstitutions(content, crb)
 
-			// Should template metrics port
-			Expect(result).To(ContainSubstring("port: {{ .Values.metrics.port }}"))
-			Expect(result).To(ContainSubstring("targetPort: {{ .Values.metrics.port }}"))
-			Expect(result).NotTo(ContainSubstring("port: 8443"))
-			Expect(result).NotTo(ContainSubstring("targetPort: 8443"))
+			Expect(result).To(ContainSubstring(`roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: {{ include "test-project.resourceName" (dict "suffix" "metrics-auth-role" "context" $) }}`))
+			Expect(result).To(ContainSubstring(`- kind: ServiceAccount
+  name: {{ include "test-project.serviceAccountName" . }}
+  namespace: {{ .Release.Namespace }}`))
+			Expect(result).To(ContainSubstring(`- kind: ServiceAccount
+  name: prometheus
+  namespace: monitoring`))
+			Expect(result).NotTo(ContainSubstring("test-project-system"))
 		})
+	})
 
-		It("should template webhook container ports in Deployment", func() {
+	Context("custom container name support", func() {
+		It("should template deployment fields when container name is not 'manager'", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Deployment with custom container name "controller-test" using default-container annotation
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
   name: test-project-controller-manager
+  namespace: test-project-system
 spec:
   template:
+    metadata:
+      annotations:
+        kubectl.kubernetes.io/default-container: controller-test
     spec:
       containers:
-      - name: manager
-        ports:
-        - containerPort: 9443
-          name: webhook-server
-          protocol: TCP`
+      - name: controller-test
+        image: controller:latest
+        imagePullPolicy: Always
+        env:
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        args:
+        - --leader-elect
+        - --health-probe-bind-address=:8081
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+          requests:
+            cpu: 10m
+            memory: 64Mi
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+        volumeMounts: []
+      serviceAccountName: controller-manager
+      volumes: []`
 
-			result := templater.templatePorts(content, deployment)
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Should template webhook containerPort
-			Expect(result).To(ContainSubstring("containerPort: {{ .Values.webhook.port }}"))
-			Expect(result).NotTo(ContainSubstring("containerPort: 9443"))
+			// Should template image reference (not hardcoded)
+			Expect(result).To(ContainSubstring(expectedManagerImageLine))
+			Expect(result).NotTo(ContainSubstring("image: controller:latest"))
+
+			// Should template imagePullPolicy
+			Expect(result).To(ContainSubstring(
+				`imagePullPolicy: {{ .Values.manager.image.pullPolicy | default "IfNotPresent" }}`))
+			Expect(result).NotTo(ContainSubstring("imagePullPolicy: Always"))
+
+			// Should template resources, defaulting to the scaffolded ones
+			Expect(result).To(ContainSubstring(`{{- with .Values.manager.resources | default (mergeOverwrite (fromYaml ` +
+				`"limits:\n  cpu: 500m\n  memory: 128Mi\nrequests:\n  cpu: 10m\n  memory: 64Mi") ` +
+				`(include "test-project.managerResourcesProfile" $ | fromYaml)) }}`))
+			Expect(result).To(ContainSubstring("{{- toYaml . | nindent 10 }}"))
+
+			// Env list + envOverrides (--set). Secret refs go in env list.
+			Expect(result).To(ContainSubstring(".Values.manager.env"))
+			Expect(result).To(ContainSubstring("toYaml .Values.manager.env"))
+			Expect(result).To(ContainSubstring("envOverrides"))
+
+			// Should template args
+			Expect(result).To(ContainSubstring("{{- range .Values.manager.args }}"))
+
+			// Container name should remain "controller-test"
+			Expect(result).To(ContainSubstring("name: controller-test"))
 		})
 
-		It("should template health probe ports in Deployment", func() {
+		It("should append extraVolumes and extraVolumeMounts when lists are present", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
@@ -2429,114 +8002,99 @@ metadata:
 spec:
   template:
     spec:
+      volumes:
+      - name: webhook-certs
+        secret:
+          secretName: webhook-server-cert
       containers:
       - name: manager
-        livenessProbe:
-          httpGet:
-            path: /healthz
-            port: 8081
-        readinessProbe:
-          httpGet:
-            path: /readyz
-            port: 8081`
+        image: controller:latest
+        volumeMounts:
+        - name: webhook-certs
+          mountPath: /tmp/k8s-webhook-server/serving-certs
+          readOnly: true
+`
 
-			result := templater.templatePorts(content, deployment)
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			Expect(result).To(ContainSubstring("port: {{ .Values.manager.healthProbe.port }}"))
-			Expect(result).NotTo(ContainSubstring("port: 8081"))
+			Expect(result).To(ContainSubstring(".Values.manager.extraVolumes"))
+			Expect(result).To(ContainSubstring(".Values.manager.extraVolumeMounts"))
 		})
 
-		// Regression test for #5865: the health probe port must be templated in all
-		// four places it appears in the manager Deployment (bind-address arg, the
-		// "health" containerPort, and the liveness and readiness httpGet ports), so it
-		// can be configured from values.yaml like the metrics and webhook ports.
-		It("should template every health probe port reference in the manager Deployment", func() {
+		It("should render extraVolumes and extraVolumeMounts after the scaffolded ones", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: apps/v1
+			for _, content := range []string{`apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
 spec:
   template:
     spec:
       containers:
-      - name: manager
-        args:
-        - --health-probe-bind-address=:8081
-        livenessProbe:
-          httpGet:
-            path: /healthz
-            port: 8081
-        ports:
-        - containerPort: 8081
-          name: health
-          protocol: TCP
-        readinessProbe:
-          httpGet:
-            path: /readyz
-            port: 8081`
-
-			result := templater.templatePorts(content, deployment)
-
-			Expect(result).To(ContainSubstring("--health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}"))
-			Expect(result).To(ContainSubstring("containerPort: {{ .Values.manager.healthProbe.port }}"))
-			Expect(result).To(ContainSubstring(`            path: /healthz
-            port: {{ .Values.manager.healthProbe.port }}`))
-			Expect(result).To(ContainSubstring(`            path: /readyz
-            port: {{ .Values.manager.healthProbe.port }}`))
-			Expect(result).NotTo(ContainSubstring("8081"))
-		})
-
-		It("should leave probes using the named health port untouched", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
-
-			content := `apiVersion: apps/v1
+      - image: controller:latest
+        name: manager
+        volumeMounts:
+        - mountPath: /etc/config
+          name: config
+      volumes:
+      - configMap:
+          name: config
+        name: config`, `apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
 spec:
   template:
     spec:
       containers:
-      - name: manager
-        args:
-        - --health-probe-bind-address=:8081
-        ports:
-        - containerPort: 8081
-          name: health
-          protocol: TCP
-        livenessProbe:
-          httpGet:
-            path: /healthz
-            port: health
-        readinessProbe:
-          httpGet:
-            path: /readyz
-            port: health`
-
-			result := templater.templatePorts(content, deployment)
-
-			Expect(result).To(ContainSubstring("--health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}"))
-			Expect(result).To(ContainSubstring("containerPort: {{ .Values.manager.healthProbe.port }}"))
-			Expect(result).To(ContainSubstring(`            path: /healthz
-            port: health`))
-			Expect(result).To(ContainSubstring(`            path: /readyz
-            port: health`))
+        - image: controller:latest
+          name: manager
+          volumeMounts:
+            - mountPath: /etc/config
+              name: config
+      volumes:
+        - configMap:
+            name: config
+          name: config`} {
+				rendered, err := renderTemplate(templater.ApplyHelmSubstitutions(content, deployment), map[string]any{
+					"manager": map[string]any{
+						"image": map[string]any{"repository": "controller"},
+						"extraVolumes": []any{map[string]any{
+							"name":   "ca-bundle",
+							"secret": map[string]any{"secretName": "ca-bundle"},
+						}},
+						"extraVolumeMounts": []any{map[string]any{
+							"name":      "ca-bundle",
+							"mountPath": "/etc/ssl/certs/ca.crt",
+							"subPath":   "ca.crt",
+						}},
+					},
+					"rbac": map[string]any{},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				var manifest map[string]any
+				Expect(yaml.Unmarshal([]byte(rendered), &manifest)).To(Succeed(), rendered)
+				podSpec := manifest["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+				Expect(podSpec["volumes"]).To(Equal([]any{
+					map[string]any{"configMap": map[string]any{"name": "config"}, "name": "config"},
+					map[string]any{"name": "ca-bundle", "secret": map[string]any{"secretName": "ca-bundle"}},
+				}))
+				container := podSpec["containers"].([]any)[0].(map[string]any)
+				Expect(container["volumeMounts"]).To(Equal([]any{
+					map[string]any{"mountPath": "/etc/config", "name": "config"},
+					map[string]any{"mountPath": "/etc/ssl/certs/ca.crt", "name": "ca-bundle", "subPath": "ca.crt"},
+				}))
+			}
 		})
 
-		It("should preserve the host when templating bind-address args in HOST:PORT form", func() {
+		It("should inject extraVolumes/extraVolumeMounts when Kustomize has volumeMounts: [] and volumes: []", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Default Kustomize output: single-line empty lists (no webhook/metrics patches)
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -2546,22 +8104,50 @@ spec:
     spec:
       containers:
       - name: manager
-        args:
-        - --metrics-bind-address=localhost:8443
-        - --health-probe-bind-address=localhost:8081`
-
-			result := templater.templatePorts(content, deployment)
+        image: controller:latest
+        volumeMounts: []
+      volumes: []`
 
-			Expect(result).To(ContainSubstring("--metrics-bind-address=localhost:{{ .Values.metrics.port }}"))
-			Expect(result).To(ContainSubstring("--health-probe-bind-address=localhost:{{ .Values.manager.healthProbe.port }}"))
-		})
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-		It("should preserve the host when templating bind-address args in IPv6 form", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+			Expect(result).To(ContainSubstring(".Values.manager.extraVolumes"))
+			Expect(result).To(ContainSubstring(".Values.manager.extraVolumeMounts"))
+		})
 
+		It("should append only extraVolumes from values and keep webhook/metrics conditional", func() {
+			deployment := &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name": "test-project-controller-manager", //nolint:goconst
+					},
+					k8sSpecField: map[string]any{
+						"template": map[string]any{
+							k8sSpecField: map[string]any{
+								"volumes": []any{
+									map[string]any{"name": "webhook-certs", "secret": map[string]any{"secretName": "webhook-server-cert"}},
+									map[string]any{"name": "metrics-certs", "secret": map[string]any{"secretName": "metrics-server-cert"}},
+								},
+								"containers": []any{
+									map[string]any{
+										"name":  "manager",
+										"image": "controller:latest",
+										"volumeMounts": []any{
+											map[string]any{
+												"name": "webhook-certs", "mountPath": "/tmp/k8s-webhook-server/serving-certs", "readOnly": true,
+											},
+											map[string]any{
+												"name": "metrics-certs", "mountPath": "/tmp/k8s-metrics-server/metrics-certs", "readOnly": true,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -2569,24 +8155,38 @@ metadata:
 spec:
   template:
     spec:
+      volumes:
+      - name: webhook-certs
+        secret:
+          secretName: webhook-server-cert
+      - name: metrics-certs
+        secret:
+          secretName: metrics-server-cert
       containers:
       - name: manager
-        args:
-        - --metrics-bind-address=[::1]:8443
-        - --health-probe-bind-address=[::1]:8081`
-
-			result := templater.templatePorts(content, deployment)
-
-			Expect(result).To(ContainSubstring("--metrics-bind-address=[::1]:{{ .Values.metrics.port }}"))
-			Expect(result).To(ContainSubstring("--health-probe-bind-address=[::1]:{{ .Values.manager.healthProbe.port }}"))
+        image: controller:latest
+        volumeMounts:
+        - mountPath: /tmp/k8s-webhook-server/serving-certs
+          name: webhook-certs
+          readOnly: true
+        - mountPath: /tmp/k8s-metrics-server/metrics-certs
+          name: metrics-certs
+          readOnly: true
+`
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+			Expect(result).To(ContainSubstring(".Values.certManager.enabled"))
+			Expect(result).To(ContainSubstring(".Values.manager.extraVolumes"))
+			Expect(result).To(ContainSubstring("webhook-certs"))
+			Expect(result).To(ContainSubstring("metrics-certs"))
 		})
 
-		It("should template port-related args in Deployment", func() {
+		It("should fall back to 'manager' when default-container annotation is missing", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Deployment without default-container annotation (backward compatibility test)
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -2596,208 +8196,53 @@ spec:
     spec:
       containers:
       - name: manager
-        args:
-        - --metrics-bind-address=:8443
-        - --health-probe-bind-address=:8081
-        - --leader-elect`
+        image: controller:latest
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi`
 
-			result := templater.templatePorts(content, deployment)
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			Expect(result).To(ContainSubstring("--metrics-bind-address=:{{ .Values.metrics.port }}"))
-			Expect(result).NotTo(ContainSubstring("--metrics-bind-address=:8443"))
-			Expect(result).To(ContainSubstring("--health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}"))
-			Expect(result).To(ContainSubstring("--leader-elect"))
+			// Should still template fields for "manager" container
+			Expect(result).To(ContainSubstring(expectedManagerImageLine))
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.resources | default "))
 		})
 
-		It("should template custom port values", func() {
+		It("should not template when container name doesn't match annotation", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Deployment with mismatched annotation and container name
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
   name: test-project-controller-manager
 spec:
-  template:
-    spec:
-      containers:
-      - name: manager
-        args:
-        - --metrics-bind-address=:9090
-        - --health-probe-bind-address=:9091
-        - --webhook-port=9444
-        ports:
-        - containerPort: 9444
-          name: webhook-server
-        livenessProbe:
-          httpGet:
-            path: /healthz
-            port: 9091`
-
-			result := templater.templatePorts(content, deployment)
-
-			Expect(result).To(ContainSubstring("--metrics-bind-address=:{{ .Values.metrics.port }}"))
-			Expect(result).To(ContainSubstring("--webhook-port={{ .Values.webhook.port }}"))
-			Expect(result).To(ContainSubstring("containerPort: {{ .Values.webhook.port }}"))
-			Expect(result).To(ContainSubstring("--health-probe-bind-address=:{{ .Values.manager.healthProbe.port }}"))
-			Expect(result).To(ContainSubstring("port: {{ .Values.manager.healthProbe.port }}"))
-			Expect(result).NotTo(ContainSubstring(":9091"))
-		})
-
-		It("should not template non-webhook/metrics resources", func() {
-			regularService := &unstructured.Unstructured{}
-			regularService.SetAPIVersion("v1")
-			regularService.SetKind("Service")
-			regularService.SetName("test-project-some-other-service")
-
-			content := `apiVersion: v1
-kind: Service
-metadata:
-  name: test-project-some-other-service
-spec:
-  ports:
-  - port: 8080
-    targetPort: 8080`
-
-			result := templater.templatePorts(content, regularService)
-
-			// Should not template regular service ports
-			Expect(result).To(ContainSubstring("port: 8080"))
-			Expect(result).To(ContainSubstring("targetPort: 8080"))
-			Expect(result).NotTo(ContainSubstring("{{ .Values"))
-		})
-
-		It("should template metrics NetworkPolicy ports", func() {
-			networkPolicy := &unstructured.Unstructured{}
-			networkPolicy.SetAPIVersion("networking.k8s.io/v1")
-			networkPolicy.SetKind("NetworkPolicy")
-			networkPolicy.SetName("test-project-allow-metrics-traffic")
-
-			content := `apiVersion: networking.k8s.io/v1
-kind: NetworkPolicy
-metadata:
-  name: test-project-allow-metrics-traffic
-spec:
-  ingress:
-  - ports:
-    - port: 8443
-      protocol: TCP`
-
-			result := templater.templatePorts(content, networkPolicy)
-
-			Expect(result).To(ContainSubstring("port: {{ .Values.metrics.port }}"))
-			Expect(result).NotTo(ContainSubstring("port: 8443"))
-		})
-
-		It("should template webhook NetworkPolicy ports", func() {
-			networkPolicy := &unstructured.Unstructured{}
-			networkPolicy.SetAPIVersion("networking.k8s.io/v1")
-			networkPolicy.SetKind("NetworkPolicy")
-			networkPolicy.SetName("test-project-allow-webhook-traffic")
-
-			content := `apiVersion: networking.k8s.io/v1
-kind: NetworkPolicy
-metadata:
-  name: test-project-allow-webhook-traffic
-spec:
-  ingress:
-  - ports:
-    - port: 443
-      protocol: TCP`
-
-			result := templater.templatePorts(content, networkPolicy)
-
-			Expect(result).To(ContainSubstring("port: {{ .Values.webhook.port }}"))
-			Expect(result).NotTo(ContainSubstring("port: 443"))
-		})
-
-		It("should not template custom NetworkPolicy ports", func() {
-			networkPolicy := &unstructured.Unstructured{}
-			networkPolicy.SetAPIVersion("networking.k8s.io/v1")
-			networkPolicy.SetKind("NetworkPolicy")
-			networkPolicy.SetName("test-project-custom-policy")
-
-			content := `apiVersion: networking.k8s.io/v1
-kind: NetworkPolicy
-metadata:
-  name: test-project-custom-policy
-spec:
-  ingress:
-  - ports:
-    - port: 8080
-      protocol: TCP`
-
-			result := templater.templatePorts(content, networkPolicy)
-
-			Expect(result).To(ContainSubstring("port: 8080"))
-			Expect(result).NotTo(ContainSubstring("{{ .Values"))
-		})
-	})
-
-	Context("cert-manager resource name templating", func() {
-		It("should template Certificate resource name with chart.fullname", func() {
-			cert := &unstructured.Unstructured{}
-			cert.SetAPIVersion("cert-manager.io/v1")
-			cert.SetKind("Certificate")
-			cert.SetName("test-project-serving-cert")
-
-			content := `apiVersion: cert-manager.io/v1
-kind: Certificate
-metadata:
-  name: test-project-serving-cert
-  namespace: test-project-system`
-
-			result := templater.ApplyHelmSubstitutions(content, cert)
-
-			expectedCert := `name: {{ include "test-project.resourceName" (dict "suffix" "serving-cert" "context" $) }}`
-			Expect(result).To(ContainSubstring(expectedCert))
-			Expect(result).NotTo(ContainSubstring("name: test-project-serving-cert"))
-		})
-
-		It("should template Issuer resource name with chart.fullname", func() {
-			issuer := &unstructured.Unstructured{}
-			issuer.SetAPIVersion("cert-manager.io/v1")
-			issuer.SetKind("Issuer")
-			issuer.SetName("test-project-selfsigned-issuer")
-
-			content := `apiVersion: cert-manager.io/v1
-kind: Issuer
-metadata:
-  name: test-project-selfsigned-issuer
-  namespace: test-project-system
-spec:
-  selfSigned: {}`
-
-			result := templater.ApplyHelmSubstitutions(content, issuer)
-
-			Expect(result).To(ContainSubstring(expectedIssuerName))
-			Expect(result).NotTo(ContainSubstring("name: test-project-selfsigned-issuer"))
-		})
-
-		It("should template issuer reference in certificates with chart.fullname", func() {
-			cert := &unstructured.Unstructured{}
-			cert.SetAPIVersion("cert-manager.io/v1")
-			cert.SetKind("Certificate")
-			cert.SetName("test-project-serving-cert")
-
-			content := `apiVersion: cert-manager.io/v1
-kind: Certificate
-metadata:
-  name: test-project-serving-cert
-spec:
-  issuerRef:
-    kind: Issuer
-    name: test-project-selfsigned-issuer`
+  template:
+    metadata:
+      annotations:
+        kubectl.kubernetes.io/default-container: main-container
+    spec:
+      containers:
+      - name: sidecar
+        image: sidecar:latest
+        resources:
+          limits:
+            cpu: 100m`
 
-			result := templater.ApplyHelmSubstitutions(content, cert)
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			Expect(result).To(ContainSubstring(expectedIssuerName))
-			Expect(result).NotTo(ContainSubstring("name: test-project-selfsigned-issuer"))
+			// Should NOT template sidecar container (doesn't match annotation)
+			Expect(result).To(ContainSubstring("image: sidecar:latest"))
+			Expect(result).NotTo(ContainSubstring("{{ .Values.manager.image.repository }}"))
 		})
+	})
 
-		It("should template all resource types generically", func() {
+	Context("resources", func() {
+		It("should replace the scaffolded resources with manager.resources", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
@@ -2805,165 +8250,305 @@ spec:
 
 			content := `apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
-  namespace: test-project-system
 spec:
   template:
     spec:
-      serviceAccountName: test-project-controller-manager`
+      containers:
+      - image: controller:latest
+        name: manager
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+          requests:
+            cpu: 10m
+            memory: 64Mi`
+			templated := templater.ApplyHelmSubstitutions(content, deployment)
+
+			render := func(manager map[string]any) string {
+				GinkgoHelper()
+				manager["image"] = map[string]any{"repository": "controller"}
+				rendered, err := renderTemplate(templated, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
 
-			result := templater.ApplyHelmSubstitutions(content, deployment)
+			By("keeping the scaffolded resources when manager.resources is unset")
+			Expect(render(map[string]any{})).To(ContainSubstring(`
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+          requests:
+            cpu: 10m
+            memory: 64Mi
+`))
 
-			// Deployment name uses test-project.resourceName
-			expectedName := `name: {{ include "test-project.resourceName" (dict "suffix" "controller-manager" "context" $) }}`
-			// ServiceAccount reference uses test-project.serviceAccountName
-			expectedSA := `serviceAccountName: {{ include "test-project.serviceAccountName" . }}`
-			Expect(result).To(ContainSubstring(expectedName))
-			Expect(result).To(ContainSubstring(expectedSA))
-			Expect(result).NotTo(ContainSubstring("name: test-project-controller-manager"))
+			By("rendering a user map without limits without limits")
+			withoutLimits := render(map[string]any{"resources": map[string]any{
+				"requests": map[string]any{"cpu": "50m", "memory": "96Mi"},
+			}})
+			Expect(withoutLimits).To(ContainSubstring(`
+        resources:
+          requests:
+            cpu: 50m
+            memory: 96Mi
+`))
+			Expect(withoutLimits).NotTo(ContainSubstring("limits:"))
+			Expect(withoutLimits).NotTo(ContainSubstring("cpu: 500m"))
 		})
 
-		It("should handle custom kustomize prefix", func() {
-			customPrefixTemplater := &Templater{
-				detectedPrefix:   "ln",            // Custom short prefix from kustomize
-				chartName:        testProjectName, // Chart/project name
-				managerNamespace: "ln-system",     // Manager namespace
-				roleNamespaces:   nil,
+		It("should merge the manager.resourcesProfile resources over the scaffolded resources", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+          requests:
+            cpu: 10m
+            memory: 64Mi`
+			templated := templater.ApplyHelmSubstitutions(content, deployment)
+			Expect(templated).To(ContainSubstring(`(include "test-project.managerResourcesProfile" $ | fromYaml)`))
+			Expect(templater.ApplyHelmSubstitutions(templated, deployment)).To(Equal(templated))
+
+			// resources renders the manager container resources for the manager values.
+			resources := func(manager map[string]any) (map[string]any, error) {
+				GinkgoHelper()
+				manager["image"] = map[string]any{"repository": "controller"}
+				rendered, err := renderTemplate(templated, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				if err != nil {
+					return nil, err
+				}
+				var object map[string]any
+				Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+				containers, _, err := unstructured.NestedSlice(object, "spec", "template", "spec", "containers")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(containers).To(HaveLen(1))
+				found, _, err := unstructured.NestedMap(containers[0].(map[string]any), "resources")
+				Expect(err).NotTo(HaveOccurred())
+				return found, nil
+			}
+			resourceMap := func(limitsCPU, limitsMemory, requestsCPU, requestsMemory string) map[string]any {
+				return map[string]any{
+					"limits":   map[string]any{"cpu": limitsCPU, "memory": limitsMemory},
+					"requests": map[string]any{"cpu": requestsCPU, "memory": requestsMemory},
+				}
 			}
 
-			issuer := &unstructured.Unstructured{}
-			issuer.SetAPIVersion("cert-manager.io/v1")
-			issuer.SetKind("Issuer")
-			issuer.SetName("ln-selfsigned-issuer")
+			for profile, expected := range map[string]map[string]any{
+				"small":  resourceMap("500m", "128Mi", "10m", "64Mi"),
+				"medium": resourceMap("1", "512Mi", "100m", "256Mi"),
+				"large":  resourceMap("2", "2Gi", "500m", "1Gi"),
+			} {
+				By("rendering the " + profile + " profile")
+				Expect(resources(map[string]any{"resourcesProfile": profile})).To(Equal(expected))
+			}
 
-			content := `apiVersion: cert-manager.io/v1
-kind: Issuer
-metadata:
-  name: ln-selfsigned-issuer
-  labels:
-    app.kubernetes.io/name: ln`
+			By("replacing the profile with manager.resources")
+			Expect(resources(map[string]any{
+				"resourcesProfile": "large",
+				"resources":        map[string]any{"limits": map[string]any{"memory": "4Gi"}},
+			})).To(Equal(map[string]any{"limits": map[string]any{"memory": "4Gi"}}))
 
-			result := customPrefixTemplater.ApplyHelmSubstitutions(content, issuer)
+			By("keeping the scaffolded resources without a profile")
+			Expect(resources(map[string]any{})).To(Equal(resourceMap("500m", "128Mi", "10m", "64Mi")))
 
-			// Resource name uses test-project.resourceName
-			Expect(result).To(ContainSubstring(expectedIssuerName))
-			Expect(result).NotTo(ContainSubstring("name: ln-selfsigned-issuer"))
-			// Label uses test-project.name
-			Expect(result).To(ContainSubstring("app.kubernetes.io/name: {{ include \"test-project.name\" . }}"))
-			Expect(result).NotTo(ContainSubstring("app.kubernetes.io/name: ln"))
+			By("failing on an unknown profile")
+			_, err := resources(map[string]any{"resourcesProfile": "huge"})
+			Expect(err).To(MatchError(ContainSubstring(`manager.resourcesProfile "huge" is not one of small, medium or large`)))
 		})
+	})
 
-		It("should template RoleBinding roleRef and subjects", func() {
-			rb := &unstructured.Unstructured{}
-			rb.SetAPIVersion("rbac.authorization.k8s.io/v1")
-			rb.SetKind("RoleBinding")
-			rb.SetName("test-project-leader-election-rolebinding")
+	Context("security contexts", func() {
+		It("should template the pod and container securityContext whatever the field order", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			content := `apiVersion: rbac.authorization.k8s.io/v1
-kind: RoleBinding
-metadata:
-  name: test-project-leader-election-rolebinding
-roleRef:
-  name: test-project-leader-election-role
-subjects:
-- kind: ServiceAccount
-  name: test-project-controller-manager`
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      serviceAccountName: test-project-controller-manager
+      securityContext:
+        runAsNonRoot: true
+      containers:
+      - name: manager
+        securityContext:
+          allowPrivilegeEscalation: false
+        image: controller:latest
+      - name: sidecar
+        image: sidecar:latest
+        securityContext:
+          privileged: true
+      terminationGracePeriodSeconds: 10`
 
-			result := templater.ApplyHelmSubstitutions(content, rb)
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// RoleBinding and Role use test-project.resourceName
-			expectedRB := `name: {{ include "test-project.resourceName" ` +
-				`(dict "suffix" "leader-election-rolebinding" "context" $) }}`
-			expectedRole := `name: {{ include "test-project.resourceName" ` +
-				`(dict "suffix" "leader-election-role" "context" $) }}`
-			// ServiceAccount subject uses test-project.serviceAccountName
-			expectedSA := `name: {{ include "test-project.serviceAccountName" . }}`
-			Expect(result).To(ContainSubstring(expectedRB))
-			Expect(result).To(ContainSubstring(expectedRole))
-			Expect(result).To(ContainSubstring(expectedSA))
+			Expect(result).To(ContainSubstring(`
+      securityContext:
+        {{- if .Values.manager.podSecurityContext }}
+        {{- toYaml .Values.manager.podSecurityContext | nindent 8 }}`))
+			Expect(result).To(ContainSubstring(`
+      - name: manager
+        securityContext:
+          {{- if .Values.manager.securityContext }}
+          {{- toYaml .Values.manager.securityContext | nindent 10 }}`))
+			Expect(result).To(ContainSubstring(`
+        securityContext:
+          privileged: true
+`))
+			Expect(strings.Count(result, ".Values.manager.podSecurityContext }}")).To(Equal(1))
+			Expect(strings.Count(result, ".Values.manager.securityContext }}")).To(Equal(1))
 		})
-	})
 
-	Context("custom container name support", func() {
-		It("should template deployment fields when container name is not 'manager'", func() {
+		It("should keep the scaffolded pod securityContext when manager.podSecurityContext is unset", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Deployment with custom container name "controller-test" using default-container annotation
 			content := `apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
-  namespace: test-project-system
 spec:
   template:
-    metadata:
-      annotations:
-        kubectl.kubernetes.io/default-container: controller-test
     spec:
       containers:
-      - name: controller-test
-        image: controller:latest
-        imagePullPolicy: Always
-        env:
-        - name: POD_NAMESPACE
-          valueFrom:
-            fieldRef:
-              fieldPath: metadata.namespace
-        args:
-        - --leader-elect
-        - --health-probe-bind-address=:8081
-        resources:
-          limits:
-            cpu: 500m
-            memory: 128Mi
-          requests:
-            cpu: 10m
-            memory: 64Mi
+      - image: controller:latest
+        name: manager
+      securityContext:
+        runAsNonRoot: true
+        seccompProfile:
+          type: RuntimeDefault`
+			templated := templater.ApplyHelmSubstitutions(content, deployment)
+
+			render := func(manager map[string]any) string {
+				GinkgoHelper()
+				manager["image"] = map[string]any{"repository": "controller"}
+				rendered, err := renderTemplate(templated, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
+			Expect(render(map[string]any{})).To(ContainSubstring(`
+      securityContext:
+        runAsNonRoot: true
+        seccompProfile:
+          type: RuntimeDefault
+`))
+			Expect(render(map[string]any{"podSecurityContext": map[string]any{"runAsUser": 1000}})).To(ContainSubstring(`
+      securityContext:
+        runAsUser: 1000
+`))
+		})
+
+		It("should keep the scaffolded container securityContext when manager.securityContext is unset", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager
         securityContext:
           allowPrivilegeEscalation: false
           capabilities:
             drop:
             - ALL
-        volumeMounts: []
-      serviceAccountName: controller-manager
-      volumes: []`
+          readOnlyRootFilesystem: true`
+			templated := templater.ApplyHelmSubstitutions(content, deployment)
+
+			render := func(manager map[string]any) string {
+				GinkgoHelper()
+				manager["image"] = map[string]any{"repository": "controller"}
+				rendered, err := renderTemplate(templated, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered
+			}
+			Expect(render(map[string]any{})).To(ContainSubstring(`
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+          readOnlyRootFilesystem: true`))
+			Expect(render(map[string]any{"securityContext": map[string]any{"privileged": false}})).To(
+				ContainSubstring("\n        securityContext:\n          privileged: false"))
+		})
+	})
 
-			result := templater.ApplyHelmSubstitutions(content, deployment)
+	// templateBasicWithStatement must correctly consume entire YAML sequence blocks
+	// (like tolerations) because their list items start at the same indentation as
+	// the parent key, distinguished only by the leading "- " marker.
+	Context("scheduling fields templating (nodeSelector / affinity / tolerations)", func() {
+		const managerTolerationsStanza = `{{- with include "test-project.managerTolerations" . | fromYamlArray }}`
+		const managerAffinityStanza = `{{- with include "test-project.managerAffinity" . | fromYaml }}`
 
-			// Should template image reference (not hardcoded)
-			Expect(result).To(ContainSubstring(
-				`image: "{{ .Values.manager.image.repository | default "controller" }}` +
-					`{{- if not (contains "@" (.Values.manager.image.repository | default "controller")) }}` +
-					`:{{ .Values.manager.image.tag | default .Chart.AppVersion }}{{- end }}"`))
-			Expect(result).NotTo(ContainSubstring("image: controller:latest"))
+		It("should replace an existing multi-item tolerations block with a single Helm stanza", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
 
-			// Should template imagePullPolicy
-			Expect(result).To(ContainSubstring(`{{- with .Values.manager.image.pullPolicy }}
-        imagePullPolicy: {{ . }}
-        {{- end }}`))
-			Expect(result).NotTo(ContainSubstring("imagePullPolicy: Always"))
+			content := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: controller:latest
+      tolerations:
+      - effect: NoSchedule
+        key: node-role.kubernetes.io/control-plane
+        operator: Exists
+      - effect: NoExecute
+        key: another-key
+        value: somevalue
+      securityContext:
+        runAsNonRoot: true
+      serviceAccountName: test-project-controller-manager`
 
-			// Should template resources
-			Expect(result).To(ContainSubstring("{{- if .Values.manager.resources }}"))
-			Expect(result).To(ContainSubstring("{{- toYaml .Values.manager.resources | nindent"))
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Env list + envOverrides (--set). Secret refs go in env list.
-			Expect(result).To(ContainSubstring(".Values.manager.env"))
-			Expect(result).To(ContainSubstring("toYaml .Values.manager.env"))
-			Expect(result).To(ContainSubstring("envOverrides"))
+			// Exactly one Helm-templated tolerations stanza must be present.
+			Expect(strings.Count(result, "tolerations:")).To(Equal(1),
+				"Expected exactly one tolerations: line in output")
+			Expect(result).To(ContainSubstring(managerTolerationsStanza))
+			Expect(result).To(ContainSubstring("tolerations: {{ toYaml . | nindent"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
 
-			// Should template args
-			Expect(result).To(ContainSubstring("{{- range .Values.manager.args }}"))
+			// The raw list items must be gone.
+			Expect(result).NotTo(ContainSubstring("- effect: NoSchedule"))
+			Expect(result).NotTo(ContainSubstring("- effect: NoExecute"))
+			Expect(result).NotTo(ContainSubstring("key: node-role.kubernetes.io/control-plane"))
+			Expect(result).NotTo(ContainSubstring("key: another-key"))
 
-			// Container name should remain "controller-test"
-			Expect(result).To(ContainSubstring("name: controller-test"))
+			// Fields after tolerations must still be present (templated).
+			Expect(result).To(ContainSubstring("securityContext:"))
+			Expect(result).To(ContainSubstring(".Values.manager.podSecurityContext"))
 		})
 
-		It("should append extraVolumes and extraVolumeMounts when lists are present", func() {
+		It("should insert a tolerations Helm stanza when the field is absent", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
@@ -2971,191 +8556,237 @@ spec:
 
 			content := `apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
 spec:
   template:
     spec:
-      volumes:
-      - name: webhook-certs
-        secret:
-          secretName: webhook-server-cert
       containers:
       - name: manager
         image: controller:latest
-        volumeMounts:
-        - name: webhook-certs
-          mountPath: /tmp/k8s-webhook-server/serving-certs
-          readOnly: true
-`
+      securityContext:
+        runAsNonRoot: true`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			Expect(result).To(ContainSubstring(".Values.manager.extraVolumes"))
-			Expect(result).To(ContainSubstring(".Values.manager.extraVolumeMounts"))
+			Expect(result).To(ContainSubstring(managerTolerationsStanza))
+			Expect(result).To(ContainSubstring("tolerations: {{ toYaml . | nindent"))
+			Expect(result).To(ContainSubstring("{{- end }}"))
 		})
 
-		It("should inject extraVolumes/extraVolumeMounts when Kustomize has volumeMounts: [] and volumes: []", func() {
+		It("should be idempotent: running templating twice does not duplicate stanzas", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Default Kustomize output: single-line empty lists (no webhook/metrics patches)
 			content := `apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
 spec:
   template:
     spec:
       containers:
       - name: manager
         image: controller:latest
-        volumeMounts: []
-      volumes: []`
+      tolerations:
+      - effect: NoSchedule
+        key: node-role.kubernetes.io/control-plane
+        operator: Exists`
 
-			result := templater.ApplyHelmSubstitutions(content, deployment)
+			first := templater.ApplyHelmSubstitutions(content, deployment)
+			second := templater.ApplyHelmSubstitutions(first, deployment)
 
-			Expect(result).To(ContainSubstring(".Values.manager.extraVolumes"))
-			Expect(result).To(ContainSubstring(".Values.manager.extraVolumeMounts"))
+			Expect(strings.Count(second, managerTolerationsStanza)).To(Equal(1),
+				"Expected exactly one tolerations stanza after two passes")
+			Expect(strings.Count(second, "tolerations:")).To(Equal(1),
+				"Expected exactly one tolerations: line after two passes")
 		})
 
-		It("should append only extraVolumes from values and keep webhook/metrics conditional", func() {
-			deployment := &unstructured.Unstructured{
-				Object: map[string]any{
-					"apiVersion": "apps/v1",
-					"kind":       "Deployment",
-					"metadata": map[string]any{
-						"name": "test-project-controller-manager", //nolint:goconst
-					},
-					k8sSpecField: map[string]any{
-						"template": map[string]any{
-							k8sSpecField: map[string]any{
-								"volumes": []any{
-									map[string]any{"name": "webhook-certs", "secret": map[string]any{"secretName": "webhook-server-cert"}},
-									map[string]any{"name": "metrics-certs", "secret": map[string]any{"secretName": "metrics-server-cert"}},
-								},
-								"containers": []any{
-									map[string]any{
-										"name":  "manager",
-										"image": "controller:latest",
-										"volumeMounts": []any{
-											map[string]any{
-												"name": "webhook-certs", "mountPath": "/tmp/k8s-webhook-server/serving-certs", "readOnly": true,
-											},
-											map[string]any{
-												"name": "metrics-certs", "mountPath": "/tmp/k8s-metrics-server/metrics-certs", "readOnly": true,
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
+		It("should merge the node failure tolerations with manager.tolerations", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: controller:latest`, deployment)
+
+			tolerations := func(manager map[string]any) []any {
+				GinkgoHelper()
+				manager["image"] = map[string]any{"repository": "controller"}
+				rendered, err := renderTemplate(result, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				object := map[string]any{}
+				Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+				found, _, err := unstructured.NestedSlice(object, "spec", "template", "spec", "tolerations")
+				Expect(err).NotTo(HaveOccurred())
+				return found
+			}
+			nodeFailure := func(key string, seconds int) map[string]any {
+				return map[string]any{
+					"key": key, "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": float64(seconds),
+				}
+			}
+			userToleration := map[string]any{
+				"key": "node-role.kubernetes.io/control-plane", "operator": "Exists", "effect": "NoSchedule",
 			}
+
+			Expect(tolerations(map[string]any{})).To(BeEmpty())
+			Expect(tolerations(map[string]any{"tolerations": []any{userToleration}})).To(Equal([]any{userToleration}))
+
+			Expect(tolerations(map[string]any{
+				"tolerations":            []any{userToleration},
+				"nodeFailureTolerations": map[string]any{"enabled": true, "tolerationSeconds": 30},
+			})).To(Equal([]any{
+				userToleration,
+				nodeFailure("node.kubernetes.io/not-ready", 30),
+				nodeFailure("node.kubernetes.io/unreachable", 30),
+			}))
+
+			By("keeping a toleration manager.tolerations already sets for one of the taints")
+			unreachable := nodeFailure("node.kubernetes.io/unreachable", 600)
+			Expect(tolerations(map[string]any{
+				"tolerations":            []any{unreachable},
+				"nodeFailureTolerations": map[string]any{"enabled": true, "tolerationSeconds": 30},
+			})).To(Equal([]any{unreachable, nodeFailure("node.kubernetes.io/not-ready", 30)}))
+
+			By("tolerating the taints for ever without tolerationSeconds")
+			Expect(tolerations(map[string]any{"nodeFailureTolerations": map[string]any{"enabled": true}})).
+				To(ContainElement(map[string]any{
+					"key": "node.kubernetes.io/not-ready", "operator": "Exists", "effect": "NoExecute",
+				}))
+		})
+
+		It("should correctly template nodeSelector (map type) without regression", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
 			content := `apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
 spec:
   template:
     spec:
-      volumes:
-      - name: webhook-certs
-        secret:
-          secretName: webhook-server-cert
-      - name: metrics-certs
-        secret:
-          secretName: metrics-server-cert
       containers:
       - name: manager
         image: controller:latest
-        volumeMounts:
-        - mountPath: /tmp/k8s-webhook-server/serving-certs
-          name: webhook-certs
-          readOnly: true
-        - mountPath: /tmp/k8s-metrics-server/metrics-certs
-          name: metrics-certs
-          readOnly: true
-`
+      nodeSelector:
+        kubernetes.io/os: linux
+        custom-label: my-node`
+
 			result := templater.ApplyHelmSubstitutions(content, deployment)
-			Expect(result).To(ContainSubstring(".Values.certManager.enabled"))
-			Expect(result).To(ContainSubstring(".Values.manager.extraVolumes"))
-			Expect(result).To(ContainSubstring("webhook-certs"))
-			Expect(result).To(ContainSubstring("metrics-certs"))
+
+			Expect(strings.Count(result, "nodeSelector:")).To(Equal(1))
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.nodeSelector }}"))
+			Expect(result).To(ContainSubstring("nodeSelector: {{ toYaml . | nindent"))
+			// Raw entries must be removed.
+			Expect(result).NotTo(ContainSubstring("kubernetes.io/os: linux"))
+			Expect(result).NotTo(ContainSubstring("custom-label: my-node"))
 		})
 
-		It("should fall back to 'manager' when default-container annotation is missing", func() {
+		It("should correctly template affinity (map type) without regression", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Deployment without default-container annotation (backward compatibility test)
 			content := `apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
 spec:
   template:
     spec:
       containers:
       - name: manager
         image: controller:latest
-        resources:
-          limits:
-            cpu: 500m
-            memory: 128Mi`
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+            - matchExpressions:
+              - key: kubernetes.io/arch
+                operator: In
+                values:
+                - amd64`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Should still template fields for "manager" container
-			Expect(result).To(ContainSubstring(
-				`image: "{{ .Values.manager.image.repository | default "controller" }}` +
-					`{{- if not (contains "@" (.Values.manager.image.repository | default "controller")) }}` +
-					`:{{ .Values.manager.image.tag | default .Chart.AppVersion }}{{- end }}"`))
-			Expect(result).To(ContainSubstring("{{- if .Values.manager.resources }}"))
+			Expect(strings.Count(result, "affinity:")).To(Equal(1))
+			Expect(result).To(ContainSubstring(managerAffinityStanza))
+			Expect(result).To(ContainSubstring("affinity: {{ toYaml . | nindent"))
+			// Raw sub-fields must be removed.
+			Expect(result).NotTo(ContainSubstring("nodeAffinity:"))
+			Expect(result).NotTo(ContainSubstring("requiredDuringSchedulingIgnoredDuringExecution:"))
 		})
 
-		It("should not template when container name doesn't match annotation", func() {
+		It("should fall back to a soft pod anti-affinity when manager.defaultAffinity is set", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Deployment with mismatched annotation and container name
-			content := `apiVersion: apps/v1
+			result := templater.ApplyHelmSubstitutions(`apiVersion: apps/v1
 kind: Deployment
-metadata:
-  name: test-project-controller-manager
 spec:
   template:
-    metadata:
-      annotations:
-        kubectl.kubernetes.io/default-container: main-container
     spec:
       containers:
-      - name: sidecar
-        image: sidecar:latest
-        resources:
-          limits:
-            cpu: 100m`
+      - name: manager
+        image: controller:latest`, deployment)
+
+			affinity := func(manager map[string]any) map[string]any {
+				GinkgoHelper()
+				manager["image"] = map[string]any{"repository": "controller"}
+				rendered, err := renderTemplate(result, map[string]any{"manager": manager, "rbac": map[string]any{}})
+				Expect(err).NotTo(HaveOccurred())
+				object := map[string]any{}
+				Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+				found, _, err := unstructured.NestedMap(object, "spec", "template", "spec", "affinity")
+				Expect(err).NotTo(HaveOccurred())
+				return found
+			}
 
-			result := templater.ApplyHelmSubstitutions(content, deployment)
+			By("leaving affinity unset when defaultAffinity is off")
+			Expect(affinity(map[string]any{})).To(BeNil())
+			Expect(affinity(map[string]any{"defaultAffinity": false})).To(BeNil())
+
+			By("spreading the replicas across nodes when defaultAffinity is on")
+			Expect(affinity(map[string]any{"defaultAffinity": true})).To(Equal(map[string]any{
+				"podAntiAffinity": map[string]any{
+					"preferredDuringSchedulingIgnoredDuringExecution": []any{map[string]any{
+						"weight": float64(100),
+						"podAffinityTerm": map[string]any{
+							"topologyKey": "kubernetes.io/hostname",
+							"labelSelector": map[string]any{"matchLabels": map[string]any{
+								"app.kubernetes.io/name": "test-project",
+								"control-plane":          "controller-manager",
+							}},
+						},
+					}},
+				},
+			}))
 
-			// Should NOT template sidecar container (doesn't match annotation)
-			Expect(result).To(ContainSubstring("image: sidecar:latest"))
-			Expect(result).NotTo(ContainSubstring("{{ .Values.manager.image.repository }}"))
+			By("preferring manager.affinity over the default")
+			userAffinity := map[string]any{"nodeAffinity": map[string]any{
+				"preferredDuringSchedulingIgnoredDuringExecution": []any{map[string]any{
+					"weight": float64(1),
+					"preference": map[string]any{"matchExpressions": []any{map[string]any{
+						"key": "kubernetes.io/arch", "operator": "In", "values": []any{"amd64"},
+					}}},
+				}},
+			}}
+			Expect(affinity(map[string]any{"defaultAffinity": true, "affinity": userAffinity})).To(Equal(userAffinity))
 		})
-	})
 
-	// templateBasicWithStatement must correctly consume entire YAML sequence blocks
-	// (like tolerations) because their list items start at the same indentation as
-	// the parent key, distinguished only by the leading "- " marker.
-	Context("scheduling fields templating (nodeSelector / affinity / tolerations)", func() {
-		It("should replace an existing multi-item tolerations block with a single Helm stanza", func() {
+		It("should not match a key that only shares a prefix with the target field name", func() {
+			// Regression guard: key matching must require the trailing colon so that
+			// e.g. "nodeSelector" does not accidentally match "nodeSelectorTerms:".
+			// This YAML tests that nodeSelectorTerms inside an affinity block is not
+			// treated as the nodeSelector field itself.
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
@@ -3169,62 +8800,133 @@ spec:
       containers:
       - name: manager
         image: controller:latest
-      tolerations:
-      - effect: NoSchedule
-        key: node-role.kubernetes.io/control-plane
-        operator: Exists
-      - effect: NoExecute
-        key: another-key
-        value: somevalue
-      securityContext:
-        runAsNonRoot: true
-      serviceAccountName: test-project-controller-manager`
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+            - matchExpressions:
+              - key: kubernetes.io/arch
+                operator: In`
+
+			result := templater.ApplyHelmSubstitutions(content, deployment)
+
+			// The nodeSelector Helm stanza must be inserted (field was absent).
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.nodeSelector }}"))
+			// The affinity block must only appear once and be Helm-templated.
+			Expect(strings.Count(result, "affinity:")).To(Equal(1))
+			Expect(result).To(ContainSubstring(managerAffinityStanza))
+			// nodeSelectorTerms is part of the affinity value; it must be gone since
+			// the whole affinity block is replaced by the Helm stanza.
+			Expect(result).NotTo(ContainSubstring("nodeSelectorTerms:"))
+		})
+	})
+
+	Context("custom labels and annotations", func() {
+		It("should add custom labels and annotations to manager Deployment only", func() {
+			deployment := &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+
+			content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/name: {{ include "test-project.name" . }}
+    helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}
+    app.kubernetes.io/instance: {{ .Release.Name }}
+    control-plane: controller-manager
+  name: {{ include "test-project.resourceName" (dict "suffix" "controller-manager" "context" $) }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  replicas: {{ .Values.manager.replicas }}
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  template:
+    metadata:
+      annotations:
+        kubectl.kubernetes.io/default-container: manager
+      labels:
+        app.kubernetes.io/name: {{ include "test-project.name" . }}
+        helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}
+        app.kubernetes.io/instance: {{ .Release.Name }}
+        app.kubernetes.io/managed-by: {{ .Release.Service }}
+        control-plane: controller-manager
+    spec:
+      containers:
+      - name: manager
+        image: controller:latest`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Exactly one Helm-templated tolerations stanza must be present.
-			Expect(strings.Count(result, "tolerations:")).To(Equal(1),
-				"Expected exactly one tolerations: line in output")
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.tolerations }}"))
-			Expect(result).To(ContainSubstring("tolerations: {{ toYaml . | nindent"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			// Should add manager.labels to Deployment (with automatic filtering of existing keys)
+			Expect(result).To(Or(
+				ContainSubstring("{{- with .Values.manager.labels }}"),
+				ContainSubstring("{{- if .Values.manager.labels }}"),
+			))
 
-			// The raw list items must be gone.
-			Expect(result).NotTo(ContainSubstring("- effect: NoSchedule"))
-			Expect(result).NotTo(ContainSubstring("- effect: NoExecute"))
-			Expect(result).NotTo(ContainSubstring("key: node-role.kubernetes.io/control-plane"))
-			Expect(result).NotTo(ContainSubstring("key: another-key"))
+			// Should add manager.pod.labels to Pod template (with automatic filtering)
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.pod }}"))
+			Expect(result).To(ContainSubstring("{{- with .labels }}"))
 
-			// Fields after tolerations must still be present (templated).
-			Expect(result).To(ContainSubstring("securityContext:"))
-			Expect(result).To(ContainSubstring(".Values.manager.podSecurityContext"))
+			// Should add manager.annotations to Deployment
+			Expect(result).To(Or(
+				ContainSubstring("{{- with .Values.manager.annotations }}"),
+				ContainSubstring("{{- if .Values.manager.annotations }}"),
+			))
+			// Verify annotations are properly nested under metadata: (not top-level)
+			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
+				"manager.annotations should be nested under Deployment metadata")
+			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
+				"annotations should not be emitted as a top-level block before spec")
+
+			// Should add manager.pod.annotations to Pod template (with automatic filtering)
+			Expect(result).To(ContainSubstring("{{- with .annotations }}"))
+
+			// Each field should appear exactly once (check for either pattern: with/without omit)
+			labelsCount := strings.Count(result, ".Values.manager.labels")
+			Expect(labelsCount).To(BeNumerically(">=", 1), "Should have labels block in Deployment")
+
+			podLabelsCount := strings.Count(result, ".labels")
+			Expect(podLabelsCount).To(BeNumerically(">=", 1), "Should have pod.labels block in Pod template")
+
+			annotationsCount := strings.Count(result, ".Values.manager.annotations")
+			Expect(annotationsCount).To(BeNumerically(">=", 1), "Should have annotations block in Deployment")
+
+			podAnnotationsCount := strings.Count(result, ".annotations")
+			Expect(podAnnotationsCount).To(BeNumerically(">=", 1), "Should have pod.annotations block in Pod template")
 		})
 
-		It("should insert a tolerations Helm stanza when the field is absent", func() {
+		It("should not add custom labels/annotations to non-manager Deployment", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+			deployment.SetName("other-deployment")
 
 			content := `apiVersion: apps/v1
 kind: Deployment
+metadata:
+  labels:
+    app: other
+  name: other-deployment
 spec:
   template:
-    spec:
-      containers:
-      - name: manager
-        image: controller:latest
-      securityContext:
-        runAsNonRoot: true`
+    metadata:
+      labels:
+        app: other`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.tolerations }}"))
-			Expect(result).To(ContainSubstring("tolerations: {{ toYaml . | nindent"))
-			Expect(result).To(ContainSubstring("{{- end }}"))
+			// Should not add any manager custom labels/annotations
+			Expect(result).NotTo(ContainSubstring("{{- if .Values.manager.labels }}"))
+			Expect(result).NotTo(ContainSubstring("{{- if .Values.manager.annotations }}"))
+			Expect(result).NotTo(ContainSubstring("{{- with .Values.manager.pod }}"))
+			Expect(result).NotTo(ContainSubstring("{{- with .labels }}"))
+			Expect(result).NotTo(ContainSubstring("{{- with .annotations }}"))
 		})
 
-		It("should be idempotent: running templating twice does not duplicate stanzas", func() {
+		It("should not duplicate labels/annotations blocks when applied twice", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
@@ -3232,291 +8934,326 @@ spec:
 
 			content := `apiVersion: apps/v1
 kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
 spec:
   template:
-    spec:
-      containers:
-      - name: manager
-        image: controller:latest
-      tolerations:
-      - effect: NoSchedule
-        key: node-role.kubernetes.io/control-plane
-        operator: Exists`
+    metadata:
+      annotations:
+        kubectl.kubernetes.io/default-container: manager
+      labels:
+        control-plane: controller-manager`
 
-			first := templater.ApplyHelmSubstitutions(content, deployment)
-			second := templater.ApplyHelmSubstitutions(first, deployment)
+			// Apply templating twice
+			result1 := templater.ApplyHelmSubstitutions(content, deployment)
+			result2 := templater.ApplyHelmSubstitutions(result1, deployment)
 
-			Expect(strings.Count(second, "{{- with .Values.manager.tolerations }}")).To(Equal(1),
-				"Expected exactly one {{- with .Values.manager.tolerations }} after two passes")
-			Expect(strings.Count(second, "tolerations:")).To(Equal(1),
-				"Expected exactly one tolerations: line after two passes")
+			// Each field should appear exactly once (idempotency check)
+			// Count manager.labels references (either with if or with omit)
+			labelsCount := strings.Count(result2, ".Values.manager.labels")
+			Expect(labelsCount).To(BeNumerically("<=", 2), "Should not duplicate labels blocks excessively")
+
+			// Count .labels references in pod context
+			podLabelsPattern := regexp.MustCompile(`{{- with (?:omit )?\.labels`)
+			podLabelsMatches := podLabelsPattern.FindAllString(result2, -1)
+			Expect(len(podLabelsMatches)).To(BeNumerically("<=", 2), "Should not duplicate pod.labels blocks excessively")
+
+			// Count manager.annotations references
+			annotationsCount := strings.Count(result2, ".Values.manager.annotations")
+			Expect(annotationsCount).To(BeNumerically("<=", 2), "Should not duplicate annotations blocks excessively")
+
+			// Count .annotations references in pod context
+			podAnnotationsPattern := regexp.MustCompile(`{{- with (?:omit )?\.annotations`)
+			podAnnotationsMatches := podAnnotationsPattern.FindAllString(result2, -1)
+			Expect(len(podAnnotationsMatches)).To(BeNumerically("<=", 2),
+				"Should not duplicate pod.annotations blocks excessively")
 		})
 
-		It("should correctly template nodeSelector (map type) without regression", func() {
+		It("should add pod annotations even when pod template has no annotations field", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Pod template with labels but NO annotations field
 			content := `apiVersion: apps/v1
 kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
 spec:
   template:
-    spec:
-      containers:
-      - name: manager
-        image: controller:latest
-      nodeSelector:
-        kubernetes.io/os: linux
-        custom-label: my-node`
+    metadata:
+      labels:
+        control-plane: controller-manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			Expect(strings.Count(result, "nodeSelector:")).To(Equal(1))
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.nodeSelector }}"))
-			Expect(result).To(ContainSubstring("nodeSelector: {{ toYaml . | nindent"))
-			// Raw entries must be removed.
-			Expect(result).NotTo(ContainSubstring("kubernetes.io/os: linux"))
-			Expect(result).NotTo(ContainSubstring("custom-label: my-node"))
+			// Should create annotations section before labels
+			Expect(result).To(ContainSubstring("annotations:"))
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.pod }}"))
+			Expect(result).To(ContainSubstring("{{- with .annotations }}"))
+
+			// Verify annotations come before labels in pod template
+			// Find the pod annotations block (either pattern)
+			annotationsPattern := regexp.MustCompile(`{{- with (?:omit )?\.annotations`)
+			labelsPattern := regexp.MustCompile(`{{- with (?:omit )?\.labels`)
+			annotationsMatch := annotationsPattern.FindStringIndex(result)
+			labelsMatch := labelsPattern.FindStringIndex(result)
+			if annotationsMatch != nil && labelsMatch != nil {
+				Expect(annotationsMatch[0]).To(BeNumerically("<", labelsMatch[0]), "Annotations should come before labels")
+			}
 		})
 
-		It("should correctly template affinity (map type) without regression", func() {
+		It("should inject custom annotations into existing Deployment metadata annotations", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Deployment with existing annotations in metadata (e.g., from commonAnnotations)
 			content := `apiVersion: apps/v1
 kind: Deployment
+metadata:
+  annotations:
+    existing-annotation: from-kustomize
+    another-annotation: value
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
 spec:
   template:
-    spec:
-      containers:
-      - name: manager
-        image: controller:latest
-      affinity:
-        nodeAffinity:
-          requiredDuringSchedulingIgnoredDuringExecution:
-            nodeSelectorTerms:
-            - matchExpressions:
-              - key: kubernetes.io/arch
-                operator: In
-                values:
-                - amd64`
+    metadata:
+      labels:
+        control-plane: controller-manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			Expect(strings.Count(result, "affinity:")).To(Equal(1))
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.affinity }}"))
-			Expect(result).To(ContainSubstring("affinity: {{ toYaml . | nindent"))
-			// Raw sub-fields must be removed.
-			Expect(result).NotTo(ContainSubstring("nodeAffinity:"))
-			Expect(result).NotTo(ContainSubstring("requiredDuringSchedulingIgnoredDuringExecution:"))
+			// Should inject custom annotations into existing annotations block (with filtering)
+			Expect(result).To(Or(
+				ContainSubstring("{{- with .Values.manager.annotations }}"),
+				ContainSubstring("{{- if .Values.manager.annotations }}"),
+			))
+			// Verify annotations are properly nested under metadata:
+			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
+				"manager.annotations should be nested under Deployment metadata")
+			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
+				"annotations should not be emitted as a top-level block before spec")
+
+			// Should NOT create duplicate annotations: key
+			annotationsCount := strings.Count(result, "annotations:")
+			Expect(annotationsCount).To(Equal(2),
+				"Should have exactly 2 annotations blocks (1 for Deployment, 1 for Pod template)")
+
+			// Existing annotations should still be present
+			Expect(result).To(ContainSubstring("existing-annotation: from-kustomize"))
+			Expect(result).To(ContainSubstring("another-annotation: value"))
+
+			// Verify injection order: custom annotations should come after existing ones
+			// Find the manager annotations block (either pattern)
+			annotationsPattern := regexp.MustCompile(`{{- (?:with|if) \.Values\.manager\.annotations`)
+			annotationsMatch := annotationsPattern.FindStringIndex(result)
+			existingAnnotationIdx := strings.Index(result, "existing-annotation: from-kustomize")
+			if annotationsMatch != nil {
+				Expect(annotationsMatch[0]).To(BeNumerically(">", existingAnnotationIdx),
+					"Custom annotations should be injected after existing annotations")
+			}
 		})
 
-		It("should not match a key that only shares a prefix with the target field name", func() {
-			// Regression guard: key matching must require the trailing colon so that
-			// e.g. "nodeSelector" does not accidentally match "nodeSelectorTerms:".
-			// This YAML tests that nodeSelectorTerms inside an affinity block is not
-			// treated as the nodeSelector field itself.
+		It("should convert empty map annotations: {} to block style for injection", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Deployment with empty map annotations: {}
+			// Should be converted to block style to allow custom annotation injection
 			content := `apiVersion: apps/v1
 kind: Deployment
+metadata:
+  annotations: {}
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
 spec:
   template:
-    spec:
-      containers:
-      - name: manager
-        image: controller:latest
-      affinity:
-        nodeAffinity:
-          requiredDuringSchedulingIgnoredDuringExecution:
-            nodeSelectorTerms:
-            - matchExpressions:
-              - key: kubernetes.io/arch
-                operator: In`
+    metadata:
+      annotations: {}
+      labels:
+        control-plane: controller-manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// The nodeSelector Helm stanza must be inserted (field was absent).
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.nodeSelector }}"))
-			// The affinity block must only appear once and be Helm-templated.
-			Expect(strings.Count(result, "affinity:")).To(Equal(1))
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.affinity }}"))
-			// nodeSelectorTerms is part of the affinity value; it must be gone since
-			// the whole affinity block is replaced by the Helm stanza.
-			Expect(result).NotTo(ContainSubstring("nodeSelectorTerms:"))
+			// Empty map should be converted to block style (not preserved as {})
+			Expect(result).NotTo(ContainSubstring("annotations: {}"))
+
+			// Should inject custom annotations template
+			Expect(result).To(Or(
+				ContainSubstring("{{- if .Values.manager.annotations }}"),
+				ContainSubstring("{{- with .Values.manager.annotations }}"),
+			))
+
+			// Should have block-style annotations: key (not flow-style)
+			Expect(regexp.MustCompile(`(?m)^  annotations:\n`).MatchString(result)).To(BeTrue(),
+				"Empty map annotations should be converted to block style")
 		})
-	})
 
-	Context("custom labels and annotations", func() {
-		It("should add custom labels and annotations to manager Deployment only", func() {
+		It("should handle inline annotations with values in Deployment metadata", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Deployment with inline annotations containing a value
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
+  annotations: {existing: value}
   labels:
-    app.kubernetes.io/name: {{ include "test-project.name" . }}
-    helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}
-    app.kubernetes.io/instance: {{ .Release.Name }}
     control-plane: controller-manager
-  name: {{ include "test-project.resourceName" (dict "suffix" "controller-manager" "context" $) }}
-  namespace: {{ .Release.Namespace }}
+  name: test-project-controller-manager
 spec:
-  replicas: {{ .Values.manager.replicas }}
-  selector:
-    matchLabels:
-      control-plane: controller-manager
   template:
     metadata:
-      annotations:
-        kubectl.kubernetes.io/default-container: manager
+      annotations: {kubectl.kubernetes.io/default-container: manager}
       labels:
-        app.kubernetes.io/name: {{ include "test-project.name" . }}
-        helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}
-        app.kubernetes.io/instance: {{ .Release.Name }}
-        app.kubernetes.io/managed-by: {{ .Release.Service }}
-        control-plane: controller-manager
-    spec:
-      containers:
-      - name: manager
-        image: controller:latest`
+        control-plane: controller-manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Should add manager.labels to Deployment (with automatic filtering of existing keys)
-			Expect(result).To(Or(
-				ContainSubstring("{{- with .Values.manager.labels }}"),
-				ContainSubstring("{{- if .Values.manager.labels }}"),
-			))
-
-			// Should add manager.pod.labels to Pod template (with automatic filtering)
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.pod }}"))
-			Expect(result).To(ContainSubstring("{{- with .labels }}"))
-
-			// Should add manager.annotations to Deployment
+			// Should inject custom annotations (with automatic filtering)
 			Expect(result).To(Or(
 				ContainSubstring("{{- with .Values.manager.annotations }}"),
 				ContainSubstring("{{- if .Values.manager.annotations }}"),
 			))
-			// Verify annotations are properly nested under metadata: (not top-level)
+			// Verify annotations are properly nested under metadata:
 			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
 				"manager.annotations should be nested under Deployment metadata")
 			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
 				"annotations should not be emitted as a top-level block before spec")
 
-			// Should add manager.pod.annotations to Pod template (with automatic filtering)
+			// Should inject pod annotations (with automatic filtering)
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.pod }}"))
 			Expect(result).To(ContainSubstring("{{- with .annotations }}"))
 
-			// Each field should appear exactly once (check for either pattern: with/without omit)
-			labelsCount := strings.Count(result, ".Values.manager.labels")
-			Expect(labelsCount).To(BeNumerically(">=", 1), "Should have labels block in Deployment")
-
-			podLabelsCount := strings.Count(result, ".labels")
-			Expect(podLabelsCount).To(BeNumerically(">=", 1), "Should have pod.labels block in Pod template")
-
-			annotationsCount := strings.Count(result, ".Values.manager.annotations")
-			Expect(annotationsCount).To(BeNumerically(">=", 1), "Should have annotations block in Deployment")
-
-			podAnnotationsCount := strings.Count(result, ".annotations")
-			Expect(podAnnotationsCount).To(BeNumerically(">=", 1), "Should have pod.annotations block in Pod template")
+			// Should NOT create duplicate annotations: key
+			annotationsCount := strings.Count(result, "annotations:")
+			Expect(annotationsCount).To(Equal(2),
+				"Should have exactly 2 annotations blocks (1 for Deployment, 1 for Pod template)")
 		})
 
-		It("should not add custom labels/annotations to non-manager Deployment", func() {
+		It("should convert flow-style annotations to block-style before injecting templates", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
-			deployment.SetName("other-deployment")
+			deployment.SetName("test-project-controller-manager")
 
+			// Deployment with flow-style annotations (inline format)
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
+  annotations: {existing: value, another: test}
   labels:
-    app: other
-  name: other-deployment
+    control-plane: controller-manager
+  name: test-project-controller-manager
 spec:
   template:
     metadata:
+      annotations: {kubectl.kubernetes.io/default-container: manager, other: value}
       labels:
-        app: other`
+        control-plane: controller-manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Should not add any manager custom labels/annotations
-			Expect(result).NotTo(ContainSubstring("{{- if .Values.manager.labels }}"))
-			Expect(result).NotTo(ContainSubstring("{{- if .Values.manager.annotations }}"))
-			Expect(result).NotTo(ContainSubstring("{{- with .Values.manager.pod }}"))
-			Expect(result).NotTo(ContainSubstring("{{- with .labels }}"))
-			Expect(result).NotTo(ContainSubstring("{{- with .annotations }}"))
+			// Flow-style should be converted to block-style
+			Expect(result).NotTo(ContainSubstring("annotations: {"))
+
+			// Should have block-style annotations with existing entries preserved
+			Expect(result).To(ContainSubstring("existing: value"))
+			Expect(result).To(ContainSubstring("another: test"))
+
+			// Should inject custom annotations template blocks (with automatic filtering)
+			Expect(result).To(Or(
+				ContainSubstring("{{- with .Values.manager.annotations }}"),
+				ContainSubstring("{{- if .Values.manager.annotations }}"),
+			))
+
+			// Verify the structure is valid (annotations: key followed by indented content)
+			lines := strings.Split(result, "\n")
+			foundDeploymentAnnotations := false
+			for i, line := range lines {
+				if strings.TrimSpace(line) == "annotations:" && !foundDeploymentAnnotations {
+					foundDeploymentAnnotations = true
+					// Next lines should be indented (existing values or templates)
+					if i+1 < len(lines) {
+						nextLine := lines[i+1]
+						Expect(nextLine).To(MatchRegexp(`^\s{2,}`), // At least 2 spaces of indentation
+							"Content after annotations: should be indented")
+					}
+				}
+			}
+			Expect(foundDeploymentAnnotations).To(BeTrue(), "Should have Deployment annotations block")
 		})
 
-		It("should not duplicate labels/annotations blocks when applied twice", func() {
+		It("should convert flow-style annotations without space to block-style", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
+			// Test YAML variant without space: annotations:{...} (also valid YAML)
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
+  annotations:{existing: value, another: test}
   labels:
     control-plane: controller-manager
   name: test-project-controller-manager
 spec:
   template:
     metadata:
-      annotations:
-        kubectl.kubernetes.io/default-container: manager
+      annotations:{kubectl.kubernetes.io/default-container: manager}
       labels:
         control-plane: controller-manager`
 
-			// Apply templating twice
-			result1 := templater.ApplyHelmSubstitutions(content, deployment)
-			result2 := templater.ApplyHelmSubstitutions(result1, deployment)
-
-			// Each field should appear exactly once (idempotency check)
-			// Count manager.labels references (either with if or with omit)
-			labelsCount := strings.Count(result2, ".Values.manager.labels")
-			Expect(labelsCount).To(BeNumerically("<=", 2), "Should not duplicate labels blocks excessively")
+			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Count .labels references in pod context
-			podLabelsPattern := regexp.MustCompile(`{{- with (?:omit )?\.labels`)
-			podLabelsMatches := podLabelsPattern.FindAllString(result2, -1)
-			Expect(len(podLabelsMatches)).To(BeNumerically("<=", 2), "Should not duplicate pod.labels blocks excessively")
+			// Flow-style should be converted to block-style (both with and without space)
+			Expect(result).NotTo(ContainSubstring("annotations: {"))
+			Expect(result).NotTo(ContainSubstring("annotations:{"))
 
-			// Count manager.annotations references
-			annotationsCount := strings.Count(result2, ".Values.manager.annotations")
-			Expect(annotationsCount).To(BeNumerically("<=", 2), "Should not duplicate annotations blocks excessively")
+			// Should have block-style annotations with existing entries preserved
+			Expect(result).To(ContainSubstring("existing: value"))
+			Expect(result).To(ContainSubstring("another: test"))
 
-			// Count .annotations references in pod context
-			podAnnotationsPattern := regexp.MustCompile(`{{- with (?:omit )?\.annotations`)
-			podAnnotationsMatches := podAnnotationsPattern.FindAllString(result2, -1)
-			Expect(len(podAnnotationsMatches)).To(BeNumerically("<=", 2),
-				"Should not duplicate pod.annotations blocks excessively")
+			// Should inject custom annotations template blocks
+			Expect(result).To(Or(
+				ContainSubstring("{{- with .Values.manager.annotations }}"),
+				ContainSubstring("{{- if .Values.manager.annotations }}"),
+			))
 		})
 
-		It("should add pod annotations even when pod template has no annotations field", func() {
+		It("should handle annotations as last field before spec in Deployment metadata", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Pod template with labels but NO annotations field
+			// Deployment with annotations as LAST field in metadata (before spec)
+			// This tests the edge case where there's no subsequent field to trigger injection
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
   labels:
     control-plane: controller-manager
-  name: test-project-controller-manager
+  annotations:
+    existing-annotation: value1
+    another-annotation: value2
 spec:
+  replicas: 1
   template:
     metadata:
       labels:
@@ -3524,47 +9261,59 @@ spec:
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Should create annotations section before labels
-			Expect(result).To(ContainSubstring("annotations:"))
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.pod }}"))
-			Expect(result).To(ContainSubstring("{{- with .annotations }}"))
+			// Should inject custom annotations after existing ones (with filtering)
+			Expect(result).To(Or(
+				ContainSubstring("{{- with .Values.manager.annotations }}"),
+				ContainSubstring("{{- if .Values.manager.annotations }}"),
+			))
+			// Verify annotations are properly nested under metadata:
+			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
+				"manager.annotations should be nested under Deployment metadata")
+			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
+				"annotations should not be emitted as a top-level block before spec")
+			Expect(result).To(ContainSubstring("existing-annotation: value1"))
+			Expect(result).To(ContainSubstring("another-annotation: value2"))
 
-			// Verify annotations come before labels in pod template
-			// Find the pod annotations block (either pattern)
-			annotationsPattern := regexp.MustCompile(`{{- with (?:omit )?\.annotations`)
-			labelsPattern := regexp.MustCompile(`{{- with (?:omit )?\.labels`)
+			// Verify injection order: custom annotations should come after existing ones
+			// Find the manager annotations block (either pattern)
+			annotationsPattern := regexp.MustCompile(`{{- (?:with|if) \.Values\.manager\.annotations`)
 			annotationsMatch := annotationsPattern.FindStringIndex(result)
-			labelsMatch := labelsPattern.FindStringIndex(result)
-			if annotationsMatch != nil && labelsMatch != nil {
-				Expect(annotationsMatch[0]).To(BeNumerically("<", labelsMatch[0]), "Annotations should come before labels")
+			existingAnnotation1Idx := strings.Index(result, "existing-annotation: value1")
+			existingAnnotation2Idx := strings.Index(result, "another-annotation: value2")
+			if annotationsMatch != nil {
+				Expect(annotationsMatch[0]).To(BeNumerically(">", existingAnnotation1Idx),
+					"Custom annotations should be injected after first existing annotation")
+				Expect(annotationsMatch[0]).To(BeNumerically(">", existingAnnotation2Idx),
+					"Custom annotations should be injected after last existing annotation")
 			}
 		})
 
-		It("should inject custom annotations into existing Deployment metadata annotations", func() {
+		It("should handle annotations with varying indentation from kustomize", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Deployment with existing annotations in metadata (e.g., from commonAnnotations)
+			// Test with 4-space indentation (some kustomize configurations)
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
-  annotations:
-    existing-annotation: from-kustomize
-    another-annotation: value
-  labels:
-    control-plane: controller-manager
-  name: test-project-controller-manager
+    labels:
+        control-plane: controller-manager
+    annotations:
+        existing-annotation: value1
+    name: test-project-controller-manager
 spec:
-  template:
-    metadata:
-      labels:
-        control-plane: controller-manager`
+    template:
+        metadata:
+            annotations:
+                kubectl.kubernetes.io/default-container: manager
+            labels:
+                control-plane: controller-manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Should inject custom annotations into existing annotations block (with filtering)
+			// Should handle different indentation correctly (with automatic filtering)
 			Expect(result).To(Or(
 				ContainSubstring("{{- with .Values.manager.annotations }}"),
 				ContainSubstring("{{- if .Values.manager.annotations }}"),
@@ -3574,376 +9323,769 @@ spec:
 				"manager.annotations should be nested under Deployment metadata")
 			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
 				"annotations should not be emitted as a top-level block before spec")
+			Expect(result).To(ContainSubstring("{{- with .Values.manager.pod }}"))
 
-			// Should NOT create duplicate annotations: key
-			annotationsCount := strings.Count(result, "annotations:")
-			Expect(annotationsCount).To(Equal(2),
-				"Should have exactly 2 annotations blocks (1 for Deployment, 1 for Pod template)")
-
-			// Existing annotations should still be present
-			Expect(result).To(ContainSubstring("existing-annotation: from-kustomize"))
-			Expect(result).To(ContainSubstring("another-annotation: value"))
-
-			// Verify injection order: custom annotations should come after existing ones
-			// Find the manager annotations block (either pattern)
-			annotationsPattern := regexp.MustCompile(`{{- (?:with|if) \.Values\.manager\.annotations`)
-			annotationsMatch := annotationsPattern.FindStringIndex(result)
-			existingAnnotationIdx := strings.Index(result, "existing-annotation: from-kustomize")
-			if annotationsMatch != nil {
-				Expect(annotationsMatch[0]).To(BeNumerically(">", existingAnnotationIdx),
-					"Custom annotations should be injected after existing annotations")
+			// Verify custom annotations come after existing ones
+			deploymentAnnotationsPattern := regexp.MustCompile(`{{- (?:with|if) \.Values\.manager\.annotations`)
+			deploymentAnnotationsMatch := deploymentAnnotationsPattern.FindStringIndex(result)
+			deploymentExistingIdx := strings.Index(result, "existing-annotation: value1")
+			if deploymentAnnotationsMatch != nil {
+				Expect(deploymentAnnotationsMatch[0]).To(BeNumerically(">", deploymentExistingIdx))
 			}
+
+			podCustomIdx := strings.LastIndex(result, "{{- with .Values.manager.pod }}")
+			podDefaultIdx := strings.Index(result, "kubectl.kubernetes.io/default-container: manager")
+			Expect(podCustomIdx).To(BeNumerically(">", podDefaultIdx))
 		})
 
-		It("should convert empty map annotations: {} to block style for injection", func() {
+		It("should add deployment annotations when name appears before labels", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Deployment with empty map annotations: {}
-			// Should be converted to block style to allow custom annotation injection
+			// Edge case: metadata.name comes before metadata.labels
+			// Previous implementation relied on name: to trigger annotations injection
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
-  annotations: {}
+  name: test-project-controller-manager
   labels:
     control-plane: controller-manager
-  name: test-project-controller-manager
 spec:
   template:
     metadata:
-      annotations: {}
       labels:
         control-plane: controller-manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Empty map should be converted to block style (not preserved as {})
-			Expect(result).NotTo(ContainSubstring("annotations: {}"))
-
-			// Should inject custom annotations template
+			// Should still inject annotations template even when no annotations field exists
+			// and name appears before labels
 			Expect(result).To(Or(
-				ContainSubstring("{{- if .Values.manager.annotations }}"),
 				ContainSubstring("{{- with .Values.manager.annotations }}"),
+				ContainSubstring("{{- if .Values.manager.annotations }}"),
 			))
-
-			// Should have block-style annotations: key (not flow-style)
-			Expect(regexp.MustCompile(`(?m)^  annotations:\n`).MatchString(result)).To(BeTrue(),
-				"Empty map annotations should be converted to block style")
+			// Verify annotations are properly nested under metadata:
+			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
+				"manager.annotations should be nested under Deployment metadata")
+			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
+				"annotations should not be emitted as a top-level block before spec")
+			Expect(result).To(ContainSubstring("annotations:"))
 		})
 
-		It("should handle inline annotations with values in Deployment metadata", func() {
+		It("should inject deployment labels when labels is the last metadata field before spec", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Deployment with inline annotations containing a value
+			// Edge case: labels is the last field in metadata, immediately followed by spec
+			// Previous implementation would update position to positionAfterDeploymentMetadata
+			// before checking shouldInjectDeploymentLabels
 			content := `apiVersion: apps/v1
 kind: Deployment
 metadata:
-  annotations: {existing: value}
+  name: test-project-controller-manager
   labels:
     control-plane: controller-manager
-  name: test-project-controller-manager
 spec:
+  replicas: 1
   template:
     metadata:
-      annotations: {kubectl.kubernetes.io/default-container: manager}
       labels:
         control-plane: controller-manager`
 
 			result := templater.ApplyHelmSubstitutions(content, deployment)
 
-			// Should inject custom annotations (with automatic filtering)
+			// Should inject custom labels template
 			Expect(result).To(Or(
-				ContainSubstring("{{- with .Values.manager.annotations }}"),
-				ContainSubstring("{{- if .Values.manager.annotations }}"),
+				ContainSubstring("{{- with .Values.manager.labels }}"),
+				ContainSubstring("{{- if .Values.manager.labels }}"),
 			))
-			// Verify annotations are properly nested under metadata:
-			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
-				"manager.annotations should be nested under Deployment metadata")
-			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
-				"annotations should not be emitted as a top-level block before spec")
 
-			// Should inject pod annotations (with automatic filtering)
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.pod }}"))
-			Expect(result).To(ContainSubstring("{{- with .annotations }}"))
+			// Verify labels come after existing label
+			labelsPattern := regexp.MustCompile(`{{- (?:with|if) \.Values\.manager\.labels`)
+			labelsMatch := labelsPattern.FindStringIndex(result)
+			existingLabelIdx := strings.Index(result, "control-plane: controller-manager")
+			if labelsMatch != nil {
+				Expect(labelsMatch[0]).To(BeNumerically(">", existingLabelIdx),
+					"Custom labels should be injected after existing labels")
+			}
+		})
+	})
 
-			// Should NOT create duplicate annotations: key
-			annotationsCount := strings.Count(result, "annotations:")
-			Expect(annotationsCount).To(Equal(2),
-				"Should have exactly 2 annotations blocks (1 for Deployment, 1 for Pod template)")
+	Context("common labels", func() {
+		It("should merge commonLabels into every labels block of a workload but not its selector", func() {
+			daemonSet := &unstructured.Unstructured{}
+			daemonSet.SetAPIVersion("apps/v1")
+			daemonSet.SetKind("DaemonSet")
+			daemonSet.SetName("test-project-agent")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  labels:
+    control-plane: agent
+  name: test-project-agent
+  namespace: test-project-system
+spec:
+  selector:
+    matchLabels:
+      control-plane: agent
+  template:
+    metadata:
+      labels:
+        control-plane: agent
+    spec:
+      containers:
+      - name: agent
+        image: agent:latest
+`, daemonSet)
+
+			Expect(strings.Count(result, "{{- with .Values.commonLabels }}")).To(Equal(2))
+			Expect(result).To(ContainSubstring(`        control-plane: agent
+        {{- with .Values.commonLabels }}
+        {{- with omit . "helm.sh/chart" "app.kubernetes.io/version" "app.kubernetes.io/instance" ` +
+				`"app.kubernetes.io/managed-by" "control-plane" }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        {{- end }}
+    spec:
+`))
+			Expect(result).To(ContainSubstring("    matchLabels:\n      control-plane: agent\n  template:\n"))
+			Expect(templater.ApplyHelmSubstitutions(result, daemonSet)).To(Equal(result))
 		})
 
-		It("should convert flow-style annotations to block-style before injecting templates", func() {
+		It("should render commonLabels on a Service without overriding the chart labels", func() {
+			service := &unstructured.Unstructured{}
+			service.SetAPIVersion("v1")
+			service.SetKind("Service")
+			service.SetName("test-project-extra-service")
+
+			templated := templater.ApplyHelmSubstitutions(`apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-extra-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 80
+`, service)
+
+			rendered, err := renderChart(map[string]string{
+				"templates/_helpers.tpl": templater.GenerateHelpers(),
+				"templates/service.yaml": templated,
+			}, map[string]any{"commonLabels": map[string]any{
+				"example.com/team":             "platform",
+				"app.kubernetes.io/managed-by": "someone-else",
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered["templates/service.yaml"]).To(ContainSubstring("    example.com/team: platform\n"))
+			Expect(rendered["templates/service.yaml"]).To(ContainSubstring("    app.kubernetes.io/managed-by: Helm\n"))
+			Expect(rendered["templates/service.yaml"]).NotTo(ContainSubstring("someone-else"))
+		})
+
+		It("should merge commonLabels into the labels of a ClusterRole", func() {
+			clusterRole := &unstructured.Unstructured{}
+			clusterRole.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			clusterRole.SetKind("ClusterRole")
+			clusterRole.SetName("test-project-metrics-reader")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-project-metrics-reader
+rules:
+- nonResourceURLs:
+  - /metrics
+  verbs:
+  - get
+`, clusterRole)
+
+			Expect(result).To(ContainSubstring(`  labels:
+    {{- include "test-project.labels" . | nindent 4 }}
+    {{- with .Values.commonLabels }}
+`))
+			Expect(strings.Count(result, ".Values.commonLabels")).To(Equal(1))
+			Expect(templater.ApplyHelmSubstitutions(result, clusterRole)).To(Equal(result))
+		})
+
+		It("should merge commonLabels past the namespace guard of RBAC resources", func() {
+			resources := map[string]string{
+				"ClusterRole": `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+  name: test-project-manager-role
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  verbs:
+  - get
+`,
+				"ClusterRoleBinding": `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+  name: test-project-manager-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: test-project-manager-role
+subjects:
+- kind: ServiceAccount
+  name: test-project-controller-manager
+  namespace: test-project-system
+`,
+			}
+
+			for kind, content := range resources {
+				resource := &unstructured.Unstructured{}
+				resource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+				resource.SetKind(kind)
+				resource.SetName(map[string]string{
+					"ClusterRole":        "test-project-manager-role",
+					"ClusterRoleBinding": "test-project-manager-rolebinding",
+				}[kind])
+
+				result := templater.ApplyHelmSubstitutions(content, resource)
+				Expect(result).To(ContainSubstring("{{- if .Values.rbac.namespaced }}\n  namespace:"), kind)
+				Expect(strings.Count(result, "\n  labels:")).To(Equal(1), kind)
+				Expect(strings.Count(result, ".Values.commonLabels")).To(Equal(1), kind)
+				Expect(templater.ApplyHelmSubstitutions(result, resource)).To(Equal(result), kind)
+
+				for _, namespaced := range []bool{false, true} {
+					rendered, err := renderChart(map[string]string{
+						"templates/_helpers.tpl": templater.GenerateHelpers(),
+						"templates/rbac.yaml":    result,
+					}, map[string]any{
+						"rbac":              map[string]any{"namespaced": namespaced},
+						"serviceAccount":    map[string]any{"enabled": true},
+						"commonLabels":      map[string]any{"team": "platform"},
+						"commonAnnotations": map[string]any{"owner": "sre"},
+					})
+					Expect(err).NotTo(HaveOccurred(), kind)
+					var parsed map[string]any
+					Expect(yaml.Unmarshal([]byte(rendered["templates/rbac.yaml"]), &parsed)).To(Succeed())
+					labels, _, _ := unstructured.NestedStringMap(parsed, "metadata", "labels")
+					Expect(labels).To(HaveKeyWithValue("team", "platform"), kind)
+					Expect(labels).To(HaveKeyWithValue("app.kubernetes.io/name", "test-project"), kind)
+					annotations, _, _ := unstructured.NestedStringMap(parsed, "metadata", "annotations")
+					Expect(annotations).To(HaveKeyWithValue("owner", "sre"), kind)
+				}
+			}
+		})
+	})
+
+	Context("common annotations", func() {
+		commonAnnotations := map[string]any{"commonAnnotations": map[string]any{"team": "platform"}}
+		// annotationsBlock returns the top-level metadata annotations of a templated resource.
+		annotationsBlock := func(result, next string) string {
+			start := strings.Index(result, "\n  annotations:")
+			if guard := strings.LastIndex(result[:start], "\n  {{-"); guard > strings.Index(result, "metadata:") {
+				start = guard
+			}
+			return result[start:strings.Index(result, next)]
+		}
+
+		It("should add a guarded annotations block to a Service without annotations", func() {
+			service := &unstructured.Unstructured{}
+			service.SetAPIVersion("v1")
+			service.SetKind("Service")
+			service.SetName("test-project-extra-service")
+
+			result := templater.ApplyHelmSubstitutions(`apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-extra-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 80
+`, service)
+
+			Expect(result).To(ContainSubstring(`  namespace: {{ .Release.Namespace }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+`))
+			Expect(templater.ApplyHelmSubstitutions(result, service)).To(Equal(result))
+
+			rendered, err := renderTemplate(annotationsBlock(result, "spec:"), commonAnnotations)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal("\n  annotations:\n    team: platform\n"))
+		})
+
+		It("should merge commonAnnotations into the manager Deployment annotations only", func() {
 			deployment := &unstructured.Unstructured{}
 			deployment.SetAPIVersion("apps/v1")
 			deployment.SetKind("Deployment")
 			deployment.SetName("test-project-controller-manager")
 
-			// Deployment with flow-style annotations (inline format)
-			content := `apiVersion: apps/v1
+			result := templater.ApplyHelmSubstitutions(`apiVersion: apps/v1
 kind: Deployment
 metadata:
-  annotations: {existing: value, another: test}
+  annotations:
+    example.com/owner: operators
   labels:
     control-plane: controller-manager
   name: test-project-controller-manager
+  namespace: test-project-system
 spec:
+  selector:
+    matchLabels:
+      control-plane: controller-manager
   template:
     metadata:
-      annotations: {kubectl.kubernetes.io/default-container: manager, other: value}
+      annotations:
+        kubectl.kubernetes.io/default-container: manager
       labels:
-        control-plane: controller-manager`
+        control-plane: controller-manager
+    spec:
+      containers:
+      - name: manager
+        image: controller:latest
+`, deployment)
+
+			Expect(strings.Count(result, ".Values.commonAnnotations")).To(Equal(1))
+			Expect(result).To(ContainSubstring(`    {{- with .Values.commonAnnotations }}
+    {{- with omit . "example.com/owner" }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- end }}
+  labels:
+`))
+			Expect(templater.ApplyHelmSubstitutions(result, deployment)).To(Equal(result))
+
+			rendered, err := renderTemplate(annotationsBlock(result, "  labels:"), map[string]any{
+				"manager": map[string]any{},
+				"commonAnnotations": map[string]any{
+					"example.com/owner": "ignored",
+					"team":              "platform",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal("\n  annotations:\n    example.com/owner: operators\n    team: platform\n"))
+		})
 
-			result := templater.ApplyHelmSubstitutions(content, deployment)
+		It("should merge commonAnnotations into CRD annotations without repeating controller-gen's", func() {
+			crd := &unstructured.Unstructured{}
+			crd.SetAPIVersion("apiextensions.k8s.io/v1")
+			crd.SetKind("CustomResourceDefinition")
+			crd.SetName("widgets.example.com")
 
-			// Flow-style should be converted to block-style
-			Expect(result).NotTo(ContainSubstring("annotations: {"))
+			result := templater.ApplyHelmSubstitutions(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v0.18.0
+  name: widgets.example.com
+spec:
+  group: example.com
+`, crd)
 
-			// Should have block-style annotations with existing entries preserved
-			Expect(result).To(ContainSubstring("existing: value"))
-			Expect(result).To(ContainSubstring("another: test"))
+			Expect(result).To(ContainSubstring(`    {{- with .Values.commonAnnotations }}
+    {{- with omit . "controller-gen.kubebuilder.io/version" }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- end }}
+`))
+			Expect(templater.ApplyHelmSubstitutions(result, crd)).To(Equal(result))
+		})
+	})
 
-			// Should inject custom annotations template blocks (with automatic filtering)
-			Expect(result).To(Or(
-				ContainSubstring("{{- with .Values.manager.annotations }}"),
-				ContainSubstring("{{- if .Values.manager.annotations }}"),
-			))
+	Context("revision annotation", func() {
+		var revisioned *Templater
+		service := &unstructured.Unstructured{}
+		service.SetAPIVersion("v1")
+		service.SetKind("Service")
+		service.SetName("test-project-extra-service")
+		content := `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-extra-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 80
+`
 
-			// Verify the structure is valid (annotations: key followed by indented content)
-			lines := strings.Split(result, "\n")
-			foundDeploymentAnnotations := false
-			for i, line := range lines {
-				if strings.TrimSpace(line) == "annotations:" && !foundDeploymentAnnotations {
-					foundDeploymentAnnotations = true
-					// Next lines should be indented (existing values or templates)
-					if i+1 < len(lines) {
-						nextLine := lines[i+1]
-						Expect(nextLine).To(MatchRegexp(`^\s{2,}`), // At least 2 spaces of indentation
-							"Content after annotations: should be indented")
-					}
-				}
-			}
-			Expect(foundDeploymentAnnotations).To(BeTrue(), "Should have Deployment annotations block")
+		BeforeEach(func() {
+			revisioned = NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{RevisionAnnotation: true})
+		})
+
+		// annotations renders the templated Service and returns its metadata annotations.
+		annotations := func(result string, values map[string]any) map[string]any {
+			GinkgoHelper()
+			rendered, err := renderTemplateWithHelpers(revisioned.GenerateHelpers(), result, values)
+			Expect(err).NotTo(HaveOccurred())
+			object := map[string]any{}
+			Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+			found, _, err := unstructured.NestedMap(object, "metadata", "annotations")
+			Expect(err).NotTo(HaveOccurred())
+			return found
+		}
+
+		It("should only add the annotation with the RevisionAnnotation option", func() {
+			Expect(templater.ApplyHelmSubstitutions(content, service)).NotTo(ContainSubstring("revisionAnnotations"))
+
+			result := revisioned.ApplyHelmSubstitutions(content, service)
+			Expect(result).To(ContainSubstring(`  namespace: {{ .Release.Namespace }}
+  {{- if or .Values.commonAnnotations (include "test-project.revisionAnnotations" . | fromYaml) }}
+  annotations:
+    {{- with .Values.commonAnnotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- with (include "test-project.revisionAnnotations" . | fromYaml) }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- end }}
+spec:
+`))
+			Expect(revisioned.ApplyHelmSubstitutions(result, service)).To(Equal(result))
 		})
 
-		It("should convert flow-style annotations without space to block-style", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+		It("should render the annotation only when revision is set", func() {
+			result := revisioned.ApplyHelmSubstitutions(content, service)
 
-			// Test YAML variant without space: annotations:{...} (also valid YAML)
-			content := `apiVersion: apps/v1
+			Expect(annotations(result, map[string]any{})).To(BeNil())
+			Expect(annotations(result, map[string]any{"revision": "a1b2c3d"})).To(Equal(map[string]any{
+				"app.kubernetes.io/revision": "a1b2c3d",
+			}))
+			Expect(annotations(result, map[string]any{"revision": 42})).To(Equal(map[string]any{
+				"app.kubernetes.io/revision": "42",
+			}))
+			Expect(annotations(result, map[string]any{
+				"revision":          "a1b2c3d",
+				"commonAnnotations": map[string]any{"team": "platform", "app.kubernetes.io/revision": "pinned"},
+			})).To(Equal(map[string]any{"team": "platform", "app.kubernetes.io/revision": "pinned"}))
+		})
+
+		It("should merge the annotation into a block merging several values", func() {
+			metricsService := &unstructured.Unstructured{}
+			metricsService.SetAPIVersion("v1")
+			metricsService.SetKind("Service")
+			metricsService.SetName("test-project-controller-manager-metrics-service")
+
+			result := revisioned.ApplyHelmSubstitutions(`apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-controller-manager-metrics-service
+  namespace: test-project-system
+spec:
+  ports:
+  - name: https
+    port: 8443
+    targetPort: 8443
+`, metricsService)
+
+			Expect(result).To(ContainSubstring(`  {{- if or ((.Values.metrics.service | default dict).annotations) ` +
+				`.Values.commonAnnotations (include "test-project.revisionAnnotations" . | fromYaml) }}
+  annotations:
+`))
+			Expect(revisioned.ApplyHelmSubstitutions(result, metricsService)).To(Equal(result))
+
+			Expect(annotations(result, map[string]any{
+				"metrics": map[string]any{
+					"enabled": true, "port": 8443,
+					"service": map[string]any{"annotations": map[string]any{"example.com/scrape": "true"}},
+				},
+				"revision": "a1b2c3d",
+			})).To(Equal(map[string]any{"example.com/scrape": "true", "app.kubernetes.io/revision": "a1b2c3d"}))
+		})
+	})
+
+	Context("provenance annotations", func() {
+		var tracked *Templater
+
+		BeforeEach(func() {
+			tracked = NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{ProvenanceAnnotations: true})
+		})
+
+		resource := func(apiVersion, kind, name string) *unstructured.Unstructured {
+			r := &unstructured.Unstructured{}
+			r.SetAPIVersion(apiVersion)
+			r.SetKind(kind)
+			r.SetName(name)
+			return r
+		}
+		kinds := []struct {
+			resource *unstructured.Unstructured
+			content  string
+		}{
+			{resource("v1", "Service", "test-project-extra-service"), `apiVersion: v1
+kind: Service
+metadata:
+  name: test-project-extra-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 80
+`},
+			{resource("v1", "ConfigMap", "test-project-settings"), `apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    example.com/owner: platform
+  name: test-project-settings
+  namespace: test-project-system
+data:
+  key: value
+`},
+			{resource("apps/v1", "Deployment", "test-project-controller-manager"), `apiVersion: apps/v1
 kind: Deployment
 metadata:
-  annotations:{existing: value, another: test}
-  labels:
-    control-plane: controller-manager
   name: test-project-controller-manager
+  namespace: test-project-system
 spec:
   template:
-    metadata:
-      annotations:{kubectl.kubernetes.io/default-container: manager}
-      labels:
-        control-plane: controller-manager`
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager
+`},
+		}
 
-			result := templater.ApplyHelmSubstitutions(content, deployment)
+		// annotations renders the templated resource and returns its metadata annotations.
+		annotations := func(result string, values map[string]any) map[string]any {
+			GinkgoHelper()
+			values["manager"] = map[string]any{"image": map[string]any{"repository": "controller"}}
+			values["rbac"] = map[string]any{}
+			rendered, err := renderTemplateWithHelpers(tracked.GenerateHelpers(), result, values)
+			Expect(err).NotTo(HaveOccurred())
+			object := map[string]any{}
+			Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+			found, _, err := unstructured.NestedMap(object, "metadata", "annotations")
+			Expect(err).NotTo(HaveOccurred())
+			return found
+		}
 
-			// Flow-style should be converted to block-style (both with and without space)
-			Expect(result).NotTo(ContainSubstring("annotations: {"))
-			Expect(result).NotTo(ContainSubstring("annotations:{"))
+		It("should only add the annotations with the ProvenanceAnnotations option", func() {
+			for _, kind := range kinds {
+				Expect(templater.ApplyHelmSubstitutions(kind.content, kind.resource)).NotTo(
+					ContainSubstring("provenanceAnnotations"))
 
-			// Should have block-style annotations with existing entries preserved
-			Expect(result).To(ContainSubstring("existing: value"))
-			Expect(result).To(ContainSubstring("another: test"))
+				result := tracked.ApplyHelmSubstitutions(kind.content, kind.resource)
+				Expect(result).To(ContainSubstring(`(include "test-project.provenanceAnnotations" . | fromYaml)`))
+				Expect(tracked.ApplyHelmSubstitutions(result, kind.resource)).To(Equal(result))
+			}
+		})
 
-			// Should inject custom annotations template blocks
-			Expect(result).To(Or(
-				ContainSubstring("{{- with .Values.manager.annotations }}"),
-				ContainSubstring("{{- if .Values.manager.annotations }}"),
-			))
+		It("should annotate every kind with part-of and the chart", func() {
+			for _, kind := range kinds {
+				result := tracked.ApplyHelmSubstitutions(kind.content, kind.resource)
+
+				Expect(annotations(result, map[string]any{})).To(And(
+					HaveKeyWithValue("app.kubernetes.io/part-of", "test-project"),
+					HaveKeyWithValue("helm.sh/chart", "test-project-0.1.0"),
+				), kind.resource.GetKind())
+				Expect(annotations(result, map[string]any{"partOf": "platform-catalog"})).To(
+					HaveKeyWithValue("app.kubernetes.io/part-of", "platform-catalog"), kind.resource.GetKind())
+			}
+
+			configMap := tracked.ApplyHelmSubstitutions(kinds[1].content, kinds[1].resource)
+			Expect(annotations(configMap, map[string]any{})).To(HaveKeyWithValue("example.com/owner", "platform"))
 		})
 
-		It("should handle annotations as last field before spec in Deployment metadata", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+		It("should let commonAnnotations override the provenance annotations", func() {
+			result := tracked.ApplyHelmSubstitutions(kinds[0].content, kinds[0].resource)
 
-			// Deployment with annotations as LAST field in metadata (before spec)
-			// This tests the edge case where there's no subsequent field to trigger injection
-			content := `apiVersion: apps/v1
-kind: Deployment
+			Expect(annotations(result, map[string]any{
+				"commonAnnotations": map[string]any{"helm.sh/chart": "pinned"},
+			})).To(Equal(map[string]any{"helm.sh/chart": "pinned", "app.kubernetes.io/part-of": "test-project"}))
+		})
+	})
+
+	Context("release selector labels", func() {
+		var scoped *Templater
+		service := &unstructured.Unstructured{}
+		service.SetAPIVersion("v1")
+		service.SetKind("Service")
+		service.SetName("test-project-webhook-service")
+		serviceContent := `apiVersion: v1
+kind: Service
 metadata:
   labels:
+    app.kubernetes.io/name: test-project
+  name: test-project-webhook-service
+  namespace: test-project-system
+spec:
+  ports:
+  - port: 443
+    targetPort: 9443
+  selector:
+    app.kubernetes.io/name: test-project
     control-plane: controller-manager
-  annotations:
-    existing-annotation: value1
-    another-annotation: value2
+`
+		deployment := &unstructured.Unstructured{}
+		deployment.SetAPIVersion("apps/v1")
+		deployment.SetKind("Deployment")
+		deployment.SetName("test-project-controller-manager")
+		Expect(unstructured.SetNestedStringMap(deployment.Object, map[string]string{
+			"app.kubernetes.io/name": "test-project",
+			"control-plane":          "controller-manager",
+		}, "spec", "selector", "matchLabels")).To(Succeed())
+		deploymentContent := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system
 spec:
-  replicas: 1
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: test-project
+      control-plane: controller-manager
   template:
     metadata:
       labels:
-        control-plane: controller-manager`
+        app.kubernetes.io/name: test-project
+        control-plane: controller-manager
+    spec:
+      containers:
+      - image: controller:latest
+        name: manager
+`
 
-			result := templater.ApplyHelmSubstitutions(content, deployment)
+		BeforeEach(func() {
+			scoped = NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil,
+				Options{ReleaseSelectorLabels: true})
+		})
 
-			// Should inject custom annotations after existing ones (with filtering)
-			Expect(result).To(Or(
-				ContainSubstring("{{- with .Values.manager.annotations }}"),
-				ContainSubstring("{{- if .Values.manager.annotations }}"),
-			))
-			// Verify annotations are properly nested under metadata:
-			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
-				"manager.annotations should be nested under Deployment metadata")
-			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
-				"annotations should not be emitted as a top-level block before spec")
-			Expect(result).To(ContainSubstring("existing-annotation: value1"))
-			Expect(result).To(ContainSubstring("another-annotation: value2"))
+		// render renders the templated resource and returns the string map found at path.
+		render := func(result string, path ...string) map[string]string {
+			GinkgoHelper()
+			rendered, err := renderTemplate(result, map[string]any{
+				"manager": map[string]any{"image": map[string]any{"repository": "controller"}},
+				"rbac":    map[string]any{},
+				"webhook": map[string]any{"enabled": true, "port": 9443},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			object := map[string]any{}
+			Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+			found, _, err := unstructured.NestedStringMap(object, path...)
+			Expect(err).NotTo(HaveOccurred())
+			return found
+		}
 
-			// Verify injection order: custom annotations should come after existing ones
-			// Find the manager annotations block (either pattern)
-			annotationsPattern := regexp.MustCompile(`{{- (?:with|if) \.Values\.manager\.annotations`)
-			annotationsMatch := annotationsPattern.FindStringIndex(result)
-			existingAnnotation1Idx := strings.Index(result, "existing-annotation: value1")
-			existingAnnotation2Idx := strings.Index(result, "another-annotation: value2")
-			if annotationsMatch != nil {
-				Expect(annotationsMatch[0]).To(BeNumerically(">", existingAnnotation1Idx),
-					"Custom annotations should be injected after first existing annotation")
-				Expect(annotationsMatch[0]).To(BeNumerically(">", existingAnnotation2Idx),
-					"Custom annotations should be injected after last existing annotation")
+		It("should only change Service selectors with the ReleaseSelectorLabels option", func() {
+			Expect(templater.ApplyHelmSubstitutions(serviceContent, service)).NotTo(ContainSubstring("selectorLabels"))
+
+			result := scoped.ApplyHelmSubstitutions(serviceContent, service)
+			Expect(result).To(ContainSubstring(`  selector:
+    {{- include "test-project.selectorLabels" . | nindent 4 }}
+    control-plane: controller-manager
+`))
+			Expect(scoped.ApplyHelmSubstitutions(result, service)).To(Equal(result))
+		})
+
+		It("should select the pods of the release by its instance", func() {
+			selector := render(scoped.ApplyHelmSubstitutions(serviceContent, service), "spec", "selector")
+			Expect(selector).To(Equal(map[string]string{
+				"app.kubernetes.io/name":     "test-project",
+				"app.kubernetes.io/instance": "my-release",
+				"control-plane":              "controller-manager",
+			}))
+
+			By("labelling the pods with the instance while the Deployment selector stays as it was")
+			result := scoped.ApplyHelmSubstitutions(deploymentContent, deployment)
+			podLabels := render(result, "spec", "template", "metadata", "labels")
+			for key, value := range selector {
+				Expect(podLabels).To(HaveKeyWithValue(key, value))
 			}
+			Expect(render(result, "spec", "selector", "matchLabels")).To(Equal(map[string]string{
+				"app.kubernetes.io/name": "test-project",
+				"control-plane":          "controller-manager",
+			}))
 		})
+	})
 
-		It("should handle annotations with varying indentation from kustomize", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+	Context("CRD conversion webhook", func() {
+		var crd *unstructured.Unstructured
 
-			// Test with 4-space indentation (some kustomize configurations)
-			content := `apiVersion: apps/v1
-kind: Deployment
+		const conversionCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
 metadata:
-    labels:
-        control-plane: controller-manager
-    annotations:
-        existing-annotation: value1
-    name: test-project-controller-manager
+  name: cronjobs.batch.example.com
 spec:
-    template:
-        metadata:
-            annotations:
-                kubectl.kubernetes.io/default-container: manager
-            labels:
-                control-plane: controller-manager`
-
-			result := templater.ApplyHelmSubstitutions(content, deployment)
-
-			// Should handle different indentation correctly (with automatic filtering)
-			Expect(result).To(Or(
-				ContainSubstring("{{- with .Values.manager.annotations }}"),
-				ContainSubstring("{{- if .Values.manager.annotations }}"),
-			))
-			// Verify annotations are properly nested under metadata:
-			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
-				"manager.annotations should be nested under Deployment metadata")
-			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
-				"annotations should not be emitted as a top-level block before spec")
-			Expect(result).To(ContainSubstring("{{- with .Values.manager.pod }}"))
-
-			// Verify custom annotations come after existing ones
-			deploymentAnnotationsPattern := regexp.MustCompile(`{{- (?:with|if) \.Values\.manager\.annotations`)
-			deploymentAnnotationsMatch := deploymentAnnotationsPattern.FindStringIndex(result)
-			deploymentExistingIdx := strings.Index(result, "existing-annotation: value1")
-			if deploymentAnnotationsMatch != nil {
-				Expect(deploymentAnnotationsMatch[0]).To(BeNumerically(">", deploymentExistingIdx))
-			}
+  conversion:
+    strategy: Webhook
+    webhook:
+      clientConfig:
+        service:
+          name: test-project-webhook-service
+          namespace: test-project-system
+          path: /convert
+      conversionReviewVersions:
+      - v1
+  group: batch.example.com
+`
 
-			podCustomIdx := strings.LastIndex(result, "{{- with .Values.manager.pod }}")
-			podDefaultIdx := strings.Index(result, "kubectl.kubernetes.io/default-container: manager")
-			Expect(podCustomIdx).To(BeNumerically(">", podDefaultIdx))
+		BeforeEach(func() {
+			crd = &unstructured.Unstructured{}
+			crd.SetAPIVersion("apiextensions.k8s.io/v1")
+			crd.SetKind("CustomResourceDefinition")
+			crd.SetName("cronjobs.batch.example.com")
 		})
 
-		It("should add deployment annotations when name appears before labels", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+		conversion := func(templated string, webhookEnabled bool) map[string]any {
+			GinkgoHelper()
+			rendered, err := renderTemplate(templated, map[string]any{
+				"crd":     map[string]any{"enabled": true},
+				"webhook": map[string]any{"enabled": webhookEnabled},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			object := map[string]any{}
+			Expect(yaml.Unmarshal([]byte(rendered), &object)).To(Succeed())
+			found, _, err := unstructured.NestedMap(object, "spec", "conversion")
+			Expect(err).NotTo(HaveOccurred())
+			return found
+		}
 
-			// Edge case: metadata.name comes before metadata.labels
-			// Previous implementation relied on name: to trigger annotations injection
-			content := `apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: test-project-controller-manager
-  labels:
-    control-plane: controller-manager
-spec:
-  template:
-    metadata:
-      labels:
-        control-plane: controller-manager`
+		It("should only render the conversion webhook when webhooks are enabled", func() {
+			result := templater.ApplyHelmSubstitutions(conversionCRD, crd)
 
-			result := templater.ApplyHelmSubstitutions(content, deployment)
+			Expect(result).To(ContainSubstring(`  conversion:
+    strategy: {{ if .Values.webhook.enabled }}Webhook{{ else }}None{{ end }}
+    {{- if .Values.webhook.enabled }}
+    webhook:
+      clientConfig:
+`))
+			Expect(result).To(ContainSubstring("      conversionReviewVersions:\n      - v1\n    {{- end }}\n  group:"))
+			Expect(templater.ApplyHelmSubstitutions(result, crd)).To(Equal(result))
+			Expect(templater.Validate(result)).To(Succeed())
 
-			// Should still inject annotations template even when no annotations field exists
-			// and name appears before labels
-			Expect(result).To(Or(
-				ContainSubstring("{{- with .Values.manager.annotations }}"),
-				ContainSubstring("{{- if .Values.manager.annotations }}"),
-			))
-			// Verify annotations are properly nested under metadata:
-			Expect(regexp.MustCompile(`(?m)^metadata:\n(?:^[ ]{2}.*\n)*^[ ]{2}annotations:\n`).MatchString(result)).To(BeTrue(),
-				"manager.annotations should be nested under Deployment metadata")
-			Expect(regexp.MustCompile(`(?ms)^annotations:\n.*?^spec:`).MatchString(result)).To(BeFalse(),
-				"annotations should not be emitted as a top-level block before spec")
-			Expect(result).To(ContainSubstring("annotations:"))
-		})
+			enabled := conversion(result, true)
+			Expect(enabled).To(HaveKeyWithValue("strategy", "Webhook"))
+			service, _, err := unstructured.NestedMap(enabled, "webhook", "clientConfig", "service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(service).To(HaveKeyWithValue("name", "my-release-test-project-webhook-service"))
+			Expect(service).To(HaveKeyWithValue("namespace", "my-namespace"))
 
-		It("should inject deployment labels when labels is the last metadata field before spec", func() {
-			deployment := &unstructured.Unstructured{}
-			deployment.SetAPIVersion("apps/v1")
-			deployment.SetKind("Deployment")
-			deployment.SetName("test-project-controller-manager")
+			Expect(conversion(result, false)).To(Equal(map[string]any{"strategy": "None"}))
+		})
 
-			// Edge case: labels is the last field in metadata, immediately followed by spec
-			// Previous implementation would update position to positionAfterDeploymentMetadata
-			// before checking shouldInjectDeploymentLabels
-			content := `apiVersion: apps/v1
-kind: Deployment
+		It("should leave a CRD without a conversion webhook unchanged", func() {
+			result := templater.ApplyHelmSubstitutions(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
 metadata:
-  name: test-project-controller-manager
-  labels:
-    control-plane: controller-manager
+  name: cronjobs.batch.example.com
 spec:
-  replicas: 1
-  template:
-    metadata:
-      labels:
-        control-plane: controller-manager`
-
-			result := templater.ApplyHelmSubstitutions(content, deployment)
-
-			// Should inject custom labels template
-			Expect(result).To(Or(
-				ContainSubstring("{{- with .Values.manager.labels }}"),
-				ContainSubstring("{{- if .Values.manager.labels }}"),
-			))
+  conversion:
+    strategy: None
+  group: batch.example.com
+`, crd)
 
-			// Verify labels come after existing label
-			labelsPattern := regexp.MustCompile(`{{- (?:with|if) \.Values\.manager\.labels`)
-			labelsMatch := labelsPattern.FindStringIndex(result)
-			existingLabelIdx := strings.Index(result, "control-plane: controller-manager")
-			if labelsMatch != nil {
-				Expect(labelsMatch[0]).To(BeNumerically(">", existingLabelIdx),
-					"Custom labels should be injected after existing labels")
-			}
+			Expect(result).To(ContainSubstring("  conversion:\n    strategy: None\n  group: batch.example.com\n"))
+			Expect(result).NotTo(ContainSubstring(".Values.webhook.enabled"))
 		})
 	})
 
@@ -3975,7 +10117,7 @@ rules:
 			Expect(result).To(ContainSubstring("kind: Role"))
 			Expect(result).To(ContainSubstring("{{- else }}"))
 			Expect(result).To(ContainSubstring("kind: ClusterRole"))
-			Expect(result).To(ContainSubstring("namespace: {{ .Release.Namespace }}"))
+			Expect(result).To(ContainSubstring("namespace: {{ .Values.rbac.watchNamespace | default .Release.Namespace }}"))
 		})
 
 		It("should add conditional kind for ClusterRoleBinding to support namespace-scoped deployment", func() {
@@ -4103,6 +10245,51 @@ rules:
 			Expect(result).NotTo(ContainSubstring("kind: Role"))
 		})
 
+		It("should toggle the metrics-reader ClusterRole with metrics.reader.enabled", func() {
+			readerResource := &unstructured.Unstructured{}
+			readerResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
+			readerResource.SetKind("ClusterRole")
+			readerResource.SetName("test-project-metrics-reader")
+
+			content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-project-metrics-reader
+rules:
+- nonResourceURLs:
+  - /metrics
+  verbs:
+  - get`
+
+			result := templater.ApplyHelmSubstitutions(content, readerResource)
+
+			Expect(result).To(HavePrefix("{{- if ternary (.Values.metrics.reader | default dict).enabled " +
+				"(and .Values.metrics.enabled .Values.metrics.secure) " +
+				"(hasKey (.Values.metrics.reader | default dict) \"enabled\") }}\n"))
+			Expect(result).NotTo(ContainSubstring("{{- if and .Values.metrics.enabled .Values.metrics.secure }}"))
+			Expect(strings.Count(result, "kind: ClusterRole")).To(Equal(1))
+
+			rendersRole := func(metrics map[string]any) bool {
+				GinkgoHelper()
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl":        templater.GenerateHelpers(),
+					"templates/metrics-reader.yaml": result,
+				}, map[string]any{"metrics": metrics})
+				Expect(err).NotTo(HaveOccurred())
+				return strings.Contains(rendered["templates/metrics-reader.yaml"], "kind: ClusterRole")
+			}
+			By("following secure metrics when metrics.reader.enabled is unset")
+			Expect(rendersRole(map[string]any{"enabled": true, "secure": true})).To(BeTrue())
+			Expect(rendersRole(map[string]any{"enabled": false, "secure": true})).To(BeFalse())
+			By("using metrics.reader.enabled when it is set")
+			Expect(rendersRole(map[string]any{
+				"enabled": true, "secure": true, "reader": map[string]any{"enabled": false},
+			})).To(BeFalse())
+			Expect(rendersRole(map[string]any{
+				"enabled": false, "secure": true, "reader": map[string]any{"enabled": true},
+			})).To(BeTrue())
+		})
+
 		It("should NOT add any conditionals to ServiceAccount (always created)", func() {
 			saResource := &unstructured.Unstructured{}
 			saResource.SetAPIVersion("v1")
@@ -4132,7 +10319,7 @@ metadata:
 				testManagerRoleUsers:          testRoleNamespaceUsers,
 			}
 
-			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces)
+			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces, Options{})
 
 			// Role in infrastructure namespace
 			infraRole := &unstructured.Unstructured{}
@@ -4165,7 +10352,7 @@ rules:
 				testManagerRoleBindingUsers: testRoleNamespaceUsers,
 			}
 
-			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces)
+			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces, Options{})
 
 			// RoleBinding in users namespace
 			usersBinding := &unstructured.Unstructured{}
@@ -4204,7 +10391,7 @@ subjects:
 				"manager-role-monitoring":     "monitoring",
 			}
 
-			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces)
+			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces, Options{})
 
 			// Role in monitoring namespace
 			monitoringRole := &unstructured.Unstructured{}
@@ -4235,7 +10422,7 @@ rules:
 				testManagerRoleName: "app-infrastructure",
 			}
 
-			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces)
+			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces, Options{})
 
 			roleResource := &unstructured.Unstructured{}
 			roleResource.SetAPIVersion("rbac.authorization.k8s.io/v1")
@@ -4265,7 +10452,7 @@ rules:
 				testManagerRoleName: testRoleNamespaceInfrastructure,
 			}
 
-			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces)
+			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces, Options{})
 
 			configMap := &unstructured.Unstructured{}
 			configMap.SetAPIVersion("v1")
@@ -4290,7 +10477,7 @@ data:
 				testManagerRoleName: testRoleNamespaceInfrastructure,
 			}
 
-			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces)
+			multiNsTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, roleNamespaces, Options{})
 
 			// Role that has a reference to a resource in its namespace
 			role := &unstructured.Unstructured{}
@@ -4319,7 +10506,7 @@ rules: []`
 	Context("ServiceAccount configuration", func() {
 		Context("when managing ServiceAccount creation via values.yaml", func() {
 			It("allows toggling ServiceAccount installation with serviceAccount.enabled flag", func() {
-				saTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil)
+				saTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
 
 				serviceAccount := &unstructured.Unstructured{}
 				serviceAccount.SetAPIVersion("v1")
@@ -4342,7 +10529,7 @@ metadata:
 			})
 
 			It("supports custom annotations for cloud provider integrations", func() {
-				saTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil)
+				saTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
 
 				serviceAccount := &unstructured.Unstructured{}
 				serviceAccount.SetAPIVersion("v1")
@@ -4364,7 +10551,7 @@ metadata:
 			})
 
 			It("supports custom labels without duplicating existing standard labels", func() {
-				saTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil)
+				saTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
 
 				serviceAccount := &unstructured.Unstructured{}
 				serviceAccount.SetAPIVersion("v1")
@@ -4391,7 +10578,7 @@ metadata:
 			// carries annotations lists annotations before labels. The generator must merge into
 			// that block instead of emitting a second annotations key.
 			It("merges custom annotations with existing annotations without duplication", func() {
-				saTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil)
+				saTemplater := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
 
 				serviceAccount := &unstructured.Unstructured{}
 				serviceAccount.SetAPIVersion("v1")
@@ -4426,6 +10613,53 @@ metadata:
 			})
 		})
 
+		Context("when hardening the ServiceAccount token mount", func() {
+			render := func(content string, serviceAccountValues map[string]any) string {
+				serviceAccount := &unstructured.Unstructured{}
+				serviceAccount.SetAPIVersion("v1")
+				serviceAccount.SetKind("ServiceAccount")
+				serviceAccount.SetName("controller-manager")
+
+				result := templater.ApplyHelmSubstitutions(content, serviceAccount)
+				Expect(strings.Count(result, ".Values.serviceAccount.automountServiceAccountToken")).To(Equal(2))
+
+				rendered, err := renderChart(map[string]string{
+					"templates/_helpers.tpl":         templater.GenerateHelpers(),
+					"templates/service-account.yaml": result,
+				}, map[string]any{"serviceAccount": serviceAccountValues})
+				Expect(err).NotTo(HaveOccurred())
+				return rendered["templates/service-account.yaml"]
+			}
+
+			It("sets automountServiceAccountToken on the ServiceAccount only when configured", func() {
+				content := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: controller-manager`
+
+				Expect(render(content, map[string]any{"enabled": true})).
+					NotTo(ContainSubstring("automountServiceAccountToken"))
+				Expect(render(content, map[string]any{"enabled": true, "automountServiceAccountToken": false})).
+					To(ContainSubstring("apiVersion: v1\nautomountServiceAccountToken: false\nkind: ServiceAccount\n"))
+				Expect(render(content, map[string]any{"enabled": true, "automountServiceAccountToken": true})).
+					To(ContainSubstring("apiVersion: v1\nautomountServiceAccountToken: true\nkind: ServiceAccount\n"))
+			})
+
+			It("keeps the value from the kustomize output as the fallback", func() {
+				content := `apiVersion: v1
+automountServiceAccountToken: false
+kind: ServiceAccount
+metadata:
+  name: controller-manager`
+
+				Expect(render(content, map[string]any{"enabled": true})).
+					To(ContainSubstring("apiVersion: v1\nautomountServiceAccountToken: false\nkind: ServiceAccount\n"))
+				rendered := render(content, map[string]any{"enabled": true, "automountServiceAccountToken": true})
+				Expect(rendered).To(ContainSubstring("automountServiceAccountToken: true\n"))
+				Expect(rendered).NotTo(ContainSubstring("automountServiceAccountToken: false"))
+			})
+		})
+
 		Context("when using default ServiceAccount with nameOverride/fullnameOverride", func() {
 			It("respects nameOverride and fullnameOverride for default ServiceAccount name", func() {
 				serviceAccount := &unstructured.Unstructured{}
@@ -4574,7 +10808,7 @@ subjects:
 			It("delegates truncation to resourceName helper for 63-character limit compliance", func() {
 				longNameTemplater := NewTemplater("very-long-project-name-that-needs-truncation",
 					"very-long-project-name-that-needs-truncation",
-					"very-long-project-name-that-needs-truncation-system", nil)
+					"very-long-project-name-that-needs-truncation-system", nil, Options{})
 
 				serviceAccount := &unstructured.Unstructured{}
 				serviceAccount.SetAPIVersion("v1")
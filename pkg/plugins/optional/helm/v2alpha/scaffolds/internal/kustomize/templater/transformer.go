@@ -0,0 +1,249 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templater
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/kustomize/templater/appliers"
+)
+
+// ResourceTransformer is a single substitution step of ApplyHelmSubstitutions. Transformers run in
+// order, each one receiving the YAML produced by the previous one.
+type ResourceTransformer interface {
+	Transform(yamlContent string, resource *unstructured.Unstructured) string
+}
+
+// TransformerFunc adapts an ordinary function to a ResourceTransformer.
+type TransformerFunc func(yamlContent string, resource *unstructured.Unstructured) string
+
+// Transform calls f(yamlContent, resource).
+func (f TransformerFunc) Transform(yamlContent string, resource *unstructured.Unstructured) string {
+	return f(yamlContent, resource)
+}
+
+// namedTransformer is a ResourceTransformer reported under name in the substitution report.
+type namedTransformer struct {
+	name string
+	TransformerFunc
+}
+
+// Name returns the step name used in the substitution report.
+func (n namedTransformer) Name() string {
+	return n.name
+}
+
+func named(name string, transform TransformerFunc) ResourceTransformer {
+	return namedTransformer{name: name, TransformerFunc: transform}
+}
+
+// transformerName returns the report name of a transformer: its Name() when it has one,
+// otherwise its position in the chain.
+func transformerName(transformer ResourceTransformer, index int) string {
+	if n, ok := transformer.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("transformer-%d", index)
+}
+
+// DefaultTransformers returns the built-in substitution steps in their default order.
+// Escaping existing template syntax and collapsing blank lines are not part of the list:
+// ApplyHelmSubstitutions always runs them first and last so every transformer sees the same input.
+func (t *Templater) DefaultTransformers() []ResourceTransformer {
+	return []ResourceTransformer{
+		named("AddConditionalWrappers", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if t.options.KeepNamespace && resource.GetKind() == common.KindNamespace {
+				return appliers.TemplateNamespaceResource(yamlContent)
+			}
+			if t.options.OmitCertManager && isCertManagerResource(resource) {
+				return ""
+			}
+			if t.options.OmitCRDs && resource.GetKind() == common.KindCRD {
+				return ""
+			}
+			return appliers.AddConditionalWrappersClassified(yamlContent, resource, t.classifyRBAC)
+		}),
+		named("SubstituteProjectNames", appliers.SubstituteProjectNames),
+		named("SubstituteNamespace", func(yamlContent string, resource *unstructured.Unstructured) string {
+			return appliers.SubstituteNamespace(
+				t.detectedPrefix, t.chartName, t.managerNamespace, t.roleNamespaces, yamlContent, resource)
+		}),
+		named("TemplateWatchNamespace", appliers.TemplateWatchNamespace),
+		named("SubstituteCertManagerReferences", func(yamlContent string, resource *unstructured.Unstructured) string {
+			return appliers.SubstituteCertManagerReferences(t.detectedPrefix, t.chartName, yamlContent, resource)
+		}),
+		named("SubstituteResourceNamesWithPrefix", func(yamlContent string, resource *unstructured.Unstructured) string {
+			return appliers.SubstituteResourceNamesWithPrefix(t.detectedPrefix, t.chartName, yamlContent, resource)
+		}),
+		named("AddHelmLabelsAndAnnotations", func(yamlContent string, resource *unstructured.Unstructured) string {
+			return appliers.AddHelmLabelsAndAnnotations(
+				t.detectedPrefix, t.chartName, t.managedBy(), yamlContent, resource)
+		}),
+		// Runs after the standard labels are added so namespace.labels cannot override them.
+		named("AddNamespaceLabels", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if !t.options.KeepNamespace || resource.GetKind() != common.KindNamespace {
+				return yamlContent
+			}
+			return appliers.AddNamespaceLabels(yamlContent)
+		}),
+		named("SubstituteRBACValues", func(yamlContent string, _ *unstructured.Unstructured) string {
+			return appliers.SubstituteRBACValues(t.detectedPrefix, t.chartName, yamlContent)
+		}),
+		named("TemplateServiceAccount", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if resource.GetKind() != common.KindServiceAccount {
+				return yamlContent
+			}
+			return appliers.TemplateServiceAccount(t.detectedPrefix, t.chartName, yamlContent)
+		}),
+		// With the flat values layout the manager image is templated first; the workload steps below
+		// leave an image that is already templated alone.
+		named("templateFlatManagerImage", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if !t.options.FlatValues || !appliers.IsPodBearingKind(resource.GetKind()) ||
+				(resource.GetKind() == common.KindDeployment && !appliers.IsManagerDeployment(resource)) {
+				return yamlContent
+			}
+			return appliers.TemplateManagerImageFrom(yamlContent, appliers.FlatImageValuesPath)
+		}),
+		named("templateManagerDeployment", t.templateManagerDeployment),
+		named("TemplateExtraDeployment", func(yamlContent string, resource *unstructured.Unstructured) string {
+			key, isExtra := t.deploymentValuesKeys[resource.GetName()]
+			if resource.GetKind() != common.KindDeployment || !isExtra {
+				return yamlContent
+			}
+			return appliers.TemplateExtraDeployment(yamlContent, key)
+		}),
+		named("templateManagerDaemonSet", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if resource.GetKind() != common.KindDaemonSet {
+				return yamlContent
+			}
+			return appliers.TemplateDaemonSetFieldsRecorded(t.chartName, yamlContent, t.recorder())
+		}),
+		named("templateManagerStatefulSet", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if resource.GetKind() != common.KindStatefulSet {
+				return yamlContent
+			}
+			return appliers.TemplateStatefulSetFieldsRecorded(t.chartName, yamlContent, t.recorder())
+		}),
+		// Other workloads sharing the manager image only get the image templated; env, args and
+		// resources stay specific to the manager Deployment. Extra Deployments are left untouched.
+		named("TemplateManagerImage", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if !appliers.IsPodBearingKind(resource.GetKind()) || resource.GetKind() == common.KindDeployment {
+				return yamlContent
+			}
+			return appliers.TemplateManagerImage(yamlContent)
+		}),
+		named("TemplatePorts", func(yamlContent string, resource *unstructured.Unstructured) string {
+			switch resource.GetKind() {
+			case common.KindService, common.KindDeployment, common.KindNetworkPolicy,
+				common.KindValidatingWebhook, common.KindMutatingWebhook, common.KindCRD:
+				return appliers.TemplatePorts(yamlContent, resource)
+			}
+			return yamlContent
+		}),
+		named("TemplateServiceAnnotations", appliers.TemplateServiceAnnotations),
+		named("TemplateMetricsServiceExposure", appliers.TemplateMetricsServiceExposure),
+		named("TemplateServiceMonitor", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if resource.GetKind() != common.KindServiceMonitor {
+				return yamlContent
+			}
+			return appliers.TemplateServiceMonitor(yamlContent)
+		}),
+		named("TemplateServiceMonitorEndpoints", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if resource.GetKind() != common.KindServiceMonitor {
+				return yamlContent
+			}
+			return appliers.TemplateServiceMonitorEndpoints(yamlContent)
+		}),
+		named("TemplateServiceMonitorNamespaceSelector",
+			func(yamlContent string, resource *unstructured.Unstructured) string {
+				if resource.GetKind() != common.KindServiceMonitor {
+					return yamlContent
+				}
+				return appliers.TemplateServiceMonitorNamespaceSelector(yamlContent)
+			}),
+		// Run after every step adding labels or annotations, so the common values merge into their blocks.
+		named("AddCommonLabels", func(yamlContent string, _ *unstructured.Unstructured) string {
+			return appliers.AddCommonLabels(yamlContent)
+		}),
+		named("AddCommonAnnotations", func(yamlContent string, _ *unstructured.Unstructured) string {
+			return appliers.AddCommonAnnotations(yamlContent)
+		}),
+		named("AddRevisionAnnotation", func(yamlContent string, _ *unstructured.Unstructured) string {
+			if !t.options.RevisionAnnotation {
+				return yamlContent
+			}
+			return appliers.AddRevisionAnnotation(t.chartName, yamlContent)
+		}),
+		named("AddProvenanceAnnotations", func(yamlContent string, _ *unstructured.Unstructured) string {
+			if !t.options.ProvenanceAnnotations {
+				return yamlContent
+			}
+			return appliers.AddProvenanceAnnotations(t.chartName, yamlContent)
+		}),
+		named("TemplateServiceSelectorLabels", func(yamlContent string, resource *unstructured.Unstructured) string {
+			if !t.options.ReleaseSelectorLabels || resource.GetKind() != common.KindService {
+				return yamlContent
+			}
+			return appliers.TemplateServiceSelectorLabels(t.chartName, yamlContent)
+		}),
+	}
+}
+
+// templateManagerDeployment applies the controller-manager Deployment specific substitutions.
+func (t *Templater) templateManagerDeployment(yamlContent string, resource *unstructured.Unstructured) string {
+	if resource.GetKind() != common.KindDeployment || !appliers.IsManagerDeployment(resource) {
+		return yamlContent
+	}
+	if _, isExtra := t.deploymentValuesKeys[resource.GetName()]; isExtra {
+		return yamlContent
+	}
+	// The checksum goes first so the pod annotations merged from values cannot override it.
+	yamlContent = appliers.ApplyStep(
+		t.recorder(), "templatePodTemplateChecksum", yamlContent, t.templatePodTemplateChecksum)
+	yamlContent = appliers.ApplyStep(
+		t.recorder(), "AddForceRestartAnnotation", yamlContent, appliers.AddForceRestartAnnotation)
+	yamlContent = appliers.ApplyStep(
+		t.recorder(), "AddCustomLabelsAndAnnotations", yamlContent, appliers.AddCustomLabelsAndAnnotations)
+	yamlContent = appliers.TemplateDeploymentFieldsRecorded(t.detectedPrefix, t.chartName, yamlContent, t.recorder())
+	for _, step := range []struct {
+		name  string
+		apply func(string) string
+	}{
+		{"MakeContainerArgsConditional", appliers.MakeContainerArgsConditional},
+		{"MakeWebhookVolumeMountsConditional", appliers.MakeWebhookVolumeMountsConditional},
+		{"MakeWebhookVolumesConditional", appliers.MakeWebhookVolumesConditional},
+		{"MakeMetricsVolumeMountsConditional", appliers.MakeMetricsVolumeMountsConditional},
+		{"MakeMetricsVolumesConditional", appliers.MakeMetricsVolumesConditional},
+		{"TemplateKubeRbacProxy", appliers.TemplateKubeRbacProxy},
+	} {
+		yamlContent = appliers.ApplyStep(t.recorder(), step.name, yamlContent, step.apply)
+	}
+	if t.options.ArgsAsYAML {
+		yamlContent = appliers.ApplyStep(
+			t.recorder(), "TemplateManagerArgsAsYAML", yamlContent, appliers.TemplateManagerArgsAsYAML)
+	}
+	return yamlContent
+}
+
+// isCertManagerResource reports whether resource is a cert-manager Certificate or Issuer.
+func isCertManagerResource(resource *unstructured.Unstructured) bool {
+	return resource.GetAPIVersion() == common.APIVersionCertManager &&
+		(resource.GetKind() == common.KindCertificate || resource.GetKind() == common.KindIssuer)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templater
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("ResourceTransformer", func() {
+	const serviceYAML = `apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app.kubernetes.io/name: test-project
+  name: test-project-extra-service
+  namespace: test-project-system
+spec:
+  ports:
+  - name: http
+    port: 8080
+`
+
+	var (
+		templater *Templater
+		service   *unstructured.Unstructured
+	)
+
+	BeforeEach(func() {
+		templater = NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
+		service = &unstructured.Unstructured{}
+		service.SetAPIVersion("v1")
+		service.SetKind("Service")
+		service.SetName("test-project-extra-service")
+	})
+
+	It("should run the same steps for a zero-value Templater", func() {
+		zeroValue := &Templater{
+			detectedPrefix:   testProjectName,
+			chartName:        testProjectName,
+			managerNamespace: testProjectSystemNamespace,
+		}
+
+		Expect(zeroValue.ApplyHelmSubstitutions(serviceYAML, service)).To(
+			Equal(templater.ApplyHelmSubstitutions(serviceYAML, service)))
+	})
+
+	Describe("substitution report", func() {
+		const deploymentYAML = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  template:
+    spec:
+      containers:
+      - command:
+        - /manager
+        env:
+        - name: LOG_LEVEL
+          value: debug
+        image: controller:latest
+        name: manager
+`
+
+		var deployment *unstructured.Unstructured
+
+		BeforeEach(func() {
+			deployment = &unstructured.Unstructured{}
+			deployment.SetAPIVersion("apps/v1")
+			deployment.SetKind("Deployment")
+			deployment.SetName("test-project-controller-manager")
+			deployment.SetLabels(map[string]string{"control-plane": "controller-manager"})
+		})
+
+		It("should flag the steps that changed a Deployment with env", func() {
+			reporting := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{RecordReport: true})
+
+			reporting.ApplyHelmSubstitutions(deploymentYAML, deployment)
+			report := reporting.Report()
+
+			Expect(report).To(HaveKeyWithValue("templateEnvironmentVariables", true))
+			Expect(report).To(HaveKeyWithValue("templateImageReference", true))
+			Expect(report).To(HaveKeyWithValue("templateManagerDeployment", true))
+			Expect(report).To(HaveKeyWithValue("MakeWebhookVolumesConditional", false))
+		})
+
+		It("should only describe the last resource", func() {
+			reporting := NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{RecordReport: true})
+
+			reporting.ApplyHelmSubstitutions(deploymentYAML, deployment)
+			reporting.ApplyHelmSubstitutions(serviceYAML, service)
+
+			Expect(reporting.Report()).NotTo(HaveKey("templateEnvironmentVariables"))
+			Expect(reporting.Report()).To(HaveKeyWithValue("SubstituteNamespace", true))
+		})
+
+		It("should not record anything unless enabled", func() {
+			templater.ApplyHelmSubstitutions(deploymentYAML, deployment)
+
+			Expect(templater.Report()).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templater
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	// inlineTemplatePattern matches a single {{ }} action
+	inlineTemplatePattern = regexp.MustCompile(`\{\{.*?\}\}`)
+	// controlActionPattern matches the body of a Helm control action
+	controlActionPattern = regexp.MustCompile(`^\{\{-?\s*(if|else|range|with|end)\b`)
+	// yamlErrorLinePattern extracts the line number reported by the YAML parser
+	yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+)
+
+// Validate checks that a templated resource is still well-formed YAML. Helm directives are
+// stripped the way a default render would see them: only the first branch of each if/else is
+// kept, standalone actions (toYaml, include, ...) are dropped, and inline values are replaced
+// with a placeholder. The returned error points at the offending line of templated.
+func (t *Templater) Validate(templated string) error {
+	stripped, origin := stripHelmDirectives(templated)
+
+	decoder := yaml.NewDecoder(strings.NewReader(stripped))
+	for {
+		var doc any
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return describeYAMLError(err, templated, origin)
+		}
+	}
+}
+
+// stripHelmDirectives removes Helm actions from templated and returns the remaining YAML together
+// with, for each of its lines, the 1-based line number in templated it came from.
+func stripHelmDirectives(templated string) (string, []int) {
+	var (
+		kept   []string
+		origin []int
+		// skipping records, per open block, whether lines are being dropped (else branches)
+		skipping []bool
+	)
+	isSkipping := func() bool {
+		return len(skipping) > 0 && skipping[len(skipping)-1]
+	}
+
+	for i, line := range strings.Split(templated, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if action := controlActionPattern.FindStringSubmatch(trimmed); action != nil &&
+			inlineTemplatePattern.ReplaceAllString(trimmed, "") == "" {
+			switch action[1] {
+			case "if", "with", "range":
+				skipping = append(skipping, isSkipping())
+			case "else":
+				// Only the first branch is kept
+				if len(skipping) > 0 {
+					skipping[len(skipping)-1] = true
+				}
+			case "end":
+				if len(skipping) > 0 {
+					skipping = skipping[:len(skipping)-1]
+				}
+			}
+			continue
+		}
+
+		if isSkipping() {
+			continue
+		}
+		// Lines made only of actions (toYaml, include, ...) expand to content that is not known here.
+		if trimmed != "" && inlineTemplatePattern.ReplaceAllString(trimmed, "") == "" {
+			continue
+		}
+
+		kept = append(kept, inlineTemplatePattern.ReplaceAllStringFunc(line, func(action string) string {
+			if controlActionPattern.MatchString(action) {
+				return ""
+			}
+			return "x"
+		}))
+		origin = append(origin, i+1)
+	}
+
+	return strings.Join(kept, "\n"), origin
+}
+
+// LintHardcodedNames reports the lines of templated that still embed the project name outside Helm
+// actions, such as a resource name or a service DNS name the substitutions did not template. Those
+// names are not scoped to the release, so two releases of the chart would collide on them. Each
+// finding reads "line N: <line>"; the error summarizes them and is nil when there are none.
+func (t *Templater) LintHardcodedNames(templated string) ([]string, error) {
+	if t.detectedPrefix == "" {
+		return nil, nil
+	}
+	// The project name as a whole word: "test-project-webhook-service" contains it, "my-test-projects" does not.
+	namePattern := regexp.MustCompile(`(^|[^A-Za-z0-9-])` + regexp.QuoteMeta(t.detectedPrefix) + `($|[^A-Za-z0-9])`)
+
+	var findings []string
+	for i, line := range strings.Split(templated, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if namePattern.MatchString(inlineTemplatePattern.ReplaceAllString(trimmed, "")) {
+			findings = append(findings, fmt.Sprintf("line %d: %s", i+1, trimmed))
+		}
+	}
+	if len(findings) == 0 {
+		return nil, nil
+	}
+	return findings, fmt.Errorf("templated output hardcodes the project name %q on %d line(s): %s",
+		t.detectedPrefix, len(findings), strings.Join(findings, "; "))
+}
+
+// describeYAMLError maps the parser error back to the templated line it refers to.
+func describeYAMLError(err error, templated string, origin []int) error {
+	match := yamlErrorLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return fmt.Errorf("templated output is not valid YAML: %w", err)
+	}
+
+	strippedLine, _ := strconv.Atoi(match[1])
+	if strippedLine < 1 || strippedLine > len(origin) {
+		return fmt.Errorf("templated output is not valid YAML: %w", err)
+	}
+
+	line := origin[strippedLine-1]
+	return fmt.Errorf("templated output is not valid YAML at line %d (%q): %w",
+		line, strings.Split(templated, "\n")[line-1], err)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templater
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Validate", func() {
+	var templater *Templater
+
+	BeforeEach(func() {
+		templater = &Templater{
+			detectedPrefix:   testProjectName,
+			chartName:        testProjectName,
+			managerNamespace: testProjectSystemNamespace,
+		}
+	})
+
+	It("should accept a templated manager Deployment", func() {
+		deploymentResource := &unstructured.Unstructured{}
+		deploymentResource.SetAPIVersion("apps/v1")
+		deploymentResource.SetKind("Deployment")
+		deploymentResource.SetName("test-project-controller-manager")
+
+		content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - args:
+        - --metrics-bind-address=:8443
+        - --leader-elect
+        - --webhook-cert-path=/tmp/k8s-webhook-server/serving-certs
+        command:
+        - /manager
+        env:
+        - name: FOO
+          value: bar
+        image: controller:latest
+        name: manager
+        resources:
+          limits:
+            cpu: 500m
+      serviceAccountName: test-project-controller-manager
+`
+
+		templated := templater.ApplyHelmSubstitutions(content, deploymentResource)
+
+		Expect(templater.Validate(templated)).To(Succeed())
+	})
+
+	It("should accept templates using if/else branches and inline values", func() {
+		templated := `{{- if .Values.rbac.namespaced }}
+kind: Role
+{{- else }}
+kind: ClusterRole
+{{- end }}
+metadata:
+  name: {{ include "test-project.resourceName" (dict "suffix" "manager-role" "context" $) }}
+  labels:
+    helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version }}
+spec:
+  endpoints:
+  - {{- if .Values.metrics.secure }}
+    bearerTokenFile: /token
+    {{- end }}
+    port: {{ if .Values.metrics.secure }}https{{ else }}http{{ end }}
+  nodeSelector:
+    {{- toYaml .Values.nodeSelector | nindent 4 }}
+`
+
+		Expect(templater.Validate(templated)).To(Succeed())
+	})
+
+	It("should report the templated line of a bad indentation", func() {
+		templated := `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ include "test-project.resourceName" (dict "suffix" "svc" "context" $) }}
+spec:
+  {{- if .Values.metrics.enabled }}
+  ports:
+  - port: 8443
+     targetPort: 8443
+  {{- end }}
+`
+
+		err := templater.Validate(templated)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("line 9"))
+		Expect(err.Error()).To(ContainSubstring(`"     targetPort: 8443"`))
+	})
+
+	It("should reject duplicate keys left behind by a substitution", func() {
+		templated := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: controller-manager
+  name: {{ include "test-project.serviceAccountName" . }}
+`
+
+		err := templater.Validate(templated)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("line 5"))
+	})
+})
+
+var _ = Describe("LintHardcodedNames", func() {
+	var templater *Templater
+
+	BeforeEach(func() {
+		templater = &Templater{
+			detectedPrefix:   testProjectName,
+			chartName:        testProjectName,
+			managerNamespace: testProjectSystemNamespace,
+		}
+	})
+
+	It("should accept output where every project name is templated", func() {
+		templated := `apiVersion: v1
+kind: Service
+metadata:
+  # Routes webhook calls to the test-project manager
+  labels:
+    app.kubernetes.io/name: {{ include "test-project.name" . }}
+  name: {{ include "test-project.resourceName" (dict "suffix" "webhook-service" "context" $) }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  selector:
+    app.kubernetes.io/name: {{ include "test-project.name" . }}
+    example.com/owner: my-test-projects
+`
+
+		findings, err := templater.LintHardcodedNames(templated)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(findings).To(BeEmpty())
+	})
+
+	It("should report the lines still embedding the project name", func() {
+		templated := `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: {{ include "test-project.resourceName" (dict "suffix" "validating-webhook-configuration" "context" $) }}
+webhooks:
+- clientConfig:
+    service:
+      name: test-project-webhook-service
+      namespace: {{ .Release.Namespace }}
+    url: https://{{ .Release.Name }}.test-project-system.svc:443/validate
+`
+
+		findings, err := templater.LintHardcodedNames(templated)
+
+		Expect(findings).To(Equal([]string{
+			"line 8: name: test-project-webhook-service",
+			"line 10: url: https://{{ .Release.Name }}.test-project-system.svc:443/validate",
+		}))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`hardcodes the project name "test-project" on 2 line(s)`))
+	})
+})
@@ -0,0 +1,119 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templater
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	// valuesPathPattern matches a .Values reference, e.g. .Values.manager.image.repository
+	valuesPathPattern = regexp.MustCompile(`\.Values((?:\.[A-Za-z0-9_]+)+)`)
+	// defaultDictPattern matches an optional values map, e.g. (.Values.webhook.service | default dict)
+	defaultDictPattern = regexp.MustCompile(`\(\.Values((?:\.[A-Za-z0-9_]+)+) \| default dict\)`)
+	// hasKeyPattern matches a key looked up in a values map, e.g. hasKey .Values.manager "enabled"
+	hasKeyPattern = regexp.MustCompile(`hasKey \.Values((?:\.[A-Za-z0-9_]+)+) "([A-Za-z0-9_]+)"`)
+	// digPattern matches the keys, default and values map of a dig call, e.g.
+	// dig "image" "tag" "latest" .Values.worker
+	digPattern = regexp.MustCompile(
+		`dig ((?:"[A-Za-z0-9_]+" )+)(?:"[^"]*"|\([^)]*\)|[^\s"()]+) \.Values((?:\.[A-Za-z0-9_]+)+)`)
+	// scopeFieldPattern matches a field of the current scope, e.g. .labels inside with .Values.manager.pod
+	scopeFieldPattern = regexp.MustCompile(`(?:^|[\s(])\.([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z0-9_]+)*)`)
+)
+
+// builtinObjects are the Helm objects a field of the current scope cannot be mistaken for.
+var builtinObjects = []string{"Values", "Release", "Chart", "Capabilities", "Template", "Files"}
+
+// ReferencedValuePaths templates a single resource, like ApplyHelmSubstitutions, and returns the
+// values paths the templated resource reads, sorted and without the .Values prefix, e.g.
+// "manager.image.repository" or "metrics.enabled". Fields read inside a with block are resolved
+// against the values map it opens. Values read by the chart helpers the resource includes, such
+// as the labels helper, are not listed.
+func (t *Templater) ReferencedValuePaths(yamlContent string, resource *unstructured.Unstructured) []string {
+	templated := t.ApplyHelmSubstitutions(yamlContent, resource)
+
+	var paths []string
+	add := func(path string) {
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	// scopes holds, per open if/with/range block, the values path "." refers to inside it, or ""
+	// when it is not a values map.
+	scopes := []string{""}
+	for _, action := range inlineTemplatePattern.FindAllString(templated, -1) {
+		// Escaped literals are text of the manifest, not Helm actions.
+		if strings.HasPrefix(action, `{{ "{{`) {
+			continue
+		}
+		body := strings.TrimSpace(strings.Trim(action, "{}-"))
+		body = defaultDictPattern.ReplaceAllString(body, ".Values$1")
+		body = hasKeyPattern.ReplaceAllString(body, ".Values$1.$2")
+		body = digPattern.ReplaceAllStringFunc(body, func(match string) string {
+			groups := digPattern.FindStringSubmatch(match)
+			keys := strings.ReplaceAll(strings.TrimSpace(groups[1]), `" "`, ".")
+			return ".Values" + groups[2] + "." + strings.Trim(keys, `"`)
+		})
+
+		scope := scopes[len(scopes)-1]
+		for _, match := range valuesPathPattern.FindAllStringSubmatch(body, -1) {
+			add(strings.TrimPrefix(match[1], "."))
+		}
+		if scope != "" {
+			for _, match := range scopeFieldPattern.FindAllStringSubmatch(body, -1) {
+				object, _, _ := strings.Cut(match[1], ".")
+				if !slices.Contains(builtinObjects, object) {
+					add(scope + "." + match[1])
+				}
+			}
+		}
+
+		switch keyword, subject, _ := strings.Cut(body, " "); keyword {
+		case "if":
+			scopes = append(scopes, scope)
+		case "with":
+			scopes = append(scopes, withScope(subject, scope))
+		case "range":
+			scopes = append(scopes, "")
+		case "else":
+			if len(scopes) > 1 {
+				scopes[len(scopes)-1] = scopes[len(scopes)-2]
+			}
+		case "end":
+			if len(scopes) > 1 {
+				scopes = scopes[:len(scopes)-1]
+			}
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// withScope returns the values path "." refers to inside a with block over subject, opened in scope.
+func withScope(subject, scope string) string {
+	if match := valuesPathPattern.FindStringSubmatch(subject); match != nil && match[0] == subject {
+		return strings.TrimPrefix(match[1], ".")
+	}
+	if match := scopeFieldPattern.FindStringSubmatch(subject); scope != "" && match != nil && match[0] == subject {
+		return scope + "." + match[1]
+	}
+	return ""
+}
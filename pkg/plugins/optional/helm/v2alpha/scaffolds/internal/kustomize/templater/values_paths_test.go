@@ -0,0 +1,120 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templater
+
+import (
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("ReferencedValuePaths", func() {
+	var templater *Templater
+
+	BeforeEach(func() {
+		templater = NewTemplater(testProjectName, testProjectName, testProjectSystemNamespace, nil, Options{})
+	})
+
+	It("should list the values paths of the manager Deployment", func() {
+		deployment := &unstructured.Unstructured{}
+		deployment.SetAPIVersion("apps/v1")
+		deployment.SetKind("Deployment")
+		deployment.SetName("test-project-controller-manager")
+		deployment.SetLabels(map[string]string{"control-plane": "controller-manager"})
+
+		paths := templater.ReferencedValuePaths(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    control-plane: controller-manager
+  name: test-project-controller-manager
+  namespace: test-project-system
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  template:
+    metadata:
+      annotations:
+        kubectl.kubernetes.io/default-container: manager
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers:
+      - args:
+        - --metrics-bind-address=:8443
+        - --leader-elect
+        command:
+        - /manager
+        image: controller:latest
+        name: manager
+      serviceAccountName: test-project-controller-manager
+`, deployment)
+
+		Expect(paths).To(ContainElements(
+			"manager.enabled",
+			"manager.replicas",
+			"manager.image.repository",
+			"manager.image.tag",
+			"manager.image.digest",
+			"manager.args",
+			"manager.leaderElection.enabled",
+			"manager.pod.annotations",
+			"manager.pod.labels",
+			"metrics.enabled",
+			"metrics.port",
+			"commonLabels",
+		))
+		Expect(paths).NotTo(ContainElement("annotations"), "fields of manager.pod are resolved against it")
+		Expect(paths).NotTo(ContainElement("webhook.port"))
+		Expect(slices.IsSorted(paths)).To(BeTrue())
+	})
+
+	It("should list the values paths of the webhook serving Certificate", func() {
+		certificate := &unstructured.Unstructured{}
+		certificate.SetAPIVersion("cert-manager.io/v1")
+		certificate.SetKind("Certificate")
+		certificate.SetName("test-project-serving-cert")
+
+		paths := templater.ReferencedValuePaths(`apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: test-project-serving-cert
+  namespace: test-project-system
+spec:
+  dnsNames:
+  - test-project-webhook-service.test-project-system.svc
+  issuerRef:
+    kind: Issuer
+    name: test-project-selfsigned-issuer
+  secretName: webhook-server-cert
+`, certificate)
+
+		Expect(paths).To(Equal([]string{
+			"certManager.enabled",
+			"certManager.ipAddresses",
+			"certManager.subject",
+			"commonAnnotations",
+			"commonLabels",
+			"webhook.certSecretName",
+			"webhook.extraDNSNames",
+		}))
+	})
+})
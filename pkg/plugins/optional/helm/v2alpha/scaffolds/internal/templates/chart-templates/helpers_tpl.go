@@ -19,6 +19,8 @@ package charttemplates
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
+	"text/template"
 
 	"sigs.k8s.io/kubebuilder/v4/pkg/machinery"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/internal/common"
@@ -35,6 +37,17 @@ type HelmHelpers struct {
 	OutputDir string
 	// Force if true allows overwriting the scaffolded file
 	Force bool
+	// ManagedBy is the app.kubernetes.io/managed-by value rendered by the labels helper.
+	// Empty uses {{ .Release.Service }}.
+	ManagedBy string
+	// OmitManagedBy leaves app.kubernetes.io/managed-by out of the labels helper.
+	OmitManagedBy bool
+	// RevisionAnnotation adds the revisionAnnotations helper, included by the resources templated
+	// with the revision annotation.
+	RevisionAnnotation bool
+	// ProvenanceAnnotations adds the provenanceAnnotations helper, included by the resources templated
+	// with the provenance annotations.
+	ProvenanceAnnotations bool
 }
 
 // SetTemplateDefaults sets the default template configuration
@@ -65,7 +78,42 @@ func (f *HelmHelpers) generateHelpersTemplate() string {
 	// preventing collisions when chart is used as a Helm dependency
 	prefix := f.ProjectName
 
-	return fmt.Sprintf(helmHelpersTemplate, prefix, prefix, prefix, prefix, prefix, prefix, prefix)
+	helpers := fmt.Sprintf(helmHelpersTemplate,
+		prefix, prefix, prefix, f.managedByLabel(), prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix, prefix,
+		prefix, prefix)
+	if f.RevisionAnnotation {
+		helpers += fmt.Sprintf(revisionAnnotationsHelperTemplate, prefix)
+	}
+	if f.ProvenanceAnnotations {
+		helpers += fmt.Sprintf(provenanceAnnotationsHelperTemplate, prefix, prefix)
+	}
+	return helpers
+}
+
+// managedByLabel returns the escaped app.kubernetes.io/managed-by line of the labels helper.
+func (f *HelmHelpers) managedByLabel() string {
+	if f.OmitManagedBy {
+		return ""
+	}
+	managedBy := f.ManagedBy
+	if managedBy == "" {
+		managedBy = "{{ .Release.Service }}"
+	}
+	return "{{`app.kubernetes.io/managed-by: " + managedBy + "`}}\n"
+}
+
+// HelpersContent returns the _helpers.tpl content scaffolded for chartName, as written to the chart.
+func HelpersContent(chartName string) string {
+	return (&HelmHelpers{ProjectNameMixin: machinery.ProjectNameMixin{ProjectName: chartName}}).Content()
+}
+
+// Content returns the _helpers.tpl content scaffolded by f, as written to the chart.
+func (f *HelmHelpers) Content() string {
+	// The body only escapes the Helm actions for the scaffolding template engine, so it always parses.
+	body := template.Must(template.New("_helpers.tpl").Parse(f.generateHelpersTemplate()))
+	var content strings.Builder
+	_ = body.Execute(&content, nil)
+	return content.String()
 }
 
 const helmHelpersTemplate = `{{` + "`" + `{{/*
@@ -93,6 +141,26 @@ If release name contains chart name it will be used as a full name.
 {{` + "`" + `{{- end }}` + "`" + `}}
 {{` + "`" + `{{- end }}` + "`" + `}}
 
+{{` + "`" + `{{/*
+Standard Helm labels added to every resource.
+*/}}` + "`" + `}}
+{{` + "`" + `{{- define "%s.labels" -}}` + "`" + `}}
+{{` + "`" + `helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}` + "`" + `}}
+{{` + "`" + `{{- with .Chart.AppVersion }}` + "`" + `}}
+{{` + "`" + `app.kubernetes.io/version: {{ . | quote }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `app.kubernetes.io/instance: {{ .Release.Name }}` + "`" + `}}
+%s{{` + "`" + `{{- end }}` + "`" + `}}
+
+{{` + "`" + `{{/*
+Selector labels matching the pods of this release: the name label and the release instance, so two
+releases of the chart select only their own pods.
+*/}}` + "`" + `}}
+{{` + "`" + `{{- define "%s.selectorLabels" -}}` + "`" + `}}
+app.kubernetes.io/name: {{` + "`" + `{{ include "%s.name" . }}` + "`" + `}}
+app.kubernetes.io/instance: {{` + "`" + `{{ .Release.Name }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+
 {{` + "`" + `{{/*
 Namespace for generated references.
 Always uses the Helm release namespace.
@@ -134,4 +202,122 @@ When disabled, serviceAccount.name must be set; use "default" to pick the namesp
 	"`" + `}}
 {{` + "`" + `{{- end }}` + "`" + `}}
 {{` + "`" + `{{- end }}` + "`" + `}}
+
+{{` + "`" + `{{/*
+Manager pod tolerations: manager.tolerations followed by NoExecute tolerations of the
+node.kubernetes.io/not-ready and node.kubernetes.io/unreachable taints when
+manager.nodeFailureTolerations.enabled is true, unless manager.tolerations already tolerates them.
+The pod is evicted from a failed node after manager.nodeFailureTolerations.tolerationSeconds.
+*/}}` + "`" + `}}
+{{` + "`" + `{{- define "%s.managerTolerations" -}}` + "`" + `}}
+{{` + "`" + `{{- $manager := .Values.manager | default dict }}` + "`" + `}}
+{{` + "`" + `{{- $tolerations := $manager.tolerations | default list }}` + "`" + `}}
+{{` + "`" + `{{- $nodeFailure := $manager.nodeFailureTolerations | default dict }}` + "`" + `}}
+{{` + "`" + `{{- if $nodeFailure.enabled }}` + "`" + `}}
+{{` + "`" + `{{- $keys := list }}` + "`" + `}}
+{{` + "`" + `{{- range $tolerations }}` + "`" + `}}
+{{` + "`" + `{{- $keys = append $keys .key }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- range list "node.kubernetes.io/not-ready" "node.kubernetes.io/unreachable" }}` + "`" + `}}
+{{` + "`" + `{{- if not (has . $keys) }}` + "`" + `}}
+{{` + "`" + `{{- $toleration := dict "key" . "operator" "Exists" "effect" "NoExecute" }}` + "`" + `}}
+{{` + "`" + `{{- if not (kindIs "invalid" $nodeFailure.tolerationSeconds) }}` + "`" + `}}
+{{` + "`" + `{{- $_ := set $toleration "tolerationSeconds" (int64 $nodeFailure.tolerationSeconds) }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- $tolerations = append $tolerations $toleration }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- with $tolerations }}` + "`" + `}}
+{{` + "`" + `{{- toYaml . }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+
+{{` + "`" + `{{/*
+Manager pod affinity: manager.affinity when set. Otherwise, when manager.defaultAffinity is true,
+a preferred pod anti-affinity on the manager selector labels spreading the replicas across nodes.
+*/}}` + "`" + `}}
+{{` + "`" + `{{- define "%s.managerAffinity" -}}` + "`" + `}}
+{{` + "`" + `{{- $manager := .Values.manager | default dict }}` + "`" + `}}
+{{` + "`" + `{{- if $manager.affinity }}` + "`" + `}}
+{{` + "`" + `{{- toYaml $manager.affinity }}` + "`" + `}}
+{{` + "`" + `{{- else if $manager.defaultAffinity }}` + "`" + `}}
+podAntiAffinity:
+  preferredDuringSchedulingIgnoredDuringExecution:
+  - weight: 100
+    podAffinityTerm:
+      topologyKey: kubernetes.io/hostname
+      labelSelector:
+        matchLabels:
+          app.kubernetes.io/name: {{` + "`" + `{{ include "%s.name" . }}` + "`" + `}}
+          control-plane: controller-manager
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+
+{{` + "`" + `{{/*
+Manager container resources for the manager.resourcesProfile named profile: small, medium or large.
+Empty when no profile is set; manager.resources is merged over it.
+*/}}` + "`" + `}}
+{{` + "`" + `{{- define "%s.managerResourcesProfile" -}}` + "`" + `}}
+{{` + "`" + `{{- $profile := (.Values.manager | default dict).resourcesProfile | default "" }}` + "`" + `}}
+{{` + "`" + `{{- if eq $profile "small" }}` + "`" + `}}
+limits:
+  cpu: 500m
+  memory: 128Mi
+requests:
+  cpu: 10m
+  memory: 64Mi
+{{` + "`" + `{{- else if eq $profile "medium" }}` + "`" + `}}
+limits:
+  cpu: "1"
+  memory: 512Mi
+requests:
+  cpu: 100m
+  memory: 256Mi
+{{` + "`" + `{{- else if eq $profile "large" }}` + "`" + `}}
+limits:
+  cpu: "2"
+  memory: 2Gi
+requests:
+  cpu: 500m
+  memory: 1Gi
+{{` + "`" + `{{- else if $profile }}` + "`" + `}}
+{{` + "`" + `{{- fail (printf "manager.resourcesProfile %%q is not one of small, medium or large" ` +
+	`$profile) }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+`
+
+// revisionAnnotationsHelperTemplate is the helper included by the resources templated with the
+// revision annotation.
+const revisionAnnotationsHelperTemplate = `
+{{` + "`" + `{{/*
+Annotations recording the deployed revision: app.kubernetes.io/revision set to revision, for GitOps
+tooling tracking deploys. Empty when revision is unset or commonAnnotations already sets the key.
+*/}}` + "`" + `}}
+{{` + "`" + `{{- define "%s.revisionAnnotations" -}}` + "`" + `}}
+{{` + "`" + `{{- if and .Values.revision ` +
+	`(not (hasKey (.Values.commonAnnotations | default dict) "app.kubernetes.io/revision")) }}` + "`" + `}}
+app.kubernetes.io/revision: {{` + "`" + `{{ .Values.revision | toString | quote }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+`
+
+// provenanceAnnotationsHelperTemplate is the helper included by the resources templated with the
+// provenance annotations.
+const provenanceAnnotationsHelperTemplate = `
+{{` + "`" + `{{/*
+Annotations recording where resources come from, for supply-chain catalogs: app.kubernetes.io/part-of
+set to partOf (default: the project name) and helm.sh/chart set to the chart name and version. Keys
+commonAnnotations already sets are left out.
+*/}}` + "`" + `}}
+{{` + "`" + `{{- define "%s.provenanceAnnotations" -}}` + "`" + `}}
+{{` + "`" + `{{- $common := .Values.commonAnnotations | default dict }}` + "`" + `}}
+{{` + "`" + `{{- if not (hasKey $common "app.kubernetes.io/part-of") }}` + "`" + `}}
+app.kubernetes.io/part-of: {{` + "`" + `{{ .Values.partOf | default "%s" | quote }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- if not (hasKey $common "helm.sh/chart") }}` + "`" + `}}
+helm.sh/chart: {{` + "`" + `{{ printf "%%s-%%s" .Chart.Name (.Chart.Version | replace "+" "_") | quote }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
+{{` + "`" + `{{- end }}` + "`" + `}}
 `
@@ -77,4 +77,81 @@ var _ = Describe("HelmHelpers", func() {
 			Expect(templateBody).NotTo(ContainSubstring(`| default "default"`))
 		})
 	})
+
+	Context("HelpersContent", func() {
+		It("returns the helpers as written to the chart, without the scaffold escaping", func() {
+			content := HelpersContent("my-operator")
+
+			Expect(content).To(ContainSubstring("{{- define \"my-operator.name\" -}}\n"))
+			Expect(content).To(ContainSubstring("{{- define \"my-operator.resourceName\" -}}\n"))
+			Expect(content).To(ContainSubstring("{{- define \"my-operator.namespaceName\" -}}\n"))
+			Expect(content).To(ContainSubstring(`{{- printf "%s-%s" $fullname $suffix | trunc 63 | trimSuffix "-" }}`))
+			Expect(content).NotTo(ContainSubstring("`"))
+		})
+
+		It("defines the standard labels helper", func() {
+			content := HelpersContent("my-operator")
+
+			Expect(content).To(ContainSubstring(`{{- define "my-operator.labels" -}}
+helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}
+{{- with .Chart.AppVersion }}
+app.kubernetes.io/version: {{ . | quote }}
+{{- end }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+app.kubernetes.io/managed-by: {{ .Release.Service }}
+{{- end }}`))
+		})
+
+		It("defines the selector labels helper with the release instance", func() {
+			content := HelpersContent("my-operator")
+
+			Expect(content).To(ContainSubstring(`{{- define "my-operator.selectorLabels" -}}
+app.kubernetes.io/name: {{ include "my-operator.name" . }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end }}`))
+		})
+
+		It("defines the provenance annotations helper only with ProvenanceAnnotations", func() {
+			Expect(HelpersContent("my-operator")).NotTo(ContainSubstring("provenanceAnnotations"))
+
+			content := (&HelmHelpers{
+				ProjectNameMixin:      machinery.ProjectNameMixin{ProjectName: "my-operator"},
+				ProvenanceAnnotations: true,
+			}).Content()
+			Expect(content).To(ContainSubstring(`{{- define "my-operator.provenanceAnnotations" -}}`))
+			Expect(content).To(ContainSubstring(
+				`app.kubernetes.io/part-of: {{ .Values.partOf | default "my-operator" | quote }}`))
+			Expect(content).To(ContainSubstring(
+				`helm.sh/chart: {{ printf "%s-%s" .Chart.Name (.Chart.Version | replace "+" "_") | quote }}`))
+		})
+
+		It("renders a fixed managed-by value or leaves it out when configured", func() {
+			fixed := (&HelmHelpers{
+				ProjectNameMixin: machinery.ProjectNameMixin{ProjectName: "my-operator"},
+				ManagedBy:        "argocd",
+			}).Content()
+			Expect(fixed).To(ContainSubstring("app.kubernetes.io/instance: {{ .Release.Name }}\n" +
+				"app.kubernetes.io/managed-by: argocd\n{{- end }}"))
+
+			omitted := (&HelmHelpers{
+				ProjectNameMixin: machinery.ProjectNameMixin{ProjectName: "my-operator"},
+				OmitManagedBy:    true,
+			}).Content()
+			Expect(omitted).To(ContainSubstring("app.kubernetes.io/instance: {{ .Release.Name }}\n{{- end }}"))
+			Expect(omitted).NotTo(ContainSubstring("managed-by"))
+		})
+
+		It("defines the revision annotations helper only with RevisionAnnotation", func() {
+			Expect(HelpersContent("my-operator")).NotTo(ContainSubstring("revisionAnnotations"))
+
+			revisioned := (&HelmHelpers{
+				ProjectNameMixin:   machinery.ProjectNameMixin{ProjectName: "my-operator"},
+				RevisionAnnotation: true,
+			}).Content()
+			Expect(revisioned).To(ContainSubstring(`{{- define "my-operator.revisionAnnotations" -}}`))
+			Expect(revisioned).To(ContainSubstring(
+				`app.kubernetes.io/revision: {{ .Values.revision | toString | quote }}`))
+			Expect(revisioned).NotTo(ContainSubstring("`"))
+		})
+	})
 })
@@ -76,8 +76,17 @@ metadata:
   labels:
     app.kubernetes.io/managed-by: {{ "{{ .Release.Service }}" }}
     app.kubernetes.io/name: {{ "{{ include \"%s.name\" . }}" }}
+    {{ "{{- with .Values.commonLabels }}" }}
+    {{ "{{- with omit . \"app.kubernetes.io/managed-by\" \"app.kubernetes.io/name\" }}" }}
+    {{ "{{- toYaml . | nindent 4 }}" }}
+    {{ "{{- end }}" }}
+    {{ "{{- end }}" }}
   name: {{ "{{ include \"%s.resourceName\" (dict \"suffix\" \"allow-metrics-traffic\" \"context\" $) }}" }}
   namespace: {{ "{{ .Release.Namespace }}" }}
+  {{ "{{- with .Values.commonAnnotations }}" }}
+  annotations:
+    {{ "{{- toYaml . | nindent 4 }}" }}
+  {{ "{{- end }}" }}
 spec:
   podSelector:
     matchLabels:
@@ -105,8 +114,17 @@ metadata:
   labels:
     app.kubernetes.io/managed-by: {{ "{{ .Release.Service }}" }}
     app.kubernetes.io/name: {{ "{{ include \"%s.name\" . }}" }}
+    {{ "{{- with .Values.commonLabels }}" }}
+    {{ "{{- with omit . \"app.kubernetes.io/managed-by\" \"app.kubernetes.io/name\" }}" }}
+    {{ "{{- toYaml . | nindent 4 }}" }}
+    {{ "{{- end }}" }}
+    {{ "{{- end }}" }}
   name: {{ "{{ include \"%s.resourceName\" (dict \"suffix\" \"allow-webhook-traffic\" \"context\" $) }}" }}
   namespace: {{ "{{ .Release.Namespace }}" }}
+  {{ "{{- with .Values.commonAnnotations }}" }}
+  annotations:
+    {{ "{{- toYaml . | nindent 4 }}" }}
+  {{ "{{- end }}" }}
 spec:
   podSelector:
     matchLabels:
@@ -130,6 +130,13 @@ var _ = Describe("NetworkPolicy", func() {
 			Expect(webhookPolicy).To(ContainSubstring(
 				`name: {{ include "test-project.resourceName" (dict "suffix" "allow-webhook-traffic" "context" $) }}`))
 			Expect(webhookPolicy).To(ContainSubstring("port: {{ .Values.webhook.port }}"))
+
+			for _, policy := range []string{metricsPolicy, webhookPolicy} {
+				Expect(policy).To(ContainSubstring("    {{- with .Values.commonLabels }}\n" +
+					"    {{- with omit . \"app.kubernetes.io/managed-by\" \"app.kubernetes.io/name\" }}\n"))
+				Expect(policy).To(ContainSubstring("  namespace: {{ .Release.Namespace }}\n" +
+					"  {{- with .Values.commonAnnotations }}\n  annotations:\n    {{- toYaml . | nindent 4 }}\n  {{- end }}\n"))
+			}
 		})
 	})
 })
@@ -68,13 +68,23 @@ metadata:
     helm.sh/chart: {{ "{{ .Chart.Name }}-{{ .Chart.Version | replace \"+\" \"_\" }}" }}
     app.kubernetes.io/instance: {{ "{{ .Release.Name }}" }}
     control-plane: controller-manager
+    {{ "{{- with .Values.commonLabels }}" }}
+    {{ "{{- with omit . \"app.kubernetes.io/managed-by\" \"app.kubernetes.io/name\" \"helm.sh/chart\" ` +
+	`\"app.kubernetes.io/instance\" \"control-plane\" }}" }}
+    {{ "{{- toYaml . | nindent 4 }}" }}
+    {{ "{{- end }}" }}
+    {{ "{{- end }}" }}
   name: ` +
 	`{{ "{{ include \"%s.resourceName\" " }}` +
 	`{{ "(dict \"suffix\" \"controller-manager-metrics-monitor\" \"context\" $) }}" }}
   namespace: {{ "{{ .Release.Namespace }}" }}
+  {{ "{{- with .Values.commonAnnotations }}" }}
+  annotations:
+    {{ "{{- toYaml . | nindent 4 }}" }}
+  {{ "{{- end }}" }}
 spec:
   endpoints:
-  - {{ "{{- if .Values.metrics.secure }}" }}
+  - {{ "{{- if and .Values.metrics.secure (not .Values.prometheus.authorization) }}" }}
     bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
     {{ "{{- end }}" }}
     path: /metrics
@@ -102,6 +112,21 @@ spec:
       insecureSkipVerify: true
       {{ "{{- end }}" }}
     {{ "{{- end }}" }}
+    {{ "{{- with .Values.prometheus.authorization }}" }}
+    authorization:
+      {{ "{{- toYaml . | nindent 6 }}" }}
+    {{ "{{- end }}" }}
+    {{ "{{- with .Values.prometheus.metricRelabelings }}" }}
+    metricRelabelings:
+      {{ "{{- toYaml . | nindent 6 }}" }}
+    {{ "{{- end }}" }}
+    {{ "{{- with .Values.prometheus.relabelings }}" }}
+    relabelings:
+      {{ "{{- toYaml . | nindent 6 }}" }}
+    {{ "{{- end }}" }}
+  namespaceSelector:
+    matchNames:
+    - {{ "{{ .Release.Namespace }}" }}
   selector:
     matchLabels:
       app.kubernetes.io/name: {{ "{{ include \"%s.name\" . }}" }}
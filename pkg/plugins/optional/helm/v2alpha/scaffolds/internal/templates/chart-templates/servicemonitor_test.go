@@ -0,0 +1,55 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package charttemplates
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ServiceMonitor", func() {
+	Context("SetTemplateDefaults", func() {
+		var serviceMonitor *ServiceMonitor
+
+		BeforeEach(func() {
+			serviceMonitor = &ServiceMonitor{
+				OutputDir:   helmChartOutputDir,
+				ServiceName: "test-project-controller-manager-metrics-service",
+				Force:       true,
+			}
+			serviceMonitor.InjectProjectName("test-project")
+		})
+
+		It("should set the correct path", func() {
+			err := serviceMonitor.SetTemplateDefaults()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceMonitor.Path).To(Equal("dist/chart/templates/prometheus/controller-manager-metrics-monitor.yaml"))
+		})
+
+		It("should scope the namespaceSelector to the release namespace", func() {
+			err := serviceMonitor.SetTemplateDefaults()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(serviceMonitor.TemplateBody).To(HavePrefix("{{`{{- if .Values.prometheus.enabled }}`}}"))
+			Expect(serviceMonitor.TemplateBody).To(ContainSubstring(`  namespaceSelector:
+    matchNames:
+    - {{ "{{ .Release.Namespace }}" }}
+  selector:
+`))
+		})
+	})
+})
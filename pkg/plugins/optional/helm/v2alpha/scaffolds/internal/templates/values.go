@@ -44,6 +44,19 @@ type HelmValues struct {
 	OutputDir string
 	// Force if true allows overwriting the scaffolded file
 	Force bool
+	// FlatValues writes the manager image settings under the top-level image key instead of
+	// manager.image
+	FlatValues bool
+	// KeepNamespace adds the namespace section configuring the Namespace kept from the kustomize output
+	KeepNamespace bool
+	// OmitCertManager disables certManager by default, as the chart ships no Certificates or Issuers
+	OmitCertManager bool
+	// OmitCRDs leaves out the crd section, as the chart ships no CustomResourceDefinitions
+	OmitCRDs bool
+	// RevisionAnnotation documents revision, recorded in the annotations of every resource
+	RevisionAnnotation bool
+	// ProvenanceAnnotations documents partOf, recorded in the annotations of every resource
+	ProvenanceAnnotations bool
 }
 
 // SetTemplateDefaults implements machinery.Template
@@ -79,7 +92,62 @@ func (f *HelmValues) generateValues() string {
 ##
 # fullnameOverride: ""
 
-## Configure the controller manager deployment
+## Global values, shared with parent charts when used as a dependency
+##
+# global:
+#   ## Registry prepended to image repositories (e.g. a mirror for air-gapped clusters)
+#   ##
+#   imageRegistry: ""
+
+## Labels added to every resource and pod template in the chart; labels a resource already sets win
+##
+# commonLabels:
+#   example.com/team: platform
+
+## Annotations added to every resource in the chart; annotations a resource already sets win
+##
+# commonAnnotations:
+#   example.com/team: platform
+
+`)
+
+	if f.RevisionAnnotation {
+		buf.WriteString(`## Revision recorded in the app.kubernetes.io/revision annotation of every resource, e.g. the
+## Git commit being deployed. Nothing is recorded while it is unset
+##
+# revision: ""
+
+`)
+	}
+
+	if f.ProvenanceAnnotations {
+		buf.WriteString(`## Value of the app.kubernetes.io/part-of annotation of every resource (default: the project name)
+##
+# partOf: ""
+
+`)
+	}
+
+	if f.KeepNamespace {
+		buf.WriteString(`## Namespace from the kustomize output, named after the release namespace. Set create to true
+## to create it with the chart instead of with helm install --create-namespace
+##
+namespace:
+  create: false
+  ## Labels added to the Namespace, e.g. to enforce a Pod Security Standard
+  ##
+  # labels:
+  #   pod-security.kubernetes.io/enforce: restricted
+
+`)
+	}
+
+	if f.FlatValues {
+		buf.WriteString("## Manager container image\n##\n")
+		f.addImageSection(&buf, "")
+	}
+
+	buf.WriteString(`## Configure the controller manager deployment
 ##
 manager:
   ## Set to false to skip manager installation
@@ -97,7 +165,9 @@ manager:
 	fmt.Fprintf(&buf, "  replicas: %d\n\n", replicas)
 
 	// Image configuration
-	f.addImageSection(&buf)
+	if !f.FlatValues {
+		f.addImageSection(&buf, "  ")
+	}
 
 	// Deployment configuration
 	f.addDeploymentConfig(&buf)
@@ -109,7 +179,7 @@ manager:
 	f.addServiceAccountSection(&buf)
 
 	// CRD configuration
-	if f.Extraction != nil && f.Extraction.Features.HasCRDs {
+	if f.Extraction != nil && f.Extraction.Features.HasCRDs && !f.OmitCRDs {
 		buf.WriteString(`## Custom Resource Definitions
 ##
 crd:
@@ -124,19 +194,51 @@ crd:
 	// Metrics configuration (always present, enabled based on detected metrics artifacts)
 	f.addMetricsSection(&buf)
 
+	// kube-rbac-proxy sidecar (only when the manager pod runs one)
+	if f.Extraction != nil && f.Extraction.Values.KubeRbacProxy != nil {
+		f.addKubeRbacProxySection(&buf)
+	}
+
+	// Deployments shipped next to the manager, each under its own key
+	if f.Extraction != nil {
+		for _, deployment := range f.Extraction.Values.Deployments {
+			f.addDeploymentValuesSection(&buf, deployment)
+		}
+	}
+
 	// Cert-manager configuration (always present)
 	// IMPORTANT: Webhooks REQUIRE cert-manager for TLS certificates.
-	// HasWebhooks = true means cert-manager MUST be enabled.
+	// HasWebhooks = true means cert-manager MUST be enabled, unless OmitCertManager leaves the
+	// certificates to the user.
 	// Also enabled when cert-manager resources exist (e.g., for metrics TLS without webhooks).
-	if f.Extraction != nil && (f.Extraction.Features.HasWebhooks || f.Extraction.Features.HasCertManager) {
+	switch {
+	case f.OmitCertManager:
+		buf.WriteString(`## Cert-manager integration for TLS certificates. The chart ships no Certificates or Issuers;
+## enable it only when they are created outside the chart.
+##
+certManager:
+  enabled: false
+
+`)
+	case f.Extraction != nil && (f.Extraction.Features.HasWebhooks || f.Extraction.Features.HasCertManager):
 		buf.WriteString(`## Cert-manager integration for TLS certificates.
 ## Required for webhook certificates and metrics endpoint certificates.
 ##
 certManager:
   enabled: true
+  ## IP SANs and subject added to the chart Certificates.
+  # ipAddresses:
+  #   - 10.0.0.10
+  # subject:
+  #   organizations:
+  #     - my-org
+  ## Spec of the chart Issuer, replacing the default self-signed issuer (e.g. a CA or ACME issuer).
+  # issuerSpec:
+  #   ca:
+  #     secretName: my-ca-key-pair
 
 `)
-	} else {
+	default:
 		buf.WriteString(`## Cert-manager integration for TLS certificates.
 ## Required for webhook certificates and metrics endpoint certificates.
 ##
@@ -146,6 +248,75 @@ certManager:
 `)
 	}
 
+	// ConfigMap data overrides
+	if f.Extraction != nil && f.Extraction.Features.HasConfigMaps {
+		buf.WriteString(`## Data merged into the ConfigMaps shipped with the chart.
+## Keys set here replace the matching keys from the kustomize output; values must be strings.
+##
+# config:
+#   log-level: debug
+
+`)
+	}
+
+	// Jobs and CronJobs
+	if f.Extraction != nil && f.Extraction.Features.HasJobs {
+		imageKey := "manager.image"
+		if f.FlatValues {
+			imageKey = "image"
+		}
+		fmt.Fprintf(&buf, `## Jobs and CronJobs shipped with the chart (e.g. migrations or periodic tasks).
+## Containers named "manager" use the %s settings.
+##
+jobs:
+  enabled: true
+
+`, imageKey)
+	}
+
+	// ValidatingAdmissionPolicies and their bindings
+	if f.Extraction != nil && f.Extraction.Features.HasAdmissionPolicies {
+		buf.WriteString(`## ValidatingAdmissionPolicies and ValidatingAdmissionPolicyBindings shipped with the chart.
+## Requires Kubernetes 1.30+ (admissionregistration.k8s.io/v1).
+##
+admissionPolicy:
+  enabled: true
+
+`)
+	}
+
+	// Gateway API Gateways and HTTPRoutes
+	if f.Extraction != nil && f.Extraction.Features.HasGateway {
+		buf.WriteString(`## Gateway API Gateways and HTTPRoutes shipped with the chart.
+## Requires the Gateway API CRDs to be installed in the cluster.
+##
+gateway:
+  enabled: true
+
+`)
+	}
+
+	// LimitRanges and ResourceQuotas
+	if f.Extraction != nil && f.Extraction.Features.HasGovernance {
+		buf.WriteString(`## LimitRanges and ResourceQuotas shipped with the chart, created in the release namespace.
+##
+governance:
+  enabled: true
+
+`)
+	}
+
+	// PriorityClass
+	if f.Extraction != nil && f.Extraction.Features.HasPriorityClass {
+		buf.WriteString(`## PriorityClass shipped with the chart. While create is true, the manager pods use it
+## instead of manager.priorityClassName.
+##
+priorityClass:
+  create: true
+`)
+		fmt.Fprintf(&buf, "  value: %d\n\n", f.Extraction.Features.PriorityClassValue)
+	}
+
 	// Webhook configuration
 	if f.Extraction != nil && f.Extraction.Features.HasWebhooks {
 		f.addWebhookSection(&buf)
@@ -159,7 +330,24 @@ certManager:
 ##
 prometheus:
 `)
-	fmt.Fprintf(&buf, "  enabled: %t\n\n", prometheusEnabled)
+	fmt.Fprintf(&buf, "  enabled: %t\n", prometheusEnabled)
+	buf.WriteString(`  ## Authorization added to every ServiceMonitor endpoint, e.g. when kube-rbac-proxy
+  ## fronts the metrics endpoint. Replaces the service account bearer token.
+  # authorization:
+  #   type: Bearer
+  #   credentials:
+  #     name: metrics-reader-token
+  #     key: token
+  ## Relabeling rules added to every ServiceMonitor endpoint, after the ones from the kustomize output.
+  ## metricRelabelings apply to the scraped samples, relabelings to the target before scraping.
+  # metricRelabelings:
+  #   - action: drop
+  #     regex: go_gc_.*
+  #     sourceLabels:
+  #       - __name__
+  # relabelings: []
+
+`)
 
 	// NetworkPolicy configuration (always present, enabled when NetworkPolicy resources exist)
 	networkPolicyEnabled := f.Extraction != nil && f.Extraction.Features.HasNetworkPolicy
@@ -174,10 +362,11 @@ networkPolicy:
 	return buf.String()
 }
 
-// addImageSection adds the image configuration
-func (f *HelmValues) addImageSection(buf *bytes.Buffer) {
+// addImageSection adds the image configuration, indented by indent
+func (f *HelmValues) addImageSection(buf *bytes.Buffer, indent string) {
 	repo := "controller"
 	tag := ""
+	digest := ""
 	pullPolicy := "IfNotPresent"
 
 	if f.Extraction != nil {
@@ -187,21 +376,29 @@ func (f *HelmValues) addImageSection(buf *bytes.Buffer) {
 		if f.Extraction.Values.Manager.Image.Tag != "" && f.Extraction.Values.Manager.Image.Tag != "latest" {
 			tag = f.Extraction.Values.Manager.Image.Tag
 		}
+		digest = f.Extraction.Values.Manager.Image.Digest
 		if f.Extraction.Values.Manager.Image.PullPolicy != "" {
 			pullPolicy = f.Extraction.Values.Manager.Image.PullPolicy
 		}
 	}
 
-	buf.WriteString("  image:\n")
-	fmt.Fprintf(buf, "    repository: %s\n", repo)
-	buf.WriteString("    ## Image tag (defaults to Chart.appVersion if not set)\n")
-	buf.WriteString("    ##\n")
+	fmt.Fprintf(buf, "%simage:\n", indent)
+	fmt.Fprintf(buf, "%s  repository: %s\n", indent, repo)
+	fmt.Fprintf(buf, "%s  ## Image tag (defaults to Chart.appVersion if not set)\n", indent)
+	fmt.Fprintf(buf, "%s  ##\n", indent)
 	if tag == "" {
-		buf.WriteString("    # tag: \"\"\n")
+		fmt.Fprintf(buf, "%s  # tag: \"\"\n", indent)
 	} else {
-		fmt.Fprintf(buf, "    tag: %q\n", tag)
+		fmt.Fprintf(buf, "%s  tag: %q\n", indent, tag)
 	}
-	fmt.Fprintf(buf, "    pullPolicy: %s\n\n", pullPolicy)
+	fmt.Fprintf(buf, "%s  ## Image digest (e.g. sha256:...). Takes precedence over tag when set\n", indent)
+	fmt.Fprintf(buf, "%s  ##\n", indent)
+	if digest == "" {
+		fmt.Fprintf(buf, "%s  # digest: \"\"\n", indent)
+	} else {
+		fmt.Fprintf(buf, "%s  digest: %q\n", indent, digest)
+	}
+	fmt.Fprintf(buf, "%s  pullPolicy: %s\n\n", indent, pullPolicy)
 }
 
 // addDeploymentConfig adds extracted deployment configuration
@@ -209,6 +406,9 @@ func (f *HelmValues) addDeploymentConfig(buf *bytes.Buffer) {
 	// Args
 	f.addArgsSection(buf)
 
+	// Leader election
+	f.addLeaderElectionSection(buf)
+
 	// Health probe (always present; every manager exposes liveness/readiness probes)
 	f.addHealthProbeSection(buf)
 
@@ -239,18 +439,42 @@ func (f *HelmValues) addDeploymentConfig(buf *bytes.Buffer) {
 	// Strategy
 	f.addStrategySection(buf)
 
+	// Progress deadline
+	f.addProgressDeadlineSection(buf)
+
+	// Minimum ready time
+	f.addMinReadySecondsSection(buf)
+
+	// StatefulSet persistence
+	f.addPersistenceSection(buf)
+
 	// Priority class name
 	f.addPriorityClassNameSection(buf)
 
+	// Host network
+	f.addHostNetworkSection(buf)
+
 	// Topology spread constraints
 	f.addTopologySpreadConstraintsSection(buf)
 
 	// Termination grace period
 	f.addTerminationGracePeriodSection(buf)
 
+	// Container lifecycle hooks
+	f.addLifecycleSection(buf)
+
+	// Termination message policy
+	f.addTerminationMessagePolicySection(buf)
+
+	// Service account token mount
+	f.addAutomountServiceAccountTokenSection(buf)
+
 	// Custom labels and annotations
 	f.addCustomLabelsAnnotationsSection(buf)
 
+	// Restart on every upgrade
+	f.addForceRestartSection(buf)
+
 	// Extra volumes and volume mounts
 	f.addExtraVolumesSection(buf)
 }
@@ -266,6 +490,17 @@ func (f *HelmValues) addArgsSection(buf *bytes.Buffer) {
 	}
 }
 
+// addLeaderElectionSection adds the leader election configuration when the manager runs with --leader-elect
+func (f *HelmValues) addLeaderElectionSection(buf *bytes.Buffer) {
+	if f.Extraction == nil || f.Extraction.Values.Manager.LeaderElection == nil {
+		return
+	}
+	buf.WriteString("  ## Leader election (--leader-elect); disable it for single-replica development deployments\n")
+	buf.WriteString("  ##\n")
+	buf.WriteString("  leaderElection:\n")
+	fmt.Fprintf(buf, "    enabled: %t\n\n", *f.Extraction.Values.Manager.LeaderElection)
+}
+
 // addEnvSection adds the environment variables configuration
 func (f *HelmValues) addEnvSection(buf *bytes.Buffer) {
 	if f.Extraction != nil && len(f.Extraction.Values.Manager.Env) > 0 {
@@ -335,6 +570,7 @@ func (f *HelmValues) addSecurityContextSection(buf *bytes.Buffer) {
 // addResourcesSection adds resources configuration
 func (f *HelmValues) addResourcesSection(buf *bytes.Buffer) {
 	buf.WriteString("  ## Resource limits and requests\n")
+	buf.WriteString("  ## Replaces the resources from the kustomize output and any resourcesProfile as a whole.\n")
 	buf.WriteString("  ##\n")
 	if f.Extraction != nil && f.Extraction.Values.Manager.Resources != nil {
 		buf.WriteString("  resources:\n")
@@ -349,6 +585,10 @@ func (f *HelmValues) addResourcesSection(buf *bytes.Buffer) {
 		buf.WriteString("  #     cpu: 10m\n")
 		buf.WriteString("  #     memory: 64Mi\n\n")
 	}
+	buf.WriteString("  ## Named resource profile for the manager container: small, medium or large.\n")
+	buf.WriteString("  ## Only applies while resources above is unset; remove it to use the profile.\n")
+	buf.WriteString("  ##\n")
+	buf.WriteString("  # resourcesProfile: small\n\n")
 }
 
 // addAffinitySection adds affinity configuration
@@ -362,6 +602,13 @@ func (f *HelmValues) addAffinitySection(buf *bytes.Buffer) {
 	} else {
 		buf.WriteString("  affinity: {}\n\n")
 	}
+
+	buf.WriteString(`  ## When no affinity is set above, prefer spreading the manager replicas across nodes
+  ## with a soft pod anti-affinity on the manager selector labels.
+  ##
+  defaultAffinity: false
+
+`)
 }
 
 // addNodeSelectorSection adds node selector configuration
@@ -388,9 +635,21 @@ func (f *HelmValues) addTolerationsSection(buf *bytes.Buffer) {
 	} else {
 		buf.WriteString("  tolerations: []\n\n")
 	}
+
+	buf.WriteString(`  ## Tolerate the node.kubernetes.io/not-ready and node.kubernetes.io/unreachable taints for
+  ## tolerationSeconds before the manager pod is evicted from a failed node. Added to the
+  ## tolerations above unless those already tolerate the taint. Lower tolerationSeconds to
+  ## reschedule the manager faster; omit it to tolerate the taints indefinitely.
+  ##
+  nodeFailureTolerations:
+    enabled: true
+    tolerationSeconds: 300
+
+`)
 }
 
-// addStrategySection adds deployment strategy configuration
+// addStrategySection adds deployment strategy configuration, and the DaemonSet update strategy when
+// the project ships DaemonSets
 func (f *HelmValues) addStrategySection(buf *bytes.Buffer) {
 	if f.Extraction != nil && f.Extraction.Values.Manager.Strategy != nil {
 		buf.WriteString("  ## Deployment strategy\n")
@@ -407,6 +666,49 @@ func (f *HelmValues) addStrategySection(buf *bytes.Buffer) {
 		buf.WriteString("  #     maxSurge: 25%\n")
 		buf.WriteString("  #     maxUnavailable: 25%\n\n")
 	}
+
+	if f.Extraction != nil && f.Extraction.Features.HasDaemonSets {
+		buf.WriteString("  ## DaemonSet update strategy, for DaemonSets running the manager container\n")
+		buf.WriteString("  ##\n")
+		buf.WriteString("  # updateStrategy:\n")
+		buf.WriteString("  #   type: RollingUpdate\n")
+		buf.WriteString("  #   rollingUpdate:\n")
+		buf.WriteString("  #     maxUnavailable: 1\n\n")
+	}
+}
+
+// addProgressDeadlineSection adds the Deployment progress deadline configuration
+func (f *HelmValues) addProgressDeadlineSection(buf *bytes.Buffer) {
+	buf.WriteString("  ## Seconds a Deployment rollout may take before it is reported as failed\n")
+	buf.WriteString("  ##\n")
+	if f.Extraction != nil && f.Extraction.Values.Manager.ProgressDeadlineSeconds != nil {
+		fmt.Fprintf(buf, "  progressDeadlineSeconds: %d\n\n", *f.Extraction.Values.Manager.ProgressDeadlineSeconds)
+	} else {
+		buf.WriteString("  # progressDeadlineSeconds: 600\n\n")
+	}
+}
+
+// addMinReadySecondsSection adds the Deployment minimum ready time configuration
+func (f *HelmValues) addMinReadySecondsSection(buf *bytes.Buffer) {
+	buf.WriteString("  ## Seconds a new manager pod must be ready before it counts as available during a rollout\n")
+	buf.WriteString("  ##\n")
+	if f.Extraction != nil && f.Extraction.Values.Manager.MinReadySeconds != nil {
+		fmt.Fprintf(buf, "  minReadySeconds: %d\n\n", *f.Extraction.Values.Manager.MinReadySeconds)
+	} else {
+		buf.WriteString("  # minReadySeconds: 0\n\n")
+	}
+}
+
+// addPersistenceSection adds the volumeClaimTemplates storage settings when the project ships StatefulSets
+func (f *HelmValues) addPersistenceSection(buf *bytes.Buffer) {
+	if f.Extraction == nil || !f.Extraction.Features.HasStatefulSets {
+		return
+	}
+	buf.WriteString("  ## Storage for the volumeClaimTemplates of StatefulSets running the manager container\n")
+	buf.WriteString("  ##\n")
+	buf.WriteString("  # persistence:\n")
+	buf.WriteString("  #   size: 1Gi\n")
+	buf.WriteString("  #   storageClassName: standard\n\n")
 }
 
 // addPriorityClassNameSection adds priority class name configuration
@@ -420,6 +722,24 @@ func (f *HelmValues) addPriorityClassNameSection(buf *bytes.Buffer) {
 	}
 }
 
+// addHostNetworkSection adds host network and DNS policy configuration
+func (f *HelmValues) addHostNetworkSection(buf *bytes.Buffer) {
+	buf.WriteString("  ## Run the manager pod on the host network\n")
+	buf.WriteString("  ## dnsPolicy only applies when hostNetwork is enabled (default: ClusterFirstWithHostNet)\n")
+	buf.WriteString("  ##\n")
+	if f.Extraction != nil && f.Extraction.Values.Manager.HostNetwork {
+		buf.WriteString("  hostNetwork: true\n")
+		if f.Extraction.Values.Manager.DNSPolicy != "" {
+			fmt.Fprintf(buf, "  dnsPolicy: %s\n\n", f.Extraction.Values.Manager.DNSPolicy)
+		} else {
+			buf.WriteString("  # dnsPolicy: ClusterFirstWithHostNet\n\n")
+		}
+	} else {
+		buf.WriteString("  # hostNetwork: false\n")
+		buf.WriteString("  # dnsPolicy: ClusterFirstWithHostNet\n\n")
+	}
+}
+
 // addTopologySpreadConstraintsSection adds topology spread constraints configuration
 func (f *HelmValues) addTopologySpreadConstraintsSection(buf *bytes.Buffer) {
 	if f.Extraction != nil && len(f.Extraction.Values.Manager.TopologySpreadConstraints) > 0 {
@@ -451,6 +771,36 @@ func (f *HelmValues) addTerminationGracePeriodSection(buf *bytes.Buffer) {
 	}
 }
 
+// addLifecycleSection adds the manager container lifecycle hooks configuration
+func (f *HelmValues) addLifecycleSection(buf *bytes.Buffer) {
+	buf.WriteString("  ## Manager container lifecycle hooks, e.g. a preStop hook draining connections before\n")
+	buf.WriteString("  ## shutdown. Keep terminationGracePeriodSeconds longer than the hook.\n")
+	buf.WriteString("  ##\n")
+	buf.WriteString("  # lifecycle:\n")
+	buf.WriteString("  #   preStop:\n")
+	buf.WriteString("  #     exec:\n")
+	buf.WriteString("  #       command: [\"sleep\", \"5\"]\n\n")
+}
+
+// addTerminationMessagePolicySection adds the manager container terminationMessagePolicy configuration
+func (f *HelmValues) addTerminationMessagePolicySection(buf *bytes.Buffer) {
+	buf.WriteString("  ## Termination message policy of the manager container (default: File). FallbackToLogsOnError\n")
+	buf.WriteString("  ## reports the last log lines as the termination message when the manager crashes.\n")
+	buf.WriteString("  ##\n")
+	buf.WriteString("  # terminationMessagePolicy: FallbackToLogsOnError\n\n")
+}
+
+// addAutomountServiceAccountTokenSection adds the pod automountServiceAccountToken configuration
+func (f *HelmValues) addAutomountServiceAccountTokenSection(buf *bytes.Buffer) {
+	buf.WriteString("  ## Mount the service account token into the manager pod; unset keeps the Kubernetes default\n")
+	buf.WriteString("  ##\n")
+	if f.Extraction != nil && f.Extraction.Values.Manager.AutomountServiceAccountToken != nil {
+		fmt.Fprintf(buf, "  automountServiceAccountToken: %t\n\n", *f.Extraction.Values.Manager.AutomountServiceAccountToken)
+	} else {
+		buf.WriteString("  # automountServiceAccountToken: true\n\n")
+	}
+}
+
 // addCustomLabelsAnnotationsSection adds custom labels and annotations configuration
 func (f *HelmValues) addCustomLabelsAnnotationsSection(buf *bytes.Buffer) {
 	buf.WriteString("  ## Custom Deployment labels\n")
@@ -466,6 +816,14 @@ func (f *HelmValues) addCustomLabelsAnnotationsSection(buf *bytes.Buffer) {
 	buf.WriteString("  #   annotations: {}\n\n")
 }
 
+// addForceRestartSection adds the flag restarting the manager pods on every upgrade
+func (f *HelmValues) addForceRestartSection(buf *bytes.Buffer) {
+	buf.WriteString("  ## Restart the manager pods on every install and upgrade, even when nothing else changed,\n")
+	buf.WriteString("  ## by stamping the pod template with a kubectl.kubernetes.io/restartedAt annotation\n")
+	buf.WriteString("  ##\n")
+	buf.WriteString("  forceRestart: false\n\n")
+}
+
 // addExtraVolumesSection adds extra volumes and volume mounts configuration
 func (f *HelmValues) addExtraVolumesSection(buf *bytes.Buffer) {
 	hasExtraVolumes := f.Extraction != nil && len(f.Extraction.Values.Manager.ExtraVolumes) > 0
@@ -487,6 +845,23 @@ func (f *HelmValues) addExtraVolumesSection(buf *bytes.Buffer) {
 			buf.WriteString("\n")
 		}
 	}
+
+	if hasExtraVolumes && slices.ContainsFunc(f.Extraction.Values.Manager.ExtraVolumes, isEmptyDirVolume) {
+		buf.WriteString("  ## sizeLimit of the emptyDir scratch volumes (e.g. 1Gi), for clusters with constrained\n")
+		buf.WriteString("  ## ephemeral storage. A sizeLimit set in the manifest is used while this is unset.\n")
+		buf.WriteString("  ##\n")
+		buf.WriteString("  # scratchSizeLimit: 1Gi\n\n")
+	}
+}
+
+// isEmptyDirVolume reports whether volume is an emptyDir pod volume.
+func isEmptyDirVolume(volume any) bool {
+	volumeMap, ok := volume.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, found := volumeMap["emptyDir"]
+	return found
 }
 
 // addRBACSection adds RBAC configuration
@@ -500,6 +875,13 @@ rbac:
   ##
   namespaced: false
 
+  ## Namespace the manager watches when it is not the release namespace. When set, the
+  ## manager gets WATCH_NAMESPACE and Roles and RoleBindings are deployed there. The leader
+  ## election Role and RoleBinding, roleNamespaces entries and ServiceAccount subjects are not
+  ## affected. Unset (default): all namespaces are watched.
+  ##
+  # watchNamespace: ""
+
 `)
 
 	// Only add roleNamespaces if multi-namespace RBAC is detected
@@ -555,6 +937,10 @@ serviceAccount:
   ##
   # labels: {}
 
+  ## Mount the ServiceAccount token into pods by default; unset keeps the Kubernetes default
+  ##
+  # automountServiceAccountToken: false
+
 `)
 }
 
@@ -582,10 +968,85 @@ metrics:
 	buf.WriteString(`  # Enable secure metrics: HTTPS with certs/auth (true) or HTTP (false).
   # Note: Metrics authn/authz needs ClusterRole access.
   secure: true
+  # appProtocol of the metrics Service port, named https or http after secure, so service
+  # meshes such as Istio and Linkerd classify the traffic (e.g. https or http).
+  # appProtocol: https
+  # Secret holding the metrics server TLS certificate (default: metrics-server-cert).
+  # Set it when bringing your own certificate instead of the one cert-manager issues.
+  # certSecretName: metrics-server-cert
+  # ClusterRole granting GET on /metrics, bound by external scrapers such as Prometheus.
+  # Created with secure metrics unless set here.
+  # reader:
+  #   enabled: true
+  # Metrics Service settings.
+  # service:
+  #   # Extra annotations merged into the Service metadata (e.g. cloud load balancer settings).
+  #   annotations: {}
+  #   # Service type; externalTrafficPolicy and loadBalancerSourceRanges only apply to
+  #   # LoadBalancer and NodePort Services.
+  #   type: ClusterIP
+  #   externalTrafficPolicy: Local
+  #   loadBalancerSourceRanges: []
 
 `)
 }
 
+// addKubeRbacProxySection adds the image and resources of the kube-rbac-proxy sidecar
+func (f *HelmValues) addKubeRbacProxySection(buf *bytes.Buffer) {
+	proxy := f.Extraction.Values.KubeRbacProxy
+
+	buf.WriteString(`## kube-rbac-proxy sidecar protecting the metrics endpoint.
+## Rendered in the manager pod only when metrics.enabled is true.
+##
+kubeRbacProxy:
+  image:
+`)
+	fmt.Fprintf(buf, "    repository: %s\n", proxy.Image.Repository)
+	if proxy.Image.Digest == "" {
+		fmt.Fprintf(buf, "    tag: %q\n", proxy.Image.Tag)
+	} else {
+		fmt.Fprintf(buf, "    digest: %q\n", proxy.Image.Digest)
+	}
+	fmt.Fprintf(buf, "    pullPolicy: %s\n", proxy.Image.PullPolicy)
+	buf.WriteString("  ## Resource limits and requests of the sidecar\n")
+	buf.WriteString("  ##\n")
+	if proxy.Resources != nil {
+		buf.WriteString("  resources:\n")
+		f.marshalAndIndent(buf, proxy.Resources, "kubeRbacProxy.resources")
+		buf.WriteString("\n")
+	} else {
+		buf.WriteString("  resources: {}\n\n")
+	}
+}
+
+// addDeploymentValuesSection adds the replicas, image and resources of a Deployment shipped next to
+// the manager
+func (f *HelmValues) addDeploymentValuesSection(buf *bytes.Buffer, deployment extractor.DeploymentValues) {
+	fmt.Fprintf(buf, "## Configure the %s Deployment\n##\n%s:\n", deployment.Name, deployment.Key)
+	if deployment.Replicas != nil {
+		fmt.Fprintf(buf, "  replicas: %d\n", *deployment.Replicas)
+	}
+	if deployment.Image.Repository != "" {
+		buf.WriteString("  image:\n")
+		fmt.Fprintf(buf, "    repository: %s\n", deployment.Image.Repository)
+		if deployment.Image.Digest == "" {
+			fmt.Fprintf(buf, "    tag: %q\n", deployment.Image.Tag)
+		} else {
+			fmt.Fprintf(buf, "    digest: %q\n", deployment.Image.Digest)
+		}
+		if deployment.Image.PullPolicy != "" {
+			fmt.Fprintf(buf, "    pullPolicy: %s\n", deployment.Image.PullPolicy)
+		}
+	}
+	if deployment.Resources != nil {
+		buf.WriteString("  resources:\n")
+		f.marshalAndIndent(buf, deployment.Resources, deployment.Key+".resources")
+	} else {
+		buf.WriteString("  resources: {}\n")
+	}
+	buf.WriteString("\n")
+}
+
 // addHealthProbeSection adds health probe configuration under the manager section
 func (f *HelmValues) addHealthProbeSection(buf *bytes.Buffer) {
 	port := 8081
@@ -599,7 +1060,9 @@ func (f *HelmValues) addHealthProbeSection(buf *bytes.Buffer) {
   healthProbe:
 `)
 	buf.WriteString("    # Health probe server port\n")
-	fmt.Fprintf(buf, "    port: %d\n\n", port)
+	fmt.Fprintf(buf, "    port: %d\n", port)
+	buf.WriteString("    # Liveness and readiness probe scheme, HTTPS when the endpoints are served over TLS\n")
+	buf.WriteString("    # scheme: HTTP\n\n")
 }
 
 // addWebhookSection adds webhook configuration
@@ -615,7 +1078,28 @@ webhook:
   enabled: true
   # Webhook server port
 `)
-	fmt.Fprintf(buf, "  port: %d\n\n", port)
+	fmt.Fprintf(buf, "  port: %d\n", port)
+	buf.WriteString(`  # Secret holding the webhook serving certificate (default: webhook-server-cert).
+  # Set it when bringing your own certificate instead of the one cert-manager issues.
+  # certSecretName: webhook-server-cert
+  # Base64-encoded PEM CA bundle the API server uses to trust the webhook server when
+  # certManager.enabled is false. Ignored while cert-manager injects the CA.
+  # caBundle: ""
+  # Extra DNS names added to the serving certificate cert-manager issues, e.g. the host of an
+  # ingress in front of the webhook Service.
+  # extraDNSNames: []
+  # matchPolicy of every webhook: Exact or Equivalent. Defaults to the manifest value, or Equivalent.
+  # matchPolicy: Equivalent
+  # sideEffects of every webhook: None or NoneOnDryRun. Defaults to the manifest value.
+  # sideEffects: None
+  # Webhook Service settings.
+  # service:
+  #   # Extra annotations merged into the Service metadata (e.g. cloud load balancer settings).
+  #   annotations: {}
+  #   # Port the webhook Service exposes; the webhook configurations call it on this port.
+  #   port: 443
+
+`)
 }
 
 // indentYAML indents YAML content by 4 spaces
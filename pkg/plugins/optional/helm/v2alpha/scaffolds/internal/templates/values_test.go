@@ -72,6 +72,310 @@ var _ = Describe("HelmValues", func() {
 		})
 	})
 
+	Describe("Image section", func() {
+		It("should document the optional image digest under manager.image", func() {
+			values := &HelmValues{Extraction: nil}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			imageSection := extractSection(result, "  image:")
+			Expect(imageSection).To(ContainSubstring("    # digest: \"\"\n"))
+			Expect(imageSection).To(ContainSubstring("Takes precedence over tag"))
+		})
+
+		It("should seed the image from the extracted manager image", func() {
+			values := &HelmValues{
+				Extraction: &extractor.Extraction{
+					Values: extractor.ValuesConfig{
+						Manager: extractor.ManagerConfig{Image: extractor.ImageConfig{
+							Repository: "example.com/controller",
+							Digest:     "sha256:abc123",
+							PullPolicy: "Always",
+						}},
+					},
+				},
+			}
+			values.ProjectName = testProjectName
+
+			imageSection := extractSection(values.generateValues(), "  image:")
+			Expect(imageSection).To(ContainSubstring("    repository: example.com/controller\n"))
+			Expect(imageSection).To(ContainSubstring("    # tag: \"\"\n"))
+			Expect(imageSection).To(ContainSubstring("    digest: \"sha256:abc123\"\n"))
+			Expect(imageSection).To(ContainSubstring("    pullPolicy: Always\n"))
+		})
+
+		It("should write the image at the top level with flat values", func() {
+			values := &HelmValues{
+				Extraction: &extractor.Extraction{
+					Values: extractor.ValuesConfig{
+						Manager: extractor.ManagerConfig{Image: extractor.ImageConfig{
+							Repository: "example.com/controller",
+						}},
+					},
+					Features: extractor.FeatureSet{HasJobs: true},
+				},
+				FlatValues: true,
+			}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			imageSection := extractSection(result, "image:")
+			Expect(imageSection).To(ContainSubstring("  repository: example.com/controller\n"))
+			Expect(imageSection).To(ContainSubstring("  pullPolicy: IfNotPresent\n"))
+			Expect(result).NotTo(ContainSubstring("\n  image:\n"))
+			Expect(result).To(ContainSubstring(`Containers named "manager" use the image settings.`))
+		})
+
+		It("should document the optional global image registry prefix", func() {
+			values := &HelmValues{Extraction: nil}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			Expect(result).To(ContainSubstring("# global:\n"))
+			Expect(result).To(ContainSubstring("#   imageRegistry: \"\"\n"))
+		})
+
+		It("should document commonLabels as a commented example", func() {
+			values := &HelmValues{Extraction: nil}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			Expect(result).To(ContainSubstring("# commonLabels:\n#   example.com/team: platform\n"))
+			Expect(result).NotTo(MatchRegexp(`(?m)^commonLabels:`))
+		})
+
+		It("should document commonAnnotations as a commented example", func() {
+			values := &HelmValues{Extraction: nil}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			Expect(result).To(ContainSubstring("# commonAnnotations:\n#   example.com/team: platform\n"))
+			Expect(result).NotTo(MatchRegexp(`(?m)^commonAnnotations:`))
+		})
+	})
+
+	Describe("kube-rbac-proxy section", func() {
+		It("should omit kubeRbacProxy without the sidecar", func() {
+			values := &HelmValues{Extraction: nil}
+			values.ProjectName = testProjectName
+
+			Expect(values.generateValues()).NotTo(ContainSubstring("kubeRbacProxy"))
+		})
+
+		It("should seed kubeRbacProxy from the extracted sidecar", func() {
+			values := &HelmValues{
+				Extraction: &extractor.Extraction{
+					Values: extractor.ValuesConfig{
+						KubeRbacProxy: &extractor.KubeRbacProxyConfig{
+							Image: extractor.ImageConfig{
+								Repository: "quay.io/brancz/kube-rbac-proxy",
+								Tag:        "v0.16.0",
+								PullPolicy: "IfNotPresent",
+							},
+							Resources: map[string]any{"limits": map[string]any{"cpu": "500m"}},
+						},
+					},
+				},
+			}
+			values.ProjectName = testProjectName
+
+			Expect(values.generateValues()).To(ContainSubstring(`kubeRbacProxy:
+  image:
+    repository: quay.io/brancz/kube-rbac-proxy
+    tag: "v0.16.0"
+    pullPolicy: IfNotPresent
+  ## Resource limits and requests of the sidecar
+  ##
+  resources:
+    limits:
+      cpu: 500m
+`))
+		})
+	})
+
+	Describe("Additional Deployment sections", func() {
+		It("should add one section per Deployment under its values key", func() {
+			replicas := 2
+			values := &HelmValues{
+				Extraction: &extractor.Extraction{
+					Values: extractor.ValuesConfig{
+						Deployments: []extractor.DeploymentValues{
+							{
+								Name:     "test-project-worker",
+								Key:      "worker",
+								Replicas: &replicas,
+								Image: extractor.ImageConfig{
+									Repository: "example.com/worker",
+									Tag:        "v1.0.0",
+									PullPolicy: "Always",
+								},
+								Resources: map[string]any{"limits": map[string]any{"cpu": "200m"}},
+							},
+							{
+								Name:  "test-project-some-operator",
+								Key:   "someOperator",
+								Image: extractor.ImageConfig{Repository: "example.com/operator", Digest: "sha256:abc123"},
+							},
+						},
+					},
+				},
+			}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+			Expect(result).To(ContainSubstring(`## Configure the test-project-worker Deployment
+##
+worker:
+  replicas: 2
+  image:
+    repository: example.com/worker
+    tag: "v1.0.0"
+    pullPolicy: Always
+  resources:
+    limits:
+      cpu: 200m
+`))
+			Expect(result).To(ContainSubstring(`someOperator:
+  image:
+    repository: example.com/operator
+    digest: "sha256:abc123"
+  resources: {}
+`))
+		})
+	})
+
+	Describe("Tolerations section", func() {
+		It("should enable the node failure tolerations next to the extracted tolerations", func() {
+			values := &HelmValues{
+				Extraction: &extractor.Extraction{
+					Values: extractor.ValuesConfig{
+						Manager: extractor.ManagerConfig{
+							Tolerations: []any{map[string]any{"key": "dedicated", "operator": "Exists"}},
+						},
+					},
+				},
+			}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+			Expect(result).To(ContainSubstring(`  tolerations:
+    - key: dedicated
+      operator: Exists
+`))
+			Expect(result).To(ContainSubstring(`  nodeFailureTolerations:
+    enabled: true
+    tolerationSeconds: 300
+`))
+		})
+	})
+
+	Describe("Affinity section", func() {
+		It("should leave defaultAffinity disabled by default", func() {
+			values := &HelmValues{Extraction: nil}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+			Expect(result).To(ContainSubstring("  affinity: {}\n"))
+			Expect(result).To(ContainSubstring("  defaultAffinity: false\n"))
+		})
+	})
+
+	Describe("Force restart section", func() {
+		It("should leave forceRestart disabled by default", func() {
+			values := &HelmValues{Extraction: nil}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+			Expect(result).To(ContainSubstring("  forceRestart: false\n"))
+		})
+	})
+
+	Describe("Host network section", func() {
+		It("should keep hostNetwork and dnsPolicy commented out by default", func() {
+			values := &HelmValues{Extraction: nil}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			Expect(result).To(ContainSubstring("  # hostNetwork: false\n"))
+			Expect(result).To(ContainSubstring("  # dnsPolicy: ClusterFirstWithHostNet\n"))
+		})
+
+		It("should emit hostNetwork and dnsPolicy extracted from the Deployment", func() {
+			values := &HelmValues{
+				Extraction: &extractor.Extraction{
+					Values: extractor.ValuesConfig{
+						Manager: extractor.ManagerConfig{HostNetwork: true, DNSPolicy: "ClusterFirstWithHostNet"},
+					},
+				},
+			}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			Expect(result).To(ContainSubstring("  hostNetwork: true\n"))
+			Expect(result).To(ContainSubstring("  dnsPolicy: ClusterFirstWithHostNet\n"))
+		})
+
+		It("should emit progressDeadlineSeconds extracted from the Deployment or document the default", func() {
+			values := &HelmValues{}
+			values.ProjectName = testProjectName
+
+			Expect(values.generateValues()).To(ContainSubstring("  # progressDeadlineSeconds: 600\n"))
+
+			deadline := 1200
+			values.Extraction = &extractor.Extraction{
+				Values: extractor.ValuesConfig{
+					Manager: extractor.ManagerConfig{ProgressDeadlineSeconds: &deadline},
+				},
+			}
+			Expect(values.generateValues()).To(ContainSubstring("  progressDeadlineSeconds: 1200\n"))
+		})
+
+		It("should document the manager resourcesProfile", func() {
+			values := &HelmValues{}
+			values.ProjectName = testProjectName
+
+			Expect(values.generateValues()).To(ContainSubstring(`  # resourcesProfile: small
+`))
+		})
+
+		It("should emit minReadySeconds extracted from the Deployment or document the default", func() {
+			values := &HelmValues{}
+			values.ProjectName = testProjectName
+
+			Expect(values.generateValues()).To(ContainSubstring("  # minReadySeconds: 0\n"))
+
+			minReady := 15
+			values.Extraction = &extractor.Extraction{
+				Values: extractor.ValuesConfig{
+					Manager: extractor.ManagerConfig{MinReadySeconds: &minReady},
+				},
+			}
+			Expect(values.generateValues()).To(ContainSubstring("  minReadySeconds: 15\n"))
+		})
+
+		It("should emit automountServiceAccountToken extracted from the Deployment or leave it commented", func() {
+			values := &HelmValues{}
+			values.ProjectName = testProjectName
+
+			Expect(values.generateValues()).To(ContainSubstring("  # automountServiceAccountToken: true\n"))
+
+			automount := false
+			values.Extraction = &extractor.Extraction{
+				Values: extractor.ValuesConfig{
+					Manager: extractor.ManagerConfig{AutomountServiceAccountToken: &automount},
+				},
+			}
+			Expect(values.generateValues()).To(ContainSubstring("  automountServiceAccountToken: false\n"))
+		})
+	})
+
 	Describe("Prometheus section", func() {
 		It("should default prometheus.enabled to false when no ServiceMonitor exists", func() {
 			values := &HelmValues{Extraction: nil}
@@ -96,6 +400,41 @@ var _ = Describe("HelmValues", func() {
 
 			Expect(result).To(ContainSubstring("prometheus:\n  enabled: true"))
 		})
+
+		It("should document prometheus.authorization commented out", func() {
+			values := &HelmValues{}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			Expect(result).To(ContainSubstring("  enabled: false\n  ## Authorization added to every ServiceMonitor endpoint"))
+			Expect(result).To(ContainSubstring("  # authorization:\n  #   type: Bearer\n"))
+			Expect(result).NotTo(MatchRegexp(`(?m)^  authorization:`))
+		})
+
+		It("should document the ServiceMonitor relabelings commented out", func() {
+			values := &HelmValues{}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			Expect(result).To(ContainSubstring("  # metricRelabelings:\n  #   - action: drop\n"))
+			Expect(result).To(ContainSubstring("  # relabelings: []\n"))
+			Expect(result).NotTo(MatchRegexp(`(?m)^  (metricRelabelings|relabelings):`))
+		})
+	})
+
+	Describe("watchNamespace rendering", func() {
+		It("should document rbac.watchNamespace commented out so Roles default to the release namespace", func() {
+			values := &HelmValues{}
+			values.ProjectName = testProjectName
+
+			result := values.generateValues()
+
+			Expect(result).To(ContainSubstring("  namespaced: false\n\n"))
+			Expect(result).To(ContainSubstring("\n  # watchNamespace: \"\"\n"))
+			Expect(result).NotTo(MatchRegexp(`(?m)^  watchNamespace:`))
+		})
 	})
 
 	Describe("RoleNamespaces rendering", func() {
@@ -266,6 +605,401 @@ var _ = Describe("HelmValues", func() {
 			})
 		})
 
+		Context("metrics cert secret name", func() {
+			It("should document certSecretName as a commented-out metrics option", func() {
+				values := &HelmValues{}
+				values.ProjectName = testProjectName
+
+				result := values.generateValues()
+
+				Expect(extractSection(result, "metrics:")).To(
+					ContainSubstring("  # certSecretName: metrics-server-cert\n"))
+			})
+		})
+
+		Context("metrics appProtocol", func() {
+			It("should document appProtocol as a commented-out metrics option", func() {
+				values := &HelmValues{}
+				values.ProjectName = testProjectName
+
+				Expect(extractSection(values.generateValues(), "metrics:")).To(
+					ContainSubstring("  # appProtocol: https\n"))
+			})
+		})
+
+		Context("Certificate subject and IP SANs", func() {
+			It("should document ipAddresses and subject when cert-manager is enabled", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasCertManager: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				section := extractSection(values.generateValues(), "certManager:")
+				Expect(section).To(ContainSubstring("  # ipAddresses:\n  #   - 10.0.0.10\n"))
+				Expect(section).To(ContainSubstring("  # subject:\n  #   organizations:\n  #     - my-org\n"))
+
+				values.Extraction.Features.HasCertManager = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("# ipAddresses:"))
+			})
+		})
+
+		Context("kept namespace", func() {
+			It("should add the namespace section only when the Namespace is kept", func() {
+				values := &HelmValues{KeepNamespace: true}
+				values.ProjectName = testProjectName
+
+				result := values.generateValues()
+				Expect(result).To(ContainSubstring("\nnamespace:\n  create: false\n"))
+				Expect(result).To(ContainSubstring("  # labels:\n  #   pod-security.kubernetes.io/enforce: restricted\n"))
+
+				values.KeepNamespace = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("\nnamespace:"))
+			})
+		})
+
+		Context("ConfigMap data overrides", func() {
+			It("should document config only when the project ships ConfigMaps", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasConfigMaps: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("# config:\n#   log-level: debug\n"))
+
+				values.Extraction.Features.HasConfigMaps = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("# config:"))
+			})
+		})
+
+		Context("Jobs", func() {
+			It("should add jobs.enabled only when the project ships Jobs or CronJobs", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasJobs: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("\njobs:\n  enabled: true\n"))
+
+				values.Extraction.Features.HasJobs = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("jobs:"))
+			})
+		})
+
+		Context("admission policies", func() {
+			It("should add admissionPolicy.enabled only when the project ships admission policies", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasAdmissionPolicies: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("\nadmissionPolicy:\n  enabled: true\n"))
+
+				values.Extraction.Features.HasAdmissionPolicies = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("admissionPolicy:"))
+			})
+		})
+
+		Context("Gateway API", func() {
+			It("should add gateway.enabled only when the project ships Gateway API resources", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasGateway: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("\ngateway:\n  enabled: true\n"))
+
+				values.Extraction.Features.HasGateway = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("\ngateway:"))
+			})
+		})
+
+		Context("governance", func() {
+			It("should add governance.enabled only when the project ships LimitRanges or ResourceQuotas", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasGovernance: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("\ngovernance:\n  enabled: true\n"))
+
+				values.Extraction.Features.HasGovernance = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("governance:"))
+			})
+		})
+
+		Context("PriorityClass", func() {
+			It("should add priorityClass only when the project ships a PriorityClass", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasPriorityClass: true, PriorityClassValue: 1000000},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("\npriorityClass:\n  create: true\n  value: 1000000\n"))
+
+				values.Extraction.Features.HasPriorityClass = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("\npriorityClass:"))
+			})
+		})
+
+		Context("lifecycle hooks", func() {
+			It("should document manager.lifecycle with a preStop example", func() {
+				values := &HelmValues{}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring(
+					"  # lifecycle:\n  #   preStop:\n  #     exec:\n  #       command: [\"sleep\", \"5\"]\n"))
+			})
+		})
+
+		Context("scratch volumes", func() {
+			It("should document scratchSizeLimit only when the manager has an emptyDir volume", func() {
+				values := &HelmValues{Extraction: &extractor.Extraction{}}
+				values.ProjectName = testProjectName
+				values.Extraction.Values.Manager.ExtraVolumes = []any{
+					map[string]any{"name": "scratch", "emptyDir": map[string]any{}},
+				}
+
+				Expect(values.generateValues()).To(ContainSubstring("\n  # scratchSizeLimit: 1Gi\n"))
+
+				values.Extraction.Values.Manager.ExtraVolumes = []any{
+					map[string]any{"name": "config", "configMap": map[string]any{"name": "manager-config"}},
+				}
+				Expect(values.generateValues()).NotTo(ContainSubstring("scratchSizeLimit"))
+			})
+		})
+
+		Context("DaemonSet update strategy", func() {
+			It("should document manager.updateStrategy only when the project ships DaemonSets", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasDaemonSets: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring(
+					"  # updateStrategy:\n  #   type: RollingUpdate\n  #   rollingUpdate:\n  #     maxUnavailable: 1\n"))
+
+				values.Extraction.Features.HasDaemonSets = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("updateStrategy"))
+			})
+		})
+
+		Context("StatefulSet persistence", func() {
+			It("should document manager.persistence only when the project ships StatefulSets", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasStatefulSets: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring(
+					"  # persistence:\n  #   size: 1Gi\n  #   storageClassName: standard\n"))
+
+				values.Extraction.Features.HasStatefulSets = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("persistence"))
+			})
+		})
+
+		Context("Service annotations", func() {
+			It("should document service.annotations for the metrics and webhook Services", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasMetrics: true, HasWebhooks: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				result := values.generateValues()
+
+				for _, section := range []string{"metrics:", "webhook:"} {
+					Expect(extractSection(result, section)).To(
+						ContainSubstring("  # service:\n  #   # Extra annotations merged into the Service metadata"))
+					Expect(extractSection(result, section)).To(ContainSubstring("  #   annotations: {}\n"))
+				}
+			})
+
+			It("should document the metrics Service type and load balancer settings", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasMetrics: true, HasWebhooks: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				result := values.generateValues()
+
+				Expect(result).To(ContainSubstring("  #   type: ClusterIP\n" +
+					"  #   externalTrafficPolicy: Local\n" +
+					"  #   loadBalancerSourceRanges: []\n"))
+				Expect(strings.Count(result, "#   type: ClusterIP")).To(Equal(1))
+			})
+		})
+
+		Context("webhook cert secret name", func() {
+			It("should document certSecretName as a commented-out webhook option", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasWebhooks: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				result := values.generateValues()
+
+				Expect(extractSection(result, "webhook:")).To(
+					ContainSubstring("  # certSecretName: webhook-server-cert\n"))
+			})
+		})
+
+		Context("webhook CA bundle", func() {
+			It("should document caBundle as a commented-out webhook option", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasWebhooks: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(extractSection(values.generateValues(), "webhook:")).To(
+					ContainSubstring("  # caBundle: \"\"\n"))
+			})
+		})
+
+		Context("omitted cert-manager resources", func() {
+			It("should disable certManager even for a project with webhooks", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasWebhooks: true, HasCertManager: true},
+					},
+					OmitCertManager: true,
+				}
+				values.ProjectName = testProjectName
+
+				section := extractSection(values.generateValues(), "certManager:")
+				Expect(section).To(ContainSubstring("certManager:\n  enabled: false\n"))
+				Expect(section).NotTo(ContainSubstring("issuerSpec"))
+			})
+		})
+
+		Context("revision annotation", func() {
+			It("should document revision only with the revision annotation", func() {
+				values := &HelmValues{RevisionAnnotation: true}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("\n# revision: \"\"\n"))
+
+				values.RevisionAnnotation = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("revision:"))
+			})
+
+			It("should document partOf only with the provenance annotations", func() {
+				values := &HelmValues{ProvenanceAnnotations: true}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("\n# partOf: \"\"\n"))
+
+				values.ProvenanceAnnotations = false
+				Expect(values.generateValues()).NotTo(ContainSubstring("partOf:"))
+			})
+		})
+
+		Context("omitted CRDs", func() {
+			It("should leave out the crd section", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasCRDs: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring("\ncrd:\n"))
+
+				values.OmitCRDs = true
+				Expect(values.generateValues()).NotTo(ContainSubstring("\ncrd:\n"))
+			})
+		})
+
+		Context("manager args", func() {
+			It("should seed manager.args with the extracted flags", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Values: extractor.ValuesConfig{
+							Manager: extractor.ManagerConfig{Args: []any{"--zap-log-level=debug", "--zap-devel"}},
+						},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring(
+					"\n  args:\n    - --zap-log-level=debug\n    - --zap-devel\n"))
+			})
+		})
+
+		Context("leader election", func() {
+			It("should emit manager.leaderElection.enabled from the extracted --leader-elect arg", func() {
+				enabled := false
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Values: extractor.ValuesConfig{
+							Manager: extractor.ManagerConfig{LeaderElection: &enabled},
+						},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).To(ContainSubstring(
+					"\n  leaderElection:\n    enabled: false\n"))
+			})
+
+			It("should omit leaderElection when the manager has no --leader-elect arg", func() {
+				values := &HelmValues{}
+				values.ProjectName = testProjectName
+
+				Expect(values.generateValues()).NotTo(ContainSubstring("leaderElection:"))
+			})
+		})
+
+		Context("metrics reader", func() {
+			It("should document metrics.reader.enabled as a commented-out option", func() {
+				values := &HelmValues{}
+				values.ProjectName = testProjectName
+
+				Expect(extractSection(values.generateValues(), "metrics:")).To(
+					ContainSubstring("  # reader:\n  #   enabled: true\n"))
+			})
+		})
+
+		Context("webhook service port", func() {
+			It("should document the webhook Service port as a commented-out option", func() {
+				values := &HelmValues{
+					Extraction: &extractor.Extraction{
+						Features: extractor.FeatureSet{HasWebhooks: true},
+					},
+				}
+				values.ProjectName = testProjectName
+
+				Expect(extractSection(values.generateValues(), "webhook:")).To(
+					ContainSubstring("  # service:\n  #   # Extra annotations"))
+				Expect(values.generateValues()).To(ContainSubstring("  #   port: 443\n"))
+			})
+		})
+
 		Context("healthProbe placement", func() {
 			It("should nest the healthProbe block under the manager section", func() {
 				values := &HelmValues{}
@@ -275,6 +1009,7 @@ var _ = Describe("HelmValues", func() {
 
 				Expect(result).To(ContainSubstring("  healthProbe:\n    # Health probe server port\n    port: 8081\n"))
 				Expect(result).NotTo(ContainSubstring("\nhealthProbe:"))
+				Expect(extractSection(result, "healthProbe:")).To(ContainSubstring("    # scheme: HTTP\n"))
 			})
 		})
 	})
@@ -296,7 +1031,7 @@ func extractSection(content, sectionName string) string {
 			if len(section) > 1 && len(line) > 0 && line[0] != ' ' && line[0] != '#' {
 				break
 			}
-			if len(section) > 20 {
+			if len(section) > 30 {
 				break
 			}
 		}
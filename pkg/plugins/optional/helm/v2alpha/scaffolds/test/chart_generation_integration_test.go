@@ -19,6 +19,8 @@ limitations under the License.
 package test
 
 import (
+	"bytes"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -29,6 +31,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/spf13/afero"
 	"helm.sh/helm/v3/pkg/action"
+	helmChart "helm.sh/helm/v3/pkg/chart"
 	helmChartLoader "helm.sh/helm/v3/pkg/chart/loader"
 
 	"sigs.k8s.io/kubebuilder/v4/pkg/config"
@@ -80,7 +83,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			err := setupKustomizeFile(manifestsFile, kustomizeYAML)
 			Expect(err).NotTo(HaveOccurred())
 
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -144,7 +148,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			projectConfig.SetProjectName("e2e-test")
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -198,6 +203,230 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 		})
 	})
 
+	Context("values.yaml coverage", func() {
+		// A template that reads .Values.a.b.c fails to render with a nil pointer error when values.yaml has
+		// no a.b map. Optional maps are read as (.Values.a.b | default dict).c, so only a must exist.
+		DescribeTable("should define every values map the generated templates dereference",
+			func(kustomizeYAML string) {
+				Expect(setupKustomizeFile(manifestsFile, kustomizeYAML)).To(Succeed())
+
+				projectConfig.SetProjectName("e2e-test")
+				scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+					scaffolds.ChartOptions{})
+				scaffolderBase.InjectFS(fs)
+				Expect(scaffolderBase.Scaffold()).To(Succeed())
+
+				chartPath := filepath.Join(tmpDir, outputDir, "chart")
+				chart, err := helmChartLoader.LoadDir(chartPath)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("verifying the defaults derived from the manifests are present")
+				Expect(valuesPathExists(chart.Values, "manager.image.repository")).To(BeTrue())
+				Expect(valuesPathExists(chart.Values, "certManager.enabled")).To(BeTrue())
+
+				By("verifying every dereferenced values map is defined")
+				valuesRef := regexp.MustCompile(`\.Values((?:\.[A-Za-z0-9_]+)+)`)
+				for _, template := range chart.Templates {
+					for _, match := range valuesRef.FindAllStringSubmatch(string(template.Data), -1) {
+						// The referenced key itself may be unset; its parents must be maps.
+						keys := strings.Split(strings.TrimPrefix(match[1], "."), ".")
+						for i := range keys[:len(keys)-1] {
+							path := strings.Join(keys[:i+1], ".")
+							Expect(valuesPathExists(chart.Values, path)).To(BeTrue(),
+								"%s references .Values%s but values.yaml has no %s", template.Name, match[1], path)
+						}
+					}
+				}
+			},
+			Entry("with webhooks and cert-manager", createKustomizeWithWebhooksAndCertManager("e2e-test")),
+			Entry("with the full Deployment configuration", createKustomizeWithFullDeploymentConfig("e2e-test")),
+		)
+	})
+
+	Context("Chart options", func() {
+		// scaffoldWith scaffolds kustomizeYAML into a chart generated with options, lints it and
+		// returns it loaded.
+		scaffoldWith := func(kustomizeYAML string, options scaffolds.ChartOptions) *helmChart.Chart {
+			Expect(setupKustomizeFile(manifestsFile, kustomizeYAML)).To(Succeed())
+
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir, options)
+			scaffolderBase.InjectFS(fs)
+			Expect(scaffolderBase.Scaffold()).To(Succeed())
+
+			chartPath := filepath.Join(tmpDir, outputDir, "chart")
+			lintResult := action.NewLint().Run([]string{chartPath}, nil)
+			Expect(lintResult.Errors).To(BeEmpty(), "helm lint failed: %v", lintResult.Errors)
+
+			chart, err := helmChartLoader.LoadDir(chartPath)
+			Expect(err).NotTo(HaveOccurred())
+			return chart
+		}
+
+		// templateData returns the content of the chart template called name.
+		templateData := func(chart *helmChart.Chart, name string) string {
+			for _, template := range chart.Templates {
+				if template.Name == name {
+					return string(template.Data)
+				}
+			}
+			Fail("chart has no template " + name)
+			return ""
+		}
+
+		It("should read the manager image from the top-level image key with flatValues", func() {
+			chart := scaffoldWith(createKustomizeWithFullDeploymentConfig("test-project"),
+				scaffolds.ChartOptions{FlatValues: true})
+
+			Expect(valuesPathExists(chart.Values, "image.repository")).To(BeTrue())
+			Expect(valuesPathExists(chart.Values, "manager.image")).To(BeFalse())
+			manager := templateData(chart, "templates/manager/manager.yaml")
+			Expect(manager).To(ContainSubstring(".Values.image.repository"))
+			Expect(manager).NotTo(ContainSubstring(".Values.manager.image."))
+		})
+
+		It("should render the roles listed in helperRBAC only with rbac.helpers.enabled", func() {
+			kustomizeYAML := createKustomizeWithCRDAndRBAC("test-project") + `---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: test-project-auditor-role
+rules:
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["get", "list"]
+`
+			chart := scaffoldWith(kustomizeYAML, scaffolds.ChartOptions{
+				HelperRBAC:    []string{"test-project-auditor-role", "test-project-manager-role"},
+				EssentialRBAC: []string{"test-project-manager-role"},
+			})
+
+			Expect(templateData(chart, "templates/rbac/auditor-role.yaml")).To(
+				HavePrefix("{{- if .Values.rbac.helpers.enabled }}\n"))
+			Expect(templateData(chart, "templates/rbac/manager-role.yaml")).NotTo(
+				ContainSubstring(".Values.rbac.helpers.enabled"))
+		})
+
+		It("should keep the Namespace behind namespace.create with keepNamespace", func() {
+			chart := scaffoldWith(createKustomizeWithCRDAndRBAC("test-project"),
+				scaffolds.ChartOptions{KeepNamespace: true})
+
+			Expect(chart.Values).To(HaveKeyWithValue("namespace", HaveKeyWithValue("create", false)))
+			namespace := templateData(chart, "templates/namespace/namespace.yaml")
+			Expect(namespace).To(HavePrefix("{{- if (.Values.namespace | default dict).create }}\n"))
+			Expect(namespace).To(ContainSubstring("name: {{ .Release.Namespace }}"))
+		})
+
+		It("should order the top-level keys canonically with canonicalKeyOrder", func() {
+			kustomizeYAML := createKustomizeWithCRDAndRBAC("test-project") + `---
+apiVersion: v1
+data:
+  log-level: info
+kind: ConfigMap
+metadata:
+  name: test-project-manager-config
+  namespace: test-project-system
+`
+			chart := scaffoldWith(kustomizeYAML, scaffolds.ChartOptions{CanonicalKeyOrder: true})
+
+			configMap := templateData(chart, "templates/extras/manager-config.yaml")
+			Expect(strings.Index(configMap, "\nkind: ConfigMap")).To(
+				BeNumerically("<", strings.Index(configMap, "\ndata:")))
+		})
+
+		It("should leave the manager image unquoted with unquotedImage", func() {
+			chart := scaffoldWith(createKustomizeWithFullDeploymentConfig("test-project"),
+				scaffolds.ChartOptions{UnquotedImage: true})
+
+			manager := templateData(chart, "templates/manager/manager.yaml")
+			Expect(manager).To(MatchRegexp(`(?m)^\s+image: \{\{`))
+			Expect(manager).NotTo(MatchRegexp(`(?m)^\s+image: "`))
+		})
+
+		It("should render the manager args with toYaml with argsAsYAML", func() {
+			chart := scaffoldWith(createKustomizeWithFullDeploymentConfig("test-project"),
+				scaffolds.ChartOptions{ArgsAsYAML: true})
+
+			manager := templateData(chart, "templates/manager/manager.yaml")
+			Expect(manager).To(MatchRegexp(`\{\{- with \.Values\.manager\.args \}\}\n\s+\{\{- toYaml \. \| nindent \d+ \}\}`))
+			Expect(manager).NotTo(ContainSubstring("range .Values.manager.args"))
+		})
+
+		It("should leave the cert-manager resources out of the chart with omitCertManager", func() {
+			chart := scaffoldWith(createKustomizeWithWebhooksAndCertManager("test-project"),
+				scaffolds.ChartOptions{OmitCertManager: true})
+
+			Expect(chart.Values).To(HaveKeyWithValue("certManager", HaveKeyWithValue("enabled", false)))
+			for _, template := range chart.Templates {
+				Expect(template.Name).NotTo(HavePrefix("templates/cert-manager/"))
+				Expect(string(template.Data)).NotTo(ContainSubstring("kind: Certificate\n"))
+			}
+		})
+
+		It("should leave the CRDs out of the chart with omitCRDs", func() {
+			chart := scaffoldWith(createKustomizeWithCRDAndRBAC("test-project"),
+				scaffolds.ChartOptions{OmitCRDs: true})
+
+			Expect(chart.Values).NotTo(HaveKey("crd"))
+			for _, template := range chart.Templates {
+				Expect(template.Name).NotTo(HavePrefix("templates/crd/"))
+			}
+		})
+
+		It("should require the manager image repository with requireImageRepository", func() {
+			chart := scaffoldWith(createKustomizeWithFullDeploymentConfig("test-project"),
+				scaffolds.ChartOptions{RequireImageRepository: true})
+
+			manager := templateData(chart, "templates/manager/manager.yaml")
+			Expect(manager).To(ContainSubstring(
+				`{{ required "manager.image.repository is required" .Values.manager.image.repository }}`))
+			Expect(manager).NotTo(ContainSubstring(`.Values.manager.image.repository | default "controller"`))
+		})
+
+		It("should define the helper the revision annotation includes with revisionAnnotation", func() {
+			chart := scaffoldWith(createKustomizeWithFullDeploymentConfig("test-project"),
+				scaffolds.ChartOptions{RevisionAnnotation: true})
+
+			Expect(templateData(chart, "templates/_helpers.tpl")).To(
+				ContainSubstring(`{{- define "test-project.revisionAnnotations" -}}`))
+			Expect(templateData(chart, "templates/manager/manager.yaml")).To(
+				ContainSubstring(`include "test-project.revisionAnnotations" .`))
+		})
+
+		It("should define the helper the provenance annotations include with provenanceAnnotations", func() {
+			chart := scaffoldWith(createKustomizeWithFullDeploymentConfig("test-project"),
+				scaffolds.ChartOptions{ProvenanceAnnotations: true})
+
+			Expect(templateData(chart, "templates/_helpers.tpl")).To(
+				ContainSubstring(`{{- define "test-project.provenanceAnnotations" -}}`))
+			Expect(templateData(chart, "templates/manager/manager.yaml")).To(
+				ContainSubstring(`include "test-project.provenanceAnnotations" .`))
+		})
+
+		It("should select the release instance from Services with releaseSelectorLabels", func() {
+			kustomizeYAML := strings.Replace(createKustomizeWithWebhooks("test-project"),
+				"  selector:\n    control-plane:", "  selector:\n    app.kubernetes.io/name: test-project\n    control-plane:", 1)
+			chart := scaffoldWith(kustomizeYAML, scaffolds.ChartOptions{ReleaseSelectorLabels: true})
+
+			service := templateData(chart, "templates/webhook/webhook-service.yaml")
+			Expect(service).To(ContainSubstring(`{{- include "test-project.selectorLabels" . | nindent 4 }}`))
+			Expect(service).To(ContainSubstring("    control-plane: controller-manager\n"))
+		})
+
+		It("should log the substitution steps changing each resource with reportSubstitutions", func() {
+			var logs bytes.Buffer
+			defaultLogger := slog.Default()
+			slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+			DeferCleanup(slog.SetDefault, defaultLogger)
+
+			scaffoldWith(createKustomizeWithFullDeploymentConfig("test-project"),
+				scaffolds.ChartOptions{ReportSubstitutions: true})
+
+			Expect(logs.String()).To(MatchRegexp(
+				`msg="Applied Helm substitutions" kind=Deployment name=test-project-controller-manager ` +
+					`steps="\[[^]]*templateEnvironmentVariables[^]]*\]"`))
+		})
+	})
+
 	Context("Chart Name Handling", func() {
 		It("should use project name in helpers regardless of kustomize namePrefix", func() {
 			// Kustomize output with custom namePrefix
@@ -206,7 +435,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			projectConfig.SetProjectName("test-project")
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -249,7 +479,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			projectConfig.SetProjectName("e2e-test")
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -360,7 +591,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 
 		Expect(setupKustomizeFile(manifestsFile, kustomizeYAML)).To(Succeed())
 
-		scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+		scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+			scaffolds.ChartOptions{})
 		scaffolderBase.InjectFS(fs)
 		Expect(scaffolderBase.Scaffold()).To(Succeed())
 
@@ -592,7 +824,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			customOutputDir := "custom-charts"
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, customOutputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, customOutputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -622,7 +855,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			err := setupKustomizeFile(manifestsFile, kustomizeYAML)
 			Expect(err).NotTo(HaveOccurred())
 
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -662,7 +896,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			err := setupKustomizeFile(manifestsFile, kustomizeYAML)
 			Expect(err).NotTo(HaveOccurred())
 
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -722,7 +957,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			err := setupKustomizeFile(manifestsFile, kustomizeYAML)
 			Expect(err).NotTo(HaveOccurred())
 
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -774,7 +1010,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			err := setupKustomizeFile(manifestsFile, kustomizeYAML)
 			Expect(err).NotTo(HaveOccurred())
 
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -814,7 +1051,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			err := setupKustomizeFile(manifestsFile, kustomizeYAML)
 			Expect(err).NotTo(HaveOccurred())
 
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -874,7 +1112,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			err := setupKustomizeFile(manifestsFile, kustomizeYAML)
 			Expect(err).NotTo(HaveOccurred())
 
-			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolderBase = scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir,
+				scaffolds.ChartOptions{})
 			scaffolderBase.InjectFS(fs)
 
 			err = scaffolderBase.Scaffold()
@@ -891,7 +1130,8 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			By("verifying tolerations appears exactly once and is Helm-templated")
 			Expect(strings.Count(managerStr, "tolerations:")).To(Equal(1),
 				"tolerations: must appear exactly once in the manager template")
-			Expect(managerStr).To(ContainSubstring("{{- with .Values.manager.tolerations }}"),
+			Expect(managerStr).To(
+				ContainSubstring(`{{- with include "test-project.managerTolerations" . | fromYamlArray }}`),
 				"manager template must contain Helm with-block for tolerations")
 			Expect(managerStr).To(ContainSubstring("tolerations: {{ toYaml . | nindent"),
 				"manager template must use toYaml for tolerations")
@@ -914,7 +1154,7 @@ var _ = Describe("Chart Generation Integration Tests", func() {
 			By("verifying affinity appears exactly once and is Helm-templated")
 			Expect(strings.Count(managerStr, "affinity:")).To(Equal(1),
 				"affinity: must appear exactly once in the manager template")
-			Expect(managerStr).To(ContainSubstring("{{- with .Values.manager.affinity }}"))
+			Expect(managerStr).To(ContainSubstring(`{{- with include "test-project.managerAffinity" . | fromYaml }}`))
 			Expect(managerStr).NotTo(ContainSubstring("nodeAffinity:"),
 				"raw affinity sub-field must not remain in the manager template")
 
@@ -1315,6 +1555,25 @@ metadata:
 	)
 }
 
+// valuesPathExists reports whether the dotted path resolves to a key in values.
+func valuesPathExists(values map[string]any, path string) bool {
+	current := values
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		value, ok := current[key]
+		if !ok {
+			return false
+		}
+		if i == len(keys)-1 {
+			return true
+		}
+		if current, ok = value.(map[string]any); !ok {
+			return false
+		}
+	}
+	return false
+}
+
 func setupKustomizeFile(filePath, content string) error {
 	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
 		return err
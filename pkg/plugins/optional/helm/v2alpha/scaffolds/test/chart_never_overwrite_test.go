@@ -94,7 +94,7 @@ spec:
 	})
 
 	It("should NEVER overwrite Chart.yaml even with --force=true", func() {
-		scaffolder := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+		scaffolder := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir, scaffolds.ChartOptions{})
 		scaffolder.InjectFS(fs)
 
 		// First scaffold
@@ -125,7 +125,7 @@ maintainers:
 		Expect(err).NotTo(HaveOccurred())
 
 		// Scaffold again WITHOUT force
-		scaffolder2 := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+		scaffolder2 := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir, scaffolds.ChartOptions{})
 		scaffolder2.InjectFS(fs)
 		err = scaffolder2.Scaffold()
 		Expect(err).NotTo(HaveOccurred())
@@ -138,7 +138,7 @@ maintainers:
 		Expect(string(content)).To(ContainSubstring("John Doe"))
 
 		// Scaffold again WITH force=true
-		scaffolder3 := scaffolds.NewChartScaffolder(projectConfig, true, manifestsFile, outputDir)
+		scaffolder3 := scaffolds.NewChartScaffolder(projectConfig, true, manifestsFile, outputDir, scaffolds.ChartOptions{})
 		scaffolder3.InjectFS(fs)
 		err = scaffolder3.Scaffold()
 		Expect(err).NotTo(HaveOccurred())
@@ -166,7 +166,7 @@ version: 99.99.99
 		Expect(err).NotTo(HaveOccurred())
 
 		// First scaffold with force=true
-		scaffolder := scaffolds.NewChartScaffolder(projectConfig, true, manifestsFile, outputDir)
+		scaffolder := scaffolds.NewChartScaffolder(projectConfig, true, manifestsFile, outputDir, scaffolds.ChartOptions{})
 		scaffolder.InjectFS(fs)
 
 		err = scaffolder.Scaffold()
@@ -29,6 +29,7 @@ import (
 
 	"sigs.k8s.io/kubebuilder/v4/pkg/machinery"
 	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/kustomize"
+	"sigs.k8s.io/kubebuilder/v4/pkg/plugins/optional/helm/v2alpha/scaffolds/internal/kustomize/templater"
 )
 
 var _ = Describe("Extras Directory Integration Test", func() {
@@ -142,7 +143,8 @@ data:
 			Expect(resources.Other).To(HaveLen(2))
 
 			By("converting to Helm chart")
-			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system", "dist", make(map[string]string))
+			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system",
+				"dist", make(map[string]string), templater.Options{})
 			builders := converter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
@@ -189,12 +191,8 @@ data:
 			// Verify standard Helm labels
 			Expect(configMapContent).To(ContainSubstring(`app.kubernetes.io/name: {{ include "test-project.name" . }}`),
 				"ConfigMap should have app.kubernetes.io/name label")
-			Expect(configMapContent).To(ContainSubstring("app.kubernetes.io/instance: {{ .Release.Name }}"),
-				"ConfigMap should have app.kubernetes.io/instance label")
-			Expect(configMapContent).To(ContainSubstring("app.kubernetes.io/managed-by: {{ .Release.Service }}"),
-				"ConfigMap should have app.kubernetes.io/managed-by label")
-			Expect(configMapContent).To(ContainSubstring(`helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}`),
-				"ConfigMap should have helm.sh/chart label")
+			Expect(configMapContent).To(ContainSubstring(`{{- include "test-project.labels" . | nindent 4 }}`),
+				"ConfigMap should get the instance, managed-by and helm.sh/chart labels from the labels helper")
 
 			// Verify data is preserved
 			Expect(configMapContent).To(ContainSubstring("key1: value1"),
@@ -219,8 +217,8 @@ data:
 			// Verify standard Helm labels
 			Expect(secretContent).To(ContainSubstring(`app.kubernetes.io/name: {{ include "test-project.name" . }}`),
 				"Secret should have app.kubernetes.io/name label")
-			Expect(secretContent).To(ContainSubstring("app.kubernetes.io/managed-by: {{ .Release.Service }}"),
-				"Secret should have app.kubernetes.io/managed-by label")
+			Expect(secretContent).To(ContainSubstring(`{{- include "test-project.labels" . | nindent 4 }}`),
+				"Secret should get the managed-by label from the labels helper")
 
 			// Verify data is preserved
 			Expect(secretContent).To(ContainSubstring("password: c2VjcmV0Cg=="),
@@ -261,7 +259,8 @@ spec:
 			resources, err := parser.Parse()
 			Expect(err).NotTo(HaveOccurred())
 
-			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system", "dist", make(map[string]string))
+			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system",
+				"dist", make(map[string]string), templater.Options{})
 			builders := converter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
@@ -325,7 +324,8 @@ spec:
 			resources, err := parser.Parse()
 			Expect(err).NotTo(HaveOccurred())
 
-			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system", "dist", make(map[string]string))
+			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system",
+				"dist", make(map[string]string), templater.Options{})
 			builders := converter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
@@ -456,7 +456,8 @@ spec:
 			Expect(resources.RoleBindings).To(HaveLen(1), "should have 1 RoleBinding")
 			Expect(resources.Other).To(HaveLen(1), "ConfigMap should be in Other")
 
-			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system", "dist", make(map[string]string))
+			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system",
+				"dist", make(map[string]string), templater.Options{})
 			builders := converter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
@@ -682,7 +683,8 @@ spec:
 			Expect(resources.RoleBindings).To(HaveLen(3), "should have 3 RoleBindings")
 
 			By("converting to Helm chart")
-			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system", "dist", make(map[string]string))
+			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system",
+				"dist", make(map[string]string), templater.Options{})
 			builders := converter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
@@ -857,7 +859,8 @@ spec:
 			Expect(clusterRoleContentStr).To(ContainSubstring("kind: ClusterRole"),
 				"ClusterRole should have ClusterRole default")
 			// Should have conditional namespace (rendered only when rbac.namespaced=true)
-			Expect(clusterRoleContentStr).To(ContainSubstring("namespace: {{ .Release.Namespace }}"),
+			Expect(clusterRoleContentStr).To(
+				ContainSubstring("namespace: {{ .Values.rbac.watchNamespace | default .Release.Namespace }}"),
 				"ClusterRole should have conditional namespace for Role variant")
 		})
 
@@ -931,7 +934,8 @@ spec:
 			resources, err := parser.Parse()
 			Expect(err).NotTo(HaveOccurred())
 
-			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system", "dist", make(map[string]string))
+			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system",
+				"dist", make(map[string]string), templater.Options{})
 			builders := converter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
@@ -1087,7 +1091,8 @@ spec:
 			resources, err := parser.Parse()
 			Expect(err).NotTo(HaveOccurred())
 
-			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system", "dist", make(map[string]string))
+			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system",
+				"dist", make(map[string]string), templater.Options{})
 			builders := converter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
@@ -1235,7 +1240,8 @@ spec:
 			Expect(cr.GetAPIVersion()).To(Equal("batch.tutorial.kubebuilder.io/v1"))
 			Expect(cr.GetName()).To(Equal("cronjob-sample"))
 
-			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system", "dist", make(map[string]string))
+			converter := kustomize.NewChartConverter(resources, "test-project", "test-project", "test-project-system",
+				"dist", make(map[string]string), templater.Options{})
 			builders := converter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
@@ -1425,7 +1431,8 @@ spec:
 			Expect(roleNamespaces).To(HaveKey("manager-rolebinding-users"))
 
 			By("converting to Helm chart with role-namespace mappings")
-			chartConverter := kustomize.NewChartConverter(resources, namePrefix, "test-project", managerNamespace, "dist", roleNamespaces)
+			chartConverter := kustomize.NewChartConverter(resources, namePrefix, "test-project", managerNamespace,
+				"dist", roleNamespaces, templater.Options{})
 			builders := chartConverter.GetChartBuilders()
 			scaffold := machinery.NewScaffold(fs)
 			err = scaffold.Execute(builders...)
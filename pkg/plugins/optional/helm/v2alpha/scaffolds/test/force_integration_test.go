@@ -138,7 +138,7 @@ spec:
 	Context("when --force flag is NOT used", func() {
 		It("should NOT overwrite existing Chart.yaml, values.yaml, .helmignore, _helpers.tpl, NOTES.txt, test-chart.yml, and allow-metrics-traffic.yaml", func() {
 			// First generation with force=false
-			scaffolder := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolder := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir, scaffolds.ChartOptions{})
 			scaffolder.InjectFS(fs)
 			err := scaffolder.Scaffold()
 			Expect(err).NotTo(HaveOccurred())
@@ -195,7 +195,7 @@ spec:
 			Expect(err).NotTo(HaveOccurred())
 
 			// Second generation with force=false
-			scaffolder2 := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolder2 := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir, scaffolds.ChartOptions{})
 			scaffolder2.InjectFS(fs)
 			err = scaffolder2.Scaffold()
 			Expect(err).NotTo(HaveOccurred())
@@ -237,7 +237,7 @@ spec:
 	Context("when --force flag IS used", func() {
 		It("should overwrite all files EXCEPT Chart.yaml (which is never overwritten)", func() {
 			// First generation with force=false
-			scaffolder := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolder := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir, scaffolds.ChartOptions{})
 			scaffolder.InjectFS(fs)
 			err := scaffolder.Scaffold()
 			Expect(err).NotTo(HaveOccurred())
@@ -278,7 +278,7 @@ spec:
 			Expect(err).NotTo(HaveOccurred())
 
 			// Second generation with force=true
-			scaffolder2 := scaffolds.NewChartScaffolder(projectConfig, true, manifestsFile, outputDir)
+			scaffolder2 := scaffolds.NewChartScaffolder(projectConfig, true, manifestsFile, outputDir, scaffolds.ChartOptions{})
 			scaffolder2.InjectFS(fs)
 			err = scaffolder2.Scaffold()
 			Expect(err).NotTo(HaveOccurred())
@@ -342,7 +342,7 @@ spec:
 			Expect(err).NotTo(HaveOccurred())
 
 			// First generation with force=true should overwrite
-			scaffolder := scaffolds.NewChartScaffolder(projectConfig, true, manifestsFile, outputDir)
+			scaffolder := scaffolds.NewChartScaffolder(projectConfig, true, manifestsFile, outputDir, scaffolds.ChartOptions{})
 			scaffolder.InjectFS(fs)
 			err = scaffolder.Scaffold()
 			Expect(err).NotTo(HaveOccurred())
@@ -363,7 +363,7 @@ spec:
 	Context("when template files are modified", func() {
 		It("should verify template files exist in templates/ directory", func() {
 			// First generation
-			scaffolder := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir)
+			scaffolder := scaffolds.NewChartScaffolder(projectConfig, false, manifestsFile, outputDir, scaffolds.ChartOptions{})
 			scaffolder.InjectFS(fs)
 			err := scaffolder.Scaffold()
 			Expect(err).NotTo(HaveOccurred())